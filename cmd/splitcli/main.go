@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -113,6 +114,15 @@ func executeCall(c types.ClientInterface, a *conf.CliArgs) (string, error) {
 			}
 		}
 		return sb.String(), err
+	case "ready":
+		status, err := c.Status()
+		if err != nil {
+			return "", err
+		}
+		asJson, err := json.Marshal(status)
+		return string(asJson), err
+	case "ping":
+		return "pong", c.Ping()
 	case "split-names":
 		names, err := c.SplitNames()
 		return strings.Join(names, ","), err
@@ -131,6 +141,36 @@ func executeCall(c types.ClientInterface, a *conf.CliArgs) (string, error) {
 		}
 		asJson, err := json.Marshal(splits)
 		return string(asJson), err
+	case "split-definition":
+		split, err := c.SplitDefinition(a.Feature)
+		if err != nil {
+			return "", err
+		}
+		asJson, err := json.Marshal(split)
+		return string(asJson), err
+	case "segment-names":
+		names, err := c.SegmentNames()
+		return strings.Join(names, ","), err
+	case "segment":
+		segment, err := c.Segment(a.Segment)
+		if err != nil {
+			return "", err
+		}
+		asJson, err := json.Marshal(segment)
+		return string(asJson), err
+	case "segment-contains-key":
+		contains, err := c.SegmentContainsKey(a.Segment, a.Key)
+		return strconv.FormatBool(contains), err
+	case "rule-based-segment-names":
+		names, err := c.RuleBasedSegmentNames()
+		return strings.Join(names, ","), err
+	case "rule-based-segment":
+		rbs, err := c.RuleBasedSegment(a.Segment)
+		if err != nil {
+			return "", err
+		}
+		asJson, err := json.Marshal(rbs)
+		return string(asJson), err
 	default:
 		return "", fmt.Errorf("unknwon method '%s'", a.Method)
 	}
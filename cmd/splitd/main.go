@@ -1,9 +1,15 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/splitd/splitio"
@@ -11,7 +17,10 @@ import (
 	"github.com/splitio/splitd/splitio/conf"
 	"github.com/splitio/splitd/splitio/link"
 	"github.com/splitio/splitd/splitio/sdk"
+	"github.com/splitio/splitd/splitio/sdk/overrides"
 	"github.com/splitio/splitd/splitio/util"
+	"github.com/splitio/splitd/splitio/util/sdnotify"
+	"github.com/splitio/splitd/splitio/util/upgrade"
 
 	"github.com/splitio/splitd/splitio/provisional/profiler"
 )
@@ -31,42 +40,288 @@ func main() {
 	exitOnErr("logging setup", err)
 	logger := logging.NewLogger(loggerCfg)
 
-	splitSDK, err := sdk.New(logger, cfg.SDK.Apikey, cfg.SDK.ToSDKConf())
+	environments, err := setupEnvironments(logger, &cfg.SDK)
 	exitOnErr("sdk initialization", err)
 
 	linkCFG, err := cfg.Link.ToListenerOpts()
 	exitOnErr("link config", err)
+	exitOnErr("api config", cfg.API.Validate(linkCFG.Tokens))
 
-	errc, lShutdown, err := link.Listen(logger, splitSDK, linkCFG)
+	// when taking over from a running instance, the listening sockets are shared with it. Accepting connections only
+	// once synchronized leaves the previous process serving them in the meantime, instead of returning "not ready"
+	upgrading := upgrade.InProgress()
+	if upgrading {
+		awaitReadiness(logger, environments, time.Duration(cfg.Upgrade.ReadyTimeoutSeconds)*time.Second)
+	}
+
+	listeners, errc, lShutdown, err := startListeners(logger, environments, linkCFG, cfg.SDK.Environments)
 	exitOnErr("rpc listener setup", err)
 
+	var handedOver atomic.Bool // set when a new process has taken over (upgrade)
 	shutdown := util.NewShutdownHandler()
 	shutdown.RegisterHook(func() {
+		if !handedOver.Load() { // the service keeps running in the new process
+			if _, err := sdnotify.Notify(sdnotify.Stopping); err != nil {
+				logger.Warning("error notifying systemd: ", err.Error())
+			}
+		}
 		err := lShutdown()
 		if err != nil {
 			logger.Error("error shutting down listener: ", err.Error())
 		}
-		splitSDK.Shutdown() // evict pending impressions & events
+		environments.Shutdown() // evict pending impressions & events
 	})
 	defer shutdown.Wait()
 
+	go notifyReadiness(logger, environments)
+
+	if cfg.Upgrade.Enabled {
+		go handleUpgrades(logger, &cfg.Upgrade, environments, listeners, func() {
+			handedOver.Store(true)
+			shutdown.Trigger()
+		})
+	}
+
+	// the instance being replaced holds the http ports until it's drained, which happens once this one is ready
+	var bindTimeout time.Duration
+	if upgrading {
+		bindTimeout = time.Duration(cfg.Upgrade.ReadyTimeoutSeconds+cfg.Upgrade.DrainTimeoutSeconds) * time.Second
+	}
+
 	if pc := cfg.Debug.Profiling; pc.Enable {
 		go func() {
-			p := profiler.New(pc.Host, pc.Port)
-			if err := p.ListenAndServe(); err != nil {
-				panic(err.Error())
+			if err := serveHTTP("profiler", bindTimeout, profiler.New(pc.Host, pc.Port).ListenAndServe); err != nil {
+				logger.Error(err.Error())
 			}
 		}()
 	}
 
 	// launch api in BG (errors will be logged but won't abort execution of app)
-	go startAPI(logger, cfg.API, *linkCFG)
+	go startAPI(logger, cfg.API, *linkCFG, environments, bindTimeout)
 
 	// Wait for connection to end (either gracefully of because of an error)
 	err = <-errc
 	exitOnErr("shutdown: ", err)
 }
 
+// setupEnvironments builds the default sdk from the top-level config, plus one for each additional environment
+func setupEnvironments(logger logging.LoggerInterface, sdkCFG *conf.SDK) (sdk.Environments, error) {
+	if err := sdkCFG.ValidateEnvironments(); err != nil {
+		return nil, err
+	}
+
+	baseCFG := sdkCFG.ToSDKConf()
+	if upgrade.SnapshotHandedOver() { // the process being replaced has just exported fresh data
+		baseCFG.Snapshot.LoadOnStartup = true
+	}
+	splitSDK, err := sdk.New(logger, sdkCFG.Apikey, baseCFG)
+	if err != nil {
+		return nil, err
+	}
+
+	environments := sdk.SingleEnvironment(splitSDK)
+	for idx := range sdkCFG.Environments {
+		env := &sdkCFG.Environments[idx]
+		envSDK, err := sdk.New(logger, env.Apikey, env.ToSDKConf(baseCFG))
+		if err != nil {
+			environments.Shutdown()
+			return nil, fmt.Errorf("environment '%s': %w", env.Name, err)
+		}
+		environments[env.Name] = envSDK
+	}
+
+	return environments, nil
+}
+
+// awaitReadiness blocks until every environment has synchronized, or the timeout expires
+func awaitReadiness(logger logging.LoggerInterface, environments sdk.Environments, timeout time.Duration) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.Now().Add(timeout)
+	for !environments.Ready() {
+		if time.Now().After(deadline) {
+			logger.Warning("environments not synchronized yet. accepting connections anyway")
+			return
+		}
+		<-ticker.C
+	}
+}
+
+// startListeners starts the main listener, plus one for each environment mapped to a dedicated address.
+// Errors from all of them are merged into a single channel
+func startListeners(
+	logger logging.LoggerInterface,
+	environments sdk.Environments,
+	linkCFG *link.ListenerOptions,
+	envCFGs []conf.Environment,
+) ([]*link.Listener, <-chan error, func() error, error) {
+	listener, err := link.StartListener(logger, environments, linkCFG)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	listeners := []*link.Listener{listener}
+	shutdownAll := func() error {
+		var errs []error
+		for _, l := range listeners {
+			if err := l.Shutdown(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	for _, env := range envCFGs {
+		if env.LinkAddress == nil || *env.LinkAddress == "" {
+			continue
+		}
+
+		envLinkCFG := *linkCFG
+		envLinkCFG.Transfer.Address = *env.LinkAddress
+		envLinkCFG.Environment = env.Name
+		listener, err := link.StartListener(logger, environments, &envLinkCFG)
+		if err != nil {
+			shutdownAll()
+			return nil, nil, nil, fmt.Errorf("environment '%s': %w", env.Name, err)
+		}
+		listeners = append(listeners, listener)
+	}
+
+	merged := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(c <-chan error) { merged <- <-c }(l.Errors())
+	}
+
+	return listeners, merged, shutdownAll, nil
+}
+
+// notifyReadiness reports the daemon as ready once every environment has synchronized: to systemd (when running as
+// a notify service, also sending watchdog keep-alives for as long as it runs), and to the process being replaced
+// if this one was started as part of an upgrade
+func notifyReadiness(logger logging.LoggerInterface, environments sdk.Environments) {
+	if !sdnotify.Enabled() && !upgrade.InProgress() {
+		return
+	}
+
+	watchdogEvery := sdnotify.WatchdogInterval() / 2
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	var ready bool
+	var lastPing time.Time
+	for now := range ticker.C {
+		var states []string
+		if !ready && environments.Ready() {
+			ready = true
+			states = append(states, sdnotify.Ready, sdnotify.Status("all environments synchronized"))
+			if err := upgrade.NotifyReady(); err != nil {
+				logger.Error("error notifying readiness to the previous process: ", err.Error())
+			}
+		}
+		if watchdogEvery > 0 && now.Sub(lastPing) >= watchdogEvery {
+			lastPing = now
+			states = append(states, sdnotify.Watchdog)
+		}
+
+		if len(states) > 0 {
+			if sent, err := sdnotify.Notify(states...); err != nil {
+				logger.Warning("error notifying systemd: ", err.Error())
+			} else if !sent { // only started to report readiness to the previous process
+				return
+			}
+		}
+
+		if ready && watchdogEvery == 0 { // nothing left to report
+			return
+		}
+	}
+}
+
+// handleUpgrades waits for SIGUSR2 & hands the listeners over to a new process started from the current executable.
+// Once it's ready, connections are drained & `onHandedOver` is called for this process to step down. If the new
+// process fails to become ready, this one keeps serving
+func handleUpgrades(
+	logger logging.LoggerInterface,
+	cfg *conf.Upgrade,
+	environments sdk.Environments,
+	listeners []*link.Listener,
+	onHandedOver func(),
+) {
+	signals := make(chan os.Signal, 1)
+	if !notifyUpgradeRequests(signals) {
+		logger.Warning("upgrades are not supported on this platform")
+		return
+	}
+	for range signals {
+		logger.Info("upgrade requested, starting new process")
+		child, err := spawnSuccessor(logger, cfg, environments, listeners)
+		if err != nil {
+			logger.Error("upgrade aborted, this process will keep serving: ", err.Error())
+			continue
+		}
+
+		logger.Info(fmt.Sprintf("new process (pid=%d) is ready. draining connections", child.Pid))
+		signal.Stop(signals)
+		if _, err := sdnotify.Notify(sdnotify.MainPID(child.Pid)); err != nil {
+			logger.Warning("error notifying systemd: ", err.Error())
+		}
+
+		for _, l := range listeners {
+			if err := l.Drain(time.Duration(cfg.DrainTimeoutSeconds) * time.Second); err != nil {
+				logger.Warning("error draining listener: ", err.Error())
+			}
+		}
+		onHandedOver()
+		return
+	}
+}
+
+// spawnSuccessor starts a new process with the same executable & arguments, and waits for it to become ready
+func spawnSuccessor(
+	logger logging.LoggerInterface,
+	cfg *conf.Upgrade,
+	environments sdk.Environments,
+	listeners []*link.Listener,
+) (*os.Process, error) {
+	handoff := &upgrade.Handoff{ReadyTimeout: time.Duration(cfg.ReadyTimeoutSeconds) * time.Second}
+	if cfg.HandOverSnapshot {
+		handoff.Snapshot = exportSnapshots(logger, environments)
+	}
+
+	for _, l := range listeners {
+		f, err := l.File()
+		if err != nil {
+			return nil, fmt.Errorf("error duplicating listener: %w", err)
+		}
+		defer f.Close() // the new process gets its own copy
+		handoff.Listeners = append(handoff.Listeners, f)
+	}
+
+	return upgrade.Spawn(handoff)
+}
+
+// exportSnapshots writes a fresh snapshot for every environment that has them enabled.
+// It returns true only if all of those succeeded, so that the new process doesn't pick up outdated data
+func exportSnapshots(logger logging.LoggerInterface, environments sdk.Environments) bool {
+	var exported int
+	for name, splitSDK := range environments {
+		withSnapshots, ok := splitSDK.(interface{ ExportSnapshot() error })
+		if !ok {
+			continue
+		}
+
+		err := withSnapshots.ExportSnapshot()
+		if errors.Is(err, sdk.ErrSnapshotsDisabled) {
+			continue
+		} else if err != nil {
+			logger.Warning(fmt.Sprintf("cannot hand over a snapshot for environment '%s': %s", name, err))
+			return false
+		}
+		exported++
+	}
+	return exported > 0
+}
+
 func printHeader() {
 	fmt.Println(splitio.ASCILogo)
 	fmt.Printf("Splitd Agent - Version %s - build [%s] (2023)\n\n", splitio.Version, splitio.CommitSHA)
@@ -89,14 +344,48 @@ func exitOnErr(ctxStr string, err error) {
 
 }
 
-func startAPI(logger logging.LoggerInterface, apiCFG conf.API, linkCFG link.ListenerOptions) {
-	server, err := api.Setup(apiCFG.Host, apiCFG.Port, logger, linkCFG)
+func startAPI(logger logging.LoggerInterface, apiCFG conf.API, linkCFG link.ListenerOptions, environments sdk.Environments, bindTimeout time.Duration) {
+	var overrideStores map[string]*overrides.Store
+	if apiCFG.AdminEnabled {
+		overrideStores = overrideStoresFor(environments)
+	}
+
+	server, err := api.Setup(apiCFG.Host, apiCFG.Port, logger, linkCFG, overrideStores)
 	if err != nil {
 		logger.Error("error creating HTTP server:", err.Error())
 		return
 	}
 
-	if err = server.ListenAndServe(); err != nil {
-		logger.Error("error starting http server:", err.Error())
+	if err := serveHTTP("http", bindTimeout, server.ListenAndServe); err != nil {
+		logger.Error(err.Error())
+	}
+}
+
+// serveHTTP runs an http server, returning the error that makes it stop. When taking over from a running instance,
+// its ports are held until it shuts down, so binding them is retried for up to `bindTimeout`. If they're still in use
+// by then, the error is returned & the previous instance is left serving them
+func serveHTTP(name string, bindTimeout time.Duration, listenAndServe func() error) error {
+	deadline := time.Now().Add(bindTimeout)
+	for {
+		err := listenAndServe()
+		if errors.Is(err, syscall.EADDRINUSE) && time.Now().Before(deadline) {
+			time.Sleep(500 * time.Millisecond)
+			continue
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("error starting %s server: %w", name, err)
+		}
+		return nil
+	}
+}
+
+// overrideStoresFor collects the forced-treatment stores of every environment, to be managed through the admin api
+func overrideStoresFor(environments sdk.Environments) map[string]*overrides.Store {
+	stores := make(map[string]*overrides.Store, len(environments))
+	for name, splitSDK := range environments {
+		if withOverrides, ok := splitSDK.(interface{ Overrides() *overrides.Store }); ok {
+			stores[name] = withOverrides.Overrides()
+		}
 	}
+	return stores
 }
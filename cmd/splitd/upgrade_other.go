@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// notifyUpgradeRequests reports upgrades as unsupported: there's no SIGUSR2 on this platform
+func notifyUpgradeRequests(chan<- os.Signal) bool {
+	return false
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyUpgradeRequests relays SIGUSR2 (upgrade request) to `signals`
+func notifyUpgradeRequests(signals chan<- os.Signal) bool {
+	signal.Notify(signals, syscall.SIGUSR2)
+	return true
+}
@@ -8,6 +8,25 @@ sdk:
     apikey: <server-side-apitoken>
     labelsEnabled: true
     streamingEnabled: true
+    storage: memory
+    queueType: locking
+    redis:
+        host: localhost
+        port: 6379
+        db: 0
+        username: ""
+        password: ""
+        prefix: ""
+        sentinelAddresses: []
+        sentinelMaster: ""
+        clusterNodes: []
+        clusterKeyHashTag: ""
+        poolSize: 10
+        maxRetries: 0
+        dialTimeoutSeconds: 5
+        readTimeoutSeconds: 10
+        writeTimeoutSeconds: 5
+        flushPeriodSeconds: 5
     fallbackTreatment:
         global_fallback_treatment:
             treatment: other
@@ -18,6 +37,15 @@ sdk:
         events: https://events.split.io/api
         streaming: https://streaming.split.io/sse
         telemetry: https://telemetry.split.io/api/v1
+    http:
+        timeoutMS: 30000
+        dialTimeoutMS: 30000
+        tlsHandshakeTimeoutMS: 10000
+        proxyURL: ""
+        caFile: ""
+        certFile: ""
+        keyFile: ""
+        headers: {}
     featureFlags:
         splitNotificationQueueSize: 5000
         splitRefreshSeconds: 30
@@ -31,10 +59,70 @@ sdk:
         countRefreshRateSeconds: 3600
         queueSize: 8192
         observerSize: 500000
+        watermark: 0
+        maxMemoryBytes: 0
+        dropPolicy: newest
+        allowedClientModes: []
+        allowClientLabels: false
     events:
         refreshRateSeconds: 60
         queueSize: 8192
+        watermark: 0
+        maxMemoryBytes: 0
+        dropPolicy: newest
+        file:
+            path: ""
+            rotationMaxFiles: 10
+            rotationMaxBytesPerFile: 104857600
+            queueSize: 8192
+            refreshRateSeconds: 5
+            trafficTypes: []
+            eventTypes: []
+        webhook:
+            url: ""
+            headers: {}
+            timeoutMS: 5000
+            queueSize: 8192
+            refreshRateSeconds: 5
+            trafficTypes: []
+            eventTypes: []
+        collector:
+            url: ""
+            queueSize: 8192
+            refreshRateSeconds: 60
+            trafficTypes: []
+            eventTypes: []
     flagSetsFilter: []
+    staleness:
+        splitsThresholdSeconds: 0
+        segmentsThresholdSeconds: 0
+        useFallbackTreatments: false
+    snapshot:
+        path: ""
+        exportPeriodSeconds: 300
+        loadOnStartup: false
+        maxAgeSeconds: 86400
+    impressionListeners:
+        queueSize: 10000
+        batchSize: 500
+        flushPeriodMS: 1000
+        file:
+            path: ""
+            rotationMaxFiles: 10
+            rotationMaxBytesPerFile: 104857600
+        stream:
+            network: unix
+            address: ""
+            writeTimeoutMS: 1000
+        webhook:
+            url: ""
+            headers: {}
+            timeoutMS: 5000
+    overrides:
+        file: ""
+    environments: []
+    defaultAttributes: {}
+    defaultAttributesEnvPrefix: ""
 link:
     type: unix-seqpacket
     address: /var/run/splitd.sock
@@ -45,6 +133,15 @@ link:
     serialization: msgpack
     bufferSize: 1024
     protocol: v1
+    environment: ""
+    idleTimeoutSeconds: 0
+    maxConnectionLifetimeSeconds: 0
+    socketMode: ""
+    socketOwner: ""
+    socketGroup: ""
+    allowedUIDs: []
+    allowedGIDs: []
+    tokens: []
 debug:
     profiling:
         enable: false
@@ -53,4 +150,9 @@ debug:
 api:
     host: 0.0.0.0
     port: 8887
-
+    adminEnabled: false
+upgrade:
+    enabled: false
+    readyTimeoutSeconds: 60
+    drainTimeoutSeconds: 10
+    handOverSnapshot: true
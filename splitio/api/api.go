@@ -10,10 +10,20 @@ import (
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/splitd/splitio/api/controllers"
 	"github.com/splitio/splitd/splitio/link"
+	"github.com/splitio/splitd/splitio/link/auth"
 	"github.com/splitio/splitd/splitio/link/client"
+	"github.com/splitio/splitd/splitio/sdk/overrides"
 )
 
-func Setup(host string, port int, logger logging.LoggerInterface, listenerCfG link.ListenerOptions) (*http.Server, error) {
+// Setup builds the http server. Admin endpoints are only registered if `overrideStores` is not nil, and only serve
+// requests authenticated with one of the link tokens that has the `admin` scope
+func Setup(
+	host string,
+	port int,
+	logger logging.LoggerInterface,
+	listenerCfG link.ListenerOptions,
+	overrideStores map[string]*overrides.Store,
+) (*http.Server, error) {
 
 	router := gin.Default()
 	mainAPI := router.Group("/api")
@@ -30,6 +40,15 @@ func Setup(host string, port int, logger logging.LoggerInterface, listenerCfG li
 
 	healthCtrl.Register(mainAPI)
 
+	if overrideStores != nil {
+		authenticator, err := auth.NewAuthenticator(listenerCfG.Tokens)
+		if err != nil {
+			return nil, fmt.Errorf("error setting up admin api authentication: %w", err)
+		}
+		adminAPI := mainAPI.Group("/admin", controllers.RequireScope(authenticator, auth.ScopeAdmin))
+		controllers.NewOverridesController(logger, overrideStores).Register(adminAPI)
+	}
+
 	return &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", host, port),
 		Handler: router,
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/splitd/splitio/link/auth"
+)
+
+var errForbidden = errors.New("token not allowed to perform this operation")
+
+// RequireScope only lets through requests presenting (as a bearer token) the secret of a token allowed to perform
+// the operations in `scope`. Since anonymous access never includes the admin scope, admin endpoints are unreachable
+// unless authentication is enabled
+func RequireScope(authenticator *auth.Authenticator, scope auth.Scope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		secret, _ := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		token, err := authenticator.Authenticate(secret)
+		if err != nil {
+			ctx.AbortWithError(http.StatusUnauthorized, err)
+			return
+		}
+
+		if !token.Scope.Allows(scope) {
+			ctx.AbortWithError(http.StatusForbidden, errForbidden)
+			return
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/splitd/splitio/link/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(authenticator *auth.Authenticator) func(secret string) int {
+		_, router := gin.CreateTestContext(httptest.NewRecorder())
+		router.GET("/admin", RequireScope(authenticator, auth.ScopeAdmin), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+		return func(secret string) int {
+			resp := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/admin", nil)
+			if secret != "" {
+				req.Header.Set("Authorization", "Bearer "+secret)
+			}
+			router.ServeHTTP(resp, req)
+			return resp.Code
+		}
+	}
+
+	authenticator, err := auth.NewAuthenticator([]auth.Token{
+		{Name: "ops", Secret: "adm1n", Scope: auth.ScopeAdmin},
+		{Name: "backend", Secret: "s3cr3t", Scope: auth.ScopeAll},
+	})
+	require.Nil(t, err)
+	do := setup(authenticator)
+	assert.Equal(t, http.StatusOK, do("adm1n"))
+	assert.Equal(t, http.StatusForbidden, do("s3cr3t"))
+	assert.Equal(t, http.StatusUnauthorized, do("wrong"))
+	assert.Equal(t, http.StatusUnauthorized, do(""))
+
+	// without authentication, nobody gets admin access
+	do = setup(nil)
+	assert.Equal(t, http.StatusForbidden, do(""))
+	assert.Equal(t, http.StatusForbidden, do("adm1n"))
+}
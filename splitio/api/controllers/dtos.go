@@ -1,5 +1,7 @@
 package controllers
 
+import "time"
+
 type SplitViewDTO struct {
 	Name                string            `json:"name"`
 	TrafficType         string            `json:"trafficType"`
@@ -11,3 +13,23 @@ type SplitViewDTO struct {
 	Sets                []string          `json:"sets"`
 	ImpressionsDisabled bool              `json:"impressionsDisabled"`
 }
+
+type ReadinessDTO struct {
+	Ready bool         `json:"ready"`
+	State string       `json:"state"`
+	Stale StalenessDTO `json:"stale"`
+}
+
+type StalenessDTO struct {
+	Splits   bool `json:"splits"`
+	Segments bool `json:"segments"`
+}
+
+type OverrideDTO struct {
+	Flag       string     `json:"flag"`
+	Key        string     `json:"key"`
+	Treatment  string     `json:"treatment"`
+	Config     *string    `json:"config"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	TTLSeconds *int64     `json:"ttlSeconds"` // takes precedence over ExpiresAt
+}
@@ -42,13 +42,27 @@ func (c *HealthCheckController) isReady(ctx *gin.Context) {
 		return
 	}
 
-	_, err = client.New(c.logger, conn, serial, c.connParams.Consumer)
+	rpcClient, err := client.New(c.logger, conn, serial, c.connParams.Consumer)
 	if err != nil {
 		ctx.AbortWithError(500, fmt.Errorf("error setting up client: %w", err))
 		return
 	}
 
-	ctx.Status(200)
+	sdkStatus, err := rpcClient.Status()
+	if err != nil {
+		ctx.AbortWithError(500, fmt.Errorf("error issuing RPC: %w", err))
+		return
+	}
+
+	status := 200
+	if !sdkStatus.State.IsReady() {
+		status = 503
+	}
+	ctx.JSON(status, ReadinessDTO{
+		Ready: sdkStatus.State.IsReady(),
+		State: sdkStatus.State.String(),
+		Stale: StalenessDTO(sdkStatus.Staleness),
+	})
 }
 
 func (c *HealthCheckController) checkFlag(ctx *gin.Context) {
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/splitio/go-toolkit/v5/logging"
@@ -47,6 +48,9 @@ func TestReadinessOk(t *testing.T) {
 	logger := logging.NewLogger(nil)
 
 	var sdkMock mocks.SDKMock
+	sdkMock.On("BlockUntilReady", time.Duration(0)).Return(nil).Once()
+	sdkMock.On("Readiness").Return(sdk.StateReady).Once()
+	sdkMock.On("Staleness").Return(sdk.Staleness{}).Once()
 
 	listenerCfg := link.DefaultListenerOptions()
 	listenerCfg.Transfer.ConnType = transfer.ConnTypeUnixStream
@@ -65,6 +69,53 @@ func TestReadinessOk(t *testing.T) {
 	router.ServeHTTP(resp, ctx.Request)
 	assert.Equal(t, 200, resp.Code)
 
+	var rd ReadinessDTO
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &rd))
+	assert.Equal(t, ReadinessDTO{Ready: true, State: "ready"}, rd)
+}
+
+func TestReadinessStates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logging.NewLogger(nil)
+
+	var sdkMock mocks.SDKMock
+	sdkMock.On("BlockUntilReady", time.Duration(0)).Return(sdk.ErrNotReady).Once()
+	sdkMock.On("Readiness").Return(sdk.StateNotReady).Once()
+	sdkMock.On("Staleness").Return(sdk.Staleness{}).Once()
+	sdkMock.On("BlockUntilReady", time.Duration(0)).Return(nil).Once()
+	sdkMock.On("Readiness").Return(sdk.StateReadyDegraded).Once()
+	sdkMock.On("Staleness").Return(sdk.Staleness{Splits: true}).Once()
+
+	listenerCfg := link.DefaultListenerOptions()
+	listenerCfg.Transfer.ConnType = transfer.ConnTypeUnixStream
+	listenerCfg.Transfer.Address = fmt.Sprintf("%s/health_test_%d", os.TempDir(), os.Getpid())
+	_, shutdown, err := link.Listen(logger, &sdkMock, &listenerCfg)
+	assert.Nil(t, err)
+	defer shutdown()
+
+	consumerCfg := link.DefaultConsumerOptions()
+	consumerCfg.Transfer = listenerCfg.Transfer
+
+	controller := NewHealthController(logging.NewLogger(nil), consumerCfg)
+
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+	controller.Register(router.Group("/api"))
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/ready", nil)
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 503, resp.Code)
+	var rd ReadinessDTO
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &rd))
+	assert.Equal(t, ReadinessDTO{Ready: false, State: "not-ready"}, rd)
+
+	resp = httptest.NewRecorder()
+	ctx, router = gin.CreateTestContext(resp)
+	controller.Register(router.Group("/api"))
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/ready", nil)
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 200, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &rd))
+	assert.Equal(t, ReadinessDTO{Ready: true, State: "ready-degraded", Stale: StalenessDTO{Splits: true}}, rd)
 }
 
 func TestReadinessFailToConnect(t *testing.T) {
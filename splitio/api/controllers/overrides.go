@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/splitio/sdk/overrides"
+)
+
+// OverridesController exposes admin endpoints to manage the forced treatments of each environment
+type OverridesController struct {
+	stores map[string]*overrides.Store
+	logger logging.LoggerInterface
+	now    func() time.Time
+}
+
+func (c *OverridesController) Register(router gin.IRouter) {
+	router.GET("/overrides", c.list)
+	router.PUT("/overrides", c.set)
+	router.DELETE("/overrides", c.clear)
+	router.DELETE("/overrides/:flag/:key", c.remove)
+}
+
+func (c *OverridesController) list(ctx *gin.Context) {
+	_, store, ok := c.storeFor(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, store.List())
+}
+
+func (c *OverridesController) set(ctx *gin.Context) {
+	env, store, ok := c.storeFor(ctx)
+	if !ok {
+		return
+	}
+
+	var dto OverrideDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.AbortWithError(http.StatusBadRequest, fmt.Errorf("error parsing override: %w", err))
+		return
+	}
+
+	o := overrides.Override{Flag: dto.Flag, Key: dto.Key, Treatment: dto.Treatment, Config: dto.Config, ExpiresAt: dto.ExpiresAt}
+	if dto.TTLSeconds != nil {
+		expiresAt := c.now().Add(time.Duration(*dto.TTLSeconds) * time.Second)
+		o.ExpiresAt = &expiresAt
+	}
+
+	if err := store.Set(o); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, overrides.ErrInvalidOverride) {
+			status = http.StatusBadRequest
+		}
+		ctx.AbortWithError(status, err)
+		return
+	}
+
+	c.logger.Info(fmt.Sprintf("override set for flag '%s' & key '%s' in environment '%s': '%s'", o.Flag, o.Key, env, o.Treatment))
+	ctx.JSON(http.StatusOK, o)
+}
+
+func (c *OverridesController) remove(ctx *gin.Context) {
+	env, store, ok := c.storeFor(ctx)
+	if !ok {
+		return
+	}
+
+	flag, key := ctx.Param("flag"), ctx.Param("key")
+	if !store.Remove(flag, key) {
+		ctx.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	c.logger.Info(fmt.Sprintf("override removed for flag '%s' & key '%s' in environment '%s'", flag, key, env))
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *OverridesController) clear(ctx *gin.Context) {
+	env, store, ok := c.storeFor(ctx)
+	if !ok {
+		return
+	}
+	store.Clear()
+	c.logger.Info(fmt.Sprintf("all overrides removed in environment '%s'", env))
+	ctx.Status(http.StatusNoContent)
+}
+
+// storeFor returns the name & store of the environment in the `environment` query param (the default one if absent),
+// aborting the request with a 404 if there's no such environment
+func (c *OverridesController) storeFor(ctx *gin.Context) (string, *overrides.Store, bool) {
+	env := ctx.Query("environment")
+	store, ok := c.stores[env]
+	if !ok || store == nil {
+		ctx.AbortWithError(http.StatusNotFound, fmt.Errorf("unknown environment '%s'", env))
+		return "", nil, false
+	}
+	return env, store, true
+}
+
+func NewOverridesController(logger logging.LoggerInterface, stores map[string]*overrides.Store) *OverridesController {
+	return &OverridesController{
+		stores: stores,
+		logger: logger,
+		now:    time.Now,
+	}
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/splitio/sdk/overrides"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverrides(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, router := gin.CreateTestContext(httptest.NewRecorder())
+	group := router.Group("/api/admin")
+
+	def, staging := overrides.NewStore(), overrides.NewStore()
+	controller := NewOverridesController(logging.NewLogger(nil), map[string]*overrides.Store{"": def, "staging": staging})
+	now := time.Now()
+	controller.now = func() time.Time { return now }
+	controller.Register(group)
+
+	do := func(method string, url string, body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, strings.NewReader(body))
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := do(http.MethodPut, "/api/admin/overrides", `{"flag": "f1", "key": "k1", "treatment": "on", "config": "{\"a\":1}"}`)
+	assert.Equal(t, 200, resp.Code)
+	o, ok := def.Get("f1", "k1")
+	require.True(t, ok)
+	assert.Equal(t, "on", o.Treatment)
+	assert.Equal(t, `{"a":1}`, *o.Config)
+
+	resp = do(http.MethodPut, "/api/admin/overrides?environment=staging", `{"flag": "f1", "key": "k1", "treatment": "off", "ttlSeconds": 60}`)
+	assert.Equal(t, 200, resp.Code)
+	o, ok = staging.Get("f1", "k1")
+	require.True(t, ok)
+	assert.Equal(t, "off", o.Treatment)
+	assert.Equal(t, now.Add(time.Minute), *o.ExpiresAt)
+
+	assert.Equal(t, 400, do(http.MethodPut, "/api/admin/overrides", `{"flag": "f1"}`).Code)
+	assert.Equal(t, 400, do(http.MethodPut, "/api/admin/overrides", `not json`).Code)
+	assert.Equal(t, 404, do(http.MethodPut, "/api/admin/overrides?environment=prod", `{"flag": "f1", "key": "k1", "treatment": "on"}`).Code)
+
+	resp = do(http.MethodGet, "/api/admin/overrides", "")
+	assert.Equal(t, 200, resp.Code)
+	var listed []overrides.Override
+	require.Nil(t, json.Unmarshal(resp.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+	assert.Equal(t, "k1", listed[0].Key)
+
+	assert.Equal(t, 204, do(http.MethodDelete, "/api/admin/overrides/f1/k1", "").Code)
+	assert.Equal(t, 404, do(http.MethodDelete, "/api/admin/overrides/f1/k1", "").Code)
+	_, ok = def.Get("f1", "k1")
+	assert.False(t, ok)
+
+	assert.Equal(t, 204, do(http.MethodDelete, "/api/admin/overrides?environment=staging", "").Code)
+	assert.Empty(t, staging.List())
+}
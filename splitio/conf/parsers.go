@@ -2,6 +2,9 @@ package conf
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/splitio/splitd/splitio/link/protocol"
 	"github.com/splitio/splitd/splitio/link/serializer"
@@ -33,3 +36,63 @@ func parseSerializer(s string) (serializer.Mechanism, error) {
 	}
 	return 0, fmt.Errorf("unknown serialization mechanism '%s'", s)
 }
+
+// parseFileMode parses an octal permission string (ie: "0660"). An empty string yields 0, meaning "unchanged"
+func parseFileMode(m string) (os.FileMode, error) {
+	if m == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseUint(m, 8, 32)
+	if err != nil || parsed > 0o777 {
+		return 0, fmt.Errorf("invalid file mode '%s'", m)
+	}
+	return os.FileMode(parsed), nil
+}
+
+// attributesFromEnv collects the variables in `environ` (formatted as KEY=value) starting with `prefix` as attributes.
+// The prefix is stripped from the name, and values are parsed as numbers or booleans where possible
+func attributesFromEnv(prefix string, environ []string) map[string]interface{} {
+	attrs := make(map[string]interface{})
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, prefix) || len(key) == len(prefix) {
+			continue
+		}
+		attrs[strings.TrimPrefix(key, prefix)] = parseAttributeValue(value)
+	}
+	return attrs
+}
+
+func parseAttributeValue(raw string) interface{} {
+	if asInt, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return asInt
+	}
+	if asFloat, err := strconv.ParseFloat(raw, 64); err == nil {
+		return asFloat
+	}
+	if asBool, err := strconv.ParseBool(raw); err == nil {
+		return asBool
+	}
+	return raw
+}
+
+// normalizeAttributes converts values decoded from yaml into the types used by the evaluator
+// (int64 for integers, []string for lists), the same way attributes sent by clients are handled
+func normalizeAttributes(attrs map[string]interface{}) map[string]interface{} {
+	normalized := make(map[string]interface{}, len(attrs))
+	for k, v := range attrs {
+		switch parsed := v.(type) {
+		case int:
+			normalized[k] = int64(parsed)
+		case []interface{}:
+			asStrSlice := make([]string, 0, len(parsed))
+			for _, item := range parsed {
+				asStrSlice = append(asStrSlice, fmt.Sprint(item))
+			}
+			normalized[k] = asStrSlice
+		default:
+			normalized[k] = v
+		}
+	}
+	return normalized
+}
@@ -44,3 +44,29 @@ func TestParseSerializer(t *testing.T) {
 	assert.NotEqual(t, serializer.MsgPack, sm)
 
 }
+
+func TestAttributesFromEnv(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"SPLITD_ATTR_region=us-east-1",
+		"SPLITD_ATTR_hostVersion=12",
+		"SPLITD_ATTR_load=0.75",
+		"SPLITD_ATTR_canary=true",
+		"SPLITD_ATTR_=ignored",
+		"SPLITD_ATTR_empty=",
+	}
+	assert.Equal(t, map[string]interface{}{
+		"region":      "us-east-1",
+		"hostVersion": int64(12),
+		"load":        0.75,
+		"canary":      true,
+		"empty":       "",
+	}, attributesFromEnv("SPLITD_ATTR_", environ))
+}
+
+func TestNormalizeAttributes(t *testing.T) {
+	assert.Equal(t,
+		map[string]interface{}{"a": int64(1), "b": []string{"x", "2"}, "c": "s", "d": 1.5},
+		normalizeAttributes(map[string]interface{}{"a": 1, "b": []interface{}{"x", 2}, "c": "s", "d": 1.5}),
+	)
+}
@@ -23,6 +23,7 @@ type CliArgs struct {
 	BufSize        int
 	ReadTimeoutMS  int
 	WriteTimeoutMS int
+	Token          string
 
 	// command
 	Method               string
@@ -30,6 +31,7 @@ type CliArgs struct {
 	BucketingKey         string
 	Feature              string
 	Features             []string
+	Segment              string
 	TrafficType          string
 	EventType            string
 	EventVal             *float64
@@ -41,6 +43,7 @@ func (a *CliArgs) LinkOpts() (*link.ConsumerOptions, error) {
 
 	opts := link.DefaultConsumerOptions()
 	lang.SetIfNotEmpty(&opts.Consumer.ID, &a.ID)
+	lang.SetIfNotEmpty(&opts.Consumer.Token, &a.Token)
 
 	var err error
 	if a.Protocol != "" {
@@ -77,11 +80,13 @@ func ParseCliArgs() (*CliArgs, error) {
 	ct := cliFlags.String("conn-type", "", "unix-seqpacket|unix-stream")
 	ca := cliFlags.String("conn-address", "", "path/ipv4-address")
 	bs := cliFlags.Int("buffer-size", 0, "read buffer size in bytes")
+	tk := cliFlags.String("token", os.Getenv("SPLITD_TOKEN"), "authentication token (defaults to $SPLITD_TOKEN)")
 	m := cliFlags.String("method", "", "treatment|treatments|treatmentWithConfig|treatmentsWithConfig|track")
 	k := cliFlags.String("key", "", "user key")
 	bk := cliFlags.String("bucketing-key", "", "bucketing key")
 	f := cliFlags.String("feature", "", "feature to evaluate")
 	fs := cliFlags.String("features", "", "features to evaluate (comma-separated list with no spaces in between)")
+	sg := cliFlags.String("segment", "", "segment (or rule-based segment) to inspect")
 	tt := cliFlags.String("traffic-type", "", "traffic type of event")
 	et := cliFlags.String("event-type", "", "event type")
 	ev := cliFlags.String("value", "", "event associated value")
@@ -125,11 +130,13 @@ func ParseCliArgs() (*CliArgs, error) {
 		ConnType:             *ct,
 		ConnAddr:             *ca,
 		BufSize:              *bs,
+		Token:                *tk,
 		Method:               *m,
 		Key:                  *k,
 		BucketingKey:         *bk,
 		Feature:              *f,
 		Features:             strings.Split(*fs, ","),
+		Segment:              *sg,
 		TrafficType:          *tt,
 		EventType:            *et,
 		EventVal:             eventVal,
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/splitd/splitio/common/lang"
 	"github.com/splitio/splitd/splitio/link"
+	"github.com/splitio/splitd/splitio/link/auth"
 	sdlogging "github.com/splitio/splitd/splitio/logging"
 	sdkConf "github.com/splitio/splitd/splitio/sdk/conf"
 	"gopkg.in/yaml.v3"
@@ -25,11 +27,12 @@ const (
 )
 
 type Config struct {
-	Logger Logger `yaml:"logging"`
-	SDK    SDK    `yaml:"sdk"`
-	Link   Link   `yaml:"link"`
-	Debug  Debug  `yaml:"debug"`
-	API    API    `yaml:"api"`
+	Logger  Logger  `yaml:"logging"`
+	SDK     SDK     `yaml:"sdk"`
+	Link    Link    `yaml:"link"`
+	Debug   Debug   `yaml:"debug"`
+	API     API     `yaml:"api"`
+	Upgrade Upgrade `yaml:"upgrade"`
 }
 
 func (c Config) String() string {
@@ -37,10 +40,60 @@ func (c Config) String() string {
 		c.SDK.Apikey = c.SDK.Apikey[:4] + "xxxxxxx"
 	}
 
+	if c.SDK.Redis.Password != nil && *c.SDK.Redis.Password != "" {
+		c.SDK.Redis.Password = lang.Ref("xxxxxxx")
+	}
+
+	envs := make([]Environment, len(c.SDK.Environments))
+	for idx, env := range c.SDK.Environments {
+		if len(env.Apikey) > 4 {
+			env.Apikey = env.Apikey[:4] + "xxxxxxx"
+		}
+		envs[idx] = env
+	}
+	c.SDK.Environments = envs
+
+	tokens := make([]AuthToken, len(c.Link.Tokens))
+	for idx, token := range c.Link.Tokens {
+		token.Secret = "xxxxxxx"
+		tokens[idx] = token
+	}
+	c.Link.Tokens = tokens
+
+	c.SDK.Listeners.Webhook.Headers = maskHeaders(c.SDK.Listeners.Webhook.Headers)
+	c.SDK.Events.Webhook.Headers = maskHeaders(c.SDK.Events.Webhook.Headers)
+	c.SDK.HTTP.Headers = maskHeaders(c.SDK.HTTP.Headers)
+	if c.SDK.HTTP.ProxyURL != nil {
+		c.SDK.HTTP.ProxyURL = lang.Ref(stripUserinfo(*c.SDK.HTTP.ProxyURL))
+	}
+
 	output, _ := json.Marshal(c)
 	return string(output)
 }
 
+// stripUserinfo removes the credentials (if any) from `rawURL`. Unparseable URLs are masked altogether
+func stripUserinfo(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "xxxxxxx"
+	}
+	parsed.User = nil
+	return parsed.String()
+}
+
+// maskHeaders returns a copy of `headers` with their values masked, since they usually carry credentials
+func maskHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return headers
+	}
+
+	masked := make(map[string]string, len(headers))
+	for name := range headers {
+		masked[name] = "xxxxxxx"
+	}
+	return masked
+}
+
 func (c *Config) parse(fn string) error {
 
 	raw, err := os.ReadFile(fn)
@@ -62,6 +115,7 @@ func (c *Config) PopulateWithDefaults() {
 	c.Logger.PopulateWithDefaults()
 	c.Debug.PopulateWithDefaults()
 	c.API.PopulateWithDefaults()
+	c.Upgrade.PopulateWithDefaults()
 }
 
 type Link struct {
@@ -74,6 +128,32 @@ type Link struct {
 	Serialization        *string `yaml:"serialization"`
 	BufferSize           *int    `yaml:"bufferSize"`
 	Protocol             *string `yaml:"protocol"`
+	Environment          *string `yaml:"environment"`
+
+	// connections without incoming rpcs for this long are closed, and the ones older than the max lifetime are asked
+	// to reconnect (after serving their next rpc). 0 disables each of them
+	IdleTimeoutSeconds           *int `yaml:"idleTimeoutSeconds"`
+	MaxConnectionLifetimeSeconds *int `yaml:"maxConnectionLifetimeSeconds"`
+
+	// socket file mode (octal, ie: "0660"), owner & group (names or numeric ids). Empty values keep the process defaults
+	SocketMode  *string `yaml:"socketMode"`
+	SocketOwner *string `yaml:"socketOwner"`
+	SocketGroup *string `yaml:"socketGroup"`
+
+	// when any of these is non-empty, only processes running as one of the UIDs or GIDs listed are allowed to connect
+	AllowedUIDs []uint32 `yaml:"allowedUIDs"`
+	AllowedGIDs []uint32 `yaml:"allowedGIDs"`
+
+	// if not empty, clients must present one of these tokens when registering
+	Tokens []AuthToken `yaml:"tokens"`
+}
+
+// AuthToken is a shared secret clients use to authenticate, along with the operations it allows:
+// `evaluate`, `track`, `inspect-splits` and/or `admin` (http admin api)
+type AuthToken struct {
+	Name   string   `yaml:"name"`
+	Secret string   `yaml:"secret"`
+	Scopes []string `yaml:"scopes"`
 }
 
 func (l *Link) PopulateWithDefaults() {
@@ -87,6 +167,15 @@ func (l *Link) PopulateWithDefaults() {
 	l.MaxSimultaneousConns = lang.Ref(linkOpts.Acceptor.MaxSimultaneousConnections)
 	l.Protocol = lang.Ref(linkOpts.Protocol.String())
 	l.Serialization = lang.Ref(linkOpts.Serialization.String())
+	l.Environment = lang.Ref(linkOpts.Environment)
+	l.IdleTimeoutSeconds = lang.Ref(int(linkOpts.Connections.IdleTimeout.Seconds()))
+	l.MaxConnectionLifetimeSeconds = lang.Ref(int(linkOpts.Connections.MaxLifetime.Seconds()))
+	l.SocketMode = lang.Ref("")
+	l.SocketOwner = lang.Ref(linkOpts.Acceptor.SocketPermissions.Owner)
+	l.SocketGroup = lang.Ref(linkOpts.Acceptor.SocketPermissions.Group)
+	l.AllowedUIDs = []uint32{}
+	l.AllowedGIDs = []uint32{}
+	l.Tokens = []AuthToken{}
 }
 
 func (l *Link) ToListenerOpts() (*link.ListenerOptions, error) {
@@ -111,13 +200,40 @@ func (l *Link) ToListenerOpts() (*link.ListenerOptions, error) {
 		}
 	}
 
+	if len(l.AllowedUIDs) > 0 {
+		opts.Acceptor.PeerAuthorization.AllowedUIDs = l.AllowedUIDs
+	}
+	if len(l.AllowedGIDs) > 0 {
+		opts.Acceptor.PeerAuthorization.AllowedGIDs = l.AllowedGIDs
+	}
+
+	for _, token := range l.Tokens {
+		scope, err := auth.ParseScopes(token.Scopes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scopes for token '%s': %w", token.Name, err)
+		}
+		opts.Tokens = append(opts.Tokens, auth.Token{Name: token.Name, Secret: token.Secret, Scope: scope})
+	}
+
+	if l.SocketMode != nil {
+		if opts.Acceptor.SocketPermissions.Mode, err = parseFileMode(*l.SocketMode); err != nil {
+			return nil, fmt.Errorf("invalid socket mode %s", *l.SocketMode)
+		}
+	}
+
 	durationFromMS := func(i int) time.Duration { return time.Duration(i) * time.Millisecond }
+	durationFromSeconds := func(seconds int) time.Duration { return time.Duration(seconds) * time.Second }
+	lang.SetIfNotNil(&opts.Acceptor.SocketPermissions.Owner, l.SocketOwner)
+	lang.SetIfNotNil(&opts.Acceptor.SocketPermissions.Group, l.SocketGroup)
 	lang.SetIfNotNil(&opts.Transfer.Address, l.Address)
+	lang.SetIfNotNil(&opts.Environment, l.Environment)
 	lang.SetIfNotNil(&opts.Transfer.BufferSize, l.BufferSize)
 	lang.SetIfNotNil(&opts.Acceptor.MaxSimultaneousConnections, l.MaxSimultaneousConns)
 	lang.MapIfNotNil(&opts.Transfer.ReadTimeout, l.ReadTimeoutMS, durationFromMS)
 	lang.MapIfNotNil(&opts.Transfer.WriteTimeout, l.WriteTimeoutMS, durationFromMS)
 	lang.MapIfNotNil(&opts.Acceptor.AcceptTimeout, l.AcceptTimeoutMS, durationFromMS)
+	lang.MapIfNotNil(&opts.Connections.IdleTimeout, l.IdleTimeoutSeconds, durationFromSeconds)
+	lang.MapIfNotNil(&opts.Connections.MaxLifetime, l.MaxConnectionLifetimeSeconds, durationFromSeconds)
 
 	return &opts, nil
 }
@@ -126,12 +242,27 @@ type SDK struct {
 	Apikey            string                 `yaml:"apikey"`
 	LabelsEnabled     *bool                  `yaml:"labelsEnabled"`
 	StreamingEnabled  *bool                  `yaml:"streamingEnabled"`
+	Storage           *string                `yaml:"storage"`
+	QueueType         *string                `yaml:"queueType"`
+	Redis             Redis                  `yaml:"redis"`
 	FallbackTreatment fallbackTreatmentInput `yaml:"fallbackTreatment"`
 	URLs              URLs                   `yaml:"urls"`
+	HTTP              HTTP                   `yaml:"http"`
 	FeatureFlags      FeatureFlags           `yaml:"featureFlags"`
 	Impressions       Impressions            `yaml:"impressions"`
 	Events            Events                 `yaml:"events"`
 	FlagSetsFilter    []string               `yaml:"flagSetsFilter"`
+	Staleness         Staleness              `yaml:"staleness"`
+	Snapshot          Snapshot               `yaml:"snapshot"`
+	Listeners         ImpressionListeners    `yaml:"impressionListeners"`
+	Overrides         Overrides              `yaml:"overrides"`
+	Environments      []Environment          `yaml:"environments"`
+
+	// DefaultAttributes are merged into every evaluation. If DefaultAttributesEnvPrefix is set, environment variables
+	// starting with it are added as well (ie: with prefix SPLITD_ATTR_, SPLITD_ATTR_region=us-east-1 sets `region`),
+	// overriding the ones in this file
+	DefaultAttributes          map[string]interface{} `yaml:"defaultAttributes"`
+	DefaultAttributesEnvPrefix *string                `yaml:"defaultAttributesEnvPrefix"`
 }
 
 func (s *SDK) PopulateWithDefaults() {
@@ -139,12 +270,139 @@ func (s *SDK) PopulateWithDefaults() {
 	s.Apikey = apikeyPlaceHolder
 	s.LabelsEnabled = lang.Ref(cfg.LabelsEnabled)
 	s.StreamingEnabled = lang.Ref(cfg.StreamingEnabled)
+	s.Storage = lang.Ref(cfg.Storage)
+	s.QueueType = lang.Ref(cfg.QueueType)
+	s.Redis.PopulateWithDefaults()
 	s.FallbackTreatment = fallbackTreatmentFromConfig(cfg.FallbackTreatment)
 	s.URLs.PopulateWithDefaults()
+	s.HTTP.PopulateWithDefaults()
 	s.FeatureFlags.PopulateWithDefaults()
 	s.Impressions.PopulateWithDefaults()
 	s.Events.PopulateWithDefaults()
 	s.FlagSetsFilter = []string{}
+	s.Staleness.PopulateWithDefaults()
+	s.Snapshot.PopulateWithDefaults()
+	s.Listeners.PopulateWithDefaults()
+	s.Overrides.PopulateWithDefaults()
+	s.Environments = []Environment{}
+	s.DefaultAttributes = map[string]interface{}{}
+	s.DefaultAttributesEnvPrefix = lang.Ref("")
+}
+
+// ValidateEnvironments checks that every additional environment has a unique name & an sdk key
+func (s *SDK) ValidateEnvironments() error {
+	seen := make(map[string]struct{}, len(s.Environments))
+	for idx, env := range s.Environments {
+		if env.Name == "" {
+			return fmt.Errorf("environment #%d has no name", idx)
+		}
+		if env.Apikey == "" {
+			return fmt.Errorf("environment '%s' has no apikey", env.Name)
+		}
+		if _, ok := seen[env.Name]; ok {
+			return fmt.Errorf("environment '%s' is declared more than once", env.Name)
+		}
+		seen[env.Name] = struct{}{}
+	}
+	return nil
+}
+
+// Environment declares an additional split environment served by the daemon. Every sdk setting not listed here
+// is inherited from the top-level ones. Clients pick an environment when registering, or get the one mapped to
+// the listener they connect to (`linkAddress` starts an extra listener bound to this environment)
+type Environment struct {
+	Name           string   `yaml:"name"`
+	Apikey         string   `yaml:"apikey"`
+	FlagSetsFilter []string `yaml:"flagSetsFilter"`
+	LinkAddress    *string  `yaml:"linkAddress"`
+	OverridesFile  *string  `yaml:"overridesFile"`
+}
+
+// ToSDKConf builds the config for this environment's sdk on top of `base`. Paths of files written by the sdk
+// get the environment name as a suffix, so that multiple instances don't write to the same files
+func (e *Environment) ToSDKConf(base *sdkConf.Config) *sdkConf.Config {
+	cfg := *base
+	if len(e.FlagSetsFilter) > 0 {
+		cfg.FlagSetsFilter = e.FlagSetsFilter
+	}
+
+	withSuffix := func(path string) string {
+		if path == "" {
+			return ""
+		}
+		return path + "." + e.Name
+	}
+	cfg.Snapshot.Path = withSuffix(cfg.Snapshot.Path)
+	cfg.ImpressionListeners.File.Path = withSuffix(cfg.ImpressionListeners.File.Path)
+	cfg.Events.File.Path = withSuffix(cfg.Events.File.Path)
+
+	// forced treatments are environment specific, so the top-level seed file is not inherited
+	cfg.Overrides.File = ""
+	lang.SetIfNotNil(&cfg.Overrides.File, e.OverridesFile)
+	return &cfg
+}
+
+type Redis struct {
+	Host                *string  `yaml:"host"`
+	Port                *int     `yaml:"port"`
+	Database            *int     `yaml:"db"`
+	Username            *string  `yaml:"username"`
+	Password            *string  `yaml:"password"`
+	Prefix              *string  `yaml:"prefix"`
+	SentinelAddresses   []string `yaml:"sentinelAddresses"`
+	SentinelMaster      *string  `yaml:"sentinelMaster"`
+	ClusterNodes        []string `yaml:"clusterNodes"`
+	ClusterKeyHashTag   *string  `yaml:"clusterKeyHashTag"`
+	PoolSize            *int     `yaml:"poolSize"`
+	MaxRetries          *int     `yaml:"maxRetries"`
+	DialTimeoutSeconds  *int     `yaml:"dialTimeoutSeconds"`
+	ReadTimeoutSeconds  *int     `yaml:"readTimeoutSeconds"`
+	WriteTimeoutSeconds *int     `yaml:"writeTimeoutSeconds"`
+	FlushPeriodSeconds  *int     `yaml:"flushPeriodSeconds"`
+}
+
+func (r *Redis) PopulateWithDefaults() {
+	cfg := sdkConf.DefaultConfig().Redis
+	r.Host = lang.Ref(cfg.Host)
+	r.Port = lang.Ref(cfg.Port)
+	r.Database = lang.Ref(cfg.Database)
+	r.Username = lang.Ref(cfg.Username)
+	r.Password = lang.Ref(cfg.Password)
+	r.Prefix = lang.Ref(cfg.Prefix)
+	r.SentinelAddresses = cfg.SentinelAddresses
+	r.SentinelMaster = lang.Ref(cfg.SentinelMaster)
+	r.ClusterNodes = cfg.ClusterNodes
+	r.ClusterKeyHashTag = lang.Ref(cfg.ClusterKeyHashTag)
+	r.PoolSize = lang.Ref(cfg.PoolSize)
+	r.MaxRetries = lang.Ref(cfg.MaxRetries)
+	r.DialTimeoutSeconds = lang.Ref(int(cfg.DialTimeout.Seconds()))
+	r.ReadTimeoutSeconds = lang.Ref(int(cfg.ReadTimeout.Seconds()))
+	r.WriteTimeoutSeconds = lang.Ref(int(cfg.WriteTimeout.Seconds()))
+	r.FlushPeriodSeconds = lang.Ref(int(cfg.FlushPeriod.Seconds()))
+}
+
+func (r *Redis) updateSDKConf(dst *sdkConf.Redis) {
+	durationFromSeconds := func(seconds int) time.Duration { return time.Duration(seconds) * time.Second }
+	lang.SetIfNotEmpty(&dst.Host, r.Host)
+	lang.SetIfNotEmpty(&dst.Port, r.Port)
+	lang.SetIfNotNil(&dst.Database, r.Database)
+	lang.SetIfNotNil(&dst.Username, r.Username)
+	lang.SetIfNotNil(&dst.Password, r.Password)
+	lang.SetIfNotNil(&dst.Prefix, r.Prefix)
+	if len(r.SentinelAddresses) > 0 {
+		dst.SentinelAddresses = r.SentinelAddresses
+	}
+	lang.SetIfNotNil(&dst.SentinelMaster, r.SentinelMaster)
+	if len(r.ClusterNodes) > 0 {
+		dst.ClusterNodes = r.ClusterNodes
+	}
+	lang.SetIfNotNil(&dst.ClusterKeyHashTag, r.ClusterKeyHashTag)
+	lang.SetIfNotEmpty(&dst.PoolSize, r.PoolSize)
+	lang.SetIfNotNil(&dst.MaxRetries, r.MaxRetries)
+	lang.MapIfNotNil(&dst.DialTimeout, r.DialTimeoutSeconds, durationFromSeconds)
+	lang.MapIfNotNil(&dst.ReadTimeout, r.ReadTimeoutSeconds, durationFromSeconds)
+	lang.MapIfNotNil(&dst.WriteTimeout, r.WriteTimeoutSeconds, durationFromSeconds)
+	lang.MapIfNotNil(&dst.FlushPeriod, r.FlushPeriodSeconds, durationFromSeconds)
 }
 
 type FeatureFlags struct {
@@ -167,12 +425,16 @@ func (f *FeatureFlags) PopulateWithDefaults() {
 }
 
 type Impressions struct {
-	Mode                    *string `yaml:"mode"`
-	RefreshRateSeconds      *int    `yaml:"refreshRateSeconds"`
-	CountRefreshRateSeconds *int    `yaml:"countRefreshRateSeconds"`
-	QueueSize               *int    `yaml:"queueSize"`
-	ObserverSize            *int    `yaml:"observerSize"`
-	Watermark               *int    `yaml:"watermark,omitempty"` // TODO(mredolatti) remove omitempty when fully implemented
+	Mode                    *string  `yaml:"mode"`
+	RefreshRateSeconds      *int     `yaml:"refreshRateSeconds"`
+	CountRefreshRateSeconds *int     `yaml:"countRefreshRateSeconds"`
+	QueueSize               *int     `yaml:"queueSize"`
+	ObserverSize            *int     `yaml:"observerSize"`
+	Watermark               *int     `yaml:"watermark"`
+	MaxMemoryBytes          *int64   `yaml:"maxMemoryBytes"`
+	DropPolicy              *string  `yaml:"dropPolicy"`
+	AllowedClientModes      []string `yaml:"allowedClientModes"`
+	AllowClientLabels       *bool    `yaml:"allowClientLabels"`
 }
 
 func (i *Impressions) PopulateWithDefaults() {
@@ -182,18 +444,213 @@ func (i *Impressions) PopulateWithDefaults() {
 	i.ObserverSize = lang.Ref(cfg.ObserverSize)
 	i.RefreshRateSeconds = lang.Ref(int(cfg.SyncPeriod.Seconds()))
 	i.QueueSize = lang.Ref(cfg.QueueSize)
+	i.Watermark = lang.Ref(cfg.Watermark)
+	i.MaxMemoryBytes = lang.Ref(cfg.MaxMemoryBytes)
+	i.DropPolicy = lang.Ref(cfg.DropPolicy)
+	i.AllowedClientModes = cfg.ClientModes
+	i.AllowClientLabels = lang.Ref(cfg.ClientLabels)
 }
 
 type Events struct {
-	RefreshRateSeconds *int `yaml:"refreshRateSeconds"`
-	QueueSize          *int `yaml:"queueSize"`
-	Watermark          *int `yaml:"watermark,omitempty"` // TODO(mredolatti) remove omitempty when fully implemented
+	RefreshRateSeconds *int                `yaml:"refreshRateSeconds"`
+	QueueSize          *int                `yaml:"queueSize"`
+	Watermark          *int                `yaml:"watermark"`
+	MaxMemoryBytes     *int64              `yaml:"maxMemoryBytes"`
+	DropPolicy         *string             `yaml:"dropPolicy"`
+	File               EventsFileSink      `yaml:"file"`
+	Webhook            EventsWebhookSink   `yaml:"webhook"`
+	Collector          EventsCollectorSink `yaml:"collector"`
 }
 
 func (e *Events) PopulateWithDefaults() {
 	cfg := sdkConf.DefaultConfig().Events
 	e.RefreshRateSeconds = lang.Ref(int(cfg.SyncPeriod.Seconds()))
 	e.QueueSize = lang.Ref(cfg.QueueSize)
+	e.Watermark = lang.Ref(cfg.Watermark)
+	e.MaxMemoryBytes = lang.Ref(cfg.MaxMemoryBytes)
+	e.DropPolicy = lang.Ref(cfg.DropPolicy)
+	e.File.PopulateWithDefaults()
+	e.Webhook.PopulateWithDefaults()
+	e.Collector.PopulateWithDefaults()
+}
+
+func (e *Events) updateSDKConf(dst *sdkConf.Events) {
+	durationFromSeconds := func(seconds int) time.Duration { return time.Duration(seconds) * time.Second }
+	lang.SetIfNotEmpty(&dst.QueueSize, e.QueueSize)
+	lang.MapIfNotNil(&dst.SyncPeriod, e.RefreshRateSeconds, durationFromSeconds)
+	lang.SetIfNotNil(&dst.Watermark, e.Watermark)
+	lang.SetIfNotNil(&dst.MaxMemoryBytes, e.MaxMemoryBytes)
+	lang.SetIfNotEmpty(&dst.DropPolicy, e.DropPolicy)
+
+	e.File.updateSDKConf(&dst.File.EventsSink)
+	lang.SetIfNotNil(&dst.File.Path, e.File.Path)
+	lang.SetIfNotNil(&dst.File.MaxFiles, e.File.RotationMaxFiles)
+	lang.SetIfNotNil(&dst.File.MaxBytesPerFile, e.File.RotationMaxBytesPerFile)
+
+	e.Webhook.updateSDKConf(&dst.Webhook.EventsSink)
+	lang.SetIfNotNil(&dst.Webhook.URL, e.Webhook.URL)
+	if len(e.Webhook.Headers) > 0 {
+		dst.Webhook.Headers = e.Webhook.Headers
+	}
+	lang.MapIfNotNil(&dst.Webhook.Timeout, e.Webhook.TimeoutMS, func(ms int) time.Duration { return time.Duration(ms) * time.Millisecond })
+
+	e.Collector.updateSDKConf(&dst.Collector.EventsSink)
+	lang.SetIfNotNil(&dst.Collector.URL, e.Collector.URL)
+}
+
+type EventsSink struct {
+	QueueSize          *int     `yaml:"queueSize"`
+	RefreshRateSeconds *int     `yaml:"refreshRateSeconds"`
+	TrafficTypes       []string `yaml:"trafficTypes"`
+	EventTypes         []string `yaml:"eventTypes"`
+}
+
+func (e *EventsSink) populateWithDefaults(cfg *sdkConf.EventsSink) {
+	e.QueueSize = lang.Ref(cfg.QueueSize)
+	e.RefreshRateSeconds = lang.Ref(int(cfg.SyncPeriod.Seconds()))
+	e.TrafficTypes = cfg.TrafficTypes
+	e.EventTypes = cfg.EventTypes
+}
+
+func (e *EventsSink) updateSDKConf(dst *sdkConf.EventsSink) {
+	lang.SetIfNotEmpty(&dst.QueueSize, e.QueueSize)
+	lang.MapIfNotNil(&dst.SyncPeriod, e.RefreshRateSeconds, func(seconds int) time.Duration { return time.Duration(seconds) * time.Second })
+	if len(e.TrafficTypes) > 0 {
+		dst.TrafficTypes = e.TrafficTypes
+	}
+	if len(e.EventTypes) > 0 {
+		dst.EventTypes = e.EventTypes
+	}
+}
+
+type EventsFileSink struct {
+	Path                    *string `yaml:"path"`
+	RotationMaxFiles        *int    `yaml:"rotationMaxFiles"`
+	RotationMaxBytesPerFile *int64  `yaml:"rotationMaxBytesPerFile"`
+	EventsSink              `yaml:",inline"`
+}
+
+func (e *EventsFileSink) PopulateWithDefaults() {
+	cfg := sdkConf.DefaultConfig().Events.File
+	e.Path = lang.Ref(cfg.Path)
+	e.RotationMaxFiles = lang.Ref(cfg.MaxFiles)
+	e.RotationMaxBytesPerFile = lang.Ref(cfg.MaxBytesPerFile)
+	e.populateWithDefaults(&cfg.EventsSink)
+}
+
+type EventsWebhookSink struct {
+	URL        *string           `yaml:"url"`
+	Headers    map[string]string `yaml:"headers"`
+	TimeoutMS  *int              `yaml:"timeoutMS"`
+	EventsSink `yaml:",inline"`
+}
+
+func (e *EventsWebhookSink) PopulateWithDefaults() {
+	cfg := sdkConf.DefaultConfig().Events.Webhook
+	e.URL = lang.Ref(cfg.URL)
+	e.Headers = cfg.Headers
+	e.TimeoutMS = lang.Ref(int(cfg.Timeout.Milliseconds()))
+	e.populateWithDefaults(&cfg.EventsSink)
+}
+
+type EventsCollectorSink struct {
+	URL        *string `yaml:"url"`
+	EventsSink `yaml:",inline"`
+}
+
+func (e *EventsCollectorSink) PopulateWithDefaults() {
+	cfg := sdkConf.DefaultConfig().Events.Collector
+	e.URL = lang.Ref(cfg.URL)
+	e.populateWithDefaults(&cfg.EventsSink)
+}
+
+type Staleness struct {
+	SplitsThresholdSeconds   *int  `yaml:"splitsThresholdSeconds"`
+	SegmentsThresholdSeconds *int  `yaml:"segmentsThresholdSeconds"`
+	UseFallbackTreatments    *bool `yaml:"useFallbackTreatments"`
+}
+
+func (s *Staleness) PopulateWithDefaults() {
+	cfg := sdkConf.DefaultConfig().Staleness
+	s.SplitsThresholdSeconds = lang.Ref(int(cfg.SplitsThreshold.Seconds()))
+	s.SegmentsThresholdSeconds = lang.Ref(int(cfg.SegmentsThreshold.Seconds()))
+	s.UseFallbackTreatments = lang.Ref(cfg.UseFallbackTreatments)
+}
+
+type Snapshot struct {
+	Path                *string `yaml:"path"`
+	ExportPeriodSeconds *int    `yaml:"exportPeriodSeconds"`
+	LoadOnStartup       *bool   `yaml:"loadOnStartup"`
+	MaxAgeSeconds       *int    `yaml:"maxAgeSeconds"`
+}
+
+func (s *Snapshot) PopulateWithDefaults() {
+	cfg := sdkConf.DefaultConfig().Snapshot
+	s.Path = lang.Ref(cfg.Path)
+	s.ExportPeriodSeconds = lang.Ref(int(cfg.ExportPeriod.Seconds()))
+	s.LoadOnStartup = lang.Ref(cfg.LoadOnStartup)
+	s.MaxAgeSeconds = lang.Ref(int(cfg.MaxAge.Seconds()))
+}
+
+type ImpressionListeners struct {
+	QueueSize     *int            `yaml:"queueSize"`
+	BatchSize     *int            `yaml:"batchSize"`
+	FlushPeriodMS *int            `yaml:"flushPeriodMS"`
+	File          FileListener    `yaml:"file"`
+	Stream        StreamListener  `yaml:"stream"`
+	Webhook       WebhookListener `yaml:"webhook"`
+}
+
+type FileListener struct {
+	Path                    *string `yaml:"path"`
+	RotationMaxFiles        *int    `yaml:"rotationMaxFiles"`
+	RotationMaxBytesPerFile *int64  `yaml:"rotationMaxBytesPerFile"`
+}
+
+type StreamListener struct {
+	Network        *string `yaml:"network"`
+	Address        *string `yaml:"address"`
+	WriteTimeoutMS *int    `yaml:"writeTimeoutMS"`
+}
+
+type WebhookListener struct {
+	URL       *string           `yaml:"url"`
+	Headers   map[string]string `yaml:"headers"`
+	TimeoutMS *int              `yaml:"timeoutMS"`
+}
+
+func (l *ImpressionListeners) PopulateWithDefaults() {
+	cfg := sdkConf.DefaultConfig().ImpressionListeners
+	l.QueueSize = lang.Ref(cfg.QueueSize)
+	l.BatchSize = lang.Ref(cfg.BatchSize)
+	l.FlushPeriodMS = lang.Ref(int(cfg.FlushPeriod.Milliseconds()))
+	l.File.Path = lang.Ref(cfg.File.Path)
+	l.File.RotationMaxFiles = lang.Ref(cfg.File.MaxFiles)
+	l.File.RotationMaxBytesPerFile = lang.Ref(cfg.File.MaxBytesPerFile)
+	l.Stream.Network = lang.Ref(cfg.Stream.Network)
+	l.Stream.Address = lang.Ref(cfg.Stream.Address)
+	l.Stream.WriteTimeoutMS = lang.Ref(int(cfg.Stream.WriteTimeout.Milliseconds()))
+	l.Webhook.URL = lang.Ref(cfg.Webhook.URL)
+	l.Webhook.Headers = cfg.Webhook.Headers
+	l.Webhook.TimeoutMS = lang.Ref(int(cfg.Webhook.Timeout.Milliseconds()))
+}
+
+func (l *ImpressionListeners) updateSDKConf(dst *sdkConf.ImpressionListeners) {
+	durationFromMS := func(ms int) time.Duration { return time.Duration(ms) * time.Millisecond }
+	lang.SetIfNotEmpty(&dst.QueueSize, l.QueueSize)
+	lang.SetIfNotEmpty(&dst.BatchSize, l.BatchSize)
+	lang.MapIfNotNil(&dst.FlushPeriod, l.FlushPeriodMS, durationFromMS)
+	lang.SetIfNotNil(&dst.File.Path, l.File.Path)
+	lang.SetIfNotNil(&dst.File.MaxFiles, l.File.RotationMaxFiles)
+	lang.SetIfNotNil(&dst.File.MaxBytesPerFile, l.File.RotationMaxBytesPerFile)
+	lang.SetIfNotEmpty(&dst.Stream.Network, l.Stream.Network)
+	lang.SetIfNotNil(&dst.Stream.Address, l.Stream.Address)
+	lang.MapIfNotNil(&dst.Stream.WriteTimeout, l.Stream.WriteTimeoutMS, durationFromMS)
+	lang.SetIfNotNil(&dst.Webhook.URL, l.Webhook.URL)
+	if len(l.Webhook.Headers) > 0 {
+		dst.Webhook.Headers = l.Webhook.Headers
+	}
+	lang.MapIfNotNil(&dst.Webhook.Timeout, l.Webhook.TimeoutMS, durationFromMS)
 }
 
 func (s *SDK) ToSDKConf() *sdkConf.Config {
@@ -201,6 +658,9 @@ func (s *SDK) ToSDKConf() *sdkConf.Config {
 	durationFromSeconds := func(seconds int) time.Duration { return time.Duration(seconds) * time.Second }
 	lang.SetIfNotNil(&cfg.LabelsEnabled, s.LabelsEnabled)
 	lang.SetIfNotNil(&cfg.StreamingEnabled, s.StreamingEnabled)
+	lang.SetIfNotEmpty(&cfg.Storage, s.Storage)
+	lang.SetIfNotEmpty(&cfg.QueueType, s.QueueType)
+	s.Redis.updateSDKConf(&cfg.Redis)
 	lang.SetIfNotEmpty(&cfg.Splits.UpdateBufferSize, s.FeatureFlags.SplitNotificationQueueSize)
 	lang.MapIfNotNil(&cfg.Splits.SyncPeriod, s.FeatureFlags.SplitRefreshRateSeconds, durationFromSeconds)
 	lang.SetIfNotEmpty(&cfg.Segments.UpdateBufferSize, s.FeatureFlags.SegmentNotificationQueueSize)
@@ -210,11 +670,30 @@ func (s *SDK) ToSDKConf() *sdkConf.Config {
 	lang.SetIfNotEmpty(&cfg.Impressions.Mode, s.Impressions.Mode)
 	lang.SetIfNotEmpty(&cfg.Impressions.ObserverSize, s.Impressions.ObserverSize)
 	lang.SetIfNotEmpty(&cfg.Impressions.QueueSize, s.Impressions.QueueSize)
+	lang.SetIfNotNil(&cfg.Impressions.Watermark, s.Impressions.Watermark)
+	lang.SetIfNotNil(&cfg.Impressions.MaxMemoryBytes, s.Impressions.MaxMemoryBytes)
+	lang.SetIfNotEmpty(&cfg.Impressions.DropPolicy, s.Impressions.DropPolicy)
 	lang.MapIfNotNil(&cfg.Impressions.SyncPeriod, s.Impressions.RefreshRateSeconds, durationFromSeconds)
 	lang.MapIfNotNil(&cfg.Impressions.CountSyncPeriod, s.Impressions.CountRefreshRateSeconds, durationFromSeconds)
-	lang.SetIfNotEmpty(&cfg.Events.QueueSize, s.Events.QueueSize)
-	lang.MapIfNotNil(&cfg.Events.SyncPeriod, s.Events.RefreshRateSeconds, durationFromSeconds)
+	lang.SetIfNotNil(&cfg.Impressions.ClientLabels, s.Impressions.AllowClientLabels)
+	if len(s.Impressions.AllowedClientModes) > 0 {
+		cfg.Impressions.ClientModes = s.Impressions.AllowedClientModes
+	}
+	s.Events.updateSDKConf(&cfg.Events)
+	lang.MapIfNotNil(&cfg.Staleness.SplitsThreshold, s.Staleness.SplitsThresholdSeconds, durationFromSeconds)
+	lang.MapIfNotNil(&cfg.Staleness.SegmentsThreshold, s.Staleness.SegmentsThresholdSeconds, durationFromSeconds)
+	lang.SetIfNotNil(&cfg.Staleness.UseFallbackTreatments, s.Staleness.UseFallbackTreatments)
+	lang.SetIfNotNil(&cfg.Snapshot.Path, s.Snapshot.Path)
+	lang.MapIfNotNil(&cfg.Snapshot.ExportPeriod, s.Snapshot.ExportPeriodSeconds, durationFromSeconds)
+	lang.SetIfNotNil(&cfg.Snapshot.LoadOnStartup, s.Snapshot.LoadOnStartup)
+	lang.MapIfNotNil(&cfg.Snapshot.MaxAge, s.Snapshot.MaxAgeSeconds, durationFromSeconds)
+	s.Listeners.updateSDKConf(&cfg.ImpressionListeners)
+	lang.SetIfNotNil(&cfg.Overrides.File, s.Overrides.File)
+	if attrs := s.defaultAttributes(os.Environ()); len(attrs) > 0 {
+		cfg.DefaultAttributes = attrs
+	}
 	s.URLs.updateSDKConfURLs(&cfg.URLs)
+	s.HTTP.updateSDKConf(&cfg.HTTP)
 	// lang.SetIfNotNil(&cfg.FlagSetsFilter, s.FlagSetsFilter)
 	if len(s.FlagSetsFilter) > 0 {
 		cfg.FlagSetsFilter = s.FlagSetsFilter
@@ -227,6 +706,26 @@ func (s *SDK) ToSDKConf() *sdkConf.Config {
 	return cfg
 }
 
+// Overrides configures forced treatments for specific keys, used mostly for QA. They can be seeded from a yaml/json
+// file listing `flag`, `key`, `treatment` and optionally `config` & `expiresAt`, and managed through the admin api
+type Overrides struct {
+	File *string `yaml:"file"`
+}
+
+func (o *Overrides) PopulateWithDefaults() {
+	o.File = lang.Ref(sdkConf.DefaultConfig().Overrides.File)
+}
+
+func (s *SDK) defaultAttributes(environ []string) map[string]interface{} {
+	attrs := normalizeAttributes(s.DefaultAttributes)
+	if s.DefaultAttributesEnvPrefix != nil && *s.DefaultAttributesEnvPrefix != "" {
+		for k, v := range attributesFromEnv(*s.DefaultAttributesEnvPrefix, environ) {
+			attrs[k] = v
+		}
+	}
+	return attrs
+}
+
 type URLs struct {
 	Auth      *string `yaml:"auth"`
 	SDK       *string `yaml:"sdk"`
@@ -252,6 +751,43 @@ func (u *URLs) PopulateWithDefaults() {
 	u.Telemetry = lang.Ref(cfg.Telemetry)
 }
 
+type HTTP struct {
+	TimeoutMS             *int              `yaml:"timeoutMS"`
+	DialTimeoutMS         *int              `yaml:"dialTimeoutMS"`
+	TLSHandshakeTimeoutMS *int              `yaml:"tlsHandshakeTimeoutMS"`
+	ProxyURL              *string           `yaml:"proxyURL"`
+	CAFile                *string           `yaml:"caFile"`
+	CertFile              *string           `yaml:"certFile"`
+	KeyFile               *string           `yaml:"keyFile"`
+	Headers               map[string]string `yaml:"headers"`
+}
+
+func (h *HTTP) PopulateWithDefaults() {
+	cfg := sdkConf.DefaultConfig().HTTP
+	h.TimeoutMS = lang.Ref(int(cfg.Timeout.Milliseconds()))
+	h.DialTimeoutMS = lang.Ref(int(cfg.DialTimeout.Milliseconds()))
+	h.TLSHandshakeTimeoutMS = lang.Ref(int(cfg.TLSHandshakeTimeout.Milliseconds()))
+	h.ProxyURL = lang.Ref(cfg.ProxyURL)
+	h.CAFile = lang.Ref(cfg.CAFile)
+	h.CertFile = lang.Ref(cfg.CertFile)
+	h.KeyFile = lang.Ref(cfg.KeyFile)
+	h.Headers = cfg.Headers
+}
+
+func (h *HTTP) updateSDKConf(dst *sdkConf.HTTP) {
+	durationFromMS := func(ms int) time.Duration { return time.Duration(ms) * time.Millisecond }
+	lang.MapIfNotNil(&dst.Timeout, h.TimeoutMS, durationFromMS)
+	lang.MapIfNotNil(&dst.DialTimeout, h.DialTimeoutMS, durationFromMS)
+	lang.MapIfNotNil(&dst.TLSHandshakeTimeout, h.TLSHandshakeTimeoutMS, durationFromMS)
+	lang.SetIfNotNil(&dst.ProxyURL, h.ProxyURL)
+	lang.SetIfNotNil(&dst.CAFile, h.CAFile)
+	lang.SetIfNotNil(&dst.CertFile, h.CertFile)
+	lang.SetIfNotNil(&dst.KeyFile, h.KeyFile)
+	if len(h.Headers) > 0 {
+		dst.Headers = h.Headers
+	}
+}
+
 type Logger struct {
 	Level                   *string `yaml:"level"`
 	Output                  *string `yaml:"output"`
@@ -291,11 +827,29 @@ func (l *Logger) ToLoggerOptions() (*logging.LoggerOptions, error) {
 type API struct {
 	Host string `yaml:"host"`
 	Port int    `yaml:"port"`
+
+	// AdminEnabled exposes endpoints that alter the daemon's behavior (ie: forced treatments).
+	// Requests must present (as a bearer token) the secret of a link token with the `admin` scope
+	AdminEnabled bool `yaml:"adminEnabled"`
 }
 
 func (a *API) PopulateWithDefaults() {
 	a.Host = "0.0.0.0"
 	a.Port = 8887
+	a.AdminEnabled = false
+}
+
+// Validate checks that, if enabled, the admin endpoints can be reached by at least one of the link tokens
+func (a *API) Validate(tokens []auth.Token) error {
+	if !a.AdminEnabled {
+		return nil
+	}
+	for _, token := range tokens {
+		if token.Scope.Allows(auth.ScopeAdmin) {
+			return nil
+		}
+	}
+	return fmt.Errorf("the admin api requires at least one link token with the `admin` scope")
 }
 
 type Debug struct {
@@ -318,6 +872,25 @@ func (p *Profiling) PopulateWithDefaults() {
 	p.Port = 8888
 }
 
+// Upgrade controls zero-downtime binary upgrades: upon SIGUSR2, a new process is started from the current executable
+// & handed the listening sockets. Once it's ready, this one drains its connections, flushes its data & exits.
+// Only listeners backed by a file descriptor (unix & tcp sockets) can be handed over
+type Upgrade struct {
+	Enabled             bool `yaml:"enabled"`
+	ReadyTimeoutSeconds int  `yaml:"readyTimeoutSeconds"`
+	DrainTimeoutSeconds int  `yaml:"drainTimeoutSeconds"`
+
+	// export a snapshot (if `sdk.snapshot.path` is set) for the new process to warm-start from
+	HandOverSnapshot bool `yaml:"handOverSnapshot"`
+}
+
+func (u *Upgrade) PopulateWithDefaults() {
+	u.Enabled = false // SIGUSR2 keeps its default behavior unless explicitly opted in
+	u.ReadyTimeoutSeconds = 60
+	u.DrainTimeoutSeconds = 10
+	u.HandOverSnapshot = true
+}
+
 // fallbackTreatmentFromConfig maps the SDK default config's FallbackTreatment into our input type.
 func fallbackTreatmentFromConfig(c dtos.FallbackTreatmentConfig) fallbackTreatmentInput {
 	parsed := new(dtos.FallbackTreatmentConfig)
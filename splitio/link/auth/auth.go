@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrInvalidScope = errors.New("invalid scope")
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Scope is a set of operations a client is allowed to perform
+type Scope uint8
+
+const (
+	ScopeEvaluate      Scope = 1 << 0 // treatment(s) evaluations
+	ScopeTrack         Scope = 1 << 1 // event tracking
+	ScopeInspectSplits Scope = 1 << 2 // feature flag, segment & rule-based segment inspection
+	ScopeAdmin         Scope = 1 << 3 // http admin api (forced treatments)
+
+	// ScopeAll covers every link operation. Admin access has to be granted explicitly
+	ScopeAll = ScopeEvaluate | ScopeTrack | ScopeInspectSplits
+)
+
+func (s Scope) Allows(required Scope) bool {
+	return s&required == required
+}
+
+func (s Scope) String() string {
+	var names []string
+	for _, scope := range []Scope{ScopeEvaluate, ScopeTrack, ScopeInspectSplits, ScopeAdmin} {
+		if s.Allows(scope) {
+			names = append(names, scope.name())
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func (s Scope) name() string {
+	switch s {
+	case ScopeEvaluate:
+		return "evaluate"
+	case ScopeTrack:
+		return "track"
+	case ScopeInspectSplits:
+		return "inspect-splits"
+	case ScopeAdmin:
+		return "admin"
+	}
+	return "invalid-scope"
+}
+
+// ParseScopes builds a scope from a list of operation names (evaluate, track, inspect-splits, admin)
+func ParseScopes(names []string) (Scope, error) {
+	var scope Scope
+	for _, name := range names {
+		switch name {
+		case "evaluate":
+			scope |= ScopeEvaluate
+		case "track":
+			scope |= ScopeTrack
+		case "inspect-splits":
+			scope |= ScopeInspectSplits
+		case "admin":
+			scope |= ScopeAdmin
+		default:
+			return 0, fmt.Errorf("%w: '%s'", ErrInvalidScope, name)
+		}
+	}
+	return scope, nil
+}
+
+// Token is a shared secret handed to clients, allowing them to perform the operations in its scope
+type Token struct {
+	Name   string
+	Secret string
+	Scope  Scope
+}
+
+// Authenticator matches the secrets presented by clients against the configured tokens.
+// If no tokens are configured, authentication is disabled & every client gets full access
+type Authenticator struct {
+	tokens []Token
+}
+
+func NewAuthenticator(tokens []Token) (*Authenticator, error) {
+	names := make(map[string]struct{}, len(tokens))
+	for idx, token := range tokens {
+		if token.Name == "" {
+			return nil, fmt.Errorf("%w: token #%d has no name", ErrInvalidToken, idx)
+		}
+		if token.Secret == "" {
+			return nil, fmt.Errorf("%w: token '%s' has no secret", ErrInvalidToken, token.Name)
+		}
+		if _, ok := names[token.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicate token name '%s'", ErrInvalidToken, token.Name)
+		}
+		names[token.Name] = struct{}{}
+	}
+	return &Authenticator{tokens: tokens}, nil
+}
+
+func (a *Authenticator) Enabled() bool {
+	return a != nil && len(a.tokens) > 0
+}
+
+// Authenticate returns the token matching the supplied secret. When authentication is disabled, an anonymous
+// token with full access is returned
+func (a *Authenticator) Authenticate(secret string) (*Token, error) {
+	if !a.Enabled() {
+		return &Token{Scope: ScopeAll}, nil
+	}
+
+	// every token is compared (in constant time) to avoid leaking information through timing
+	var found *Token
+	for idx := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(a.tokens[idx].Secret), []byte(secret)) == 1 && found == nil {
+			found = &a.tokens[idx]
+		}
+	}
+
+	if found == nil {
+		return nil, ErrUnauthorized
+	}
+	return found, nil
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopes(t *testing.T) {
+	scope, err := ParseScopes([]string{"evaluate", "track"})
+	assert.Nil(t, err)
+	assert.Equal(t, ScopeEvaluate|ScopeTrack, scope)
+	assert.True(t, scope.Allows(ScopeEvaluate))
+	assert.True(t, scope.Allows(ScopeTrack))
+	assert.False(t, scope.Allows(ScopeInspectSplits))
+	assert.True(t, scope.Allows(0))
+	assert.Equal(t, "evaluate,track", scope.String())
+
+	scope, err = ParseScopes([]string{"inspect-splits", "admin"})
+	assert.Nil(t, err)
+	assert.Equal(t, ScopeInspectSplits|ScopeAdmin, scope)
+	assert.Equal(t, "inspect-splits,admin", scope.String())
+	assert.False(t, ScopeAll.Allows(ScopeAdmin))
+
+	_, err = ParseScopes([]string{"evaluate", "sarasa"})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+
+	scope, err = ParseScopes(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, Scope(0), scope)
+}
+
+func TestAuthenticator(t *testing.T) {
+	var disabled *Authenticator
+	assert.False(t, disabled.Enabled())
+	token, err := disabled.Authenticate("")
+	assert.Nil(t, err)
+	assert.Equal(t, ScopeAll, token.Scope)
+
+	a, err := NewAuthenticator([]Token{
+		{Name: "web", Secret: "s3cr3t", Scope: ScopeEvaluate},
+		{Name: "backend", Secret: "other", Scope: ScopeAll},
+	})
+	require.Nil(t, err)
+	assert.True(t, a.Enabled())
+
+	token, err = a.Authenticate("s3cr3t")
+	assert.Nil(t, err)
+	assert.Equal(t, "web", token.Name)
+
+	token, err = a.Authenticate("other")
+	assert.Nil(t, err)
+	assert.Equal(t, "backend", token.Name)
+
+	_, err = a.Authenticate("wrong")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, err = a.Authenticate("")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = NewAuthenticator([]Token{{Name: "", Secret: "a"}})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = NewAuthenticator([]Token{{Name: "a", Secret: ""}})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = NewAuthenticator([]Token{{Name: "a", Secret: "1"}, {Name: "a", Secret: "2"}})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -16,7 +16,20 @@ import (
 func New(logger logging.LoggerInterface, conn transfer.RawConn, serial serializer.Interface, opts Options) (types.ClientInterface, error) {
 	switch opts.Protocol {
 	case protocol.V1:
-		return clientv1.New(opts.ID, logger, conn, serial, opts.ImpressionsFeedback)
+		var regOpts []clientv1.RegisterOpt
+		if opts.Environment != "" {
+			regOpts = append(regOpts, clientv1.WithEnvironment(opts.Environment))
+		}
+		if opts.ImpressionsMode != "" {
+			regOpts = append(regOpts, clientv1.WithImpressionsMode(opts.ImpressionsMode))
+		}
+		if opts.Labels != nil {
+			regOpts = append(regOpts, clientv1.WithLabels(*opts.Labels))
+		}
+		if opts.Token != "" {
+			regOpts = append(regOpts, clientv1.WithToken(opts.Token))
+		}
+		return clientv1.New(opts.ID, logger, conn, serial, opts.ImpressionsFeedback, regOpts...)
 	}
 	return nil, fmt.Errorf("unknown protocol version: '%d'", opts.Protocol)
 }
@@ -25,6 +38,10 @@ type Options struct {
 	ID                  string
 	Protocol            protocol.Version
 	ImpressionsFeedback bool
+	Environment         string // if empty, the daemon picks the default one for the listener in use
+	ImpressionsMode     string // if empty, the daemon's default is used
+	Labels              *bool  // if nil, the daemon's default is used
+	Token               string // only required if the daemon has authentication enabled
 }
 
 func DefaultOptions() Options {
@@ -0,0 +1,522 @@
+// Package pool implements a client that spreads calls across a set of connections to the daemon, re-establishing
+// them (with backoff) when they break. It's safe for concurrent use
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/splitio/link/client/types"
+	"github.com/splitio/splitd/splitio/sdk"
+)
+
+var (
+	// ErrDaemonUnreachable is returned when no connection to the daemon could be used to serve a call.
+	// Evaluations failing with it still return fallback results
+	ErrDaemonUnreachable = errors.New("split daemon unreachable")
+	ErrPoolClosed        = errors.New("client pool is closed")
+)
+
+// Factory builds a new client, connected & registered against the daemon
+type Factory func() (types.ClientInterface, error)
+
+type Options struct {
+	Size              int           // number of connections kept against the daemon
+	MinBackoff        time.Duration // wait after the first failed reconnection attempt
+	MaxBackoff        time.Duration // upper bound for the wait between reconnection attempts
+	FallbackTreatment string        // treatment returned while the daemon is unreachable
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Size:              4,
+		MinBackoff:        100 * time.Millisecond,
+		MaxBackoff:        10 * time.Second,
+		FallbackTreatment: "control",
+	}
+}
+
+type Pool struct {
+	logger   logging.LoggerInterface
+	factory  Factory
+	opts     Options
+	slots    chan *slot
+	done     chan struct{}
+	shutdown sync.Once
+	closeErr error
+
+	attrsMutex   sync.Mutex
+	attrs        map[string]interface{}
+	attrsVersion uint64
+}
+
+// slot holds one of the pool's connections. It's only accessed by the goroutine that took it from the pool
+type slot struct {
+	client       types.ClientInterface // nil while disconnected
+	retryAt      time.Time
+	backoff      time.Duration
+	attrsVersion uint64 // version of the pool's bound attributes last sent through this connection
+}
+
+// New builds a pool and tries to connect all of its slots. If the daemon cannot be reached, the pool is returned
+// anyway and connections are retried on demand. Any other error (ie: authentication) is returned right away
+func New(logger logging.LoggerInterface, factory Factory, opts Options) (*Pool, error) {
+	if opts.Size <= 0 {
+		return nil, fmt.Errorf("invalid pool size %d", opts.Size)
+	}
+
+	p := &Pool{
+		logger:  logger,
+		factory: factory,
+		opts:    opts,
+		slots:   make(chan *slot, opts.Size),
+		done:    make(chan struct{}),
+	}
+
+	for idx := 0; idx < opts.Size; idx++ {
+		s := &slot{}
+		if err := p.connect(s); err != nil && !errors.Is(err, types.ErrConnectionLost) {
+			p.closeSlots(append(drain(p.slots, idx), s))
+			return nil, err
+		}
+		p.slots <- s
+	}
+	return p, nil
+}
+
+// Treatment implements types.ClientInterface. Since the daemon records an impression for every evaluation, calls
+// whose connection broke after sending them are not retried
+func (p *Pool) Treatment(key string, bucketingKey string, feature string, attrs map[string]interface{}, optFns ...types.OptFn) (*types.Result, error) {
+	return p.TreatmentContext(context.Background(), key, bucketingKey, feature, attrs, optFns...)
+}
+
+// TreatmentContext implements types.ClientInterface
+func (p *Pool) TreatmentContext(ctx context.Context, key string, bucketingKey string, feature string, attrs map[string]interface{}, optFns ...types.OptFn) (*types.Result, error) {
+	var res *types.Result
+	err := p.do(ctx, false, func(c types.ClientInterface) (err error) {
+		res, err = c.TreatmentContext(ctx, key, bucketingKey, feature, attrs, optFns...)
+		return err
+	})
+	if errors.Is(err, ErrDaemonUnreachable) {
+		return &types.Result{Treatment: p.opts.FallbackTreatment}, err
+	}
+	return res, err
+}
+
+// Treatments implements types.ClientInterface
+func (p *Pool) Treatments(key string, bucketingKey string, features []string, attrs map[string]interface{}, optFns ...types.OptFn) (types.Results, error) {
+	return p.TreatmentsContext(context.Background(), key, bucketingKey, features, attrs, optFns...)
+}
+
+// TreatmentsContext implements types.ClientInterface
+func (p *Pool) TreatmentsContext(ctx context.Context, key string, bucketingKey string, features []string, attrs map[string]interface{}, optFns ...types.OptFn) (types.Results, error) {
+	var res types.Results
+	err := p.do(ctx, false, func(c types.ClientInterface) (err error) {
+		res, err = c.TreatmentsContext(ctx, key, bucketingKey, features, attrs, optFns...)
+		return err
+	})
+	if errors.Is(err, ErrDaemonUnreachable) {
+		return p.fallbackResults(features), err
+	}
+	return res, err
+}
+
+// TreatmentWithConfig implements types.ClientInterface
+func (p *Pool) TreatmentWithConfig(key string, bucketingKey string, feature string, attrs map[string]interface{}, optFns ...types.OptFn) (*types.Result, error) {
+	return p.TreatmentWithConfigContext(context.Background(), key, bucketingKey, feature, attrs, optFns...)
+}
+
+// TreatmentWithConfigContext implements types.ClientInterface
+func (p *Pool) TreatmentWithConfigContext(ctx context.Context, key string, bucketingKey string, feature string, attrs map[string]interface{}, optFns ...types.OptFn) (*types.Result, error) {
+	var res *types.Result
+	err := p.do(ctx, false, func(c types.ClientInterface) (err error) {
+		res, err = c.TreatmentWithConfigContext(ctx, key, bucketingKey, feature, attrs, optFns...)
+		return err
+	})
+	if errors.Is(err, ErrDaemonUnreachable) {
+		return &types.Result{Treatment: p.opts.FallbackTreatment}, err
+	}
+	return res, err
+}
+
+// TreatmentsWithConfig implements types.ClientInterface
+func (p *Pool) TreatmentsWithConfig(key string, bucketingKey string, features []string, attrs map[string]interface{}, optFns ...types.OptFn) (types.Results, error) {
+	return p.TreatmentsWithConfigContext(context.Background(), key, bucketingKey, features, attrs, optFns...)
+}
+
+// TreatmentsWithConfigContext implements types.ClientInterface
+func (p *Pool) TreatmentsWithConfigContext(ctx context.Context, key string, bucketingKey string, features []string, attrs map[string]interface{}, optFns ...types.OptFn) (types.Results, error) {
+	var res types.Results
+	err := p.do(ctx, false, func(c types.ClientInterface) (err error) {
+		res, err = c.TreatmentsWithConfigContext(ctx, key, bucketingKey, features, attrs, optFns...)
+		return err
+	})
+	if errors.Is(err, ErrDaemonUnreachable) {
+		return p.fallbackResults(features), err
+	}
+	return res, err
+}
+
+// Track implements types.ClientInterface. Since the daemon might have queued the event before the connection
+// broke, it's not retried unless it was never sent
+func (p *Pool) Track(key string, trafficType string, eventType string, value *float64, properties map[string]interface{}) error {
+	return p.TrackContext(context.Background(), key, trafficType, eventType, value, properties)
+}
+
+// TrackContext implements types.ClientInterface
+func (p *Pool) TrackContext(ctx context.Context, key string, trafficType string, eventType string, value *float64, properties map[string]interface{}) error {
+	return p.do(ctx, false, func(c types.ClientInterface) error {
+		return c.TrackContext(ctx, key, trafficType, eventType, value, properties)
+	})
+}
+
+// BindAttributes implements types.ClientInterface. Attributes are bound to every connection in the pool
+// (including the ones established later on) before it's used for the next time
+func (p *Pool) BindAttributes(attrs map[string]interface{}) error {
+	return p.BindAttributesContext(context.Background(), attrs)
+}
+
+// BindAttributesContext implements types.ClientInterface
+func (p *Pool) BindAttributesContext(ctx context.Context, attrs map[string]interface{}) error {
+	p.attrsMutex.Lock()
+	p.attrs = attrs
+	p.attrsVersion++
+	p.attrsMutex.Unlock()
+
+	// bind them right away on one of the connections so that errors are reported to the caller
+	return p.do(ctx, true, func(types.ClientInterface) error { return nil })
+}
+
+// BlockUntilReady implements types.ClientInterface. Reconnections are retried until the timeout expires
+func (p *Pool) BlockUntilReady(timeout time.Duration) error {
+	return p.BlockUntilReadyContext(context.Background(), timeout)
+}
+
+// BlockUntilReadyContext implements types.ClientInterface
+func (p *Pool) BlockUntilReadyContext(ctx context.Context, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := p.do(ctx, true, func(c types.ClientInterface) error { return c.BlockUntilReadyContext(ctx, time.Until(deadline)) })
+		if !errors.Is(err, ErrDaemonUnreachable) || time.Now().Add(p.opts.MinBackoff).After(deadline) {
+			return err
+		}
+
+		select {
+		case <-time.After(p.opts.MinBackoff):
+		case <-p.done:
+			return ErrPoolClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Ping implements types.ClientInterface
+func (p *Pool) Ping() error {
+	return p.PingContext(context.Background())
+}
+
+// PingContext implements types.ClientInterface
+func (p *Pool) PingContext(ctx context.Context) error {
+	return p.do(ctx, true, func(c types.ClientInterface) error { return c.PingContext(ctx) })
+}
+
+// Status implements types.ClientInterface
+func (p *Pool) Status() (*sdk.Status, error) {
+	return p.StatusContext(context.Background())
+}
+
+// StatusContext implements types.ClientInterface
+func (p *Pool) StatusContext(ctx context.Context) (*sdk.Status, error) {
+	var res *sdk.Status
+	err := p.do(ctx, true, func(c types.ClientInterface) (err error) {
+		res, err = c.StatusContext(ctx)
+		return err
+	})
+	return res, err
+}
+
+// SplitNames implements types.ClientInterface
+func (p *Pool) SplitNames() ([]string, error) {
+	return p.SplitNamesContext(context.Background())
+}
+
+// SplitNamesContext implements types.ClientInterface
+func (p *Pool) SplitNamesContext(ctx context.Context) ([]string, error) {
+	var res []string
+	err := p.do(ctx, true, func(c types.ClientInterface) (err error) {
+		res, err = c.SplitNamesContext(ctx)
+		return err
+	})
+	return res, err
+}
+
+// Split implements types.ClientInterface
+func (p *Pool) Split(name string) (*sdk.SplitView, error) {
+	return p.SplitContext(context.Background(), name)
+}
+
+// SplitContext implements types.ClientInterface
+func (p *Pool) SplitContext(ctx context.Context, name string) (*sdk.SplitView, error) {
+	var res *sdk.SplitView
+	err := p.do(ctx, true, func(c types.ClientInterface) (err error) {
+		res, err = c.SplitContext(ctx, name)
+		return err
+	})
+	return res, err
+}
+
+// Splits implements types.ClientInterface
+func (p *Pool) Splits() ([]sdk.SplitView, error) {
+	return p.SplitsContext(context.Background())
+}
+
+// SplitsContext implements types.ClientInterface
+func (p *Pool) SplitsContext(ctx context.Context) ([]sdk.SplitView, error) {
+	var res []sdk.SplitView
+	err := p.do(ctx, true, func(c types.ClientInterface) (err error) {
+		res, err = c.SplitsContext(ctx)
+		return err
+	})
+	return res, err
+}
+
+// SplitDefinition implements types.ClientInterface
+func (p *Pool) SplitDefinition(name string) (*dtos.SplitDTO, error) {
+	return p.SplitDefinitionContext(context.Background(), name)
+}
+
+// SplitDefinitionContext implements types.ClientInterface
+func (p *Pool) SplitDefinitionContext(ctx context.Context, name string) (*dtos.SplitDTO, error) {
+	var res *dtos.SplitDTO
+	err := p.do(ctx, true, func(c types.ClientInterface) (err error) {
+		res, err = c.SplitDefinitionContext(ctx, name)
+		return err
+	})
+	return res, err
+}
+
+// SegmentNames implements types.ClientInterface
+func (p *Pool) SegmentNames() ([]string, error) {
+	return p.SegmentNamesContext(context.Background())
+}
+
+// SegmentNamesContext implements types.ClientInterface
+func (p *Pool) SegmentNamesContext(ctx context.Context) ([]string, error) {
+	var res []string
+	err := p.do(ctx, true, func(c types.ClientInterface) (err error) {
+		res, err = c.SegmentNamesContext(ctx)
+		return err
+	})
+	return res, err
+}
+
+// Segment implements types.ClientInterface
+func (p *Pool) Segment(name string) (*sdk.SegmentView, error) {
+	return p.SegmentContext(context.Background(), name)
+}
+
+// SegmentContext implements types.ClientInterface
+func (p *Pool) SegmentContext(ctx context.Context, name string) (*sdk.SegmentView, error) {
+	var res *sdk.SegmentView
+	err := p.do(ctx, true, func(c types.ClientInterface) (err error) {
+		res, err = c.SegmentContext(ctx, name)
+		return err
+	})
+	return res, err
+}
+
+// SegmentContainsKey implements types.ClientInterface
+func (p *Pool) SegmentContainsKey(name string, key string) (bool, error) {
+	return p.SegmentContainsKeyContext(context.Background(), name, key)
+}
+
+// SegmentContainsKeyContext implements types.ClientInterface
+func (p *Pool) SegmentContainsKeyContext(ctx context.Context, name string, key string) (bool, error) {
+	var res bool
+	err := p.do(ctx, true, func(c types.ClientInterface) (err error) {
+		res, err = c.SegmentContainsKeyContext(ctx, name, key)
+		return err
+	})
+	return res, err
+}
+
+// RuleBasedSegmentNames implements types.ClientInterface
+func (p *Pool) RuleBasedSegmentNames() ([]string, error) {
+	return p.RuleBasedSegmentNamesContext(context.Background())
+}
+
+// RuleBasedSegmentNamesContext implements types.ClientInterface
+func (p *Pool) RuleBasedSegmentNamesContext(ctx context.Context) ([]string, error) {
+	var res []string
+	err := p.do(ctx, true, func(c types.ClientInterface) (err error) {
+		res, err = c.RuleBasedSegmentNamesContext(ctx)
+		return err
+	})
+	return res, err
+}
+
+// RuleBasedSegment implements types.ClientInterface
+func (p *Pool) RuleBasedSegment(name string) (*dtos.RuleBasedSegmentDTO, error) {
+	return p.RuleBasedSegmentContext(context.Background(), name)
+}
+
+// RuleBasedSegmentContext implements types.ClientInterface
+func (p *Pool) RuleBasedSegmentContext(ctx context.Context, name string) (*dtos.RuleBasedSegmentDTO, error) {
+	var res *dtos.RuleBasedSegmentDTO
+	err := p.do(ctx, true, func(c types.ClientInterface) (err error) {
+		res, err = c.RuleBasedSegmentContext(ctx, name)
+		return err
+	})
+	return res, err
+}
+
+// Shutdown implements types.ClientInterface. It waits for in-flight calls to complete & closes all connections
+func (p *Pool) Shutdown() error {
+	p.shutdown.Do(func() {
+		close(p.done)
+		p.closeErr = p.closeSlots(drain(p.slots, p.opts.Size))
+	})
+	return p.closeErr
+}
+
+// do runs `op` with one of the pool's clients. If the connection turns out to be closed by the daemon or broken
+// before the rpc was sent, or broken while running an idempotent operation, it's retried once on a fresh connection.
+// Operations recording impressions or events are not idempotent: retrying them could record duplicates
+func (p *Pool) do(ctx context.Context, idempotent bool, op func(c types.ClientInterface) error) error {
+	err := p.withClient(ctx, op)
+	if errors.Is(err, types.ErrConnectionExpired) || errors.Is(err, types.ErrRequestNotSent) ||
+		(idempotent && errors.Is(err, types.ErrConnectionLost)) {
+		err = p.withClient(ctx, op)
+	}
+
+	if isConnectionError(err) && !errors.Is(err, ErrDaemonUnreachable) {
+		return fmt.Errorf("%w: %w", ErrDaemonUnreachable, err)
+	}
+	return err
+}
+
+func (p *Pool) withClient(ctx context.Context, op func(c types.ClientInterface) error) error {
+	s, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { p.slots <- s }()
+
+	if err = p.connect(s); err != nil {
+		return err
+	}
+
+	if err = p.bindAttributes(ctx, s); err == nil {
+		err = op(s.client)
+	} else if isConnectionError(err) {
+		err = fmt.Errorf("%w: %w", types.ErrRequestNotSent, err) // `op` didn't get to run
+	}
+
+	if isConnectionError(err) {
+		p.logger.Debug(fmt.Sprintf("discarding connection to the daemon: %s", err))
+		s.client.Shutdown()
+		s.client = nil
+	}
+	return err
+}
+
+func (p *Pool) acquire(ctx context.Context) (*slot, error) {
+	select {
+	case <-p.done:
+		return nil, ErrPoolClosed
+	default:
+	}
+
+	select {
+	case s := <-p.slots:
+		return s, nil
+	case <-p.done:
+		return nil, ErrPoolClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// connect establishes a new connection for the slot if it has none, unless it's still waiting for the backoff
+// from a previous failed attempt to expire
+func (p *Pool) connect(s *slot) error {
+	if s.client != nil {
+		return nil
+	}
+
+	now := time.Now()
+	if now.Before(s.retryAt) {
+		return fmt.Errorf("%w: next reconnection attempt in %s", ErrDaemonUnreachable, s.retryAt.Sub(now).Round(time.Millisecond))
+	}
+
+	c, err := p.factory()
+	if err != nil {
+		s.backoff = min(max(2*s.backoff, p.opts.MinBackoff), p.opts.MaxBackoff)
+		s.retryAt = now.Add(s.backoff)
+		p.logger.Warning(fmt.Sprintf("error connecting to the daemon (retrying in %s): %s", s.backoff, err))
+		if isConnectionError(err) {
+			return fmt.Errorf("%w: %w", ErrDaemonUnreachable, err)
+		}
+		return err
+	}
+
+	s.client, s.backoff, s.retryAt, s.attrsVersion = c, 0, time.Time{}, 0
+	return nil
+}
+
+// bindAttributes sends the pool's bound attributes through the slot's connection, if it doesn't have them yet
+func (p *Pool) bindAttributes(ctx context.Context, s *slot) error {
+	p.attrsMutex.Lock()
+	attrs, version := p.attrs, p.attrsVersion
+	p.attrsMutex.Unlock()
+
+	if s.attrsVersion == version {
+		return nil
+	}
+
+	err := s.client.BindAttributesContext(ctx, attrs)
+	if !isConnectionError(err) { // rejected attributes are not retried on every call
+		s.attrsVersion = version
+	}
+	return err
+}
+
+func (p *Pool) fallbackResults(features []string) types.Results {
+	results := make(types.Results, len(features))
+	for _, feature := range features {
+		results[feature] = types.Result{Treatment: p.opts.FallbackTreatment}
+	}
+	return results
+}
+
+func (p *Pool) closeSlots(slots []*slot) error {
+	var errs []error
+	for _, s := range slots {
+		if s.client != nil {
+			errs = append(errs, s.client.Shutdown())
+			s.client = nil
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// drain takes `count` slots out of the channel, blocking until they're all available
+func drain(slots chan *slot, count int) []*slot {
+	taken := make([]*slot, 0, count)
+	for len(taken) < count {
+		taken = append(taken, <-slots)
+	}
+	return taken
+}
+
+func isConnectionError(err error) bool {
+	return errors.Is(err, types.ErrConnectionLost) || errors.Is(err, types.ErrConnectionExpired)
+}
+
+var _ types.ClientInterface = (*Pool)(nil)
@@ -0,0 +1,291 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/splitio/link/client/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clientMock implements the subset of types.ClientInterface exercised by these tests
+type clientMock struct {
+	types.ClientInterface
+	treatment func() (*types.Result, error)
+	track     func() error
+	attrs     map[string]interface{}
+	closed    bool
+}
+
+func (c *clientMock) TreatmentContext(context.Context, string, string, string, map[string]interface{}, ...types.OptFn) (*types.Result, error) {
+	return c.treatment()
+}
+
+func (c *clientMock) TreatmentsContext(_ context.Context, _ string, _ string, features []string, _ map[string]interface{}, _ ...types.OptFn) (types.Results, error) {
+	res, err := c.treatment()
+	if err != nil {
+		return nil, err
+	}
+	results := make(types.Results, len(features))
+	for _, feature := range features {
+		results[feature] = *res
+	}
+	return results, nil
+}
+
+func (c *clientMock) TrackContext(context.Context, string, string, string, *float64, map[string]interface{}) error {
+	return c.track()
+}
+
+func (c *clientMock) BindAttributesContext(_ context.Context, attrs map[string]interface{}) error {
+	c.attrs = attrs
+	return nil
+}
+
+func (c *clientMock) Shutdown() error {
+	c.closed = true
+	return nil
+}
+
+func on() (*types.Result, error) { return &types.Result{Treatment: "on"}, nil }
+
+func lost() error { return fmt.Errorf("%w: broken pipe", types.ErrConnectionLost) }
+
+func lostBeforeSending() error {
+	return fmt.Errorf("%w (%w): broken pipe", types.ErrConnectionLost, types.ErrRequestNotSent)
+}
+
+// factoryMock hands out the supplied clients in order, failing with a connection error once they're exhausted
+type factoryMock struct {
+	mutex   sync.Mutex
+	clients []*clientMock
+	calls   int
+}
+
+func (f *factoryMock) build() (types.ClientInterface, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.calls++
+	if len(f.clients) == 0 {
+		return nil, lost()
+	}
+	c := f.clients[0]
+	f.clients = f.clients[1:]
+	return c, nil
+}
+
+func (f *factoryMock) callCount() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.calls
+}
+
+func testOpts(size int) Options {
+	opts := DefaultOptions()
+	opts.Size = size
+	opts.MinBackoff = 50 * time.Millisecond
+	opts.MaxBackoff = 200 * time.Millisecond
+	return opts
+}
+
+func TestReconnectOnConnectionLost(t *testing.T) {
+	broken := &clientMock{treatment: func() (*types.Result, error) { return nil, lostBeforeSending() }}
+	healthy := &clientMock{treatment: on}
+	factory := &factoryMock{clients: []*clientMock{broken, healthy}}
+
+	p, err := New(logging.NewLogger(nil), factory.build, testOpts(1))
+	require.Nil(t, err)
+
+	// the evaluation never reached the daemon, so it's transparently retried on a new connection
+	res, err := p.Treatment("key1", "", "feat1", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "on", res.Treatment)
+	assert.True(t, broken.closed)
+	assert.Equal(t, 2, factory.callCount())
+
+	assert.Nil(t, p.Shutdown())
+	assert.True(t, healthy.closed)
+	_, err = p.Treatment("key1", "", "feat1", nil)
+	assert.ErrorIs(t, err, ErrPoolClosed)
+	assert.Nil(t, p.Shutdown())
+}
+
+func TestReconnectOnConnectionExpired(t *testing.T) {
+	var tracked atomic.Int32
+	expired := &clientMock{track: func() error { return types.ErrConnectionExpired }}
+	healthy := &clientMock{track: func() error { tracked.Add(1); return nil }}
+	factory := &factoryMock{clients: []*clientMock{expired, healthy}}
+
+	p, err := New(logging.NewLogger(nil), factory.build, testOpts(1))
+	require.Nil(t, err)
+
+	// nothing was sent through the expired connection, so even non-idempotent calls are retried
+	assert.Nil(t, p.Track("key1", "user", "click", nil, nil))
+	assert.Equal(t, int32(1), tracked.Load())
+	assert.True(t, expired.closed)
+}
+
+func TestTrackNotRetried(t *testing.T) {
+	broken := &clientMock{track: lost}
+	healthy := &clientMock{track: func() error { return nil }}
+	factory := &factoryMock{clients: []*clientMock{broken, healthy}}
+
+	p, err := New(logging.NewLogger(nil), factory.build, testOpts(1))
+	require.Nil(t, err)
+
+	err = p.Track("key1", "user", "click", nil, nil)
+	assert.ErrorIs(t, err, ErrDaemonUnreachable)
+	assert.ErrorIs(t, err, types.ErrConnectionLost)
+	assert.Equal(t, 1, factory.callCount())
+
+	assert.Nil(t, p.Track("key1", "user", "click", nil, nil))
+	assert.Equal(t, 2, factory.callCount())
+}
+
+func TestTreatmentNotRetriedOnceSent(t *testing.T) {
+	broken := &clientMock{treatment: func() (*types.Result, error) { return nil, lost() }}
+	healthy := &clientMock{treatment: on}
+	factory := &factoryMock{clients: []*clientMock{broken, healthy}}
+
+	p, err := New(logging.NewLogger(nil), factory.build, testOpts(1))
+	require.Nil(t, err)
+
+	// the daemon might have recorded an impression already, so the fallback treatment is returned instead
+	res, err := p.Treatment("key1", "", "feat1", nil)
+	assert.ErrorIs(t, err, ErrDaemonUnreachable)
+	assert.ErrorIs(t, err, types.ErrConnectionLost)
+	assert.Equal(t, "control", res.Treatment)
+	assert.Equal(t, 1, factory.callCount())
+
+	ress, err := p.Treatments("key1", "", []string{"feat1"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "on", ress["feat1"].Treatment)
+	assert.Equal(t, 2, factory.callCount())
+}
+
+func TestUnreachableDaemon(t *testing.T) {
+	factory := &factoryMock{}
+	p, err := New(logging.NewLogger(nil), factory.build, testOpts(1))
+	require.Nil(t, err)
+	assert.Equal(t, 1, factory.callCount())
+
+	res, err := p.Treatment("key1", "", "feat1", nil)
+	assert.ErrorIs(t, err, ErrDaemonUnreachable)
+	assert.Equal(t, "control", res.Treatment)
+
+	results, err := p.Treatments("key1", "", []string{"feat1", "feat2"}, nil)
+	assert.ErrorIs(t, err, ErrDaemonUnreachable)
+	assert.Equal(t, types.Results{"feat1": {Treatment: "control"}, "feat2": {Treatment: "control"}}, results)
+
+	// reconnections are not attempted until the backoff expires
+	assert.Equal(t, 1, factory.callCount())
+
+	healthy := &clientMock{treatment: on}
+	factory.mutex.Lock()
+	factory.clients = []*clientMock{healthy}
+	factory.mutex.Unlock()
+
+	time.Sleep(60 * time.Millisecond)
+	res, err = p.Treatment("key1", "", "feat1", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "on", res.Treatment)
+	assert.Equal(t, 2, factory.callCount())
+}
+
+func TestBlockUntilReadyWaitsForDaemon(t *testing.T) {
+	factory := &factoryMock{}
+	p, err := New(logging.NewLogger(nil), factory.build, testOpts(1))
+	require.Nil(t, err)
+
+	before := time.Now()
+	err = p.BlockUntilReady(120 * time.Millisecond)
+	assert.ErrorIs(t, err, ErrDaemonUnreachable)
+	assert.GreaterOrEqual(t, time.Since(before), 70*time.Millisecond)
+	assert.Greater(t, factory.callCount(), 1)
+}
+
+func TestBoundAttributesReapplied(t *testing.T) {
+	broken := &clientMock{treatment: func() (*types.Result, error) { return nil, lostBeforeSending() }}
+	healthy := &clientMock{treatment: on}
+	factory := &factoryMock{clients: []*clientMock{broken, healthy}}
+
+	p, err := New(logging.NewLogger(nil), factory.build, testOpts(1))
+	require.Nil(t, err)
+
+	attrs := map[string]interface{}{"a": 1}
+	assert.Nil(t, p.BindAttributes(attrs))
+	assert.Equal(t, attrs, broken.attrs)
+
+	_, err = p.Treatment("key1", "", "feat1", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, attrs, healthy.attrs)
+}
+
+func TestNonConnectionErrorsOnStartup(t *testing.T) {
+	errUnauthorized := errors.New("unauthorized")
+	first := &clientMock{}
+	calls := 0
+	factory := func() (types.ClientInterface, error) {
+		if calls++; calls == 1 {
+			return first, nil
+		}
+		return nil, errUnauthorized
+	}
+
+	p, err := New(logging.NewLogger(nil), factory, testOpts(2))
+	assert.Nil(t, p)
+	assert.ErrorIs(t, err, errUnauthorized)
+	assert.True(t, first.closed)
+
+	_, err = New(logging.NewLogger(nil), factory, testOpts(0))
+	assert.ErrorContains(t, err, "invalid pool size")
+}
+
+func TestConcurrentUsage(t *testing.T) {
+	var failures atomic.Int32
+	newClient := func() *clientMock {
+		return &clientMock{treatment: func() (*types.Result, error) {
+			if failures.Add(1)%7 == 0 {
+				return nil, lost()
+			}
+			return on()
+		}}
+	}
+
+	var created atomic.Int32
+	factory := func() (types.ClientInterface, error) {
+		created.Add(1)
+		return newClient(), nil
+	}
+
+	p, err := New(logging.NewLogger(nil), factory, testOpts(3))
+	require.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				// a retry can land on another broken connection, in which case the fallback is returned
+				res, err := p.Treatment("key1", "", "feat1", nil)
+				if err != nil {
+					assert.ErrorIs(t, err, ErrDaemonUnreachable)
+					assert.Equal(t, "control", res.Treatment)
+				} else {
+					assert.Equal(t, "on", res.Treatment)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Greater(t, created.Load(), int32(3))
+	assert.Nil(t, p.Shutdown())
+}
@@ -1,26 +1,78 @@
 package types
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/splitd/splitio/sdk"
 )
 
+// ErrConnectionExpired is returned once the daemon has closed the connection on purpose (ie: max lifetime reached).
+// Further calls must be issued through a new client
+var ErrConnectionExpired = errors.New("connection closed by the daemon, a new one is required")
+
+// ErrConnectionLost is returned when an rpc couldn't be completed because the connection to the daemon broke.
+// The outcome of the rpc is unknown, and further calls must be issued through a new client
+var ErrConnectionLost = errors.New("connection to the daemon lost")
+
+// ErrRequestNotSent accompanies ErrConnectionLost when the connection broke before the rpc reached the daemon.
+// Since it can't have been processed, it's safe to retry it even if it's not idempotent
+var ErrRequestNotSent = errors.New("rpc not sent")
+
+// ClientInterface is the client-side API of the daemon. Every call has a variant bound to a context, whose deadline
+// (if earlier than the configured timeouts) is applied to the socket operations, and whose cancellation interrupts them.
+// A call cancelled while waiting for a response doesn't invalidate the connection: the late response is discarded
+// before issuing the next one
 type ClientInterface interface {
 	Treatment(key string, bucketingKey string, feature string, attrs map[string]interface{}, optFns ...OptFn) (*Result, error)
 	Treatments(key string, bucketingKey string, features []string, attrs map[string]interface{}, optFns ...OptFn) (Results, error)
 	TreatmentWithConfig(key string, bucketingKey string, feature string, attrs map[string]interface{}, optFns ...OptFn) (*Result, error)
 	TreatmentsWithConfig(key string, bucketingKey string, features []string, attrs map[string]interface{}, optFns ...OptFn) (Results, error)
 	Track(key string, trafficType string, eventType string, value *float64, properties map[string]interface{}) error
+	BindAttributes(attrs map[string]interface{}) error
+	BlockUntilReady(timeout time.Duration) error
+	Ping() error
+	Status() (*sdk.Status, error)
 	SplitNames() ([]string, error)
 	Split(name string) (*sdk.SplitView, error)
 	Splits() ([]sdk.SplitView, error)
+	SplitDefinition(name string) (*dtos.SplitDTO, error)
+	SegmentNames() ([]string, error)
+	Segment(name string) (*sdk.SegmentView, error)
+	SegmentContainsKey(name string, key string) (bool, error)
+	RuleBasedSegmentNames() ([]string, error)
+	RuleBasedSegment(name string) (*dtos.RuleBasedSegmentDTO, error)
 	Shutdown() error
+
+	TreatmentContext(ctx context.Context, key string, bucketingKey string, feature string, attrs map[string]interface{}, optFns ...OptFn) (*Result, error)
+	TreatmentsContext(ctx context.Context, key string, bucketingKey string, features []string, attrs map[string]interface{}, optFns ...OptFn) (Results, error)
+	TreatmentWithConfigContext(ctx context.Context, key string, bucketingKey string, feature string, attrs map[string]interface{}, optFns ...OptFn) (*Result, error)
+	TreatmentsWithConfigContext(ctx context.Context, key string, bucketingKey string, features []string, attrs map[string]interface{}, optFns ...OptFn) (Results, error)
+	TrackContext(ctx context.Context, key string, trafficType string, eventType string, value *float64, properties map[string]interface{}) error
+	BindAttributesContext(ctx context.Context, attrs map[string]interface{}) error
+	BlockUntilReadyContext(ctx context.Context, timeout time.Duration) error
+	PingContext(ctx context.Context) error
+	StatusContext(ctx context.Context) (*sdk.Status, error)
+	SplitNamesContext(ctx context.Context) ([]string, error)
+	SplitContext(ctx context.Context, name string) (*sdk.SplitView, error)
+	SplitsContext(ctx context.Context) ([]sdk.SplitView, error)
+	SplitDefinitionContext(ctx context.Context, name string) (*dtos.SplitDTO, error)
+	SegmentNamesContext(ctx context.Context) ([]string, error)
+	SegmentContext(ctx context.Context, name string) (*sdk.SegmentView, error)
+	SegmentContainsKeyContext(ctx context.Context, name string, key string) (bool, error)
+	RuleBasedSegmentNamesContext(ctx context.Context) ([]string, error)
+	RuleBasedSegmentContext(ctx context.Context, name string) (*dtos.RuleBasedSegmentDTO, error)
 }
 
 type Result struct {
 	Treatment  string
 	Impression *dtos.Impression
 	Config     *string
+	Stale      bool
+	Label      string // only set for non-regular evaluations (ie: flag not found)
+	Warnings   []sdk.ValidationWarning
 }
 
 type Results = map[string]Result
@@ -1,12 +1,18 @@
 package v1
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/splitd/splitio"
 	"github.com/splitio/splitd/splitio/common/lang"
+	"github.com/splitio/splitd/splitio/link/auth"
 	"github.com/splitio/splitd/splitio/link/client/types"
 	"github.com/splitio/splitd/splitio/link/protocol"
 	protov1 "github.com/splitio/splitd/splitio/link/protocol/v1"
@@ -24,6 +30,10 @@ type Impl struct {
 	conn             transfer.RawConn
 	serializer       serializer.Interface
 	listenerFeedback bool
+	expired          bool // the daemon closed the connection after the last response
+	broken           bool // a previous rpc failed to send or receive, the connection state is unknown
+	pending          int  // responses due for calls abandoned while waiting for them
+	writeBuffer      bytes.Buffer
 }
 
 func (c *Impl) WithEvaluationOptions(e *dtos.EvaluationOptions) types.OptFn {
@@ -36,7 +46,40 @@ func defaultOpts() types.Options {
 	}
 }
 
-func New(id string, logger logging.LoggerInterface, conn transfer.RawConn, serializer serializer.Interface, listenerFeedback bool) (*Impl, error) {
+// RegisterOpt customizes the arguments sent to the daemon when registering
+type RegisterOpt = func(args *protov1.RegisterArgs)
+
+// WithEnvironment requests the connection to be served by a specific environment, instead of the listener's default
+func WithEnvironment(name string) RegisterOpt {
+	return func(args *protov1.RegisterArgs) { args.Environment = name }
+}
+
+// WithToken authenticates the client with a shared secret configured in the daemon
+func WithToken(token string) RegisterOpt {
+	return func(args *protov1.RegisterArgs) { args.Token = token }
+}
+
+// WithImpressionsMode requests a specific impressions mode for this client. Unknown modes are ignored
+func WithImpressionsMode(mode string) RegisterOpt {
+	return func(args *protov1.RegisterArgs) {
+		if flag, ok := protov1.ImpressionsModeFlag(mode); ok {
+			args.Flags |= flag
+		}
+	}
+}
+
+// WithLabels requests labels to be enabled or disabled in this client's impressions
+func WithLabels(enabled bool) RegisterOpt {
+	return func(args *protov1.RegisterArgs) {
+		if enabled {
+			args.Flags |= protov1.RegisterFlagLabelsEnabled
+		} else {
+			args.Flags |= protov1.RegisterFlagLabelsDisabled
+		}
+	}
+}
+
+func New(id string, logger logging.LoggerInterface, conn transfer.RawConn, serializer serializer.Interface, listenerFeedback bool, regOpts ...RegisterOpt) (*Impl, error) {
 	i := &Impl{
 		logger:           logger,
 		conn:             conn,
@@ -44,7 +87,7 @@ func New(id string, logger logging.LoggerInterface, conn transfer.RawConn, seria
 		listenerFeedback: listenerFeedback,
 	}
 
-	if err := i.register(id, listenerFeedback); err != nil {
+	if err := i.register(context.Background(), id, listenerFeedback, regOpts...); err != nil {
 		i.conn.Shutdown()
 		return nil, fmt.Errorf("error during client registration: %w", err)
 	}
@@ -54,30 +97,55 @@ func New(id string, logger logging.LoggerInterface, conn transfer.RawConn, seria
 
 // Treatment implements Interface
 func (c *Impl) Treatment(key string, bucketingKey string, feature string, attrs map[string]interface{}, optFns ...types.OptFn) (*types.Result, error) {
+	return c.TreatmentContext(context.Background(), key, bucketingKey, feature, attrs, optFns...)
+}
+
+// TreatmentContext implements types.ClientInterface
+func (c *Impl) TreatmentContext(ctx context.Context, key string, bucketingKey string, feature string, attrs map[string]interface{}, optFns ...types.OptFn) (*types.Result, error) {
 	options := getOptions(optFns...)
-	return c.treatment(key, bucketingKey, feature, attrs, false, options.EvaluationOptions)
+	return c.treatment(ctx, key, bucketingKey, feature, attrs, false, options.EvaluationOptions)
 }
 
 // TreatmentWithConfig implements types.ClientInterface
 func (c *Impl) TreatmentWithConfig(key string, bucketingKey string, feature string, attrs map[string]interface{}, optFns ...types.OptFn) (*types.Result, error) {
+	return c.TreatmentWithConfigContext(context.Background(), key, bucketingKey, feature, attrs, optFns...)
+}
+
+// TreatmentWithConfigContext implements types.ClientInterface
+func (c *Impl) TreatmentWithConfigContext(ctx context.Context, key string, bucketingKey string, feature string, attrs map[string]interface{}, optFns ...types.OptFn) (*types.Result, error) {
 	options := getOptions(optFns...)
-	return c.treatment(key, bucketingKey, feature, attrs, true, options.EvaluationOptions)
+	return c.treatment(ctx, key, bucketingKey, feature, attrs, true, options.EvaluationOptions)
 }
 
 // Treatment implements Interface
 func (c *Impl) Treatments(key string, bucketingKey string, features []string, attrs map[string]interface{}, optFns ...types.OptFn) (types.Results, error) {
+	return c.TreatmentsContext(context.Background(), key, bucketingKey, features, attrs, optFns...)
+}
+
+// TreatmentsContext implements types.ClientInterface
+func (c *Impl) TreatmentsContext(ctx context.Context, key string, bucketingKey string, features []string, attrs map[string]interface{}, optFns ...types.OptFn) (types.Results, error) {
 	options := getOptions(optFns...)
-	return c.treatments(key, bucketingKey, features, attrs, false, options.EvaluationOptions)
+	return c.treatments(ctx, key, bucketingKey, features, attrs, false, options.EvaluationOptions)
 }
 
 // TreatmentsWithConfig implements types.ClientInterface
 func (c *Impl) TreatmentsWithConfig(key string, bucketingKey string, features []string, attrs map[string]interface{}, optFns ...types.OptFn) (types.Results, error) {
+	return c.TreatmentsWithConfigContext(context.Background(), key, bucketingKey, features, attrs, optFns...)
+}
+
+// TreatmentsWithConfigContext implements types.ClientInterface
+func (c *Impl) TreatmentsWithConfigContext(ctx context.Context, key string, bucketingKey string, features []string, attrs map[string]interface{}, optFns ...types.OptFn) (types.Results, error) {
 	options := getOptions(optFns...)
-	return c.treatments(key, bucketingKey, features, attrs, true, options.EvaluationOptions)
+	return c.treatments(ctx, key, bucketingKey, features, attrs, true, options.EvaluationOptions)
 }
 
 // Track implements types.ClientInterface
 func (c *Impl) Track(key string, trafficType string, eventType string, value *float64, properties map[string]interface{}) error {
+	return c.TrackContext(context.Background(), key, trafficType, eventType, value, properties)
+}
+
+// TrackContext implements types.ClientInterface
+func (c *Impl) TrackContext(ctx context.Context, key string, trafficType string, eventType string, value *float64, properties map[string]interface{}) error {
 
 	rpc := protov1.RPC{
 		RPCBase: protocol.RPCBase{Version: protocol.V1},
@@ -85,46 +153,167 @@ func (c *Impl) Track(key string, trafficType string, eventType string, value *fl
 		Args:    protov1.TrackArgs{Key: key, TrafficType: trafficType, EventType: eventType, Value: value, Properties: properties}.Encode(),
 	}
 
-	resp, err := doRPC[protov1.ResponseWrapper[protov1.TrackPayload]](c, &rpc)
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.TrackPayload]](ctx, c, &rpc)
 	if err != nil {
 		return fmt.Errorf("error executing track rpc: %w", err)
 	}
 
 	if resp.Status != protov1.ResultOk {
-		return fmt.Errorf("server responded track rpc with error %d", resp.Status)
+		return responseError("track", resp.Status)
+	}
+
+	for _, w := range resp.Payload.Warnings {
+		c.logger.Warning(fmt.Sprintf("track: [%d] %s", w.Code, w.Message))
+	}
+
+	if resp.Payload.Error != "" {
+		return fmt.Errorf("%w: %s", sdk.ErrInvalidInput, resp.Payload.Error)
+	}
+
+	return nil
+}
+
+// BindAttributes implements types.ClientInterface. Bound attributes are applied by the daemon to every later
+// evaluation issued through this connection (attributes passed in each call take precedence).
+// Binding again replaces the previous set, and a nil map clears it
+func (c *Impl) BindAttributes(attrs map[string]interface{}) error {
+	return c.BindAttributesContext(context.Background(), attrs)
+}
+
+// BindAttributesContext implements types.ClientInterface
+func (c *Impl) BindAttributesContext(ctx context.Context, attrs map[string]interface{}) error {
+
+	rpc := protov1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  protov1.OCBindAttributes,
+		Args:    protov1.BindAttributesArgs{Attributes: attrs}.Encode(),
+	}
+
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.BindAttributesPayload]](ctx, c, &rpc)
+	if err != nil {
+		return fmt.Errorf("error executing bind-attributes rpc: %w", err)
+	}
+
+	if resp.Status != protov1.ResultOk {
+		return responseError("bind-attributes", resp.Status)
+	}
+
+	return nil
+}
+
+// BlockUntilReady implements types.ClientInterface
+func (c *Impl) BlockUntilReady(timeout time.Duration) error {
+	return c.BlockUntilReadyContext(context.Background(), timeout)
+}
+
+// BlockUntilReadyContext implements types.ClientInterface
+func (c *Impl) BlockUntilReadyContext(ctx context.Context, timeout time.Duration) error {
+	resp, err := c.ready(ctx, timeout)
+	if err != nil {
+		return err
+	}
+
+	if !resp.Ready {
+		return sdk.ErrNotReady
+	}
+
+	return nil
+}
+
+// Status implements types.ClientInterface
+func (c *Impl) Status() (*sdk.Status, error) {
+	return c.StatusContext(context.Background())
+}
+
+// StatusContext implements types.ClientInterface
+func (c *Impl) StatusContext(ctx context.Context) (*sdk.Status, error) {
+	resp, err := c.ready(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &sdk.Status{
+		State:     sdk.State(resp.State),
+		Staleness: sdk.Staleness{Splits: resp.StaleSplits, Segments: resp.StaleSegments},
+	}, nil
+}
+
+// Ping implements types.ClientInterface. It keeps the connection alive when the daemon closes idle ones
+func (c *Impl) Ping() error {
+	return c.PingContext(context.Background())
+}
+
+// PingContext implements types.ClientInterface
+func (c *Impl) PingContext(ctx context.Context) error {
+	rpc := protov1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: protov1.OCPing, Args: protov1.PingArgs{}.Encode()}
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.EmptyPayload]](ctx, c, &rpc)
+	if err != nil {
+		return fmt.Errorf("error executing ping rpc: %w", err)
+	}
+
+	if resp.Status != protov1.ResultOk {
+		return responseError("ping", resp.Status)
 	}
 
 	return nil
 }
 
+func (c *Impl) ready(ctx context.Context, timeout time.Duration) (*protov1.ReadyPayload, error) {
+	rpc := protov1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  protov1.OCReady,
+		Args:    protov1.ReadyArgs{TimeoutMS: timeout.Milliseconds()}.Encode(),
+	}
+
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.ReadyPayload]](ctx, c, &rpc)
+	if err != nil {
+		return nil, fmt.Errorf("error executing ready rpc: %w", err)
+	}
+
+	if resp.Status != protov1.ResultOk {
+		return nil, responseError("ready", resp.Status)
+	}
+
+	return &resp.Payload, nil
+}
+
 func (c *Impl) SplitNames() ([]string, error) {
+	return c.SplitNamesContext(context.Background())
+}
+
+// SplitNamesContext implements types.ClientInterface
+func (c *Impl) SplitNamesContext(ctx context.Context) ([]string, error) {
 	rpc := protov1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: protov1.OCSplitNames}
-	resp, err := doRPC[protov1.ResponseWrapper[protov1.SplitNamesPayload]](c, &rpc)
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.SplitNamesPayload]](ctx, c, &rpc)
 	if err != nil {
 		return nil, fmt.Errorf("error executing split-names rpc: %w", err)
 	}
 
 	if resp.Status != protov1.ResultOk {
-		return nil, fmt.Errorf("server responded split-names rpc with error %d", resp.Status)
+		return nil, responseError("split-names", resp.Status)
 	}
 
 	return resp.Payload.Names, nil
 }
 
-func (c *Impl) Split(name string) (*sdk.SplitView, error) { // TODO(mredolatti): use a local dto instead of package sdk's
+func (c *Impl) Split(name string) (*sdk.SplitView, error) {
+	return c.SplitContext(context.Background(), name)
+}
+
+// SplitContext implements types.ClientInterface
+func (c *Impl) SplitContext(ctx context.Context, name string) (*sdk.SplitView, error) { // TODO(mredolatti): use a local dto instead of package sdk's
 	rpc := protov1.RPC{
 		RPCBase: protocol.RPCBase{Version: protocol.V1},
 		OpCode:  protov1.OCSplit,
 		Args:    protov1.SplitArgs{Name: name}.Encode(),
 	}
 
-	resp, err := doRPC[protov1.ResponseWrapper[protov1.SplitPayload]](c, &rpc)
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.SplitPayload]](ctx, c, &rpc)
 	if err != nil {
 		return nil, fmt.Errorf("error executing split rpc: %w", err)
 	}
 
 	if resp.Status != protov1.ResultOk {
-		return nil, fmt.Errorf("server responded split rpc with error %d", resp.Status)
+		return nil, responseError("split", resp.Status)
 	}
 
 	if resp.Payload.Name == "" {
@@ -135,14 +324,19 @@ func (c *Impl) Split(name string) (*sdk.SplitView, error) { // TODO(mredolatti):
 }
 
 func (c *Impl) Splits() ([]sdk.SplitView, error) {
+	return c.SplitsContext(context.Background())
+}
+
+// SplitsContext implements types.ClientInterface
+func (c *Impl) SplitsContext(ctx context.Context) ([]sdk.SplitView, error) {
 	rpc := protov1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: protov1.OCSplits}
-	resp, err := doRPC[protov1.ResponseWrapper[protov1.SplitsPayload]](c, &rpc)
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.SplitsPayload]](ctx, c, &rpc)
 	if err != nil {
 		return nil, fmt.Errorf("error executing splits rpc: %w", err)
 	}
 
 	if resp.Status != protov1.ResultOk {
-		return nil, fmt.Errorf("server responded splits rpc with error %d", resp.Status)
+		return nil, responseError("splits", resp.Status)
 	}
 
 	views := make([]sdk.SplitView, 0, len(resp.Payload.Splits))
@@ -153,7 +347,155 @@ func (c *Impl) Splits() ([]sdk.SplitView, error) {
 	return views, nil
 }
 
-func (c *Impl) treatment(key string, bucketingKey string, feature string, attrs map[string]interface{}, withConfig bool, evaluationOptions *dtos.EvaluationOptions) (*types.Result, error) {
+// SplitDefinition implements types.ClientInterface
+func (c *Impl) SplitDefinition(name string) (*dtos.SplitDTO, error) {
+	return c.SplitDefinitionContext(context.Background(), name)
+}
+
+// SplitDefinitionContext implements types.ClientInterface
+func (c *Impl) SplitDefinitionContext(ctx context.Context, name string) (*dtos.SplitDTO, error) {
+	rpc := protov1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  protov1.OCSplitDefinition,
+		Args:    protov1.SplitDefinitionArgs{Name: name}.Encode(),
+	}
+
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.SplitDefinitionPayload]](ctx, c, &rpc)
+	if err != nil {
+		return nil, fmt.Errorf("error executing split-definition rpc: %w", err)
+	}
+
+	if resp.Status != protov1.ResultOk {
+		return nil, responseError("split-definition", resp.Status)
+	}
+
+	return resp.Payload.Definition, nil
+}
+
+// SegmentNames implements types.ClientInterface
+func (c *Impl) SegmentNames() ([]string, error) {
+	return c.SegmentNamesContext(context.Background())
+}
+
+// SegmentNamesContext implements types.ClientInterface
+func (c *Impl) SegmentNamesContext(ctx context.Context) ([]string, error) {
+	rpc := protov1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: protov1.OCSegmentNames}
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.SegmentNamesPayload]](ctx, c, &rpc)
+	if err != nil {
+		return nil, fmt.Errorf("error executing segment-names rpc: %w", err)
+	}
+
+	if resp.Status != protov1.ResultOk {
+		return nil, responseError("segment-names", resp.Status)
+	}
+
+	return resp.Payload.Names, nil
+}
+
+// Segment implements types.ClientInterface
+func (c *Impl) Segment(name string) (*sdk.SegmentView, error) {
+	return c.SegmentContext(context.Background(), name)
+}
+
+// SegmentContext implements types.ClientInterface
+func (c *Impl) SegmentContext(ctx context.Context, name string) (*sdk.SegmentView, error) {
+	rpc := protov1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  protov1.OCSegment,
+		Args:    protov1.SegmentArgs{Name: name}.Encode(),
+	}
+
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.SegmentPayload]](ctx, c, &rpc)
+	if err != nil {
+		return nil, fmt.Errorf("error executing segment rpc: %w", err)
+	}
+
+	if resp.Status != protov1.ResultOk {
+		return nil, responseError("segment", resp.Status)
+	}
+
+	if resp.Payload.Name == "" {
+		return nil, nil
+	}
+
+	return lang.Ref(sdk.SegmentView(resp.Payload)), nil
+}
+
+// SegmentContainsKey implements types.ClientInterface
+func (c *Impl) SegmentContainsKey(name string, key string) (bool, error) {
+	return c.SegmentContainsKeyContext(context.Background(), name, key)
+}
+
+// SegmentContainsKeyContext implements types.ClientInterface
+func (c *Impl) SegmentContainsKeyContext(ctx context.Context, name string, key string) (bool, error) {
+	rpc := protov1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  protov1.OCSegmentContainsKey,
+		Args:    protov1.SegmentContainsKeyArgs{Name: name, Key: key}.Encode(),
+	}
+
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.SegmentContainsKeyPayload]](ctx, c, &rpc)
+	if err != nil {
+		return false, fmt.Errorf("error executing segment-contains-key rpc: %w", err)
+	}
+
+	if resp.Status != protov1.ResultOk {
+		return false, responseError("segment-contains-key", resp.Status)
+	}
+
+	if !resp.Payload.Exists {
+		return false, sdk.ErrSegmentNotFound
+	}
+
+	return resp.Payload.Contains, nil
+}
+
+// RuleBasedSegmentNames implements types.ClientInterface
+func (c *Impl) RuleBasedSegmentNames() ([]string, error) {
+	return c.RuleBasedSegmentNamesContext(context.Background())
+}
+
+// RuleBasedSegmentNamesContext implements types.ClientInterface
+func (c *Impl) RuleBasedSegmentNamesContext(ctx context.Context) ([]string, error) {
+	rpc := protov1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: protov1.OCRuleBasedSegmentNames}
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.RuleBasedSegmentNamesPayload]](ctx, c, &rpc)
+	if err != nil {
+		return nil, fmt.Errorf("error executing rule-based-segment-names rpc: %w", err)
+	}
+
+	if resp.Status != protov1.ResultOk {
+		return nil, responseError("rule-based-segment-names", resp.Status)
+	}
+
+	return resp.Payload.Names, nil
+}
+
+// RuleBasedSegment implements types.ClientInterface
+func (c *Impl) RuleBasedSegment(name string) (*dtos.RuleBasedSegmentDTO, error) {
+	return c.RuleBasedSegmentContext(context.Background(), name)
+}
+
+// RuleBasedSegmentContext implements types.ClientInterface
+func (c *Impl) RuleBasedSegmentContext(ctx context.Context, name string) (*dtos.RuleBasedSegmentDTO, error) {
+	rpc := protov1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  protov1.OCRuleBasedSegment,
+		Args:    protov1.RuleBasedSegmentArgs{Name: name}.Encode(),
+	}
+
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.RuleBasedSegmentPayload]](ctx, c, &rpc)
+	if err != nil {
+		return nil, fmt.Errorf("error executing rule-based-segment rpc: %w", err)
+	}
+
+	if resp.Status != protov1.ResultOk {
+		return nil, responseError("rule-based-segment", resp.Status)
+	}
+
+	return resp.Payload.Definition, nil
+}
+
+func (c *Impl) treatment(ctx context.Context, key string, bucketingKey string, feature string, attrs map[string]interface{}, withConfig bool, evaluationOptions *dtos.EvaluationOptions) (*types.Result, error) {
 	var bkp *string
 	if bucketingKey != "" {
 		bkp = &bucketingKey
@@ -169,13 +511,13 @@ func (c *Impl) treatment(key string, bucketingKey string, feature string, attrs
 		rpc.OpCode = protov1.OCTreatmentWithConfig
 	}
 
-	resp, err := doRPC[protov1.ResponseWrapper[protov1.TreatmentPayload]](c, &rpc)
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.TreatmentPayload]](ctx, c, &rpc)
 	if err != nil {
 		return &types.Result{Treatment: Control}, fmt.Errorf("error executing treatment rpc: %w", err)
 	}
 
 	if resp.Status != protov1.ResultOk {
-		return &types.Result{Treatment: Control}, fmt.Errorf("server responded treatment rpc with error %d", resp.Status)
+		return &types.Result{Treatment: Control}, responseError("treatment", resp.Status)
 	}
 
 	var imp *dtos.Impression
@@ -192,7 +534,13 @@ func (c *Impl) treatment(key string, bucketingKey string, feature string, attrs
 		}
 	}
 
-	toRet := &types.Result{Treatment: resp.Payload.Treatment, Impression: imp}
+	toRet := &types.Result{
+		Treatment:  resp.Payload.Treatment,
+		Impression: imp,
+		Stale:      resp.Payload.Stale,
+		Label:      resp.Payload.Label,
+		Warnings:   fromWarningsPayload(resp.Payload.Warnings),
+	}
 	if withConfig {
 		toRet.Config = resp.Payload.Config
 	}
@@ -200,7 +548,7 @@ func (c *Impl) treatment(key string, bucketingKey string, feature string, attrs
 	return toRet, nil
 }
 
-func (c *Impl) treatments(key string, bucketingKey string, features []string, attrs map[string]interface{}, withConfig bool, evaluationOptions *dtos.EvaluationOptions) (types.Results, error) {
+func (c *Impl) treatments(ctx context.Context, key string, bucketingKey string, features []string, attrs map[string]interface{}, withConfig bool, evaluationOptions *dtos.EvaluationOptions) (types.Results, error) {
 	var bkp *string
 	if bucketingKey != "" {
 		bkp = &bucketingKey
@@ -215,13 +563,13 @@ func (c *Impl) treatments(key string, bucketingKey string, features []string, at
 		rpc.OpCode = protov1.OCTreatmentsWithConfig
 	}
 
-	resp, err := doRPC[protov1.ResponseWrapper[protov1.TreatmentsPayload]](c, &rpc)
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.TreatmentsPayload]](ctx, c, &rpc)
 	if err != nil {
 		return nil, fmt.Errorf("error executing treatments rpc: %w", err)
 	}
 
 	if resp.Status != protov1.ResultOk {
-		return nil, fmt.Errorf("server responded treatments rpc with error %d", resp.Status)
+		return nil, responseError("treatments", resp.Status)
 	}
 
 	results := make(types.Results)
@@ -240,7 +588,13 @@ func (c *Impl) treatments(key string, bucketingKey string, features []string, at
 			}
 		}
 
-		res := types.Result{Treatment: resp.Payload.Results[idx].Treatment, Impression: imp}
+		res := types.Result{
+			Treatment:  resp.Payload.Results[idx].Treatment,
+			Impression: imp,
+			Stale:      resp.Payload.Results[idx].Stale,
+			Label:      resp.Payload.Results[idx].Label,
+			Warnings:   fromWarningsPayload(resp.Payload.Results[idx].Warnings),
+		}
 		if withConfig {
 			res.Config = resp.Payload.Results[idx].Config
 		}
@@ -250,43 +604,72 @@ func (c *Impl) treatments(key string, bucketingKey string, features []string, at
 	return results, nil
 }
 
-func (c *Impl) register(id string, impressionsFeedback bool) error {
-	var flags protov1.RegisterFlags
+func (c *Impl) register(ctx context.Context, id string, impressionsFeedback bool, regOpts ...RegisterOpt) error {
+	args := protov1.RegisterArgs{ID: id, SDKVersion: fmt.Sprintf("splitd-%s", splitio.Version)}
 	if impressionsFeedback {
-		flags |= 1 << protov1.RegisterFlagReturnImpressionData
+		args.Flags |= 1 << protov1.RegisterFlagReturnImpressionData
 	}
+	for _, opt := range regOpts {
+		opt(&args)
+	}
+
 	rpc := protov1.RPC{
 		RPCBase: protocol.RPCBase{Version: protocol.V1},
 		OpCode:  protov1.OCRegister,
-		Args:    protov1.RegisterArgs{ID: id, SDKVersion: fmt.Sprintf("splitd-%s", splitio.Version), Flags: flags}.Encode(),
+		Args:    args.Encode(),
 	}
 
-	resp, err := doRPC[protov1.ResponseWrapper[protov1.RegisterPayload]](c, &rpc)
+	resp, err := doRPC[protov1.ResponseWrapper[protov1.RegisterPayload]](ctx, c, &rpc)
 	if err != nil {
 		return fmt.Errorf("error executing register rpc: %w", err)
 	}
 
+	if resp.Status == protov1.ResultUnauthorized {
+		return fmt.Errorf("%w: invalid or missing token", auth.ErrUnauthorized)
+	}
+
+	if resp.Status == protov1.ResultUnknownEnvironment {
+		return fmt.Errorf("%w: '%s'", sdk.ErrUnknownEnvironment, args.Environment)
+	}
+
 	if resp.Status != protov1.ResultOk {
-		return fmt.Errorf("server responded register rpc with error %d", resp.Status)
+		return responseError("register", resp.Status)
 	}
 
 	return nil
 }
 
-func doRPC[T any](c *Impl, rpc *protov1.RPC) (*T, error) {
-	serialized, err := c.serializer.Serialize(rpc)
+func doRPC[T any](ctx context.Context, c *Impl, rpc *protov1.RPC) (*T, error) {
+	if c.expired {
+		return nil, types.ErrConnectionExpired
+	}
+	if c.broken {
+		return nil, fmt.Errorf("%w (%w)", types.ErrConnectionLost, types.ErrRequestNotSent)
+	}
+
+	// responses to calls abandoned while waiting for them (cancelled or timed out) arrive first
+	for c.pending > 0 {
+		if err := c.discardLateResponse(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if c.expired {
+		return nil, types.ErrConnectionExpired
+	}
+
+	serialized, err := serializer.SerializeWith(c.serializer, &c.writeBuffer, rpc)
 	if err != nil {
 		return nil, fmt.Errorf("error serializing rpc: %w", err)
 	}
 
-	err = c.conn.SendMessage(serialized)
+	err = c.send(ctx, serialized)
 	if err != nil {
-		return nil, fmt.Errorf("error sending message to split daemon: %w", err)
+		return nil, c.transferError("error sending message to split daemon", err, false)
 	}
 
-	resp, err := c.conn.ReceiveMessage()
+	resp, err := c.receive(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response from daemon: %w", err)
+		return nil, c.transferError("error reading response from daemon", err, true)
 	}
 
 	var response T
@@ -295,13 +678,97 @@ func doRPC[T any](c *Impl, rpc *protov1.RPC) (*T, error) {
 		return nil, fmt.Errorf("error de-serializing server response: %w", err)
 	}
 
+	// the response is valid, but the daemon has closed the connection right after sending it
+	if withReconnect, ok := any(&response).(interface{ ReconnectRequested() bool }); ok && withReconnect.ReconnectRequested() {
+		c.logger.Debug("connection closed by the daemon, further calls require a new client")
+		c.expired = true
+	}
+
 	return &response, nil
 }
 
+// discardLateResponse reads & drops the response to an abandoned call, only looking for a reconnection request
+func (c *Impl) discardLateResponse(ctx context.Context) error {
+	raw, err := c.receive(ctx)
+	if err != nil {
+		return c.transferError("error reading late response from daemon", err, false)
+	}
+	c.pending--
+
+	var response protov1.ResponseWrapper[protov1.EmptyPayload]
+	if c.serializer.Parse(raw, &response) == nil && response.ReconnectRequested() {
+		c.logger.Debug("connection closed by the daemon, further calls require a new client")
+		c.expired = true
+	}
+	return nil
+}
+
+func (c *Impl) send(ctx context.Context, message []byte) error {
+	if cc, ok := c.conn.(transfer.ContextConn); ok {
+		return cc.SendMessageContext(ctx, message)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.conn.SendMessage(message)
+}
+
+func (c *Impl) receive(ctx context.Context) ([]byte, error) {
+	if cc, ok := c.conn.(transfer.ContextConn); ok {
+		return cc.ReceiveMessageContext(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.conn.ReceiveMessage()
+}
+
+// transferError classifies an error returned by the transfer layer. Timeouts & cancellations leave the connection
+// usable (taking note of the response still due if the rpc was sent), anything else renders it unusable. A partially
+// written message is never processed by the daemon, so a failed send is reported as ErrRequestNotSent
+func (c *Impl) transferError(message string, err error, sent bool) error {
+	if isTimeout(err) && !errors.Is(err, transfer.ErrIncompleteWrite) {
+		if sent {
+			c.pending++
+		}
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	c.broken = true
+	if !sent {
+		return fmt.Errorf("%w (%w): %s: %w", types.ErrConnectionLost, types.ErrRequestNotSent, message, err)
+	}
+	return fmt.Errorf("%w: %s: %w", types.ErrConnectionLost, message, err)
+}
+
+func isTimeout(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded)
+}
+
 func (c *Impl) Shutdown() error {
 	return c.conn.Shutdown()
 }
 
+// responseError builds the error for an rpc the daemon responded with a non-ok status
+func responseError(rpc string, status protov1.Result) error {
+	if status == protov1.ResultUnauthorized {
+		return fmt.Errorf("%w: token not allowed to perform %s rpcs", auth.ErrUnauthorized, rpc)
+	}
+	return fmt.Errorf("server responded %s rpc with error %d", rpc, status)
+}
+
+func fromWarningsPayload(warnings []protov1.ValidationWarning) []sdk.ValidationWarning {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	toRet := make([]sdk.ValidationWarning, 0, len(warnings))
+	for _, w := range warnings {
+		toRet = append(toRet, sdk.ValidationWarning{Code: sdk.WarningCode(w.Code), Message: w.Message})
+	}
+	return toRet
+}
+
 func getOptions(optFns ...types.OptFn) types.Options {
 	options := defaultOpts()
 	for _, optFn := range optFns {
@@ -1,11 +1,19 @@
 package v1
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/splitio"
 	"github.com/splitio/splitd/splitio/common/lang"
+	"github.com/splitio/splitd/splitio/link/auth"
+	"github.com/splitio/splitd/splitio/link/client/types"
+	"github.com/splitio/splitd/splitio/link/protocol"
 	v1 "github.com/splitio/splitd/splitio/link/protocol/v1"
 	proto1Mocks "github.com/splitio/splitd/splitio/link/protocol/v1/mocks"
 	serializerMocks "github.com/splitio/splitd/splitio/link/serializer/mocks"
@@ -49,6 +57,51 @@ func TestClientGetTreatmentNoImpression(t *testing.T) {
 	assert.Nil(t, res.Impression)
 }
 
+func TestClientGetTreatmentWarnings(t *testing.T) {
+
+	logger := logging.NewLogger(nil)
+
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("SendMessage", []byte("registrationMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationSuccess"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("treatmentMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("treatmentResult"), nil).Once()
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", proto1Mocks.NewRegisterRPC("some", false)).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationSuccess"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultOk}
+	}).Once()
+
+	serializerMock.On("Serialize", proto1Mocks.NewTreatmentRPC("key1", "", " missing", nil, nil, false)).
+		Return([]byte("treatmentMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("treatmentResult"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.TreatmentPayload]) = v1.ResponseWrapper[v1.TreatmentPayload]{
+			Status: v1.ResultOk,
+			Payload: v1.TreatmentPayload{
+				Treatment: "control",
+				Label:     sdk.LabelFlagNotFound,
+				Warnings: []v1.ValidationWarning{
+					{Code: uint8(sdk.WarningFlagNameTrimmed), Message: "trimmed"},
+					{Code: uint8(sdk.WarningFlagNotFound), Message: "not found"},
+				},
+			},
+		}
+	}).Once()
+	client, err := New("some", logger, rawConnMock, serializerMock, false)
+	assert.NotNil(t, client)
+	assert.Nil(t, err)
+
+	res, err := client.Treatment("key1", "", " missing", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "control", res.Treatment)
+	assert.Equal(t, sdk.LabelFlagNotFound, res.Label)
+	assert.Equal(t, []sdk.ValidationWarning{
+		{Code: sdk.WarningFlagNameTrimmed, Message: "trimmed"},
+		{Code: sdk.WarningFlagNotFound, Message: "not found"},
+	}, res.Warnings)
+}
+
 func TestClientGetTreatmentWithConfig(t *testing.T) {
 
 	logger := logging.NewLogger(nil)
@@ -113,6 +166,67 @@ func TestTrack(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestTrackValidationError(t *testing.T) {
+
+	logger := logging.NewLogger(nil)
+
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("SendMessage", []byte("registrationMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationSuccess"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("trackMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("trackResult"), nil).Once()
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", proto1Mocks.NewRegisterRPC("some", false)).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationSuccess"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultOk}
+	}).Once()
+
+	serializerMock.On("Serialize", proto1Mocks.NewTrackRPC("", "user", "checkin", nil, nil)).Return([]byte("trackMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("trackResult"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.TrackPayload]) = v1.ResponseWrapper[v1.TrackPayload]{
+			Status:  v1.ResultOk,
+			Payload: v1.TrackPayload{Success: false, Error: "Track: key cannot be empty"},
+		}
+	}).Once()
+	client, err := New("some", logger, rawConnMock, serializerMock, false)
+	assert.NotNil(t, client)
+	assert.Nil(t, err)
+
+	err = client.Track("", "user", "checkin", nil, nil)
+	assert.ErrorIs(t, err, sdk.ErrInvalidInput)
+	assert.ErrorContains(t, err, "key cannot be empty")
+}
+
+func TestBindAttributes(t *testing.T) {
+
+	logger := logging.NewLogger(nil)
+
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("SendMessage", []byte("registrationMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationSuccess"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("bindMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("bindResult"), nil).Once()
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", proto1Mocks.NewRegisterRPC("some", false)).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationSuccess"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultOk}
+	}).Once()
+
+	serializerMock.On("Serialize", proto1Mocks.NewBindAttributesRPC(map[string]interface{}{"region": "us-east-1"})).
+		Return([]byte("bindMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("bindResult"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.BindAttributesPayload]) = *proto1Mocks.NewBindAttributesResp(true, 1)
+	}).Once()
+	client, err := New("some", logger, rawConnMock, serializerMock, false)
+	assert.NotNil(t, client)
+	assert.Nil(t, err)
+
+	err = client.BindAttributes(map[string]interface{}{"region": "us-east-1"})
+	assert.Nil(t, err)
+}
+
 func TestClientGetTreatmentWithImpression(t *testing.T) {
 
 	logger := logging.NewLogger(nil)
@@ -431,6 +545,225 @@ func TestClientSplit(t *testing.T) {
 	}, res)
 }
 
+func TestClientSegmentContainsKey(t *testing.T) {
+
+	logger := logging.NewLogger(nil)
+
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("SendMessage", []byte("registrationMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationSuccess"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("containsMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("containsResult"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("missingMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("missingResult"), nil).Once()
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", proto1Mocks.NewRegisterRPC("some", false)).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationSuccess"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultOk}
+	}).Once()
+
+	serializerMock.On("Serialize", proto1Mocks.NewSegmentContainsKeyRPC("seg1", "key1")).
+		Return([]byte("containsMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("containsResult"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.SegmentContainsKeyPayload]) = *proto1Mocks.NewSegmentContainsKeyResp(true, true, true)
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewSegmentContainsKeyRPC("seg2", "key1")).
+		Return([]byte("missingMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("missingResult"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.SegmentContainsKeyPayload]) = *proto1Mocks.NewSegmentContainsKeyResp(true, false, false)
+	}).Once()
+
+	client, err := New("some", logger, rawConnMock, serializerMock, false)
+	assert.NotNil(t, client)
+	assert.Nil(t, err)
+
+	contains, err := client.SegmentContainsKey("seg1", "key1")
+	assert.Nil(t, err)
+	assert.True(t, contains)
+
+	contains, err = client.SegmentContainsKey("seg2", "key1")
+	assert.ErrorIs(t, err, sdk.ErrSegmentNotFound)
+	assert.False(t, contains)
+}
+
+func TestClientSplitDefinition(t *testing.T) {
+
+	logger := logging.NewLogger(nil)
+
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("SendMessage", []byte("registrationMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationSuccess"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("splitMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("splitResult"), nil).Once()
+
+	def := &dtos.SplitDTO{Name: "s1", TrafficTypeName: "tt1", ChangeNumber: 1}
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", proto1Mocks.NewRegisterRPC("some", false)).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationSuccess"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultOk}
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewSplitDefinitionRPC("s1")).
+		Return([]byte("splitMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("splitResult"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.SplitDefinitionPayload]) = *proto1Mocks.NewSplitDefinitionResp(true, def)
+	}).Once()
+
+	client, err := New("some", logger, rawConnMock, serializerMock, false)
+	assert.NotNil(t, client)
+	assert.Nil(t, err)
+
+	res, err := client.SplitDefinition("s1")
+	assert.Nil(t, err)
+	assert.Equal(t, def, res)
+}
+
+func TestClientBlockUntilReady(t *testing.T) {
+
+	logger := logging.NewLogger(nil)
+
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("SendMessage", []byte("registrationMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationSuccess"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("readyMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("notReadyResult"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("stateMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("stateResult"), nil).Once()
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", proto1Mocks.NewRegisterRPC("some", false)).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationSuccess"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultOk}
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewReadyRPC(1500)).Return([]byte("readyMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("notReadyResult"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.ReadyPayload]) = *proto1Mocks.NewReadyResp(true, false, byte(sdk.StateNotReady))
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewReadyRPC(0)).Return([]byte("stateMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("stateResult"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.ReadyPayload]) = *proto1Mocks.NewReadyResp(true, true, byte(sdk.StateReady))
+	}).Once()
+
+	client, err := New("some", logger, rawConnMock, serializerMock, false)
+	assert.NotNil(t, client)
+	assert.Nil(t, err)
+
+	assert.ErrorIs(t, client.BlockUntilReady(1500*time.Millisecond), sdk.ErrNotReady)
+
+	status, err := client.Status()
+	assert.Nil(t, err)
+	assert.Equal(t, &sdk.Status{State: sdk.StateReady}, status)
+}
+
+func TestClientRegisterWithEnvironment(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	registerRPC := &v1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  v1.OCRegister,
+		Args:    []interface{}{"some", fmt.Sprintf("splitd-%s", splitio.Version), v1.RegisterFlags(0), int64(0), "staging"},
+	}
+
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("SendMessage", []byte("registrationMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationSuccess"), nil).Once()
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", registerRPC).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationSuccess"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultOk}
+	}).Once()
+
+	client, err := New("some", logger, rawConnMock, serializerMock, false, WithEnvironment("staging"))
+	assert.NotNil(t, client)
+	assert.Nil(t, err)
+
+	// unknown environment
+	rawConnMock = &transferMocks.RawConnMock{}
+	rawConnMock.On("SendMessage", []byte("registrationMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationFailure"), nil).Once()
+	rawConnMock.On("Shutdown").Return(nil).Once()
+
+	serializerMock = &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", registerRPC).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationFailure"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultUnknownEnvironment}
+	}).Once()
+
+	client, err = New("some", logger, rawConnMock, serializerMock, false, WithEnvironment("staging"))
+	assert.Nil(t, client)
+	assert.ErrorIs(t, err, sdk.ErrUnknownEnvironment)
+	rawConnMock.AssertExpectations(t)
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	registerRPC := &v1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  v1.OCRegister,
+		Args:    []interface{}{"some", fmt.Sprintf("splitd-%s", splitio.Version), v1.RegisterFlags(0), int64(0), "", "s3cr3t"},
+	}
+
+	// invalid token
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("SendMessage", []byte("registrationMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationFailure"), nil).Once()
+	rawConnMock.On("Shutdown").Return(nil).Once()
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", registerRPC).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationFailure"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultUnauthorized}
+	}).Once()
+
+	client, err := New("some", logger, rawConnMock, serializerMock, false, WithToken("s3cr3t"))
+	assert.Nil(t, client)
+	assert.ErrorIs(t, err, auth.ErrUnauthorized)
+	rawConnMock.AssertExpectations(t)
+
+	// operation out of the token's scope
+	rawConnMock = &transferMocks.RawConnMock{}
+	rawConnMock.On("SendMessage", []byte("registrationMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationSuccess"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("trackMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("trackResult"), nil).Once()
+
+	serializerMock = &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", registerRPC).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationSuccess"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultOk}
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewTrackRPC("key", "user", "checkin", nil, nil)).Return([]byte("trackMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("trackResult"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.TrackPayload]) = v1.ResponseWrapper[v1.TrackPayload]{Status: v1.ResultUnauthorized}
+	}).Once()
+
+	client, err = New("some", logger, rawConnMock, serializerMock, false, WithToken("s3cr3t"))
+	assert.NotNil(t, client)
+	assert.Nil(t, err)
+	err = client.Track("key", "user", "checkin", nil, nil)
+	assert.ErrorIs(t, err, auth.ErrUnauthorized)
+}
+
+func TestRegisterOpts(t *testing.T) {
+	var args v1.RegisterArgs
+	WithImpressionsMode("none")(&args)
+	WithLabels(true)(&args)
+	assert.Equal(t, "none", args.ImpressionsMode())
+	assert.Equal(t, lang.Ref(true), args.LabelsEnabled())
+
+	args = v1.RegisterArgs{}
+	WithImpressionsMode("sarasa")(&args)
+	WithLabels(false)(&args)
+	WithEnvironment("staging")(&args)
+	assert.Equal(t, "", args.ImpressionsMode())
+	assert.Equal(t, lang.Ref(false), args.LabelsEnabled())
+	assert.Equal(t, "staging", args.Environment)
+
+	WithToken("s3cr3t")(&args)
+	assert.Equal(t, "s3cr3t", args.Token)
+}
+
 func validateImpression(t *testing.T, expected *dtos.Impression, actual *dtos.Impression) {
 	t.Helper()
 	assert.Equal(t, expected.BucketingKey, actual.BucketingKey)
@@ -444,3 +777,143 @@ func validateImpression(t *testing.T, expected *dtos.Impression, actual *dtos.Im
 	assert.Equal(t, expected.Properties, actual.Properties)
 
 }
+
+func TestClientPingAndReconnectRequest(t *testing.T) {
+	logger := logging.NewLogger(nil)
+
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("SendMessage", []byte("registrationMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationSuccess"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("pingMessage")).Return(nil).Twice()
+	rawConnMock.On("ReceiveMessage").Return([]byte("pong"), nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("pongAndReconnect"), nil).Once()
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", proto1Mocks.NewRegisterRPC("some", false)).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationSuccess"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultOk}
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewPingRPC()).Return([]byte("pingMessage"), nil).Twice()
+	serializerMock.On("Parse", []byte("pong"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.EmptyPayload]) = *proto1Mocks.NewPingResp(true)
+	}).Once()
+	serializerMock.On("Parse", []byte("pongAndReconnect"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.EmptyPayload]) = v1.ResponseWrapper[v1.EmptyPayload]{Status: v1.ResultOk, Reconnect: true}
+	}).Once()
+
+	client, err := New("some", logger, rawConnMock, serializerMock, false)
+	assert.Nil(t, err)
+	assert.Nil(t, client.Ping())
+	assert.Nil(t, client.Ping()) // valid response, but the connection is closed afterwards
+
+	// nothing else is sent through the connection
+	assert.ErrorIs(t, client.Ping(), types.ErrConnectionExpired)
+	_, err = client.Treatment("key1", "buck1", "feat1", nil)
+	assert.ErrorIs(t, err, types.ErrConnectionExpired)
+	rawConnMock.AssertExpectations(t)
+	serializerMock.AssertExpectations(t)
+}
+
+func TestClientConnectionLost(t *testing.T) {
+	logger := logging.NewLogger(nil)
+
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("SendMessage", []byte("registrationMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationSuccess"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("pingMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte(nil), io.EOF).Once()
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", proto1Mocks.NewRegisterRPC("some", false)).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationSuccess"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultOk}
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewPingRPC()).Return([]byte("pingMessage"), nil).Once()
+
+	client, err := New("some", logger, rawConnMock, serializerMock, false)
+	assert.Nil(t, err)
+
+	err = client.Ping()
+	assert.ErrorIs(t, err, types.ErrConnectionLost)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.NotErrorIs(t, err, types.ErrRequestNotSent)
+
+	// the connection is not used anymore
+	_, err = client.Treatment("key1", "buck1", "feat1", nil)
+	assert.ErrorIs(t, err, types.ErrConnectionLost)
+	assert.ErrorIs(t, err, types.ErrRequestNotSent)
+	rawConnMock.AssertExpectations(t)
+	serializerMock.AssertExpectations(t)
+}
+
+func TestClientSendFailed(t *testing.T) {
+	logger := logging.NewLogger(nil)
+
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("SendMessage", []byte("registrationMessage")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationSuccess"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("treatmentMessage")).Return(io.ErrClosedPipe).Once()
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", proto1Mocks.NewRegisterRPC("some", false)).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationSuccess"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultOk}
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewTreatmentRPC("key1", "buck1", "feat1", nil, nil, false)).
+		Return([]byte("treatmentMessage"), nil).Once()
+
+	client, err := New("some", logger, rawConnMock, serializerMock, false)
+	assert.Nil(t, err)
+
+	// the daemon never got the rpc, so it's safe to retry it elsewhere
+	_, err = client.Treatment("key1", "buck1", "feat1", nil)
+	assert.ErrorIs(t, err, types.ErrConnectionLost)
+	assert.ErrorIs(t, err, types.ErrRequestNotSent)
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+	rawConnMock.AssertExpectations(t)
+	serializerMock.AssertExpectations(t)
+}
+
+func TestClientCancelledCallLateResponse(t *testing.T) {
+	logger := logging.NewLogger(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	connMock := &transferMocks.ContextConnMock{}
+	connMock.On("SendMessageContext", mock.Anything, []byte("registrationMessage")).Return(nil).Once()
+	connMock.On("ReceiveMessageContext", mock.Anything).Return([]byte("registrationSuccess"), nil).Once()
+	connMock.On("SendMessageContext", mock.Anything, []byte("treatmentMessage")).Return(nil).Twice()
+	connMock.On("ReceiveMessageContext", ctx).Return([]byte(nil), fmt.Errorf("%w: read interrupted", context.Canceled)).Once()
+	connMock.On("ReceiveMessageContext", context.Background()).Return([]byte("lateResponse"), nil).Once()
+	connMock.On("ReceiveMessageContext", context.Background()).Return([]byte("treatmentResult"), nil).Once()
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Serialize", proto1Mocks.NewRegisterRPC("some", false)).Return([]byte("registrationMessage"), nil).Once()
+	serializerMock.On("Parse", []byte("registrationSuccess"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.RegisterPayload]) = v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultOk}
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewTreatmentRPC("key1", "buck1", "feat1", nil, nil, false)).
+		Return([]byte("treatmentMessage"), nil).Twice()
+	serializerMock.On("Parse", []byte("lateResponse"), mock.Anything).Return(nil).Once()
+	serializerMock.On("Parse", []byte("treatmentResult"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.ResponseWrapper[v1.TreatmentPayload]) = v1.ResponseWrapper[v1.TreatmentPayload]{
+			Status:  v1.ResultOk,
+			Payload: v1.TreatmentPayload{Treatment: "on"},
+		}
+	}).Once()
+
+	client, err := New("some", logger, connMock, serializerMock, false)
+	assert.Nil(t, err)
+
+	_, err = client.TreatmentContext(ctx, "key1", "buck1", "feat1", nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, types.ErrConnectionLost)
+
+	// the response to the cancelled call is discarded before issuing the next one
+	res, err := client.Treatment("key1", "buck1", "feat1", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "on", res.Treatment)
+	connMock.AssertExpectations(t)
+	serializerMock.AssertExpectations(t)
+}
@@ -2,9 +2,13 @@ package link
 
 import (
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/splitio/link/auth"
 	"github.com/splitio/splitd/splitio/link/client"
+	"github.com/splitio/splitd/splitio/link/client/pool"
 	"github.com/splitio/splitd/splitio/link/client/types"
 	"github.com/splitio/splitd/splitio/link/protocol"
 	"github.com/splitio/splitd/splitio/link/serializer"
@@ -14,28 +18,74 @@ import (
 )
 
 func Listen(logger logging.LoggerInterface, sdkFacade sdk.Interface, opts *ListenerOptions) (<-chan error, func() error, error) {
+	return ListenEnvironments(logger, sdk.SingleEnvironment(sdkFacade), opts)
+}
+
+// ListenEnvironments starts a listener serving multiple environments. Clients pick one when registering,
+// otherwise they're served by the listener's default (`opts.Environment`)
+func ListenEnvironments(logger logging.LoggerInterface, environments sdk.Environments, opts *ListenerOptions) (<-chan error, func() error, error) {
+	l, err := StartListener(logger, environments, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l.Errors(), l.Shutdown, nil
+}
+
+// Listener is a running link listener
+type Listener struct {
+	acceptor *transfer.Acceptor
+	errc     <-chan error
+}
+
+// StartListener works as ListenEnvironments, but returns a handle that allows handing the listener over to
+// another process
+func StartListener(logger logging.LoggerInterface, environments sdk.Environments, opts *ListenerOptions) (*Listener, error) {
 
 	acceptor, err := transfer.NewAcceptor(logger, &opts.Transfer, &opts.Acceptor)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error setting up transfer module: %w", err)
+		return nil, fmt.Errorf("error setting up transfer module: %w", err)
 	}
 
 	s, err := serializer.Setup(opts.Serialization)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error building serializer")
+		return nil, fmt.Errorf("error building serializer")
+	}
+
+	authn, err := auth.NewAuthenticator(opts.Tokens)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up authentication: %w", err)
 	}
 
-	svc, err := service.New(logger, sdkFacade, s, opts.Protocol)
+	svc, err := service.New(logger, environments, opts.Environment, s, opts.Protocol, authn, opts.Connections)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error setting up service handler: %w", err)
+		return nil, fmt.Errorf("error setting up service handler: %w", err)
 	}
 
 	ec, err := acceptor.Start(svc.HandleNewClient)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error setting up listener: %w", err)
+		return nil, fmt.Errorf("error setting up listener: %w", err)
 	}
 
-	return ec, acceptor.Shutdown, nil
+	return &Listener{acceptor: acceptor, errc: ec}, nil
+}
+
+// Errors returns a channel that yields once the listener stops: nil if it was shut down, or the error that caused it
+func (l *Listener) Errors() <-chan error {
+	return l.errc
+}
+
+func (l *Listener) Shutdown() error {
+	return l.acceptor.Shutdown()
+}
+
+// File returns a duplicate of the listening socket, to be passed on to another process
+func (l *Listener) File() (*os.File, error) {
+	return l.acceptor.File()
+}
+
+// Drain stops accepting connections (keeping the socket in place), and waits for the active ones to finish
+func (l *Listener) Drain(timeout time.Duration) error {
+	return l.acceptor.Drain(timeout)
 }
 
 func Consumer(logger logging.LoggerInterface, opts *ConsumerOptions) (types.ClientInterface, error) {
@@ -53,11 +103,33 @@ func Consumer(logger logging.LoggerInterface, opts *ConsumerOptions) (types.Clie
 	return client.New(logger, conn, s, opts.Consumer)
 }
 
+// PooledConsumer builds a client backed by a pool of connections to the daemon, which are re-established when they
+// break. Unlike the one returned by Consumer, it's safe for concurrent use
+func PooledConsumer(logger logging.LoggerInterface, opts *ConsumerOptions) (types.ClientInterface, error) {
+	s, err := serializer.Setup(opts.Serialization)
+	if err != nil {
+		return nil, fmt.Errorf("error building serializer")
+	}
+
+	factory := func() (types.ClientInterface, error) {
+		conn, err := transfer.NewClientConn(logger, &opts.Transfer)
+		if err != nil {
+			return nil, fmt.Errorf("%w: error creating connection: %w", types.ErrConnectionLost, err)
+		}
+		return client.New(logger, conn, s, opts.Consumer)
+	}
+
+	return pool.New(logger, factory, opts.Pool)
+}
+
 type ListenerOptions struct {
 	Transfer      transfer.Options
 	Acceptor      transfer.AcceptorConfig
 	Serialization serializer.Mechanism
 	Protocol      protocol.Version
+	Environment   string       // environment used for clients that don't request one
+	Tokens        []auth.Token // if not empty, clients must present one of these when registering
+	Connections   service.ConnectionPolicy
 }
 
 func DefaultListenerOptions() ListenerOptions {
@@ -66,6 +138,7 @@ func DefaultListenerOptions() ListenerOptions {
 		Acceptor:      transfer.DefaultAcceptorConfig(),
 		Serialization: serializer.MsgPack,
 		Protocol:      protocol.V1,
+		Environment:   sdk.DefaultEnvironment,
 	}
 }
 
@@ -73,6 +146,7 @@ type ConsumerOptions struct {
 	Transfer      transfer.Options
 	Consumer      client.Options
 	Serialization serializer.Mechanism
+	Pool          pool.Options // only used by PooledConsumer
 }
 
 func DefaultConsumerOptions() ConsumerOptions {
@@ -80,5 +154,6 @@ func DefaultConsumerOptions() ConsumerOptions {
 		Transfer:      transfer.DefaultOpts(),
 		Consumer:      client.DefaultOptions(),
 		Serialization: serializer.MsgPack,
+		Pool:          pool.DefaultOptions(),
 	}
 }
@@ -1,13 +1,16 @@
 package link
 
 import (
+	"path"
 	"testing"
 
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/splitd/splitio/link/client"
+	"github.com/splitio/splitd/splitio/link/client/pool"
 	"github.com/splitio/splitd/splitio/link/protocol"
 	"github.com/splitio/splitd/splitio/link/serializer"
 	"github.com/splitio/splitd/splitio/link/transfer"
+	"github.com/splitio/splitd/splitio/sdk"
 	"github.com/splitio/splitd/splitio/sdk/mocks"
 	"github.com/stretchr/testify/assert"
 )
@@ -25,6 +28,7 @@ func TestOptions(t *testing.T) {
 		Transfer:      transfer.DefaultOpts(),
 		Consumer:      client.DefaultOptions(),
 		Serialization: serializer.MsgPack,
+		Pool:          pool.DefaultOptions(),
 	}, DefaultConsumerOptions())
 }
 
@@ -49,6 +53,13 @@ func TestListenErrors(t *testing.T) {
 	assert.Nil(t, acc)
 	assert.Nil(t, shutdown)
 	assert.ErrorContains(t, err, "serializer")
+
+	lo = DefaultListenerOptions()
+	lo.Environment = "staging"
+	acc, shutdown, err = Listen(logging.NewLogger(nil), &mocks.SDKMock{}, &lo)
+	assert.Nil(t, acc)
+	assert.Nil(t, shutdown)
+	assert.ErrorIs(t, err, sdk.ErrUnknownEnvironment)
 }
 
 func TestConsumerErrors(t *testing.T) {
@@ -65,3 +76,22 @@ func TestConsumerErrors(t *testing.T) {
 	assert.ErrorContains(t, err, "serializer")
 
 }
+
+func TestPooledConsumerUnreachableDaemon(t *testing.T) {
+	co := DefaultConsumerOptions()
+	co.Serialization = serializer.Mechanism(123)
+	c, err := PooledConsumer(logging.NewLogger(nil), &co)
+	assert.Nil(t, c)
+	assert.ErrorContains(t, err, "serializer")
+
+	co = DefaultConsumerOptions()
+	co.Transfer.Address = path.Join(t.TempDir(), "nonexistant.sock")
+	c, err = PooledConsumer(logging.NewLogger(nil), &co)
+	assert.Nil(t, err)
+	assert.NotNil(t, c)
+
+	res, err := c.Treatment("key1", "", "feat1", nil)
+	assert.ErrorIs(t, err, pool.ErrDaemonUnreachable)
+	assert.Equal(t, "control", res.Treatment)
+	assert.Nil(t, c.Shutdown())
+}
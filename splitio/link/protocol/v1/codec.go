@@ -0,0 +1,262 @@
+package v1
+
+import (
+	"github.com/splitio/splitd/splitio/link/protocol"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// Reset clears the rpc, so that it can be reused for decoding the next one
+func (r *RPC) Reset() {
+	*r = RPC{}
+}
+
+// DecodeMsgpack implements msgpack.CustomDecoder. Arguments of single-treatment rpcs (the hot path) are decoded
+// straight into a TreatmentArgs struct, skipping the generic []interface{} representation (and the type assertions
+// needed to populate it later). For every other opcode (or if the opcode is not known by the time the arguments are
+// read) they're decoded into Args, as usual
+func (r *RPC) DecodeMsgpack(dec *msgpack.Decoder) error {
+	fields, err := dec.DecodeMapLen()
+	if err != nil {
+		return err
+	}
+
+	for idx := 0; idx < fields; idx++ {
+		keyLen, err := dec.DecodeBytesLen()
+		if err != nil {
+			return err
+		}
+
+		if keyLen != 1 { // all known keys are 1 byte long
+			if err = skipBytes(dec, keyLen); err != nil {
+				return err
+			}
+			if err = dec.Skip(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err = dec.ReadFull(r.key[:]); err != nil {
+			return err
+		}
+
+		switch r.key[0] {
+		case 'v':
+			version, err := dec.DecodeUint8()
+			if err != nil {
+				return err
+			}
+			r.Version = protocol.Version(version)
+		case 'o':
+			opcode, err := dec.DecodeUint8()
+			if err != nil {
+				return err
+			}
+			r.OpCode = OpCode(opcode)
+		case 'a':
+			if r.OpCode == OCTreatment || r.OpCode == OCTreatmentWithConfig {
+				err = r.decodeTreatmentArgs(dec)
+			} else {
+				r.Args, err = dec.DecodeSlice()
+			}
+			if err != nil {
+				return err
+			}
+		default:
+			if err = dec.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeTreatmentArgs reads the argument list of a treatment rpc into a typed struct. Malformed data is returned,
+// while arguments of unexpected types are skipped & reported later on by PopulateFromRPC (as with generic decoding)
+func (r *RPC) decodeTreatmentArgs(dec *msgpack.Decoder) error {
+	r.typedArgs, r.treatmentArgs = true, TreatmentArgs{}
+	t := &r.treatmentArgs
+
+	count, err := dec.DecodeArrayLen()
+	if err != nil {
+		return err
+	}
+
+	var argsErr error
+	if count < 4 {
+		argsErr = RPCParseError{Code: PECWrongArgCount}
+	}
+
+	for idx := 0; idx < count; idx++ {
+		if argsErr != nil || idx > TreatmentArgImpressionPropertiesIdx {
+			if err = dec.Skip(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		code, err := dec.PeekCode()
+		if err != nil {
+			return err
+		}
+
+		var valid bool
+		switch idx {
+		case TreatmentArgKeyIdx:
+			if valid = msgpcode.IsString(code); valid {
+				t.Key, err = dec.DecodeString()
+			}
+		case TreatmentArgBucketingKeyIdx:
+			if code == msgpcode.Nil {
+				valid, err = true, dec.DecodeNil()
+			} else if valid = msgpcode.IsString(code); valid {
+				var bk string
+				bk, err = dec.DecodeString()
+				t.BucketingKey = &bk
+			}
+		case TreatmentArgFeatureIdx:
+			if valid = msgpcode.IsString(code); valid {
+				t.Feature, err = dec.DecodeString()
+			}
+		case TreatmentArgAttributesIdx:
+			if valid = code == msgpcode.Nil || isMap(code); valid {
+				t.Attributes, err = dec.DecodeMap()
+				t.Attributes = sanitizeAttributes(t.Attributes)
+			}
+		case TreatmentArgImpressionPropertiesIdx:
+			if valid = code == msgpcode.Nil || isMap(code); valid {
+				t.ImpressionProperties, err = dec.DecodeMap()
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !valid {
+			argsErr = RPCParseError{Code: PECInvalidArgType, Data: int64(idx)}
+			if err = dec.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+
+	r.treatmentArgsErr = argsErr
+	return nil
+}
+
+func isMap(code byte) bool {
+	return msgpcode.IsFixedMap(code) || code == msgpcode.Map16 || code == msgpcode.Map32
+}
+
+func skipBytes(dec *msgpack.Decoder, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	return dec.ReadFull(make([]byte, n))
+}
+
+// EncodeMsgpack implements msgpack.CustomEncoder, producing the same output as reflection-based encoding
+// (struct payloads are never omitted) without the allocations needed to figure out which fields are empty.
+// Value receivers are used so that payloads stored in maps (non-addressable) can still be encoded
+func (r ResponseWrapper[T]) EncodeMsgpack(enc *msgpack.Encoder) error {
+	fields := 2
+	if r.Reconnect {
+		fields++
+	}
+
+	if err := enc.EncodeMapLen(fields); err != nil {
+		return err
+	}
+	if err := encodeKey(enc, "s"); err != nil {
+		return err
+	}
+	if err := enc.EncodeUint8(uint8(r.Status)); err != nil {
+		return err
+	}
+	if err := encodeKey(enc, "p"); err != nil {
+		return err
+	}
+	if err := enc.Encode(&r.Payload); err != nil {
+		return err
+	}
+	if r.Reconnect {
+		if err := encodeKey(enc, "r"); err != nil {
+			return err
+		}
+		return enc.EncodeBool(true)
+	}
+	return nil
+}
+
+// EncodeMsgpack implements msgpack.CustomEncoder. Single treatment responses are on the hot path, so the payload is
+// encoded by hand (keeping field order & omitempty semantics) instead of relying on reflection
+func (t TreatmentPayload) EncodeMsgpack(enc *msgpack.Encoder) error {
+	fields := 1
+	for _, present := range [...]bool{t.Config != nil, t.ListenerData != nil, t.Stale, t.Label != "", len(t.Warnings) > 0} {
+		if present {
+			fields++
+		}
+	}
+
+	if err := enc.EncodeMapLen(fields); err != nil {
+		return err
+	}
+	if err := encodeKey(enc, "t"); err != nil {
+		return err
+	}
+	if err := enc.EncodeString(t.Treatment); err != nil {
+		return err
+	}
+	if t.Config != nil {
+		if err := encodeKey(enc, "c"); err != nil {
+			return err
+		}
+		if err := enc.EncodeString(*t.Config); err != nil {
+			return err
+		}
+	}
+	if t.ListenerData != nil {
+		if err := encodeKey(enc, "l"); err != nil {
+			return err
+		}
+		if err := enc.Encode(t.ListenerData); err != nil {
+			return err
+		}
+	}
+	if t.Stale {
+		if err := encodeKey(enc, "x"); err != nil {
+			return err
+		}
+		if err := enc.EncodeBool(true); err != nil {
+			return err
+		}
+	}
+	if t.Label != "" {
+		if err := encodeKey(enc, "a"); err != nil {
+			return err
+		}
+		if err := enc.EncodeString(t.Label); err != nil {
+			return err
+		}
+	}
+	if len(t.Warnings) > 0 {
+		if err := encodeKey(enc, "w"); err != nil {
+			return err
+		}
+		if err := enc.Encode(t.Warnings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeKey(enc *msgpack.Encoder, key string) error {
+	return enc.EncodeString(key)
+}
+
+var _ msgpack.CustomDecoder = (*RPC)(nil)
+var _ msgpack.CustomEncoder = ResponseWrapper[TreatmentPayload]{}
+var _ msgpack.CustomEncoder = TreatmentPayload{}
@@ -0,0 +1,186 @@
+package v1
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/splitio/splitd/splitio/common/lang"
+	"github.com/splitio/splitd/splitio/link/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// plainRPC has the same layout as RPC but no custom decoder, so it's decoded via reflection
+type plainRPC RPC
+
+func TestTreatmentArgsTypedDecoding(t *testing.T) {
+	argLists := [][]interface{}{
+		{"key", "bk", "feat1", map[string]interface{}{"a": 1, "b": []interface{}{"x", 2}}},
+		{"key", nil, "feat1", nil},
+		{"key", "", "feat1", map[string]interface{}{}},
+		{"key", "bk", "feat1", nil, map[string]interface{}{"p": "v"}},
+		{"key", "bk", "feat1", nil, nil, "extra"},
+		{},
+		{"key", "bk", "feat1"},
+		{nil, nil, nil, nil},
+		{"key", 123, nil, nil},
+		{"key", "bk", nil, nil},
+		{"key", "bk", "feat1", 123},
+		{"key", "bk", "feat1", []interface{}{1}},
+		{"key", "bk", "feat1", nil, 123},
+	}
+
+	for _, opcode := range []OpCode{OCTreatment, OCTreatmentWithConfig} {
+		for _, args := range argLists {
+			raw, err := msgpack.Marshal(RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: opcode, Args: args})
+			assert.Nil(t, err)
+
+			var generic plainRPC
+			assert.Nil(t, msgpack.Unmarshal(raw, &generic))
+			var expected TreatmentArgs
+			expectedErr := expected.PopulateFromRPC((*RPC)(&generic))
+
+			var typed RPC
+			assert.Nil(t, msgpack.Unmarshal(raw, &typed))
+			assert.True(t, typed.typedArgs)
+			assert.Nil(t, typed.Args)
+			assert.Equal(t, protocol.V1, typed.Version)
+			assert.Equal(t, opcode, typed.OpCode)
+
+			var actual TreatmentArgs
+			actualErr := actual.PopulateFromRPC(&typed)
+			assert.Equal(t, expectedErr, actualErr, "args: %v", args)
+			if expectedErr == nil {
+				assert.Equal(t, expected, actual, "args: %v", args)
+			}
+		}
+	}
+}
+
+func TestRPCDecodingGenericFallback(t *testing.T) {
+	// arguments of other rpcs are decoded into Args
+	raw, err := msgpack.Marshal(RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCTreatments, Args: []interface{}{"key", nil, []string{"f1"}, nil}})
+	assert.Nil(t, err)
+	var rpc RPC
+	assert.Nil(t, msgpack.Unmarshal(raw, &rpc))
+	assert.False(t, rpc.typedArgs)
+	assert.Equal(t, []interface{}{"key", nil, []interface{}{"f1"}, nil}, rpc.Args)
+
+	// arguments before the opcode and unknown keys (keys are sorted so that "a" is always encoded before "o")
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetSortMapKeys(true)
+	err = enc.Encode(map[string]interface{}{
+		"a":       []interface{}{"key", "bk", "feat1", nil},
+		"unknown": map[string]interface{}{"x": 1},
+		"o":       OCTreatment,
+		"v":       protocol.V1,
+		"z":       "ignored",
+	})
+	assert.Nil(t, err)
+	raw = buf.Bytes()
+	rpc.Reset()
+	assert.Nil(t, msgpack.Unmarshal(raw, &rpc))
+	assert.False(t, rpc.typedArgs)
+	assert.Equal(t, OCTreatment, rpc.OpCode)
+	assert.Equal(t, protocol.V1, rpc.Version)
+
+	var args TreatmentArgs
+	assert.Nil(t, args.PopulateFromRPC(&rpc))
+	assert.Equal(t, TreatmentArgs{Key: "key", BucketingKey: lang.Ref("bk"), Feature: "feat1"}, args)
+
+	// malformed input
+	assert.NotNil(t, msgpack.Unmarshal([]byte{0x81, 0xa1, 'o'}, &rpc))
+	assert.NotNil(t, msgpack.Unmarshal([]byte{0x82, 0xa1, 'o', 0x11, 0xa1, 'a', 0x94, 0xa3, 'k'}, &rpc))
+}
+
+// plainTreatmentPayload & plainResponse mirror the response types without the custom encoders
+type plainTreatmentPayload TreatmentPayload
+
+type plainResponse struct {
+	Status    Result                `msgpack:"s"`
+	Payload   plainTreatmentPayload `msgpack:"p,omitempty"`
+	Reconnect bool                  `msgpack:"r,omitempty"`
+}
+
+func TestTreatmentResponseEncoding(t *testing.T) {
+	payloads := []TreatmentPayload{
+		{},
+		{Treatment: "on"},
+		{Treatment: "on", Config: lang.Ref(`{"a": 1}`), Label: "some label"},
+		{Treatment: "off", ListenerData: &ListenerExtraData{Label: "l1", Timestamp: 123, ChangeNumber: 456}, Stale: true},
+		{Treatment: "control", Warnings: []ValidationWarning{{Code: 1, Message: "trimmed"}}},
+		{Treatment: "on", Warnings: []ValidationWarning{}},
+	}
+
+	for _, payload := range payloads {
+		for _, reconnect := range []bool{false, true} {
+			response := ResponseWrapper[TreatmentPayload]{Status: ResultOk, Payload: payload, Reconnect: reconnect}
+			expected, err := msgpack.Marshal(plainResponse{Status: ResultOk, Payload: plainTreatmentPayload(payload), Reconnect: reconnect})
+			assert.Nil(t, err)
+
+			encoded, err := msgpack.Marshal(&response)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, encoded)
+
+			var decoded ResponseWrapper[TreatmentPayload]
+			assert.Nil(t, msgpack.Unmarshal(encoded, &decoded))
+			assert.Equal(t, reconnect, decoded.Reconnect)
+			assert.Equal(t, payload.Treatment, decoded.Payload.Treatment)
+		}
+	}
+
+	// payloads nested in maps are not addressable
+	response := ResponseWrapper[TreatmentsWithFeaturePayload]{
+		Status:  ResultOk,
+		Payload: TreatmentsWithFeaturePayload{Results: map[string]TreatmentPayload{"f1": {Treatment: "on", Label: "l"}}},
+	}
+	encoded, err := msgpack.Marshal(response)
+	assert.Nil(t, err)
+	var decoded ResponseWrapper[TreatmentsWithFeaturePayload]
+	assert.Nil(t, msgpack.Unmarshal(encoded, &decoded))
+	assert.Equal(t, response, decoded)
+}
+
+func benchmarkTreatmentRPCDecoding(b *testing.B, decode func([]byte) error) {
+	raw, err := msgpack.Marshal(RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  OCTreatment,
+		Args:    TreatmentArgs{Key: "key", BucketingKey: lang.Ref("bk"), Feature: "feat1", Attributes: map[string]interface{}{"a": 1}}.Encode(),
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := decode(raw); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTreatmentRPCDecodingGeneric(b *testing.B) {
+	var rpc plainRPC
+	var args TreatmentArgs
+	benchmarkTreatmentRPCDecoding(b, func(raw []byte) error {
+		rpc = plainRPC{}
+		if err := msgpack.Unmarshal(raw, &rpc); err != nil {
+			return err
+		}
+		return args.PopulateFromRPC((*RPC)(&rpc))
+	})
+}
+
+func BenchmarkTreatmentRPCDecodingTyped(b *testing.B) {
+	var rpc RPC
+	var args TreatmentArgs
+	benchmarkTreatmentRPCDecoding(b, func(raw []byte) error {
+		rpc.Reset()
+		if err := msgpack.Unmarshal(raw, &rpc); err != nil {
+			return err
+		}
+		return args.PopulateFromRPC(&rpc)
+	})
+}
@@ -7,9 +7,10 @@ import (
 type RPCParseErrorCode int
 
 const (
-	PECOpCodeMismatch = 1
-	PECWrongArgCount  = 2
-	PECInvalidArgType = 3
+	PECOpCodeMismatch  = 1
+	PECWrongArgCount   = 2
+	PECInvalidArgType  = 3
+	PECInvalidArgValue = 4
 )
 
 func (c RPCParseErrorCode) formatWithData(data int64) string {
@@ -20,6 +21,8 @@ func (c RPCParseErrorCode) formatWithData(data int64) string {
 		return "wrong number of arguments for current opcode"
 	case PECInvalidArgType:
 		return "wrong argument type at index " + strconv.Itoa(int(data))
+	case PECInvalidArgValue:
+		return "invalid argument value at index " + strconv.Itoa(int(data))
 	default:
 		return "unknown error"
 	}
@@ -17,6 +17,9 @@ func TestErrors(t *testing.T) {
 	e3 := RPCParseError{Code: PECInvalidArgType, Data: 2}
 	assert.Equal(t, "wrong argument type at index 2", e3.Error())
 
+	e5 := RPCParseError{Code: PECInvalidArgValue, Data: 2}
+	assert.Equal(t, "invalid argument value at index 2", e5.Error())
+
     e4 := RPCParseError{Code: RPCParseErrorCode(777)}
     assert.Equal(t, "unknown error", e4.Error())
 }
@@ -3,6 +3,8 @@ package mocks
 import (
 	"fmt"
 
+	"github.com/splitio/go-split-commons/v9/dtos"
+
 	"github.com/splitio/splitd/splitio"
 	"github.com/splitio/splitd/splitio/common/lang"
 	"github.com/splitio/splitd/splitio/link/protocol"
@@ -22,6 +24,14 @@ func NewRegisterRPC(id string, listener bool) *v1.RPC {
 	}
 }
 
+func NewReadyRPC(timeoutMS int64) *v1.RPC {
+	return &v1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  v1.OCReady,
+		Args:    []interface{}{timeoutMS},
+	}
+}
+
 func NewTreatmentRPC(key string, bucketing string, feature string, attrs map[string]interface{}, impressionProperties map[string]interface{}, withConfig bool) *v1.RPC {
 	rpc := &v1.RPC{
 		RPCBase: protocol.RPCBase{Version: protocol.V1},
@@ -55,6 +65,18 @@ func NewTrackRPC(key string, trafficType string, eventType string, eventVal *flo
 	}
 }
 
+func NewBindAttributesRPC(attributes map[string]interface{}) *v1.RPC {
+	return &v1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  v1.OCBindAttributes,
+		Args:    []interface{}{attributes},
+	}
+}
+
+func NewPingRPC() *v1.RPC {
+	return &v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCPing}
+}
+
 func NewSplitNamesRPC() *v1.RPC {
 	return &v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCSplitNames}
 }
@@ -71,6 +93,46 @@ func NewSplitRPC(name string) *v1.RPC {
 	}
 }
 
+func NewSplitDefinitionRPC(name string) *v1.RPC {
+	return &v1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  v1.OCSplitDefinition,
+		Args:    []interface{}{name},
+	}
+}
+
+func NewSegmentNamesRPC() *v1.RPC {
+	return &v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCSegmentNames}
+}
+
+func NewSegmentRPC(name string) *v1.RPC {
+	return &v1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  v1.OCSegment,
+		Args:    []interface{}{name},
+	}
+}
+
+func NewSegmentContainsKeyRPC(name string, key string) *v1.RPC {
+	return &v1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  v1.OCSegmentContainsKey,
+		Args:    []interface{}{name, key},
+	}
+}
+
+func NewRuleBasedSegmentNamesRPC() *v1.RPC {
+	return &v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCRuleBasedSegmentNames}
+}
+
+func NewRuleBasedSegmentRPC(name string) *v1.RPC {
+	return &v1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  v1.OCRuleBasedSegment,
+		Args:    []interface{}{name},
+	}
+}
+
 func NewRegisterResp(ok bool) *v1.ResponseWrapper[v1.RegisterPayload] {
 	res := v1.ResultOk
 	if !ok {
@@ -82,6 +144,17 @@ func NewRegisterResp(ok bool) *v1.ResponseWrapper[v1.RegisterPayload] {
 	}
 }
 
+func NewReadyResp(ok bool, ready bool, state byte) *v1.ResponseWrapper[v1.ReadyPayload] {
+	res := v1.ResultOk
+	if !ok {
+		res = v1.ResultInternalError
+	}
+	return &v1.ResponseWrapper[v1.ReadyPayload]{
+		Status:  res,
+		Payload: v1.ReadyPayload{Ready: ready, State: state},
+	}
+}
+
 func NewTreatmentResp(ok bool, treatment string, ilData *v1.ListenerExtraData) *v1.ResponseWrapper[v1.TreatmentPayload] {
 	res := v1.ResultOk
 	if !ok {
@@ -178,6 +251,25 @@ func NewTrackResp(ok bool) *v1.ResponseWrapper[v1.TrackPayload] {
 	}
 }
 
+func NewBindAttributesResp(ok bool, bound int) *v1.ResponseWrapper[v1.BindAttributesPayload] {
+	res := v1.ResultOk
+	if !ok {
+		res = v1.ResultInternalError
+	}
+	return &v1.ResponseWrapper[v1.BindAttributesPayload]{
+		Status:  res,
+		Payload: v1.BindAttributesPayload{Bound: bound},
+	}
+}
+
+func NewPingResp(ok bool) *v1.ResponseWrapper[v1.EmptyPayload] {
+	res := v1.ResultOk
+	if !ok {
+		res = v1.ResultInternalError
+	}
+	return &v1.ResponseWrapper[v1.EmptyPayload]{Status: res}
+}
+
 func NewSplitNamesResp(ok bool, names []string) *v1.ResponseWrapper[v1.SplitNamesPayload] {
 	res := v1.ResultOk
 	if !ok {
@@ -208,6 +300,57 @@ func NewSplitsResp(ok bool, splits []v1.SplitPayload) *v1.ResponseWrapper[v1.Spl
 	}
 }
 
+func NewSplitDefinitionResp(ok bool, def *dtos.SplitDTO) *v1.ResponseWrapper[v1.SplitDefinitionPayload] {
+	res := v1.ResultOk
+	if !ok {
+		res = v1.ResultInternalError
+	}
+	return &v1.ResponseWrapper[v1.SplitDefinitionPayload]{Status: res, Payload: v1.SplitDefinitionPayload{Definition: def}}
+}
+
+func NewSegmentNamesResp(ok bool, names []string) *v1.ResponseWrapper[v1.SegmentNamesPayload] {
+	res := v1.ResultOk
+	if !ok {
+		res = v1.ResultInternalError
+	}
+	return &v1.ResponseWrapper[v1.SegmentNamesPayload]{Status: res, Payload: v1.SegmentNamesPayload{Names: names}}
+}
+
+func NewSegmentResp(ok bool, segment v1.SegmentPayload) *v1.ResponseWrapper[v1.SegmentPayload] {
+	res := v1.ResultOk
+	if !ok {
+		res = v1.ResultInternalError
+	}
+	return &v1.ResponseWrapper[v1.SegmentPayload]{Status: res, Payload: segment}
+}
+
+func NewSegmentContainsKeyResp(ok bool, exists bool, contains bool) *v1.ResponseWrapper[v1.SegmentContainsKeyPayload] {
+	res := v1.ResultOk
+	if !ok {
+		res = v1.ResultInternalError
+	}
+	return &v1.ResponseWrapper[v1.SegmentContainsKeyPayload]{
+		Status:  res,
+		Payload: v1.SegmentContainsKeyPayload{Exists: exists, Contains: contains},
+	}
+}
+
+func NewRuleBasedSegmentNamesResp(ok bool, names []string) *v1.ResponseWrapper[v1.RuleBasedSegmentNamesPayload] {
+	res := v1.ResultOk
+	if !ok {
+		res = v1.ResultInternalError
+	}
+	return &v1.ResponseWrapper[v1.RuleBasedSegmentNamesPayload]{Status: res, Payload: v1.RuleBasedSegmentNamesPayload{Names: names}}
+}
+
+func NewRuleBasedSegmentResp(ok bool, def *dtos.RuleBasedSegmentDTO) *v1.ResponseWrapper[v1.RuleBasedSegmentPayload] {
+	res := v1.ResultOk
+	if !ok {
+		res = v1.ResultInternalError
+	}
+	return &v1.ResponseWrapper[v1.RuleBasedSegmentPayload]{Status: res, Payload: v1.RuleBasedSegmentPayload{Definition: def}}
+}
+
 func nilOrVal(v *float64) interface{} {
 	if v == nil {
 		return nil
@@ -1,27 +1,67 @@
 package v1
 
+import "github.com/splitio/go-split-commons/v9/dtos"
+
 type Result byte
 
 const (
 	ResultOk            Result = 0x01
 	ResultInternalError Result = 0x10
+
+	// ResultUnknownEnvironment is returned on registration when the requested environment is not served by the daemon
+	ResultUnknownEnvironment Result = 0x11
+
+	// ResultUnauthorized is returned when the client failed to authenticate upon registration, or its token's scope
+	// doesn't allow the requested operation
+	ResultUnauthorized Result = 0x12
 )
 
 type ResponseWrapper[T validPayloadsConstraint] struct {
 	Status  Result `msgpack:"s"`
 	Payload T      `msgpack:"p,omitempty"`
+
+	// Reconnect is set when the daemon closes the connection right after sending this response (ie: max lifetime
+	// reached). The response itself is valid, but further rpcs must be sent through a new connection
+	Reconnect bool `msgpack:"r,omitempty"`
+}
+
+// RequestReconnect flags the response as the last one sent through the connection
+func (r *ResponseWrapper[T]) RequestReconnect() {
+	r.Reconnect = true
+}
+
+// ReconnectRequested returns true if the connection was closed after sending this response
+func (r *ResponseWrapper[T]) ReconnectRequested() bool {
+	return r.Reconnect
 }
 
-type RegisterPayload struct{}
+type RegisterPayload struct {
+	// Ready is only populated when the client registers with RegisterFlagBlockUntilReady
+	Ready bool `msgpack:"r,omitempty"`
+}
+
+type ReadyPayload struct {
+	Ready         bool `msgpack:"r"`
+	State         byte `msgpack:"s"` // 0: not ready, 1: ready, 2: ready with streaming degraded to polling
+	StaleSplits   bool `msgpack:"p,omitempty"`
+	StaleSegments bool `msgpack:"g,omitempty"`
+}
+
+type BindAttributesPayload struct {
+	Bound int `msgpack:"b"` // number of attributes bound to the connection
+}
 
 type TreatmentsWithFeaturePayload struct {
 	Results map[string]TreatmentPayload `msgpack:"r"`
 }
 
 type TreatmentPayload struct {
-	Treatment    string             `msgpack:"t"`
-	Config       *string            `msgpack:"c,omitempty"`
-	ListenerData *ListenerExtraData `msgpack:"l,omitempty"`
+	Treatment    string              `msgpack:"t"`
+	Config       *string             `msgpack:"c,omitempty"`
+	ListenerData *ListenerExtraData  `msgpack:"l,omitempty"`
+	Stale        bool                `msgpack:"x,omitempty"`
+	Label        string              `msgpack:"a,omitempty"`
+	Warnings     []ValidationWarning `msgpack:"w,omitempty"`
 }
 
 type TreatmentsPayload struct {
@@ -29,7 +69,15 @@ type TreatmentsPayload struct {
 }
 
 type TrackPayload struct {
-	Success bool `msgpack:"s"`
+	Success  bool                `msgpack:"s"`
+	Warnings []ValidationWarning `msgpack:"w,omitempty"`
+	Error    string              `msgpack:"e,omitempty"` // only set when the event was rejected for failing validation
+}
+
+// ValidationWarning describes a non-fatal issue found in the arguments of a call
+type ValidationWarning struct {
+	Code    uint8  `msgpack:"c"`
+	Message string `msgpack:"m"`
 }
 
 type SplitNamesPayload struct {
@@ -52,12 +100,42 @@ type SplitsPayload struct {
 	Splits []SplitPayload `msgpack:"s"`
 }
 
+type SplitDefinitionPayload struct {
+	Definition *dtos.SplitDTO `msgpack:"d,omitempty"`
+}
+
+type SegmentNamesPayload struct {
+	Names []string `msgpack:"n"`
+}
+
+type SegmentPayload struct {
+	Name         string `msgpack:"n"`
+	KeyCount     int64  `msgpack:"k"`
+	ChangeNumber int64  `msgpack:"c"`
+}
+
+type SegmentContainsKeyPayload struct {
+	Exists   bool `msgpack:"e"`
+	Contains bool `msgpack:"c"`
+}
+
+type RuleBasedSegmentNamesPayload struct {
+	Names []string `msgpack:"n"`
+}
+
+type RuleBasedSegmentPayload struct {
+	Definition *dtos.RuleBasedSegmentDTO `msgpack:"d,omitempty"`
+}
+
 type ListenerExtraData struct {
 	Label        string `msgpack:"l"`
 	Timestamp    int64  `msgpack:"m"`
 	ChangeNumber int64  `msgpack:"c"`
 }
 
+// EmptyPayload is used for responses carrying no data (ie: errors returned regardless of the operation requested)
+type EmptyPayload struct{}
+
 type validPayloadsConstraint interface {
 	TreatmentPayload |
 		TreatmentsPayload |
@@ -66,5 +144,14 @@ type validPayloadsConstraint interface {
 		SplitPayload |
 		SplitsPayload |
 		RegisterPayload |
-		TreatmentsWithFeaturePayload
+		ReadyPayload |
+		BindAttributesPayload |
+		TreatmentsWithFeaturePayload |
+		SplitDefinitionPayload |
+		SegmentNamesPayload |
+		SegmentPayload |
+		SegmentContainsKeyPayload |
+		RuleBasedSegmentNamesPayload |
+		RuleBasedSegmentPayload |
+		EmptyPayload
 }
@@ -2,8 +2,11 @@ package v1
 
 import (
 	"errors"
+	"math/bits"
 	"time"
 
+	"github.com/splitio/go-split-commons/v9/conf"
+	"github.com/splitio/splitd/splitio/common/lang"
 	"github.com/splitio/splitd/splitio/link/protocol"
 )
 
@@ -13,6 +16,13 @@ const (
 	// Registration & login
 	OCRegister OpCode = 0x00
 
+	// Status ops
+	OCReady OpCode = 0x01
+
+	// Session ops
+	OCBindAttributes OpCode = 0x02
+	OCPing           OpCode = 0x03
+
 	// Treatment-related ops
 	OCTreatment                      OpCode = 0x11
 	OCTreatments                     OpCode = 0x12
@@ -29,12 +39,26 @@ const (
 	OCSplitNames OpCode = 0xA0
 	OCSplit      OpCode = 0xA1
 	OCSplits     OpCode = 0xA2
+
+	// Inspection ops (full definitions & segment contents)
+	OCSplitDefinition       OpCode = 0xA3
+	OCSegmentNames          OpCode = 0xA4
+	OCSegment               OpCode = 0xA5
+	OCSegmentContainsKey    OpCode = 0xA6
+	OCRuleBasedSegmentNames OpCode = 0xA7
+	OCRuleBasedSegment      OpCode = 0xA8
 )
 
 func (o OpCode) String() string {
 	switch o {
 	case OCRegister:
 		return "register"
+	case OCReady:
+		return "ready"
+	case OCBindAttributes:
+		return "bind-attributes"
+	case OCPing:
+		return "ping"
 	case OCTreatment:
 		return "treatment"
 	case OCTreatments:
@@ -59,6 +83,18 @@ func (o OpCode) String() string {
 		return "split"
 	case OCSplits:
 		return "splits"
+	case OCSplitDefinition:
+		return "split-definition"
+	case OCSegmentNames:
+		return "segment-names"
+	case OCSegment:
+		return "segment"
+	case OCSegmentContainsKey:
+		return "segment-contains-key"
+	case OCRuleBasedSegmentNames:
+		return "rule-based-segment-names"
+	case OCRuleBasedSegment:
+		return "rule-based-segment"
 	default:
 		return "unknown"
 	}
@@ -68,6 +104,12 @@ type RPC struct {
 	protocol.RPCBase
 	OpCode OpCode        `msgpack:"o"`
 	Args   []interface{} `msgpack:"a"`
+
+	// set when the arguments are decoded straight into a typed struct instead of Args (see DecodeMsgpack)
+	typedArgs        bool
+	treatmentArgs    TreatmentArgs
+	treatmentArgsErr error
+	key              [1]byte // scratch space for decoding field names
 }
 
 type Arguments interface {
@@ -76,24 +118,95 @@ type Arguments interface {
 }
 
 type RegisterArgs struct {
-	ID         string        `msgpack:"i"`
-	SDKVersion string        `msgpack:"s"`
-	Flags      RegisterFlags `msgpack:"f"`
+	ID             string        `msgpack:"i"`
+	SDKVersion     string        `msgpack:"s"`
+	Flags          RegisterFlags `msgpack:"f"`
+	ReadyTimeoutMS int64         `msgpack:"t"`
+	Environment    string        `msgpack:"e"`
+	Token          string        `msgpack:"k"`
 }
 
 const (
-	RegisterArgIDIdx         = 0
-	RegisterArgSDKVersionIdx = 1
-	RegisterArgFlagsIdx      = 2
+	RegisterArgIDIdx             = 0
+	RegisterArgSDKVersionIdx     = 1
+	RegisterArgFlagsIdx          = 2
+	RegisterArgReadyTimeoutMSIdx = 3 // optional, only used if RegisterFlagBlockUntilReady is set
+	RegisterArgEnvironmentIdx    = 4 // optional, the listener's default environment is used if not present or empty
+	RegisterArgTokenIdx          = 5 // optional, only required if the daemon has authentication enabled
 )
 
 type RegisterFlags uint64
 
 const (
 	RegisterFlagReturnImpressionData RegisterFlags = (1 << 0)
+
+	// (1 << 1) is reserved: clients ask for impression data as `1 << RegisterFlagReturnImpressionData`, so it must
+	// not be given any other meaning
+
+	// labels & impressions mode overrides. at most one of each group can be set.
+	// they're only honored if the daemon's config allows clients to override them
+	RegisterFlagLabelsEnabled            RegisterFlags = (1 << 2)
+	RegisterFlagLabelsDisabled           RegisterFlags = (1 << 3)
+	RegisterFlagImpressionsModeDebug     RegisterFlags = (1 << 4)
+	RegisterFlagImpressionsModeOptimized RegisterFlags = (1 << 5)
+	RegisterFlagImpressionsModeNone      RegisterFlags = (1 << 6)
+
+	RegisterFlagBlockUntilReady RegisterFlags = (1 << 7)
+
+	registerFlagsLabels          = RegisterFlagLabelsEnabled | RegisterFlagLabelsDisabled
+	registerFlagsImpressionsMode = RegisterFlagImpressionsModeDebug | RegisterFlagImpressionsModeOptimized | RegisterFlagImpressionsModeNone
 )
 
+// ImpressionsMode returns the impressions mode requested by the client, or an empty string if none
+func (r *RegisterArgs) ImpressionsMode() string {
+	switch r.Flags & registerFlagsImpressionsMode {
+	case RegisterFlagImpressionsModeDebug:
+		return conf.ImpressionsModeDebug
+	case RegisterFlagImpressionsModeOptimized:
+		return conf.ImpressionsModeOptimized
+	case RegisterFlagImpressionsModeNone:
+		return conf.ImpressionsModeNone
+	default:
+		return ""
+	}
+}
+
+// LabelsEnabled returns whether the client wants labels in its impressions, or nil if it has no preference
+func (r *RegisterArgs) LabelsEnabled() *bool {
+	switch r.Flags & registerFlagsLabels {
+	case RegisterFlagLabelsEnabled:
+		return lang.Ref(true)
+	case RegisterFlagLabelsDisabled:
+		return lang.Ref(false)
+	default:
+		return nil
+	}
+}
+
+// ImpressionsModeFlag returns the flag used to request impressions mode `mode`
+func ImpressionsModeFlag(mode string) (RegisterFlags, bool) {
+	switch mode {
+	case conf.ImpressionsModeDebug:
+		return RegisterFlagImpressionsModeDebug, true
+	case conf.ImpressionsModeOptimized:
+		return RegisterFlagImpressionsModeOptimized, true
+	case conf.ImpressionsModeNone:
+		return RegisterFlagImpressionsModeNone, true
+	default:
+		return 0, false
+	}
+}
+
 func (r RegisterArgs) Encode() []interface{} {
+	if r.Token != "" {
+		return []interface{}{r.ID, r.SDKVersion, r.Flags, r.ReadyTimeoutMS, r.Environment, r.Token}
+	}
+	if r.Environment != "" {
+		return []interface{}{r.ID, r.SDKVersion, r.Flags, r.ReadyTimeoutMS, r.Environment}
+	}
+	if r.Flags&RegisterFlagBlockUntilReady != 0 {
+		return []interface{}{r.ID, r.SDKVersion, r.Flags, r.ReadyTimeoutMS}
+	}
 	return []interface{}{r.ID, r.SDKVersion, r.Flags}
 }
 
@@ -102,7 +215,7 @@ func (r *RegisterArgs) PopulateFromRPC(rpc *RPC) error {
 		return RPCParseError{Code: PECOpCodeMismatch}
 	}
 
-	if len(rpc.Args) != 3 {
+	if len(rpc.Args) < 3 || len(rpc.Args) > 6 {
 		return RPCParseError{Code: PECWrongArgCount}
 	}
 
@@ -119,6 +232,57 @@ func (r *RegisterArgs) PopulateFromRPC(rpc *RPC) error {
 		return RPCParseError{Code: PECInvalidArgType, Data: int64(RegisterArgFlagsIdx)}
 	}
 
+	if bits.OnesCount64(uint64(r.Flags&registerFlagsLabels)) > 1 || bits.OnesCount64(uint64(r.Flags&registerFlagsImpressionsMode)) > 1 {
+		return RPCParseError{Code: PECInvalidArgValue, Data: int64(RegisterArgFlagsIdx)}
+	}
+
+	if len(rpc.Args) > RegisterArgReadyTimeoutMSIdx {
+		if r.ReadyTimeoutMS, ok = tryInt[int64](rpc.Args[RegisterArgReadyTimeoutMSIdx]); !ok {
+			return RPCParseError{Code: PECInvalidArgType, Data: int64(RegisterArgReadyTimeoutMSIdx)}
+		}
+	}
+
+	if len(rpc.Args) > RegisterArgEnvironmentIdx {
+		if r.Environment, ok = rpc.Args[RegisterArgEnvironmentIdx].(string); !ok {
+			return RPCParseError{Code: PECInvalidArgType, Data: int64(RegisterArgEnvironmentIdx)}
+		}
+	}
+
+	if len(rpc.Args) > RegisterArgTokenIdx {
+		if r.Token, ok = rpc.Args[RegisterArgTokenIdx].(string); !ok {
+			return RPCParseError{Code: PECInvalidArgType, Data: int64(RegisterArgTokenIdx)}
+		}
+	}
+
+	return nil
+}
+
+const (
+	ReadyArgTimeoutMSIdx int = 0
+)
+
+type ReadyArgs struct {
+	TimeoutMS int64 `msgpack:"t"`
+}
+
+func (r ReadyArgs) Encode() []interface{} {
+	return []interface{}{r.TimeoutMS}
+}
+
+func (r *ReadyArgs) PopulateFromRPC(rpc *RPC) error {
+	if rpc.OpCode != OCReady {
+		return RPCParseError{Code: PECOpCodeMismatch}
+	}
+
+	if len(rpc.Args) != 1 {
+		return RPCParseError{Code: PECWrongArgCount}
+	}
+
+	var ok bool
+	if r.TimeoutMS, ok = tryInt[int64](rpc.Args[ReadyArgTimeoutMSIdx]); !ok {
+		return RPCParseError{Code: PECInvalidArgType, Data: int64(ReadyArgTimeoutMSIdx)}
+	}
+
 	return nil
 }
 
@@ -150,6 +314,10 @@ func (t *TreatmentArgs) PopulateFromRPC(rpc *RPC) error {
 	if rpc.OpCode != OCTreatment && rpc.OpCode != OCTreatmentWithConfig {
 		return RPCParseError{Code: PECOpCodeMismatch}
 	}
+	if rpc.typedArgs {
+		*t = rpc.treatmentArgs
+		return rpc.treatmentArgsErr
+	}
 	if len(rpc.Args) < 4 {
 		return RPCParseError{Code: PECWrongArgCount}
 	}
@@ -416,8 +584,9 @@ func (r TrackArgs) Encode() []interface{} {
 	asInterface = append(asInterface, r.Key, r.TrafficType, r.EventType)
 	if r.Value == nil {
 		asInterface = append(asInterface, nil)
+	} else {
+		asInterface = append(asInterface, *r.Value)
 	}
-	asInterface = append(asInterface, *r.Value)
 	asInterface = append(asInterface, r.Properties)
 	return asInterface
 }
@@ -496,6 +665,59 @@ func (t *SplitsArgs) PopulateFromRPC(rpc *RPC) error {
 	return nil
 }
 
+const (
+	BindAttributesArgAttributesIdx int = 0
+)
+
+// BindAttributesArgs carries the attributes to be applied to every evaluation issued later through the same connection.
+// Binding replaces any previously bound set. A nil/empty map clears them
+type BindAttributesArgs struct {
+	Attributes map[string]interface{}
+}
+
+func (b BindAttributesArgs) Encode() []interface{} {
+	return []interface{}{b.Attributes}
+}
+
+func (b *BindAttributesArgs) PopulateFromRPC(rpc *RPC) error {
+	if rpc.OpCode != OCBindAttributes {
+		return RPCParseError{Code: PECOpCodeMismatch}
+	}
+
+	if len(rpc.Args) != 1 {
+		return RPCParseError{Code: PECWrongArgCount}
+	}
+
+	if rpc.Args[BindAttributesArgAttributesIdx] != nil {
+		rawAttrs, err := getOptional[map[string]interface{}](rpc.Args[BindAttributesArgAttributesIdx])
+		if err != nil {
+			return RPCParseError{Code: PECInvalidArgType, Data: int64(BindAttributesArgAttributesIdx)}
+		}
+		b.Attributes = sanitizeAttributes(rawAttrs)
+	}
+
+	return nil
+}
+
+// PingArgs carries no data. Pings are used by clients as keepalives, preventing idle connections from being closed
+type PingArgs struct{}
+
+func (p PingArgs) Encode() []interface{} {
+	return nil
+}
+
+func (p *PingArgs) PopulateFromRPC(rpc *RPC) error {
+	if rpc.OpCode != OCPing {
+		return RPCParseError{Code: PECOpCodeMismatch}
+	}
+
+	if len(rpc.Args) != 0 {
+		return RPCParseError{Code: PECWrongArgCount}
+	}
+
+	return nil
+}
+
 const (
 	SplitArgNameIdx int = 0
 )
@@ -525,7 +747,155 @@ func (t *SplitArgs) PopulateFromRPC(rpc *RPC) error {
 	return nil
 }
 
+type SplitDefinitionArgs struct {
+	Name string
+}
+
+func (s SplitDefinitionArgs) Encode() []interface{} {
+	return []interface{}{s.Name}
+}
+
+func (t *SplitDefinitionArgs) PopulateFromRPC(rpc *RPC) error {
+	if rpc.OpCode != OCSplitDefinition {
+		return RPCParseError{Code: PECOpCodeMismatch}
+	}
+
+	name, err := parseSingleStringArg(rpc)
+	if err != nil {
+		return err
+	}
+	t.Name = name
+	return nil
+}
+
+type SegmentNamesArgs struct{}
+
+func (s SegmentNamesArgs) Encode() []interface{} {
+	return nil
+}
+
+func (t *SegmentNamesArgs) PopulateFromRPC(rpc *RPC) error {
+	if rpc.OpCode != OCSegmentNames {
+		return RPCParseError{Code: PECOpCodeMismatch}
+	}
+
+	if len(rpc.Args) != 0 {
+		return RPCParseError{Code: PECWrongArgCount}
+	}
+
+	return nil
+}
+
+type SegmentArgs struct {
+	Name string
+}
+
+func (s SegmentArgs) Encode() []interface{} {
+	return []interface{}{s.Name}
+}
+
+func (t *SegmentArgs) PopulateFromRPC(rpc *RPC) error {
+	if rpc.OpCode != OCSegment {
+		return RPCParseError{Code: PECOpCodeMismatch}
+	}
+
+	name, err := parseSingleStringArg(rpc)
+	if err != nil {
+		return err
+	}
+	t.Name = name
+	return nil
+}
+
+const (
+	SegmentContainsKeyArgNameIdx int = 0
+	SegmentContainsKeyArgKeyIdx  int = 1
+)
+
+type SegmentContainsKeyArgs struct {
+	Name string
+	Key  string
+}
+
+func (s SegmentContainsKeyArgs) Encode() []interface{} {
+	return []interface{}{s.Name, s.Key}
+}
+
+func (t *SegmentContainsKeyArgs) PopulateFromRPC(rpc *RPC) error {
+	if rpc.OpCode != OCSegmentContainsKey {
+		return RPCParseError{Code: PECOpCodeMismatch}
+	}
+
+	if len(rpc.Args) != 2 {
+		return RPCParseError{Code: PECWrongArgCount}
+	}
+
+	var ok bool
+	if t.Name, ok = rpc.Args[SegmentContainsKeyArgNameIdx].(string); !ok {
+		return RPCParseError{Code: PECInvalidArgType, Data: int64(SegmentContainsKeyArgNameIdx)}
+	}
+
+	if t.Key, ok = rpc.Args[SegmentContainsKeyArgKeyIdx].(string); !ok {
+		return RPCParseError{Code: PECInvalidArgType, Data: int64(SegmentContainsKeyArgKeyIdx)}
+	}
+
+	return nil
+}
+
+type RuleBasedSegmentNamesArgs struct{}
+
+func (s RuleBasedSegmentNamesArgs) Encode() []interface{} {
+	return nil
+}
+
+func (t *RuleBasedSegmentNamesArgs) PopulateFromRPC(rpc *RPC) error {
+	if rpc.OpCode != OCRuleBasedSegmentNames {
+		return RPCParseError{Code: PECOpCodeMismatch}
+	}
+
+	if len(rpc.Args) != 0 {
+		return RPCParseError{Code: PECWrongArgCount}
+	}
+
+	return nil
+}
+
+type RuleBasedSegmentArgs struct {
+	Name string
+}
+
+func (s RuleBasedSegmentArgs) Encode() []interface{} {
+	return []interface{}{s.Name}
+}
+
+func (t *RuleBasedSegmentArgs) PopulateFromRPC(rpc *RPC) error {
+	if rpc.OpCode != OCRuleBasedSegment {
+		return RPCParseError{Code: PECOpCodeMismatch}
+	}
+
+	name, err := parseSingleStringArg(rpc)
+	if err != nil {
+		return err
+	}
+	t.Name = name
+	return nil
+}
+
 // -- helpers
+
+// parseSingleStringArg is used by rpcs whose only argument is the name of an entity to look up
+func parseSingleStringArg(rpc *RPC) (string, error) {
+	if len(rpc.Args) != 1 {
+		return "", RPCParseError{Code: PECWrongArgCount}
+	}
+
+	asStr, ok := rpc.Args[0].(string)
+	if !ok {
+		return "", RPCParseError{Code: PECInvalidArgType, Data: 0}
+	}
+	return asStr, nil
+}
+
 var ErrWrongType = errors.New("wrong type")
 
 func getOptionalRef[T any](i interface{}) (*T, error) {
@@ -636,6 +1006,13 @@ func tryNumberAsFloat(x interface{}) (float64, bool) {
 }
 
 var _ Arguments = (*RegisterArgs)(nil)
+var _ Arguments = (*ReadyArgs)(nil)
 var _ Arguments = (*TreatmentArgs)(nil)
 var _ Arguments = (*TreatmentsArgs)(nil)
 var _ Arguments = (*TrackArgs)(nil)
+var _ Arguments = (*SplitDefinitionArgs)(nil)
+var _ Arguments = (*SegmentNamesArgs)(nil)
+var _ Arguments = (*SegmentArgs)(nil)
+var _ Arguments = (*SegmentContainsKeyArgs)(nil)
+var _ Arguments = (*RuleBasedSegmentNamesArgs)(nil)
+var _ Arguments = (*RuleBasedSegmentArgs)(nil)
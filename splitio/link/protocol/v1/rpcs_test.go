@@ -24,6 +24,15 @@ func TestOpStringify(t *testing.T) {
 	assert.Equal(t, "split-names", OCSplitNames.String())
 	assert.Equal(t, "split", OCSplit.String())
 	assert.Equal(t, "splits", OCSplits.String())
+	assert.Equal(t, "ready", OCReady.String())
+	assert.Equal(t, "bind-attributes", OCBindAttributes.String())
+	assert.Equal(t, "ping", OCPing.String())
+	assert.Equal(t, "split-definition", OCSplitDefinition.String())
+	assert.Equal(t, "segment-names", OCSegmentNames.String())
+	assert.Equal(t, "segment", OCSegment.String())
+	assert.Equal(t, "segment-contains-key", OCSegmentContainsKey.String())
+	assert.Equal(t, "rule-based-segment-names", OCRuleBasedSegmentNames.String())
+	assert.Equal(t, "rule-based-segment", OCRuleBasedSegment.String())
 	assert.Equal(t, "unknown", OpCode(255).String())
 }
 
@@ -57,6 +66,148 @@ func TestRegisterRPCParsing(t *testing.T) {
 	assert.Equal(t, "some", r.ID)
 	assert.Equal(t, "some_sdk-1.2.3", r.SDKVersion)
 	assert.Equal(t, RegisterFlags(uint64(0)), r.Flags)
+
+	assert.Equal(t,
+		RPCParseError{Code: PECInvalidArgType, Data: int64(RegisterArgReadyTimeoutMSIdx)},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCRegister, Args: []interface{}{"some", "some_sdk-1.2.3", uint64(RegisterFlagBlockUntilReady), "1s"}}),
+	)
+	err = r.PopulateFromRPC(&RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  OCRegister,
+		Args:    []interface{}{"some", "some_sdk-1.2.3", uint64(RegisterFlagBlockUntilReady), uint16(500)},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, RegisterFlagBlockUntilReady, r.Flags)
+	assert.Equal(t, int64(500), r.ReadyTimeoutMS)
+
+	// the reserved bit set by clients asking for impression data doesn't request anything else
+	legacyImpressionData := uint64(1 << 1)
+	err = r.PopulateFromRPC(&RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  OCRegister,
+		Args:    []interface{}{"some", "some_sdk-1.2.3", legacyImpressionData},
+	})
+	assert.Nil(t, err)
+	assert.Zero(t, r.Flags&RegisterFlagBlockUntilReady)
+	assert.Zero(t, r.Flags&registerFlagsLabels)
+	assert.Zero(t, r.Flags&registerFlagsImpressionsMode)
+
+	assert.Equal(t,
+		RPCParseError{Code: PECInvalidArgType, Data: int64(RegisterArgEnvironmentIdx)},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCRegister, Args: []interface{}{"some", "some_sdk-1.2.3", uint64(0), int64(0), 123}}),
+	)
+	err = r.PopulateFromRPC(&RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  OCRegister,
+		Args:    []interface{}{"some", "some_sdk-1.2.3", uint64(0), int64(0), "staging"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "staging", r.Environment)
+	assert.Equal(t,
+		RPCParseError{Code: PECInvalidArgType, Data: int64(RegisterArgTokenIdx)},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCRegister, Args: []interface{}{"some", "some_sdk-1.2.3", uint64(0), int64(0), "staging", 1}}),
+	)
+	err = r.PopulateFromRPC(&RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  OCRegister,
+		Args:    []interface{}{"some", "some_sdk-1.2.3", uint64(0), int64(0), "", "s3cr3t"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr3t", r.Token)
+	assert.Equal(t,
+		RPCParseError{Code: PECWrongArgCount},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCRegister, Args: []interface{}{"some", "some_sdk-1.2.3", uint64(0), int64(0), "staging", "s3cr3t", 1}}),
+	)
+
+	// impressions mode & labels overrides
+	assert.Nil(t, r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCRegister, Args: []interface{}{"some", "some_sdk-1.2.3", uint64(0)}}))
+	assert.Equal(t, "", r.ImpressionsMode())
+	assert.Nil(t, r.LabelsEnabled())
+
+	flags := uint64(RegisterFlagImpressionsModeNone | RegisterFlagLabelsDisabled)
+	assert.Nil(t, r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCRegister, Args: []interface{}{"some", "some_sdk-1.2.3", flags}}))
+	assert.Equal(t, "none", r.ImpressionsMode())
+	assert.Equal(t, lang.Ref(false), r.LabelsEnabled())
+
+	flags = uint64(RegisterFlagImpressionsModeDebug | RegisterFlagLabelsEnabled)
+	assert.Nil(t, r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCRegister, Args: []interface{}{"some", "some_sdk-1.2.3", flags}}))
+	assert.Equal(t, "debug", r.ImpressionsMode())
+	assert.Equal(t, lang.Ref(true), r.LabelsEnabled())
+
+	flags = uint64(RegisterFlagImpressionsModeDebug | RegisterFlagImpressionsModeOptimized)
+	assert.Equal(t,
+		RPCParseError{Code: PECInvalidArgValue, Data: int64(RegisterArgFlagsIdx)},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCRegister, Args: []interface{}{"some", "some_sdk-1.2.3", flags}}),
+	)
+	flags = uint64(RegisterFlagLabelsEnabled | RegisterFlagLabelsDisabled)
+	assert.Equal(t,
+		RPCParseError{Code: PECInvalidArgValue, Data: int64(RegisterArgFlagsIdx)},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCRegister, Args: []interface{}{"some", "some_sdk-1.2.3", flags}}),
+	)
+
+	flag, ok := ImpressionsModeFlag("optimized")
+	assert.True(t, ok)
+	assert.Equal(t, RegisterFlagImpressionsModeOptimized, flag)
+	_, ok = ImpressionsModeFlag("sarasa")
+	assert.False(t, ok)
+}
+
+func TestReadyRPCParsing(t *testing.T) {
+	var r ReadyArgs
+	assert.Equal(t,
+		RPCParseError{Code: PECOpCodeMismatch},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCRegister, Args: []interface{}{int64(1)}}),
+	)
+	assert.Equal(t,
+		RPCParseError{Code: PECWrongArgCount},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCReady, Args: nil}),
+	)
+	assert.Equal(t,
+		RPCParseError{Code: PECInvalidArgType, Data: int64(ReadyArgTimeoutMSIdx)},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCReady, Args: []interface{}{"1s"}}),
+	)
+	assert.Nil(t, r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCReady, Args: []interface{}{uint32(1000)}}))
+	assert.Equal(t, int64(1000), r.TimeoutMS)
+}
+
+func TestBindAttributesRPCParsing(t *testing.T) {
+	var r BindAttributesArgs
+	assert.Equal(t,
+		RPCParseError{Code: PECOpCodeMismatch},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCReady, Args: []interface{}{nil}}),
+	)
+	assert.Equal(t,
+		RPCParseError{Code: PECWrongArgCount},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCBindAttributes, Args: nil}),
+	)
+	assert.Equal(t,
+		RPCParseError{Code: PECInvalidArgType, Data: int64(BindAttributesArgAttributesIdx)},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCBindAttributes, Args: []interface{}{"attrs"}}),
+	)
+
+	assert.Nil(t, r.PopulateFromRPC(&RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  OCBindAttributes,
+		Args:    BindAttributesArgs{Attributes: map[string]interface{}{"region": "us-east-1", "build": uint8(12)}}.Encode(),
+	}))
+	assert.Equal(t, map[string]interface{}{"region": "us-east-1", "build": int64(12)}, r.Attributes)
+
+	r = BindAttributesArgs{}
+	assert.Nil(t, r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCBindAttributes, Args: []interface{}{nil}}))
+	assert.Nil(t, r.Attributes)
+}
+
+func TestPingRPCParsing(t *testing.T) {
+	var r PingArgs
+	assert.Equal(t,
+		RPCParseError{Code: PECOpCodeMismatch},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCReady, Args: nil}),
+	)
+	assert.Equal(t,
+		RPCParseError{Code: PECWrongArgCount},
+		r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCPing, Args: []interface{}{"hi"}}),
+	)
+	assert.Nil(t, r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCPing, Args: PingArgs{}.Encode()}))
 }
 
 func TestTreatmentRPCParsing(t *testing.T) {
@@ -360,6 +511,15 @@ func TestTreatmentsByFlagSetsRPCParsing(t *testing.T) {
 			Args:    []interface{}{"key", "bk", []interface{}{"s"}, nil, 123}}))
 }
 
+func TestTrackEncodeWithoutValue(t *testing.T) {
+	encoded := TrackArgs{Key: "k", TrafficType: "user", EventType: "checkin"}.Encode()
+	assert.Equal(t, []interface{}{"k", "user", "checkin", nil, map[string]interface{}(nil)}, encoded)
+
+	var r TrackArgs
+	assert.Nil(t, r.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCTrack, Args: encoded}))
+	assert.Nil(t, r.Value)
+}
+
 func TestTrackRPCParsing(t *testing.T) {
 	var r TrackArgs
 	assert.Equal(t,
@@ -483,6 +643,62 @@ func TestSplitRPCProcessing(t *testing.T) {
 	assert.Equal(t, "s1", r.Name)
 }
 
+func TestInspectionRPCsProcessing(t *testing.T) {
+	var sd SplitDefinitionArgs
+	assert.Equal(t,
+		RPCParseError{Code: PECOpCodeMismatch},
+		sd.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCSplit, Args: []interface{}{"s1"}}),
+	)
+	assert.Equal(t,
+		RPCParseError{Code: PECWrongArgCount},
+		sd.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCSplitDefinition, Args: nil}),
+	)
+	assert.Equal(t,
+		RPCParseError{Code: PECInvalidArgType, Data: 0},
+		sd.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCSplitDefinition, Args: []interface{}{123}}),
+	)
+	assert.Nil(t, sd.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCSplitDefinition, Args: []interface{}{"s1"}}))
+	assert.Equal(t, "s1", sd.Name)
+
+	var sn SegmentNamesArgs
+	assert.Equal(t,
+		RPCParseError{Code: PECWrongArgCount},
+		sn.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCSegmentNames, Args: []interface{}{"asd"}}),
+	)
+	assert.Nil(t, sn.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCSegmentNames, Args: nil}))
+
+	var seg SegmentArgs
+	assert.Equal(t,
+		RPCParseError{Code: PECOpCodeMismatch},
+		seg.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCSegmentNames, Args: []interface{}{"seg1"}}),
+	)
+	assert.Nil(t, seg.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCSegment, Args: []interface{}{"seg1"}}))
+	assert.Equal(t, "seg1", seg.Name)
+
+	var sck SegmentContainsKeyArgs
+	assert.Equal(t,
+		RPCParseError{Code: PECWrongArgCount},
+		sck.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCSegmentContainsKey, Args: []interface{}{"seg1"}}),
+	)
+	assert.Equal(t,
+		RPCParseError{Code: PECInvalidArgType, Data: int64(SegmentContainsKeyArgKeyIdx)},
+		sck.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCSegmentContainsKey, Args: []interface{}{"seg1", 1}}),
+	)
+	assert.Nil(t, sck.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCSegmentContainsKey, Args: []interface{}{"seg1", "key1"}}))
+	assert.Equal(t, SegmentContainsKeyArgs{Name: "seg1", Key: "key1"}, sck)
+
+	var rbsn RuleBasedSegmentNamesArgs
+	assert.Nil(t, rbsn.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCRuleBasedSegmentNames, Args: nil}))
+
+	var rbs RuleBasedSegmentArgs
+	assert.Equal(t,
+		RPCParseError{Code: PECOpCodeMismatch},
+		rbs.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCSegment, Args: []interface{}{"rbs1"}}),
+	)
+	assert.Nil(t, rbs.PopulateFromRPC(&RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: OCRuleBasedSegment, Args: []interface{}{"rbs1"}}))
+	assert.Equal(t, "rbs1", rbs.Name)
+}
+
 func TestSanitizeAttributes(t *testing.T) {
 	now := time.Now()
 	attrs := map[string]interface{}{
@@ -527,6 +743,22 @@ func TestRPCEncoding(t *testing.T) {
 	assert.Equal(t, ra.ID, encodedRA[RegisterArgIDIdx].(string))
 	assert.Equal(t, ra.SDKVersion, encodedRA[RegisterArgSDKVersionIdx].(string))
 	assert.Equal(t, ra.Flags, encodedRA[RegisterArgFlagsIdx].(RegisterFlags))
+	assert.Len(t, encodedRA, 3)
+
+	ra.Flags, ra.ReadyTimeoutMS = RegisterFlagBlockUntilReady, 1000
+	encodedRA = ra.Encode()
+	assert.Equal(t, ra.ReadyTimeoutMS, encodedRA[RegisterArgReadyTimeoutMSIdx].(int64))
+	assert.Len(t, encodedRA, 4)
+
+	ra.Flags, ra.ReadyTimeoutMS, ra.Environment = 0, 0, "staging"
+	encodedRA = ra.Encode()
+	assert.Equal(t, ra.Environment, encodedRA[RegisterArgEnvironmentIdx].(string))
+	assert.Len(t, encodedRA, 5)
+
+	ra.Environment, ra.Token = "", "s3cr3t"
+	encodedRA = ra.Encode()
+	assert.Equal(t, ra.Token, encodedRA[RegisterArgTokenIdx].(string))
+	assert.Len(t, encodedRA, 6)
 
 	ta := TreatmentArgs{
 		Key:          "someKey",
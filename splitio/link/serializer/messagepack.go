@@ -1,6 +1,9 @@
 package serializer
 
 import (
+	"bytes"
+	"sync"
+
 	"github.com/vmihailenco/msgpack/v5"
 )
 
@@ -12,9 +15,22 @@ type Interface interface {
 type MessagePack struct {
 }
 
+// readers wrap incoming messages for decoding. They're pooled, as msgpack.Unmarshal allocates a new one per call
+var readers = sync.Pool{New: func() any { return new(bytes.Reader) }}
+
 // Parse implements Interface
 func (*MessagePack) Parse(raw []byte, obj interface{}) error {
-	return msgpack.Unmarshal(raw, &obj)
+	r := readers.Get().(*bytes.Reader)
+	r.Reset(raw)
+	dec := msgpack.GetDecoder()
+	dec.Reset(r)
+
+	err := dec.Decode(obj)
+
+	msgpack.PutDecoder(dec)
+	r.Reset(nil)
+	readers.Put(r)
+	return err
 }
 
 // Serialize implements Interface
@@ -22,8 +38,17 @@ func (*MessagePack) Serialize(obj interface{}) ([]byte, error) {
 	return msgpack.Marshal(obj)
 }
 
+// SerializeInto implements BufferedSerializer
+func (*MessagePack) SerializeInto(buf *bytes.Buffer, obj interface{}) error {
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+	enc.Reset(buf)
+	return enc.Encode(obj)
+}
+
 func newMessagePack() *MessagePack {
 	return &MessagePack{}
 }
 
 var _ Interface = (*MessagePack)(nil)
+var _ BufferedSerializer = (*MessagePack)(nil)
@@ -1,6 +1,9 @@
 package serializer
 
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+)
 
 type Mechanism int
 
@@ -24,3 +27,24 @@ func Setup(mechanism Mechanism) (Interface, error) {
 	}
 	return nil, fmt.Errorf("unknown serialization mechanism '%d'", mechanism)
 }
+
+// BufferedSerializer is implemented by serializers able to write into a caller-owned buffer, which can then be reused
+// across messages instead of allocating a new slice for each one
+type BufferedSerializer interface {
+	SerializeInto(buf *bytes.Buffer, obj interface{}) error
+}
+
+// SerializeWith serializes `obj` into `buf` if the serializer supports it, or into a newly allocated slice otherwise.
+// The returned slice is only valid until the buffer is reset
+func SerializeWith(s Interface, buf *bytes.Buffer, obj interface{}) ([]byte, error) {
+	bs, ok := s.(BufferedSerializer)
+	if !ok {
+		return s.Serialize(obj)
+	}
+
+	buf.Reset()
+	if err := bs.SerializeInto(buf, obj); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -1,8 +1,10 @@
 package serializer
 
 import (
-	"github.com/stretchr/testify/assert"
+	"bytes"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestConnType(t *testing.T) {
@@ -17,3 +19,61 @@ func TestSetup(t *testing.T) {
 	_, err = Setup(Mechanism(123))
 	assert.ErrorContains(t, err, "unknown serialization mechanism")
 }
+
+type unbuffered struct{ Interface }
+
+func TestSerializeWith(t *testing.T) {
+	mp := newMessagePack()
+	obj := &benchmarkMessage{Version: 1, OpCode: 2, Args: []interface{}{"a", int8(1)}}
+	expected, err := mp.Serialize(obj)
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	buf.WriteString("leftovers")
+	serialized, err := SerializeWith(mp, &buf, obj)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, serialized)
+
+	var parsed *benchmarkMessage
+	assert.Nil(t, mp.Parse(serialized, &parsed))
+	assert.Equal(t, obj, parsed)
+
+	// serializers unable to write into a buffer allocate a new slice
+	buf.Reset()
+	serialized, err = SerializeWith(unbuffered{mp}, &buf, obj)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, serialized)
+	assert.Zero(t, buf.Len())
+
+	_, err = SerializeWith(mp, &buf, make(chan int))
+	assert.NotNil(t, err)
+}
+
+type benchmarkMessage struct {
+	Version uint8         `msgpack:"v"`
+	OpCode  uint8         `msgpack:"o"`
+	Args    []interface{} `msgpack:"a"`
+}
+
+func BenchmarkSerialize(b *testing.B) {
+	mp := newMessagePack()
+	msg := benchmarkMessage{Version: 1, OpCode: 0x11, Args: []interface{}{"key", nil, "feat1", nil}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := mp.Serialize(&msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSerializeWithBuffer(b *testing.B) {
+	mp := newMessagePack()
+	msg := benchmarkMessage{Version: 1, OpCode: 0x11, Args: []interface{}{"key", nil, "feat1", nil}}
+	var buf bytes.Buffer
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := SerializeWith(mp, &buf, &msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/splitio/link/auth"
 	"github.com/splitio/splitd/splitio/link/protocol"
 	"github.com/splitio/splitd/splitio/link/serializer"
 	"github.com/splitio/splitd/splitio/link/transfer"
@@ -17,13 +18,16 @@ var (
 	ErrParsingData = errors.New("error parsing incoming message")
 )
 
+// ConnectionPolicy limits how long client connections are kept open
+type ConnectionPolicy = serviceV1.ConnectionPolicy
+
 type Interface interface {
 	HandleNewClient(cc transfer.RawConn)
 }
 
 type Impl struct {
 	logger           logging.LoggerInterface
-	splitSDK         sdk.Interface
+	environments     sdk.Environments
 	newClientManager ClientManagerFactory
 }
 
@@ -33,17 +37,31 @@ func (s *Impl) HandleNewClient(cc transfer.RawConn) {
 	// TODO(mredolatti): Track active connections
 }
 
-func New(logger logging.LoggerInterface, splitSDK sdk.Interface, serial serializer.Interface, proto protocol.Version) (*Impl, error) {
+// New builds a service handler routing each client to one of the supplied environments.
+// Clients not requesting a specific one upon registration are served by `defaultEnv`
+func New(
+	logger logging.LoggerInterface,
+	environments sdk.Environments,
+	defaultEnv string,
+	serial serializer.Interface,
+	proto protocol.Version,
+	authn *auth.Authenticator,
+	policy ConnectionPolicy,
+) (*Impl, error) {
+
+	if _, err := environments.Get(defaultEnv); err != nil {
+		return nil, fmt.Errorf("invalid default environment: %w", err)
+	}
 
 	switch proto {
 	case protocol.V1:
-		cmf, err := newCMFactoryForV1(logger, splitSDK, serial)
+		cmf, err := newCMFactoryForV1(logger, environments, defaultEnv, serial, authn, policy)
 		if err != nil {
 			return nil, fmt.Errorf("error setting up client-manager factory: %w", err)
 		}
 		return &Impl{
 			logger:           logger,
-			splitSDK:         splitSDK,
+			environments:     environments,
 			newClientManager: cmf,
 		}, nil
 	}
@@ -58,9 +76,16 @@ type ClientManager interface {
 
 type ClientManagerFactory func(transfer.RawConn) ClientManager
 
-func newCMFactoryForV1(logger logging.LoggerInterface, splitSDK sdk.Interface, serial serializer.Interface) (ClientManagerFactory, error) {
+func newCMFactoryForV1(
+	logger logging.LoggerInterface,
+	environments sdk.Environments,
+	defaultEnv string,
+	serial serializer.Interface,
+	authn *auth.Authenticator,
+	policy ConnectionPolicy,
+) (ClientManagerFactory, error) {
 	return func(conn transfer.RawConn) ClientManager {
-		return serviceV1.NewClientManager(conn, logger, splitSDK, serial)
+		return serviceV1.NewMultiEnvClientManager(conn, logger, environments, defaultEnv, serial, authn, policy)
 	}, nil
 }
 
@@ -1,15 +1,18 @@
 package v1
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"runtime/debug"
+	"time"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/go-toolkit/v5/logging"
 
+	"github.com/splitio/splitd/splitio/link/auth"
 	protov1 "github.com/splitio/splitd/splitio/link/protocol/v1"
 	"github.com/splitio/splitd/splitio/link/serializer"
 	"github.com/splitio/splitd/splitio/link/transfer"
@@ -22,7 +25,39 @@ type ClientManager struct {
 	serializer   serializer.Interface
 	logger       logging.LoggerInterface
 	clientConfig *types.ClientConfig
-	splitSDK     sdk.Interface
+	environments sdk.Environments
+	defaultEnv   string
+	splitSDK     sdk.Interface // sdk serving the environment picked by the client upon registration
+	authn        *auth.Authenticator
+	token        *auth.Token // token presented by the client upon registration
+	policy       ConnectionPolicy
+	connectedAt  time.Time
+	lastRPCAt    time.Time
+
+	// reused across rpcs, in order to keep the hot path free of allocations
+	rpc               protov1.RPC
+	writeBuffer       bytes.Buffer
+	treatmentResponse protov1.ResponseWrapper[protov1.TreatmentPayload]
+	listenerData      protov1.ListenerExtraData
+	evaluationOptions dtos.EvaluationOptions
+}
+
+// ConnectionPolicy limits how long connections are kept open. Zero values disable the corresponding limit
+type ConnectionPolicy struct {
+	// connections without incoming rpcs for this long are closed (clients can send pings to keep them alive)
+	IdleTimeout time.Duration
+
+	// connections older than this are closed after responding to their next rpc. The response is flagged,
+	// so that the client opens a new connection for further calls
+	MaxLifetime time.Duration
+}
+
+func (p *ConnectionPolicy) idleExpired(lastRPCAt time.Time, now time.Time) bool {
+	return p.IdleTimeout > 0 && now.Sub(lastRPCAt) >= p.IdleTimeout
+}
+
+func (p *ConnectionPolicy) lifetimeExpired(connectedAt time.Time, now time.Time) bool {
+	return p.MaxLifetime > 0 && now.Sub(connectedAt) >= p.MaxLifetime
 }
 
 func NewClientManager(
@@ -31,11 +66,34 @@ func NewClientManager(
 	splitSDK sdk.Interface,
 	serializer serializer.Interface,
 ) *ClientManager {
+	cm := NewMultiEnvClientManager(cc, logger, sdk.SingleEnvironment(splitSDK), sdk.DefaultEnvironment, serializer, nil, ConnectionPolicy{})
+	cm.splitSDK = splitSDK
+	return cm
+}
+
+// NewMultiEnvClientManager builds a client manager that routes the connection to the environment requested by the client
+// when registering, or to `defaultEnv` if none is requested. If `authn` has tokens configured, clients must present one
+// of them when registering, and are only allowed to perform the operations in its scope
+func NewMultiEnvClientManager(
+	cc transfer.RawConn,
+	logger logging.LoggerInterface,
+	environments sdk.Environments,
+	defaultEnv string,
+	serializer serializer.Interface,
+	authn *auth.Authenticator,
+	policy ConnectionPolicy,
+) *ClientManager {
+	now := time.Now()
 	return &ClientManager{
-		cc:         cc,
-		logger:     logger,
-		serializer: serializer,
-		splitSDK:   splitSDK,
+		cc:           cc,
+		logger:       logger,
+		serializer:   serializer,
+		environments: environments,
+		defaultEnv:   defaultEnv,
+		authn:        authn,
+		policy:       policy,
+		connectedAt:  now,
+		lastRPCAt:    now,
 	}
 }
 
@@ -60,6 +118,10 @@ func (m *ClientManager) handleClientInteractions() error {
 				m.logger.Debug(fmt.Sprintf("connection remotely closed for metadata=%s", formatClientConfig(m.clientConfig)))
 				return nil
 			} else if errors.Is(err, os.ErrDeadlineExceeded) { // we waited for an RPC, got none, try again.
+				if m.policy.idleExpired(m.lastRPCAt, time.Now()) { // frees the slot held by an idle (or leaked) connection
+					m.logger.Debug(fmt.Sprintf("closing connection idle for longer than %s for metadata=%s", m.policy.IdleTimeout, formatClientConfig(m.clientConfig)))
+					return nil
+				}
 				m.logger.Debug(fmt.Sprintf("read timeout/no RPC fetched. restarting loop for metadata=%s", formatClientConfig(m.clientConfig)))
 				continue
 			} else {
@@ -68,34 +130,49 @@ func (m *ClientManager) handleClientInteractions() error {
 			}
 		}
 
+		m.lastRPCAt = time.Now()
 		response, err := m.dispatchRPC(rpc)
 		if err != nil {
 			return fmt.Errorf("error handling RPC: %w", err)
 		}
 
+		expired := m.policy.lifetimeExpired(m.connectedAt, m.lastRPCAt)
+		if withReconnect, ok := response.(reconnectRequester); ok && expired {
+			withReconnect.RequestReconnect()
+		} else {
+			expired = false // the client cannot be told to reconnect. keep the connection
+		}
+
 		if err = m.sendResponse(response); err != nil {
 			return err
 		}
+
+		if expired {
+			m.logger.Debug(fmt.Sprintf("connection reached its max lifetime of %s, closing for metadata=%s", m.policy.MaxLifetime, formatClientConfig(m.clientConfig)))
+			return nil
+		}
 	}
 }
 
+// reconnectRequester is implemented by every response wrapper
+type reconnectRequester interface{ RequestReconnect() }
+
 func (m *ClientManager) fetchRPC() (*protov1.RPC, error) {
 	read, err := m.cc.ReceiveMessage()
 	if err != nil {
 		return nil, fmt.Errorf("error reading from conn: %w", err)
 	}
 
-	var parsed protov1.RPC
-	if err = m.serializer.Parse(read, &parsed); err != nil {
+	m.rpc.Reset()
+	if err = m.serializer.Parse(read, &m.rpc); err != nil {
 		return nil, fmt.Errorf("error parsing message: %w", err)
 	}
 
-	return &parsed, nil
+	return &m.rpc, nil
 }
 
 func (m *ClientManager) sendResponse(response interface{}) error {
-	serialized, err := m.serializer.Serialize(response)
-
+	serialized, err := serializer.SerializeWith(m.serializer, &m.writeBuffer, response)
 	if err != nil {
 		return fmt.Errorf("error serializing response: %w", err)
 	}
@@ -114,9 +191,21 @@ func (m *ClientManager) dispatchRPC(rpc *protov1.RPC) (interface{}, error) {
 		return nil, fmt.Errorf("first call must be 'register'`")
 	}
 
+	if required := requiredScope(rpc.OpCode); m.token != nil && !m.token.Scope.Allows(required) {
+		m.logger.Warning(fmt.Sprintf("client with id=%s (token '%s') is not allowed to perform '%s' operations",
+			m.clientConfig.Metadata.ID, m.token.Name, rpc.OpCode))
+		return &protov1.ResponseWrapper[protov1.EmptyPayload]{Status: protov1.ResultUnauthorized}, nil
+	}
+
 	switch rpc.OpCode {
 	case protov1.OCRegister:
 		return m.handleRegistration(rpc)
+	case protov1.OCReady:
+		return m.handleReady(rpc)
+	case protov1.OCBindAttributes:
+		return m.handleBindAttributes(rpc)
+	case protov1.OCPing:
+		return m.handlePing(rpc)
 	case protov1.OCTreatment:
 		return m.handleGetTreatment(rpc, false)
 	case protov1.OCTreatments:
@@ -141,6 +230,18 @@ func (m *ClientManager) dispatchRPC(rpc *protov1.RPC) (interface{}, error) {
 		return m.handleSplit(rpc)
 	case protov1.OCSplits:
 		return m.handleSplits(rpc)
+	case protov1.OCSplitDefinition:
+		return m.handleSplitDefinition(rpc)
+	case protov1.OCSegmentNames:
+		return m.handleSegmentNames(rpc)
+	case protov1.OCSegment:
+		return m.handleSegment(rpc)
+	case protov1.OCSegmentContainsKey:
+		return m.handleSegmentContainsKey(rpc)
+	case protov1.OCRuleBasedSegmentNames:
+		return m.handleRuleBasedSegmentNames(rpc)
+	case protov1.OCRuleBasedSegment:
+		return m.handleRuleBasedSegment(rpc)
 	}
 
 	return nil, fmt.Errorf("RPC not implemented")
@@ -153,14 +254,91 @@ func (m *ClientManager) handleRegistration(rpc *protov1.RPC) (interface{}, error
 		return nil, fmt.Errorf("error parsing register arguments: %w", err)
 	}
 
+	token, err := m.authn.Authenticate(args.Token)
+	if err != nil {
+		m.logger.Warning(fmt.Sprintf("client with id=%s failed to authenticate: %s", args.ID, err))
+		return &protov1.ResponseWrapper[protov1.RegisterPayload]{Status: protov1.ResultUnauthorized}, nil
+	}
+
+	envName := args.Environment
+	if envName == "" {
+		envName = m.defaultEnv
+	}
+
+	splitSDK, err := m.environments.Get(envName)
+	if err != nil {
+		m.logger.Warning(fmt.Sprintf("client with id=%s requested an environment not served by this daemon: %s", args.ID, err))
+		return &protov1.ResponseWrapper[protov1.RegisterPayload]{Status: protov1.ResultUnknownEnvironment}, nil
+	}
+
+	m.splitSDK = splitSDK
+	m.token = token
 	m.clientConfig = &types.ClientConfig{
 		Metadata: types.ClientMetadata{
 			ID:         args.ID,
 			SdkVersion: args.SDKVersion,
 		},
 		ReturnImpressionData: (args.Flags & protov1.RegisterFlagReturnImpressionData) != 0,
+		ImpressionsMode:      args.ImpressionsMode(),
+		LabelsEnabled:        args.LabelsEnabled(),
+	}
+
+	if peer := transfer.PeerCredentialsOf(m.cc); peer != nil {
+		m.clientConfig.Metadata.PID = peer.PID
+		m.clientConfig.Metadata.UID = peer.UID
+	}
+	m.logger.Info(fmt.Sprintf("client registered: id=%s sdk=%s pid=%d uid=%d environment='%s'",
+		args.ID, args.SDKVersion, m.clientConfig.Metadata.PID, m.clientConfig.Metadata.UID, envName))
+
+	var payload protov1.RegisterPayload
+	if (args.Flags & protov1.RegisterFlagBlockUntilReady) != 0 {
+		payload.Ready = m.splitSDK.BlockUntilReady(time.Duration(args.ReadyTimeoutMS)*time.Millisecond) == nil
+	}
+	return &protov1.ResponseWrapper[protov1.RegisterPayload]{Status: protov1.ResultOk, Payload: payload}, nil
+}
+
+func (m *ClientManager) handleReady(rpc *protov1.RPC) (interface{}, error) {
+
+	var args protov1.ReadyArgs
+	if err := args.PopulateFromRPC(rpc); err != nil {
+		return nil, fmt.Errorf("error parsing ready arguments: %w", err)
+	}
+
+	err := m.splitSDK.BlockUntilReady(time.Duration(args.TimeoutMS) * time.Millisecond)
+	staleness := m.splitSDK.Staleness()
+	return &protov1.ResponseWrapper[protov1.ReadyPayload]{
+		Status: protov1.ResultOk,
+		Payload: protov1.ReadyPayload{
+			Ready:         err == nil,
+			State:         byte(m.splitSDK.Readiness()),
+			StaleSplits:   staleness.Splits,
+			StaleSegments: staleness.Segments,
+		},
+	}, nil
+}
+
+func (m *ClientManager) handleBindAttributes(rpc *protov1.RPC) (interface{}, error) {
+
+	var args protov1.BindAttributesArgs
+	if err := args.PopulateFromRPC(rpc); err != nil {
+		return nil, fmt.Errorf("error parsing bind-attributes arguments: %w", err)
 	}
-	return &protov1.ResponseWrapper[protov1.RegisterPayload]{Status: protov1.ResultOk}, nil
+
+	m.clientConfig.Attributes = args.Attributes
+	return &protov1.ResponseWrapper[protov1.BindAttributesPayload]{
+		Status:  protov1.ResultOk,
+		Payload: protov1.BindAttributesPayload{Bound: len(args.Attributes)},
+	}, nil
+}
+
+func (m *ClientManager) handlePing(rpc *protov1.RPC) (interface{}, error) {
+
+	var args protov1.PingArgs
+	if err := args.PopulateFromRPC(rpc); err != nil {
+		return nil, fmt.Errorf("error parsing ping arguments: %w", err)
+	}
+
+	return &protov1.ResponseWrapper[protov1.EmptyPayload]{Status: protov1.ResultOk}, nil
 }
 
 func (m *ClientManager) handleGetTreatment(rpc *protov1.RPC, withConfig bool) (interface{}, error) {
@@ -170,14 +348,22 @@ func (m *ClientManager) handleGetTreatment(rpc *protov1.RPC, withConfig bool) (i
 		return nil, fmt.Errorf("error parsing treatment arguments: %w", err)
 	}
 
-	res, err := m.splitSDK.Treatment(m.clientConfig, args.Key, args.BucketingKey, args.Feature, args.Attributes, &dtos.EvaluationOptions{Properties: args.ImpressionProperties})
+	m.evaluationOptions = dtos.EvaluationOptions{Properties: args.ImpressionProperties}
+	res, err := m.splitSDK.Treatment(m.clientConfig, args.Key, args.BucketingKey, args.Feature, args.Attributes, &m.evaluationOptions)
 	if err != nil {
 		return &protov1.ResponseWrapper[protov1.TreatmentPayload]{Status: protov1.ResultInternalError}, err
 	}
 
-	response := &protov1.ResponseWrapper[protov1.TreatmentPayload]{
-		Status:  protov1.ResultOk,
-		Payload: protov1.TreatmentPayload{Treatment: res.Treatment},
+	// the response is only used until it's serialized, so the same one is reused for every call
+	response := &m.treatmentResponse
+	*response = protov1.ResponseWrapper[protov1.TreatmentPayload]{
+		Status: protov1.ResultOk,
+		Payload: protov1.TreatmentPayload{
+			Treatment: res.Treatment,
+			Stale:     res.Stale,
+			Label:     res.Label,
+			Warnings:  toWarningsPayload(res.Warnings),
+		},
 	}
 
 	if withConfig {
@@ -185,11 +371,12 @@ func (m *ClientManager) handleGetTreatment(rpc *protov1.RPC, withConfig bool) (i
 	}
 
 	if m.clientConfig.ReturnImpressionData && res.Impression != nil {
-		response.Payload.ListenerData = &protov1.ListenerExtraData{
+		m.listenerData = protov1.ListenerExtraData{
 			Label:        res.Impression.Label,
 			Timestamp:    res.Impression.Time,
 			ChangeNumber: res.Impression.ChangeNumber,
 		}
+		response.Payload.ListenerData = &m.listenerData
 	}
 
 	return response, nil
@@ -216,6 +403,9 @@ func (m *ClientManager) handleGetTreatments(rpc *protov1.RPC, withConfig bool) (
 		}
 
 		results[idx].Treatment = ff.Treatment
+		results[idx].Stale = ff.Stale
+		results[idx].Label = ff.Label
+		results[idx].Warnings = toWarningsPayload(ff.Warnings)
 		if m.clientConfig.ReturnImpressionData && ff.Impression != nil {
 			results[idx].ListenerData = &protov1.ListenerExtraData{
 				Label:        ff.Impression.Label,
@@ -253,6 +443,9 @@ func (m *ClientManager) handleGetTreatmentsByFlagSet(rpc *protov1.RPC, withConfi
 	for feature, evaluationResult := range res {
 		currentPayload := protov1.TreatmentPayload{
 			Treatment: evaluationResult.Treatment,
+			Stale:     evaluationResult.Stale,
+			Label:     evaluationResult.Label,
+			Warnings:  toWarningsPayload(evaluationResult.Warnings),
 		}
 
 		if m.clientConfig.ReturnImpressionData && evaluationResult.Impression != nil {
@@ -294,6 +487,9 @@ func (m *ClientManager) handleGetTreatmentsByFlagSets(rpc *protov1.RPC, withConf
 	for feature, evaluationResult := range res {
 		currentPayload := protov1.TreatmentPayload{
 			Treatment: evaluationResult.Treatment,
+			Stale:     evaluationResult.Stale,
+			Label:     evaluationResult.Label,
+			Warnings:  toWarningsPayload(evaluationResult.Warnings),
 		}
 
 		if m.clientConfig.ReturnImpressionData && evaluationResult.Impression != nil {
@@ -326,14 +522,21 @@ func (m *ClientManager) handleTrack(rpc *protov1.RPC) (interface{}, error) {
 		return nil, fmt.Errorf("error parsing track arguments: %w", err)
 	}
 
-	err := m.splitSDK.Track(m.clientConfig, args.Key, args.TrafficType, args.EventType, args.Value, args.Properties)
-	if err != nil && !errors.Is(err, sdk.ErrEventsQueueFull) {
+	warnings, err := m.splitSDK.Track(m.clientConfig, args.Key, args.TrafficType, args.EventType, args.Value, args.Properties)
+	if err != nil && !errors.Is(err, sdk.ErrEventsQueueFull) && !errors.Is(err, sdk.ErrInvalidInput) {
 		return &protov1.ResponseWrapper[protov1.TrackPayload]{Status: protov1.ResultInternalError}, err
 	}
 
 	response := &protov1.ResponseWrapper[protov1.TrackPayload]{
-		Status:  protov1.ResultOk,
-		Payload: protov1.TrackPayload{Success: err == nil}, // if err != nil it can only be ErrEventsQueueFull at this point
+		Status: protov1.ResultOk,
+		Payload: protov1.TrackPayload{
+			Success:  err == nil, // if err != nil it can only be ErrEventsQueueFull or a validation error at this point
+			Warnings: toWarningsPayload(warnings),
+		},
+	}
+
+	if errors.Is(err, sdk.ErrInvalidInput) {
+		response.Payload.Error = err.Error()
 	}
 
 	return response, nil
@@ -406,6 +609,155 @@ func (m *ClientManager) handleSplits(rpc *protov1.RPC) (interface{}, error) {
 	return response, nil
 }
 
+func (m *ClientManager) handleSplitDefinition(rpc *protov1.RPC) (interface{}, error) {
+
+	var args protov1.SplitDefinitionArgs
+	if err := args.PopulateFromRPC(rpc); err != nil {
+		return nil, fmt.Errorf("error parsing split-definition arguments: %w", err)
+	}
+
+	def, err := m.splitSDK.SplitDefinition(args.Name)
+	if err != nil {
+		if errors.Is(err, sdk.ErrSplitNotFound) {
+			return &protov1.ResponseWrapper[protov1.SplitDefinitionPayload]{Status: protov1.ResultOk}, nil
+		}
+		return &protov1.ResponseWrapper[protov1.SplitDefinitionPayload]{Status: protov1.ResultInternalError}, err
+	}
+
+	return &protov1.ResponseWrapper[protov1.SplitDefinitionPayload]{
+		Status:  protov1.ResultOk,
+		Payload: protov1.SplitDefinitionPayload{Definition: def},
+	}, nil
+}
+
+func (m *ClientManager) handleSegmentNames(rpc *protov1.RPC) (interface{}, error) {
+
+	var args protov1.SegmentNamesArgs
+	if err := args.PopulateFromRPC(rpc); err != nil {
+		return nil, fmt.Errorf("error parsing segment-names arguments: %w", err)
+	}
+
+	names, err := m.splitSDK.SegmentNames()
+	if err != nil {
+		return &protov1.ResponseWrapper[protov1.SegmentNamesPayload]{Status: protov1.ResultInternalError}, err
+	}
+
+	return &protov1.ResponseWrapper[protov1.SegmentNamesPayload]{
+		Status:  protov1.ResultOk,
+		Payload: protov1.SegmentNamesPayload{Names: names},
+	}, nil
+}
+
+func (m *ClientManager) handleSegment(rpc *protov1.RPC) (interface{}, error) {
+
+	var args protov1.SegmentArgs
+	if err := args.PopulateFromRPC(rpc); err != nil {
+		return nil, fmt.Errorf("error parsing segment arguments: %w", err)
+	}
+
+	view, err := m.splitSDK.Segment(args.Name)
+	if err != nil {
+		if errors.Is(err, sdk.ErrSegmentNotFound) {
+			return &protov1.ResponseWrapper[protov1.SegmentPayload]{Status: protov1.ResultOk}, nil
+		}
+		return &protov1.ResponseWrapper[protov1.SegmentPayload]{Status: protov1.ResultInternalError}, err
+	}
+
+	return &protov1.ResponseWrapper[protov1.SegmentPayload]{
+		Status:  protov1.ResultOk,
+		Payload: protov1.SegmentPayload(*view),
+	}, nil
+}
+
+func (m *ClientManager) handleSegmentContainsKey(rpc *protov1.RPC) (interface{}, error) {
+
+	var args protov1.SegmentContainsKeyArgs
+	if err := args.PopulateFromRPC(rpc); err != nil {
+		return nil, fmt.Errorf("error parsing segment-contains-key arguments: %w", err)
+	}
+
+	contains, err := m.splitSDK.SegmentContainsKey(args.Name, args.Key)
+	if err != nil {
+		if errors.Is(err, sdk.ErrSegmentNotFound) {
+			return &protov1.ResponseWrapper[protov1.SegmentContainsKeyPayload]{Status: protov1.ResultOk}, nil
+		}
+		return &protov1.ResponseWrapper[protov1.SegmentContainsKeyPayload]{Status: protov1.ResultInternalError}, err
+	}
+
+	return &protov1.ResponseWrapper[protov1.SegmentContainsKeyPayload]{
+		Status:  protov1.ResultOk,
+		Payload: protov1.SegmentContainsKeyPayload{Exists: true, Contains: contains},
+	}, nil
+}
+
+func (m *ClientManager) handleRuleBasedSegmentNames(rpc *protov1.RPC) (interface{}, error) {
+
+	var args protov1.RuleBasedSegmentNamesArgs
+	if err := args.PopulateFromRPC(rpc); err != nil {
+		return nil, fmt.Errorf("error parsing rule-based-segment-names arguments: %w", err)
+	}
+
+	names, err := m.splitSDK.RuleBasedSegmentNames()
+	if err != nil {
+		return &protov1.ResponseWrapper[protov1.RuleBasedSegmentNamesPayload]{Status: protov1.ResultInternalError}, err
+	}
+
+	return &protov1.ResponseWrapper[protov1.RuleBasedSegmentNamesPayload]{
+		Status:  protov1.ResultOk,
+		Payload: protov1.RuleBasedSegmentNamesPayload{Names: names},
+	}, nil
+}
+
+func (m *ClientManager) handleRuleBasedSegment(rpc *protov1.RPC) (interface{}, error) {
+
+	var args protov1.RuleBasedSegmentArgs
+	if err := args.PopulateFromRPC(rpc); err != nil {
+		return nil, fmt.Errorf("error parsing rule-based-segment arguments: %w", err)
+	}
+
+	def, err := m.splitSDK.RuleBasedSegment(args.Name)
+	if err != nil {
+		if errors.Is(err, sdk.ErrRuleBasedSegmentNotFound) {
+			return &protov1.ResponseWrapper[protov1.RuleBasedSegmentPayload]{Status: protov1.ResultOk}, nil
+		}
+		return &protov1.ResponseWrapper[protov1.RuleBasedSegmentPayload]{Status: protov1.ResultInternalError}, err
+	}
+
+	return &protov1.ResponseWrapper[protov1.RuleBasedSegmentPayload]{
+		Status:  protov1.ResultOk,
+		Payload: protov1.RuleBasedSegmentPayload{Definition: def},
+	}, nil
+}
+
+// requiredScope returns the scope a client's token must have in order to perform an operation
+func requiredScope(oc protov1.OpCode) auth.Scope {
+	switch oc {
+	case protov1.OCTreatment, protov1.OCTreatments, protov1.OCTreatmentWithConfig, protov1.OCTreatmentsWithConfig,
+		protov1.OCTreatmentsByFlagSet, protov1.OCTreatmentsWithConfigByFlagSet,
+		protov1.OCTreatmentsByFlagSets, protov1.OCTreatmentsWithConfigByFlagSets:
+		return auth.ScopeEvaluate
+	case protov1.OCTrack:
+		return auth.ScopeTrack
+	case protov1.OCSplitNames, protov1.OCSplit, protov1.OCSplits, protov1.OCSplitDefinition,
+		protov1.OCSegmentNames, protov1.OCSegment, protov1.OCSegmentContainsKey,
+		protov1.OCRuleBasedSegmentNames, protov1.OCRuleBasedSegment:
+		return auth.ScopeInspectSplits
+	}
+	return 0 // register, ready, bind-attributes, ping & unknown opcodes
+}
+
+func toWarningsPayload(warnings []sdk.ValidationWarning) []protov1.ValidationWarning {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	toRet := make([]protov1.ValidationWarning, 0, len(warnings))
+	for _, w := range warnings {
+		toRet = append(toRet, protov1.ValidationWarning{Code: uint8(w.Code), Message: w.Message})
+	}
+	return toRet
+}
+
 func formatClientConfig(c *types.ClientConfig) string {
 	if c == nil {
 		return "<nil>"
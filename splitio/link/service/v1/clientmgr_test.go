@@ -3,14 +3,18 @@ package v1
 import (
 	"errors"
 	"io"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/splitd/splitio/common/lang"
+	"github.com/splitio/splitd/splitio/link/auth"
 	"github.com/splitio/splitd/splitio/link/protocol"
 	v1 "github.com/splitio/splitd/splitio/link/protocol/v1"
 	proto1Mocks "github.com/splitio/splitd/splitio/link/protocol/v1/mocks"
+	"github.com/splitio/splitd/splitio/link/serializer"
 	serializerMocks "github.com/splitio/splitd/splitio/link/serializer/mocks"
 	transferMocks "github.com/splitio/splitd/splitio/link/transfer/mocks"
 	"github.com/splitio/splitd/splitio/sdk"
@@ -463,7 +467,7 @@ func TestTrack(t *testing.T) {
 		On("Track",
 			&types.ClientConfig{Metadata: types.ClientMetadata{ID: "someID", SdkVersion: "some_sdk-1.2.3"}},
 			"key1", "user", "checkin", lang.Ref(float64(2.75)), map[string]interface{}{"a": 1}).
-		Return((error)(nil)).Once()
+		Return([]sdk.ValidationWarning(nil), (error)(nil)).Once()
 
 	logger := logging.NewLogger(nil)
 	cm := NewClientManager(rawConnMock, logger, sdkMock, serializerMock)
@@ -471,6 +475,69 @@ func TestTrack(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestTrackValidation(t *testing.T) {
+	sdkMock := &sdkMocks.SDKMock{}
+	cm := NewClientManager(nil, logging.NewLogger(nil), sdkMock, nil)
+	_, err := cm.dispatchRPC(&v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCRegister, Args: []interface{}{"someID", "some_sdk-1.2.3", uint64(0)}})
+	assert.Nil(t, err)
+
+	warnings := []sdk.ValidationWarning{{Code: sdk.WarningTrafficTypeLowercased, Message: "lowercased"}}
+	sdkMock.On("Track", mock.Anything, "key1", "User", "checkin", (*float64)(nil), map[string]interface{}(nil)).Return(warnings, nil).Once()
+	res, err := cm.dispatchRPC(proto1Mocks.NewTrackRPC("key1", "User", "checkin", nil, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, &v1.ResponseWrapper[v1.TrackPayload]{
+		Status:  v1.ResultOk,
+		Payload: v1.TrackPayload{Success: true, Warnings: []v1.ValidationWarning{{Code: uint8(sdk.WarningTrafficTypeLowercased), Message: "lowercased"}}},
+	}, res)
+
+	sdkMock.On("Track", mock.Anything, "key1", "user", "check in", (*float64)(nil), map[string]interface{}(nil)).
+		Return([]sdk.ValidationWarning(nil), sdk.ErrInvalidEventType).Once()
+	res, err = cm.dispatchRPC(proto1Mocks.NewTrackRPC("key1", "user", "check in", nil, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, &v1.ResponseWrapper[v1.TrackPayload]{
+		Status:  v1.ResultOk,
+		Payload: v1.TrackPayload{Success: false, Error: sdk.ErrInvalidEventType.Error()},
+	}, res)
+
+	sdkMock.On("Track", mock.Anything, "key1", "user", "checkin", (*float64)(nil), map[string]interface{}(nil)).
+		Return([]sdk.ValidationWarning(nil), errors.New("something")).Once()
+	res, err = cm.dispatchRPC(proto1Mocks.NewTrackRPC("key1", "user", "checkin", nil, nil))
+	assert.NotNil(t, err)
+	assert.Equal(t, &v1.ResponseWrapper[v1.TrackPayload]{Status: v1.ResultInternalError}, res)
+	sdkMock.AssertExpectations(t)
+}
+
+func TestTreatmentsValidationWarnings(t *testing.T) {
+	sdkMock := &sdkMocks.SDKMock{}
+	cm := NewClientManager(nil, logging.NewLogger(nil), sdkMock, nil)
+	_, err := cm.dispatchRPC(&v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCRegister, Args: []interface{}{"someID", "some_sdk-1.2.3", uint64(0)}})
+	assert.Nil(t, err)
+
+	sdkMock.On("Treatments", mock.Anything, "key", (*string)(nil), []string{" f1", "missing"}, map[string]interface{}(nil)).
+		Return(map[string]sdk.EvaluationResult{
+			" f1": {Treatment: "on", Warnings: []sdk.ValidationWarning{{Code: sdk.WarningFlagNameTrimmed, Message: "trimmed"}}},
+			"missing": {
+				Treatment: "control",
+				Label:     sdk.LabelFlagNotFound,
+				Warnings:  []sdk.ValidationWarning{{Code: sdk.WarningFlagNotFound, Message: "not found"}},
+			},
+		}, nil).Once()
+	res, err := cm.dispatchRPC(&v1.RPC{
+		RPCBase: protocol.RPCBase{Version: protocol.V1},
+		OpCode:  v1.OCTreatments,
+		Args:    []interface{}{"key", nil, []interface{}{" f1", "missing"}, map[string]interface{}(nil)},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, &v1.ResponseWrapper[v1.TreatmentsPayload]{
+		Status: v1.ResultOk,
+		Payload: v1.TreatmentsPayload{Results: []v1.TreatmentPayload{
+			{Treatment: "on", Warnings: []v1.ValidationWarning{{Code: uint8(sdk.WarningFlagNameTrimmed), Message: "trimmed"}}},
+			{Treatment: "control", Label: sdk.LabelFlagNotFound, Warnings: []v1.ValidationWarning{{Code: uint8(sdk.WarningFlagNotFound), Message: "not found"}}},
+		}},
+	}, res)
+	sdkMock.AssertExpectations(t)
+}
+
 func TestSplitNames(t *testing.T) {
 	rawConnMock := &transferMocks.RawConnMock{}
 	rawConnMock.On("ReceiveMessage").Return([]byte("registrationMessage"), nil).Once()
@@ -621,6 +688,133 @@ func TestSplitNotFound(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestSplitDefinition(t *testing.T) {
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationMessage"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("successRegistration")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("splitDefinition"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("successPayload")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte(nil), io.EOF).Once()
+
+	def := &dtos.SplitDTO{Name: "s1", TrafficTypeName: "tt1", ChangeNumber: 1, DefaultTreatment: "off"}
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Parse", []byte("registrationMessage"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.RPC) = v1.RPC{
+			RPCBase: protocol.RPCBase{Version: protocol.V1},
+			OpCode:  v1.OCRegister,
+			Args:    []interface{}{"someID", "some_sdk-1.2.3", uint64(0)},
+		}
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewRegisterResp(true)).Return([]byte("successRegistration"), nil).Once()
+	serializerMock.On("Parse", []byte("splitDefinition"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.RPC) = *proto1Mocks.NewSplitDefinitionRPC("s1")
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewSplitDefinitionResp(true, def)).Return([]byte("successPayload"), nil).Once()
+
+	sdkMock := &sdkMocks.SDKMock{}
+	sdkMock.On("SplitDefinition", "s1").Return(def, (error)(nil)).Once()
+
+	logger := logging.NewLogger(nil)
+	cm := NewClientManager(rawConnMock, logger, sdkMock, serializerMock)
+	err := cm.handleClientInteractions()
+	assert.Nil(t, err)
+}
+
+func TestSegmentInspection(t *testing.T) {
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationMessage"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("successRegistration")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("segmentNames"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("segmentNamesPayload")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("segment"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("segmentPayload")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("containsKey"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("containsKeyPayload")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("containsKeyMissing"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("containsKeyMissingPayload")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte(nil), io.EOF).Once()
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Parse", []byte("registrationMessage"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.RPC) = v1.RPC{
+			RPCBase: protocol.RPCBase{Version: protocol.V1},
+			OpCode:  v1.OCRegister,
+			Args:    []interface{}{"someID", "some_sdk-1.2.3", uint64(0)},
+		}
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewRegisterResp(true)).Return([]byte("successRegistration"), nil).Once()
+	serializerMock.On("Parse", []byte("segmentNames"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.RPC) = *proto1Mocks.NewSegmentNamesRPC()
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewSegmentNamesResp(true, []string{"seg1", "seg2"})).
+		Return([]byte("segmentNamesPayload"), nil).Once()
+	serializerMock.On("Parse", []byte("segment"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.RPC) = *proto1Mocks.NewSegmentRPC("seg1")
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewSegmentResp(true, v1.SegmentPayload{Name: "seg1", KeyCount: 3, ChangeNumber: 123})).
+		Return([]byte("segmentPayload"), nil).Once()
+	serializerMock.On("Parse", []byte("containsKey"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.RPC) = *proto1Mocks.NewSegmentContainsKeyRPC("seg1", "key1")
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewSegmentContainsKeyResp(true, true, true)).
+		Return([]byte("containsKeyPayload"), nil).Once()
+	serializerMock.On("Parse", []byte("containsKeyMissing"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.RPC) = *proto1Mocks.NewSegmentContainsKeyRPC("seg3", "key1")
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewSegmentContainsKeyResp(true, false, false)).
+		Return([]byte("containsKeyMissingPayload"), nil).Once()
+
+	sdkMock := &sdkMocks.SDKMock{}
+	sdkMock.On("SegmentNames").Return([]string{"seg1", "seg2"}, (error)(nil)).Once()
+	sdkMock.On("Segment", "seg1").Return(&sdk.SegmentView{Name: "seg1", KeyCount: 3, ChangeNumber: 123}, (error)(nil)).Once()
+	sdkMock.On("SegmentContainsKey", "seg1", "key1").Return(true, (error)(nil)).Once()
+	sdkMock.On("SegmentContainsKey", "seg3", "key1").Return(false, sdk.ErrSegmentNotFound).Once()
+
+	logger := logging.NewLogger(nil)
+	cm := NewClientManager(rawConnMock, logger, sdkMock, serializerMock)
+	err := cm.handleClientInteractions()
+	assert.Nil(t, err)
+	sdkMock.AssertExpectations(t)
+}
+
+func TestRegisterBlockingUntilReadyAndReady(t *testing.T) {
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("ReceiveMessage").Return([]byte("registrationMessage"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("successRegistration")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("ready"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("readyPayload")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte(nil), io.EOF).Once()
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Parse", []byte("registrationMessage"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.RPC) = v1.RPC{
+			RPCBase: protocol.RPCBase{Version: protocol.V1},
+			OpCode:  v1.OCRegister,
+			Args:    []interface{}{"someID", "some_sdk-1.2.3", uint64(v1.RegisterFlagBlockUntilReady), int64(2000)},
+		}
+	}).Once()
+	serializerMock.On("Serialize", &v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultOk, Payload: v1.RegisterPayload{Ready: false}}).
+		Return([]byte("successRegistration"), nil).Once()
+	serializerMock.On("Parse", []byte("ready"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.RPC) = *proto1Mocks.NewReadyRPC(500)
+	}).Once()
+	serializerMock.On("Serialize", proto1Mocks.NewReadyResp(true, true, byte(sdk.StateReadyDegraded))).
+		Return([]byte("readyPayload"), nil).Once()
+
+	sdkMock := &sdkMocks.SDKMock{}
+	sdkMock.On("BlockUntilReady", 2*time.Second).Return(sdk.ErrNotReady).Once()
+	sdkMock.On("BlockUntilReady", 500*time.Millisecond).Return(nil).Once()
+	sdkMock.On("Readiness").Return(sdk.StateReadyDegraded).Once()
+	sdkMock.On("Staleness").Return(sdk.Staleness{}).Once()
+
+	logger := logging.NewLogger(nil)
+	cm := NewClientManager(rawConnMock, logger, sdkMock, serializerMock)
+	err := cm.handleClientInteractions()
+	assert.Nil(t, err)
+	sdkMock.AssertExpectations(t)
+}
+
 func TestTreatmentWithoutRegister(t *testing.T) {
 	rawConnMock := &transferMocks.RawConnMock{}
 	rawConnMock.On("ReceiveMessage").Return([]byte("treatmentMessage"), nil).Once()
@@ -742,6 +936,86 @@ func TestHandleRPCErrors(t *testing.T) {
 	assert.ErrorContains(t, err, "error parsing treatments arguments")
 }
 
+func TestRegisterEnvironmentRouting(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	defaultMock := &sdkMocks.SDKMock{}
+	stagingMock := &sdkMocks.SDKMock{}
+	envs := sdk.Environments{sdk.DefaultEnvironment: defaultMock, "staging": stagingMock}
+	expectedCfg := &types.ClientConfig{Metadata: types.ClientMetadata{ID: "someID", SdkVersion: "some_sdk-1.2.3"}}
+	stagingMock.On("Treatment", expectedCfg, "key", (*string)(nil), "someFeature", map[string]interface{}(nil)).
+		Return(&sdk.EvaluationResult{Treatment: "on"}, nil).Twice()
+	treatmentRPC := &v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCTreatment, Args: []interface{}{"key", nil, "someFeature", nil}}
+
+	// environment requested by the client
+	cm := NewMultiEnvClientManager(nil, logger, envs, sdk.DefaultEnvironment, nil, nil, ConnectionPolicy{})
+	res, err := cm.dispatchRPC(&v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCRegister, Args: []interface{}{"someID", "some_sdk-1.2.3", uint64(0), int64(0), "staging"}})
+	assert.Nil(t, err)
+	assert.Equal(t, proto1Mocks.NewRegisterResp(true), res)
+	res, err = cm.dispatchRPC(treatmentRPC)
+	assert.Nil(t, err)
+	assert.Equal(t, proto1Mocks.NewTreatmentResp(true, "on", nil), res)
+
+	// environment taken from the listener's default
+	cm = NewMultiEnvClientManager(nil, logger, envs, "staging", nil, nil, ConnectionPolicy{})
+	_, err = cm.dispatchRPC(&v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCRegister, Args: []interface{}{"someID", "some_sdk-1.2.3", uint64(0)}})
+	assert.Nil(t, err)
+	res, err = cm.dispatchRPC(treatmentRPC)
+	assert.Nil(t, err)
+	assert.Equal(t, proto1Mocks.NewTreatmentResp(true, "on", nil), res)
+
+	// unknown environment
+	cm = NewMultiEnvClientManager(nil, logger, envs, sdk.DefaultEnvironment, nil, nil, ConnectionPolicy{})
+	res, err = cm.dispatchRPC(&v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCRegister, Args: []interface{}{"someID", "some_sdk-1.2.3", uint64(0), int64(0), "production"}})
+	assert.Nil(t, err)
+	assert.Equal(t, &v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultUnknownEnvironment}, res)
+	_, err = cm.dispatchRPC(treatmentRPC)
+	assert.ErrorContains(t, err, "first call must be 'register'")
+
+	stagingMock.AssertExpectations(t)
+	defaultMock.AssertExpectations(t)
+}
+
+func TestRegisterImpressionOverrides(t *testing.T) {
+	cm := NewClientManager(nil, logging.NewLogger(nil), &sdkMocks.SDKMock{}, nil)
+	flags := uint64(v1.RegisterFlagImpressionsModeDebug | v1.RegisterFlagLabelsDisabled)
+	res, err := cm.dispatchRPC(&v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCRegister, Args: []interface{}{"someID", "some_sdk-1.2.3", flags}})
+	assert.Nil(t, err)
+	assert.Equal(t, proto1Mocks.NewRegisterResp(true), res)
+	assert.Equal(t, &types.ClientConfig{
+		Metadata:        types.ClientMetadata{ID: "someID", SdkVersion: "some_sdk-1.2.3"},
+		ImpressionsMode: "debug",
+		LabelsEnabled:   lang.Ref(false),
+	}, cm.clientConfig)
+}
+
+func TestBindAttributes(t *testing.T) {
+	sdkMock := &sdkMocks.SDKMock{}
+	cm := NewClientManager(nil, logging.NewLogger(nil), sdkMock, nil)
+	_, err := cm.dispatchRPC(&v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCRegister, Args: []interface{}{"someID", "some_sdk-1.2.3", uint64(0)}})
+	assert.Nil(t, err)
+
+	attrs := map[string]interface{}{"region": "us-east-1", "build": int64(12)}
+	res, err := cm.dispatchRPC(&v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCBindAttributes, Args: []interface{}{attrs}})
+	assert.Nil(t, err)
+	assert.Equal(t, &v1.ResponseWrapper[v1.BindAttributesPayload]{Status: v1.ResultOk, Payload: v1.BindAttributesPayload{Bound: 2}}, res)
+
+	sdkMock.
+		On("Treatment", &types.ClientConfig{Metadata: types.ClientMetadata{ID: "someID", SdkVersion: "some_sdk-1.2.3"}, Attributes: attrs}, "key", (*string)(nil), "someFeature", map[string]interface{}(nil)).
+		Return(&sdk.EvaluationResult{Treatment: "on"}, nil).Once()
+	_, err = cm.dispatchRPC(&v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCTreatment, Args: []interface{}{"key", nil, "someFeature", nil}})
+	assert.Nil(t, err)
+
+	// binding again replaces the previous set
+	res, err = cm.dispatchRPC(&v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCBindAttributes, Args: []interface{}{nil}})
+	assert.Nil(t, err)
+	assert.Equal(t, &v1.ResponseWrapper[v1.BindAttributesPayload]{Status: v1.ResultOk}, res)
+	assert.Nil(t, cm.clientConfig.Attributes)
+
+	_, err = cm.dispatchRPC(&v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCBindAttributes, Args: []interface{}{"nope"}})
+	assert.NotNil(t, err)
+	sdkMock.AssertExpectations(t)
+}
+
 type loggerMock struct{ mock.Mock }
 
 func (m *loggerMock) Debug(msg ...interface{})   { m.Called(msg...) }
@@ -755,3 +1029,165 @@ func ref[T any](t T) *T {
 }
 
 var _ logging.LoggerInterface = (*loggerMock)(nil)
+
+func TestRegisterAuthentication(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	sdkMock := &sdkMocks.SDKMock{}
+	envs := sdk.SingleEnvironment(sdkMock)
+	authn, err := auth.NewAuthenticator([]auth.Token{
+		{Name: "evaluator", Secret: "evalSecret", Scope: auth.ScopeEvaluate},
+		{Name: "full", Secret: "fullSecret", Scope: auth.ScopeAll},
+	})
+	assert.Nil(t, err)
+
+	registerRPC := func(token string) *v1.RPC {
+		return &v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCRegister, Args: []interface{}{"someID", "some_sdk-1.2.3", uint64(0), int64(0), "", token}}
+	}
+	treatmentRPC := &v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCTreatment, Args: []interface{}{"key", nil, "someFeature", nil}}
+	sdkMock.On("Treatment", mock.Anything, "key", (*string)(nil), "someFeature", map[string]interface{}(nil)).
+		Return(&sdk.EvaluationResult{Treatment: "on"}, nil).Twice()
+	sdkMock.On("SplitNames").Return([]string{"someFeature"}, nil).Once()
+
+	// missing/wrong token -> unauthorized & client remains unregistered
+	cm := NewMultiEnvClientManager(nil, logger, envs, sdk.DefaultEnvironment, nil, authn, ConnectionPolicy{})
+	res, err := cm.dispatchRPC(&v1.RPC{RPCBase: protocol.RPCBase{Version: protocol.V1}, OpCode: v1.OCRegister, Args: []interface{}{"someID", "some_sdk-1.2.3", uint64(0)}})
+	assert.Nil(t, err)
+	assert.Equal(t, &v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultUnauthorized}, res)
+	res, err = cm.dispatchRPC(registerRPC("wrong"))
+	assert.Nil(t, err)
+	assert.Equal(t, &v1.ResponseWrapper[v1.RegisterPayload]{Status: v1.ResultUnauthorized}, res)
+	_, err = cm.dispatchRPC(treatmentRPC)
+	assert.NotNil(t, err)
+
+	// evaluate-only token
+	res, err = cm.dispatchRPC(registerRPC("evalSecret"))
+	assert.Nil(t, err)
+	assert.Equal(t, proto1Mocks.NewRegisterResp(true), res)
+	res, err = cm.dispatchRPC(treatmentRPC)
+	assert.Nil(t, err)
+	assert.Equal(t, v1.ResultOk, res.(*v1.ResponseWrapper[v1.TreatmentPayload]).Status)
+	res, err = cm.dispatchRPC(proto1Mocks.NewTrackRPC("key", "user", "checkin", nil, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, &v1.ResponseWrapper[v1.EmptyPayload]{Status: v1.ResultUnauthorized}, res)
+	res, err = cm.dispatchRPC(proto1Mocks.NewSplitNamesRPC())
+	assert.Nil(t, err)
+	assert.Equal(t, &v1.ResponseWrapper[v1.EmptyPayload]{Status: v1.ResultUnauthorized}, res)
+
+	// full access token
+	cm = NewMultiEnvClientManager(nil, logger, envs, sdk.DefaultEnvironment, nil, authn, ConnectionPolicy{})
+	_, err = cm.dispatchRPC(registerRPC("fullSecret"))
+	assert.Nil(t, err)
+	res, err = cm.dispatchRPC(treatmentRPC)
+	assert.Nil(t, err)
+	assert.Equal(t, v1.ResultOk, res.(*v1.ResponseWrapper[v1.TreatmentPayload]).Status)
+	res, err = cm.dispatchRPC(proto1Mocks.NewSplitNamesRPC())
+	assert.Nil(t, err)
+	assert.Equal(t, v1.ResultOk, res.(*v1.ResponseWrapper[v1.SplitNamesPayload]).Status)
+
+	sdkMock.AssertExpectations(t)
+}
+
+func TestConnectionMaxLifetime(t *testing.T) {
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("ReceiveMessage").Return([]byte("ping1"), nil).Once()
+	rawConnMock.On("SendMessage", []byte("pong")).Return(nil).Once()
+	rawConnMock.On("ReceiveMessage").Return([]byte("ping2"), nil).After(100 * time.Millisecond).Once()
+	rawConnMock.On("SendMessage", []byte("pongAndReconnect")).Return(nil).Once()
+	// no more reads, the connection is closed right after responding
+
+	serializerMock := &serializerMocks.SerializerMock{}
+	serializerMock.On("Parse", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*v1.RPC) = *proto1Mocks.NewPingRPC()
+	})
+	serializerMock.On("Serialize", proto1Mocks.NewPingResp(true)).Return([]byte("pong"), nil).Once()
+	withReconnect := proto1Mocks.NewPingResp(true)
+	withReconnect.Reconnect = true
+	serializerMock.On("Serialize", withReconnect).Return([]byte("pongAndReconnect"), nil).Once()
+
+	logger := logging.NewLogger(nil)
+	cm := NewClientManager(rawConnMock, logger, &sdkMocks.SDKMock{}, serializerMock)
+	cm.clientConfig = &types.ClientConfig{}
+	cm.policy = ConnectionPolicy{MaxLifetime: 50 * time.Millisecond}
+	assert.Nil(t, cm.handleClientInteractions())
+	rawConnMock.AssertExpectations(t)
+	serializerMock.AssertExpectations(t)
+}
+
+func TestConnectionIdleTimeout(t *testing.T) {
+	rawConnMock := &transferMocks.RawConnMock{}
+	rawConnMock.On("ReceiveMessage").Return([]byte(nil), os.ErrDeadlineExceeded).After(20 * time.Millisecond)
+
+	logger := logging.NewLogger(nil)
+	cm := NewClientManager(rawConnMock, logger, &sdkMocks.SDKMock{}, &serializerMocks.SerializerMock{})
+	cm.policy = ConnectionPolicy{IdleTimeout: 50 * time.Millisecond}
+
+	before := time.Now()
+	assert.Nil(t, cm.handleClientInteractions())
+	assert.GreaterOrEqual(t, time.Since(before), 50*time.Millisecond)
+	assert.Less(t, time.Since(before), time.Second)
+}
+
+func TestConnectionPolicy(t *testing.T) {
+	now := time.Now()
+	var disabled ConnectionPolicy
+	assert.False(t, disabled.idleExpired(now.Add(-time.Hour), now))
+	assert.False(t, disabled.lifetimeExpired(now.Add(-time.Hour), now))
+
+	p := ConnectionPolicy{IdleTimeout: time.Minute, MaxLifetime: time.Hour}
+	assert.False(t, p.idleExpired(now.Add(-30*time.Second), now))
+	assert.True(t, p.idleExpired(now.Add(-time.Minute), now))
+	assert.False(t, p.lifetimeExpired(now.Add(-30*time.Minute), now))
+	assert.True(t, p.lifetimeExpired(now.Add(-2*time.Hour), now))
+}
+
+// replayConn feeds a registration followed by `count` copies of an rpc to the client manager, discarding responses
+type replayConn struct {
+	registration []byte
+	rpc          []byte
+	count        int
+	registered   bool
+}
+
+func (c *replayConn) ReceiveMessage() ([]byte, error) {
+	if !c.registered {
+		c.registered = true
+		return c.registration, nil
+	}
+	if c.count == 0 {
+		return nil, io.EOF
+	}
+	c.count--
+	return c.rpc, nil
+}
+
+func (c *replayConn) SendMessage([]byte) error { return nil }
+func (c *replayConn) Shutdown() error          { return nil }
+
+// evaluatorSDK returns a fixed result for every evaluation, without recording calls like mocks do
+type evaluatorSDK struct {
+	sdk.Interface
+	result sdk.EvaluationResult
+}
+
+func (s *evaluatorSDK) Treatment(*types.ClientConfig, string, *string, string, map[string]interface{}, *dtos.EvaluationOptions) (*sdk.EvaluationResult, error) {
+	return &s.result, nil
+}
+
+func BenchmarkTreatmentRPC(b *testing.B) {
+	s, err := serializer.Setup(serializer.MsgPack)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	registration, _ := s.Serialize(proto1Mocks.NewRegisterRPC("some", false))
+	rpc, _ := s.Serialize(proto1Mocks.NewTreatmentRPC("key1", "", "feat1", map[string]interface{}{"age": 30, "country": "ar"}, nil, false))
+	conn := &replayConn{registration: registration, rpc: rpc, count: b.N}
+	logger := logging.NewLogger(&logging.LoggerOptions{LogLevel: logging.LevelError})
+	cm := NewClientManager(conn, logger, &evaluatorSDK{result: sdk.EvaluationResult{Treatment: "on"}}, s)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	if err := cm.handleClientInteractions(); err != nil {
+		b.Fatal(err)
+	}
+}
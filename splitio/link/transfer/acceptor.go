@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"os"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -30,13 +31,25 @@ type Acceptor struct {
 	maxConns       int
 	sem            *semaphore.Weighted
 	maxWait        time.Duration
+	permissions    SocketPermissions
+	authorization  PeerAuthorization
+	inherited      net.Listener // pre-opened listener (ie: systemd socket activation)
+	draining       atomic.Bool
+	active         sync.Map // connections currently being served
 }
 
-var errNoSetDeadline = errors.New("listener doesn't support setting a deadline")
+var (
+	errNoSetDeadline = errors.New("listener doesn't support setting a deadline")
+
+	ErrDrainTimeout         = errors.New("timed out waiting for active connections to finish")
+	ErrListenerNotShareable = errors.New("listener cannot be handed over to another process")
+)
 
 type AcceptorConfig struct {
 	AcceptTimeout              time.Duration
 	MaxSimultaneousConnections int
+	SocketPermissions          SocketPermissions
+	PeerAuthorization          PeerAuthorization
 }
 
 func DefaultAcceptorConfig() AcceptorConfig {
@@ -54,13 +67,15 @@ func newAcceptor(address net.Addr, rawConnFactory RawConnFactory, logger logging
 		maxConns:       cfg.MaxSimultaneousConnections,
 		sem:            semaphore.NewWeighted(int64(cfg.MaxSimultaneousConnections)),
 		maxWait:        cfg.AcceptTimeout,
+		permissions:    cfg.SocketPermissions,
+		authorization:  cfg.PeerAuthorization,
 	}
 }
 
 func (a *Acceptor) Start(onClientAttachedCallback OnClientAttachedCallback) (<-chan error, error) {
-	l, err := net.Listen(a.address.Network(), a.address.String())
+	l, err := a.listen()
 	if err != nil {
-		return nil, fmt.Errorf("error listening on provided address: %w", err)
+		return nil, err
 	}
 	a.listener.Store(l)
 
@@ -92,6 +107,13 @@ func (a *Acceptor) Start(onClientAttachedCallback OnClientAttachedCallback) (<-c
 				return
 			}
 
+			peer, err := a.checkPeer(conn)
+			if err != nil {
+				a.logger.Warning(fmt.Sprintf("rejecting incoming connection: %s", err))
+				conn.Close()
+				continue
+			}
+
 			// try to acquire a semaphore slot (throughput limiting):
 			// to avoid leaks, the lifetime of the context/deadline is scoped to a func containing a defer statement
 			err = func() error {
@@ -107,15 +129,57 @@ func (a *Acceptor) Start(onClientAttachedCallback OnClientAttachedCallback) (<-c
 			}
 
 			go func(rc RawConn) {
+				a.active.Store(rc, struct{}{})
 				onClientAttachedCallback(rc)
 				rc.Shutdown()
+				a.active.Delete(rc)
 				a.sem.Release(1)
-			}(a.rawConnFactory(conn))
+			}(a.wrap(conn, peer))
 		}
 	}()
 	return ret, nil
 }
 
+// listen returns the inherited listener if there's one, or creates a new one otherwise
+func (a *Acceptor) listen() (net.Listener, error) {
+	if a.inherited != nil {
+		// socket file & permissions are managed by whoever created the socket
+		a.logger.Info(fmt.Sprintf("using inherited listener for %s", a.address))
+		return a.inherited, nil
+	}
+
+	if isUnixSocket(a.address) && a.permissions.configured() {
+		return listenWithPermissions(a.address, a.permissions)
+	}
+
+	l, err := net.Listen(a.address.Network(), a.address.String())
+	if err != nil {
+		return nil, fmt.Errorf("error listening on provided address: %w", err)
+	}
+	return l, nil
+}
+
+// checkPeer fetches the credentials of the connecting process & verifies it's allowed to connect
+func (a *Acceptor) checkPeer(conn net.Conn) (*PeerCredentials, error) {
+	if !isUnixSocket(a.address) {
+		return nil, a.authorization.authorize(nil)
+	}
+
+	peer, err := peerCredentials(conn)
+	if err != nil {
+		a.logger.Debug(fmt.Sprintf("could not fetch peer credentials: %s", err))
+	}
+	return peer, a.authorization.authorize(peer)
+}
+
+func (a *Acceptor) wrap(conn net.Conn, peer *PeerCredentials) RawConn {
+	var rc RawConn = &drainableConn{RawConn: a.rawConnFactory(conn), draining: &a.draining}
+	if peer == nil {
+		return rc
+	}
+	return &connWithPeer{RawConn: rc, peer: peer}
+}
+
 func (a *Acceptor) Shutdown() error {
 	listener, ok := a.listener.Load().(net.Listener)
 	if !ok {
@@ -123,13 +187,66 @@ func (a *Acceptor) Shutdown() error {
 	}
 
 	err := listener.Close()
-	if err != nil {
+	if err != nil && !errors.Is(err, net.ErrClosed) { // already closed when draining
 		return fmt.Errorf("error shutting down listener: %w", err)
 	}
 
 	return nil
 }
 
+// File returns a duplicate of the listening socket's descriptor, to be handed over to another process.
+// Both unix & tcp listeners can be handed over
+func (a *Acceptor) File() (*os.File, error) {
+	listener, ok := a.listener.Load().(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, ErrListenerNotShareable
+	}
+	return listener.File()
+}
+
+// Drain stops accepting connections without removing the socket file (so that another process can keep serving it),
+// and waits up to `timeout` for the active connections to finish. Handlers are told to end their connections as soon
+// as they're idle (in between RPCs), and the ones still active when the timeout expires are forcibly closed
+func (a *Acceptor) Drain(timeout time.Duration) error {
+	a.draining.Store(true)
+	if listener, ok := a.listener.Load().(*net.UnixListener); ok {
+		listener.SetUnlinkOnClose(false)
+	}
+
+	if err := a.Shutdown(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := a.sem.Acquire(ctx, int64(a.maxConns)); err == nil { // every slot is free -> no active connections
+		a.sem.Release(int64(a.maxConns))
+		return nil
+	}
+
+	var forced int
+	a.active.Range(func(key, _ any) bool {
+		key.(RawConn).Shutdown()
+		forced++
+		return true
+	})
+	return fmt.Errorf("%w: %d connection(s) forcibly closed", ErrDrainTimeout, forced)
+}
+
+// drainableConn ends the interaction with the client when the acceptor is being drained: the next read
+// (which only happens in between RPCs) reports the connection as closed
+type drainableConn struct {
+	RawConn
+	draining *atomic.Bool
+}
+
+func (c *drainableConn) ReceiveMessage() ([]byte, error) {
+	if c.draining.Load() {
+		return nil, io.EOF
+	}
+	return c.RawConn.ReceiveMessage()
+}
+
 // -- small helper & interface to uniformly set deadlines on different types of sockets
 // @{
 
@@ -142,4 +259,8 @@ func setDeadline(listener net.Listener, deadline time.Time) error {
 
 type hasSetDeadLine interface{ SetDeadline(t time.Time) error }
 
+func isUnixSocket(address net.Addr) bool {
+	return address.Network() == "unix" || address.Network() == "unixpacket"
+}
+
 // @}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAcceptor(t *testing.T) {
@@ -97,3 +98,139 @@ func TestNewAcceptorInstantiation(t *testing.T) {
 	assert.Equal(t, "unix", acc.address.(*net.UnixAddr).Network())
 	assert.Nil(t, acc.Shutdown())
 }
+
+func TestAcceptorDrain(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	serverSockFN := path.Join(t.TempDir(), "drain.sock")
+
+	connOpts := DefaultOpts()
+	connOpts.ReadTimeout = 50 * time.Millisecond
+	acceptorConfig := DefaultAcceptorConfig()
+	acceptorConfig.AcceptTimeout = 100 * time.Millisecond
+	acc := newAcceptor(&net.UnixAddr{Net: "unix", Name: serverSockFN}, func(c net.Conn) RawConn {
+		return newConnWrapper(c, lpFramerFromConn, &connOpts)
+	}, logger, &acceptorConfig)
+
+	echo := func(c RawConn) {
+		for {
+			message, err := c.ReceiveMessage()
+			if os.IsTimeout(err) {
+				continue
+			} else if err != nil {
+				return
+			}
+			c.SendMessage(message)
+		}
+	}
+
+	endc, err := acc.Start(echo)
+	require.Nil(t, err)
+
+	clientOpts := DefaultOpts()
+	clientOpts.Address = serverSockFN
+	clientOpts.ConnType = ConnTypeUnixStream
+	client, err := NewClientConn(logger, &clientOpts)
+	require.Nil(t, err)
+	require.Nil(t, client.SendMessage([]byte("before")))
+	recv, err := client.ReceiveMessage()
+	require.Nil(t, err)
+	assert.Equal(t, []byte("before"), recv)
+
+	// hand the listener over to a "new process"
+	f, err := acc.File()
+	require.Nil(t, err)
+	inherited, err := net.FileListener(f)
+	f.Close()
+	require.Nil(t, err)
+	successor := newAcceptor(&net.UnixAddr{Net: "unix", Name: serverSockFN}, func(c net.Conn) RawConn {
+		return newConnWrapper(c, lpFramerFromConn, &connOpts)
+	}, logger, &acceptorConfig)
+	successor.inherited = inherited
+	successorEndc, err := successor.Start(echo)
+	require.Nil(t, err)
+	defer func() {
+		assert.Nil(t, successor.Shutdown())
+		assert.Nil(t, <-successorEndc)
+	}()
+
+	assert.Nil(t, acc.Drain(time.Second))
+	assert.Nil(t, <-endc)
+	assert.Nil(t, acc.Shutdown()) // idempotent
+
+	// the idle connection was closed & the socket file is still in place, served by the successor
+	_, err = client.ReceiveMessage()
+	assert.ErrorIs(t, err, io.EOF)
+	_, err = os.Stat(serverSockFN)
+	assert.Nil(t, err)
+
+	client, err = NewClientConn(logger, &clientOpts)
+	require.Nil(t, err)
+	require.Nil(t, client.SendMessage([]byte("after")))
+	recv, err = client.ReceiveMessage()
+	require.Nil(t, err)
+	assert.Equal(t, []byte("after"), recv)
+	client.Shutdown()
+}
+
+func TestAcceptorFile(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	acceptorConfig := DefaultAcceptorConfig()
+	acceptorConfig.AcceptTimeout = 100 * time.Millisecond
+	factory := func(c net.Conn) RawConn { return newConnWrapper(c, nil, &Options{}) }
+
+	// not started yet
+	acc := newAcceptor(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}, factory, logger, &acceptorConfig)
+	_, err := acc.File()
+	assert.ErrorIs(t, err, ErrListenerNotShareable)
+
+	addresses := []net.Addr{
+		&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)},
+		&net.UnixAddr{Net: "unix", Name: path.Join(t.TempDir(), "file.sock")},
+	}
+	for _, address := range addresses {
+		acc := newAcceptor(address, factory, logger, &acceptorConfig)
+		endc, err := acc.Start(func(RawConn) {})
+		require.Nil(t, err)
+
+		f, err := acc.File()
+		require.Nil(t, err)
+		inherited, err := net.FileListener(f)
+		f.Close()
+		require.Nil(t, err)
+		assert.Equal(t, address.Network(), inherited.Addr().Network())
+		inherited.Close()
+
+		assert.Nil(t, acc.Shutdown())
+		assert.Nil(t, <-endc)
+	}
+}
+
+func TestAcceptorDrainTimeout(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	serverSockFN := path.Join(t.TempDir(), "drain.sock")
+
+	connOpts := DefaultOpts()
+	acceptorConfig := DefaultAcceptorConfig()
+	acceptorConfig.AcceptTimeout = 100 * time.Millisecond
+	acc := newAcceptor(&net.UnixAddr{Net: "unix", Name: serverSockFN}, func(c net.Conn) RawConn {
+		return newConnWrapper(c, lpFramerFromConn, &connOpts)
+	}, logger, &acceptorConfig)
+
+	attached := make(chan struct{})
+	endc, err := acc.Start(func(c RawConn) {
+		close(attached)
+		time.Sleep(500 * time.Millisecond) // busy handling a request
+	})
+	require.Nil(t, err)
+
+	clientOpts := DefaultOpts()
+	clientOpts.Address = serverSockFN
+	clientOpts.ConnType = ConnTypeUnixStream
+	client, err := NewClientConn(logger, &clientOpts)
+	require.Nil(t, err)
+	defer client.Shutdown()
+	<-attached
+
+	assert.ErrorIs(t, acc.Drain(50*time.Millisecond), ErrDrainTimeout)
+	assert.Nil(t, <-endc)
+}
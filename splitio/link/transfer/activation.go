@@ -0,0 +1,67 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// systemd passes activated sockets starting at this descriptor (SD_LISTEN_FDS_START)
+const listenFDsStart = 3
+
+// handoffFDsEnv holds the number of listeners passed by a running splitd to the process replacing it (upgrade).
+// Descriptors are laid out as in socket activation, but the new PID cannot be known in advance, so LISTEN_PID isn't used
+const handoffFDsEnv = "SPLITD_HANDOFF_FDS"
+
+var ErrActivationTypeMismatch = errors.New("socket passed by systemd doesn't match the configured connection type")
+
+// activated holds the listeners passed by systemd (socket activation). They're read from the environment once
+// per process, and handed to the first acceptor bound to the same address
+var activated struct {
+	once      sync.Once
+	mutex     sync.Mutex
+	listeners []net.Listener
+	err       error
+}
+
+// inheritedListener returns the listener systemd passed for `address`, if any. Ownership is transferred to the
+// caller, so subsequent calls for the same address return nil
+func inheritedListener(address net.Addr) (net.Listener, error) {
+	activated.once.Do(func() {
+		activated.listeners, activated.err = listenersFromEnv(os.Getenv, os.Getpid(), listenFDsStart)
+		// as sd_listen_fds(3) does, prevent child processes from picking up the sockets
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+		os.Unsetenv(handoffFDsEnv)
+	})
+
+	activated.mutex.Lock()
+	defer activated.mutex.Unlock()
+	if activated.err != nil {
+		return nil, activated.err
+	}
+
+	for idx, l := range activated.listeners {
+		if l == nil || l.Addr().String() != address.String() {
+			continue
+		}
+
+		if l.Addr().Network() != address.Network() {
+			return nil, fmt.Errorf("%w: got %s, expected %s", ErrActivationTypeMismatch, l.Addr().Network(), address.Network())
+		}
+
+		activated.listeners[idx] = nil
+		return l, nil
+	}
+	return nil, nil
+}
+
+// HandoffEnv returns the environment entry that makes a child process pick up `count` listeners, passed as its
+// first extra files (starting at descriptor 3)
+func HandoffEnv(count int) string {
+	return handoffFDsEnv + "=" + strconv.Itoa(count)
+}
@@ -0,0 +1,121 @@
+//go:build unix
+
+package transfer
+
+import (
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenersFromEnv(t *testing.T) {
+	sockFN := path.Join(t.TempDir(), "activated.sock")
+	original, err := net.Listen("unixpacket", sockFN)
+	require.Nil(t, err)
+	original.(*net.UnixListener).SetUnlinkOnClose(false) // the socket file must outlive this listener, as it happens with systemd
+	f, err := original.(*net.UnixListener).File()
+	require.Nil(t, err)
+	fd, err := syscall.Dup(int(f.Fd())) // ownership of this descriptor is taken by listenersFromEnv
+	require.Nil(t, err)
+	handoffFD, err := syscall.Dup(int(f.Fd()))
+	require.Nil(t, err)
+	f.Close()
+	original.Close()
+
+	env := map[string]string{"LISTEN_PID": strconv.Itoa(os.Getpid()), "LISTEN_FDS": "1"}
+	getenv := func(k string) string { return env[k] }
+
+	// sockets for another process
+	listeners, err := listenersFromEnv(getenv, os.Getpid()+1, fd)
+	assert.Nil(t, err)
+	assert.Empty(t, listeners)
+
+	listeners, err = listenersFromEnv(getenv, os.Getpid(), fd)
+	require.Nil(t, err)
+	require.Len(t, listeners, 1)
+	assert.Equal(t, sockFN, listeners[0].Addr().String())
+	assert.Equal(t, "unixpacket", listeners[0].Addr().Network())
+	defer listeners[0].Close()
+
+	// the inherited listener is usable
+	go func() {
+		if c, err := net.Dial("unixpacket", sockFN); err == nil {
+			c.Write([]byte("hi"))
+			c.Close()
+		}
+	}()
+	listeners[0].(*net.UnixListener).SetDeadline(time.Now().Add(time.Second))
+	conn, err := listeners[0].Accept()
+	require.Nil(t, err)
+	buf := make([]byte, 10)
+	n, err := conn.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "hi", string(buf[:n]))
+	conn.Close()
+
+	env["LISTEN_FDS"] = "sarasa"
+	_, err = listenersFromEnv(getenv, os.Getpid(), 3)
+	assert.NotNil(t, err)
+
+	listeners, err = listenersFromEnv(func(string) string { return "" }, os.Getpid(), 3)
+	assert.Nil(t, err)
+	assert.Empty(t, listeners)
+
+	// listeners handed over by a previous splitd don't carry the pid of the receiving process
+	handoffEnv := map[string]string{"SPLITD_HANDOFF_FDS": "1"}
+	listeners, err = listenersFromEnv(func(k string) string { return handoffEnv[k] }, os.Getpid()+1, handoffFD)
+	require.Nil(t, err)
+	require.Len(t, listeners, 1)
+	assert.Equal(t, sockFN, listeners[0].Addr().String())
+	listeners[0].Close()
+
+	handoffEnv["SPLITD_HANDOFF_FDS"] = "sarasa"
+	_, err = listenersFromEnv(func(k string) string { return handoffEnv[k] }, os.Getpid(), 3)
+	assert.ErrorContains(t, err, "SPLITD_HANDOFF_FDS")
+	assert.Equal(t, "SPLITD_HANDOFF_FDS=2", HandoffEnv(2))
+}
+
+func TestInheritedListener(t *testing.T) {
+	sockFN := path.Join(t.TempDir(), "activated.sock")
+	l, err := net.Listen("unixpacket", sockFN)
+	require.Nil(t, err)
+	defer l.Close()
+
+	activated.once.Do(func() {}) // skip reading the environment
+	activated.mutex.Lock()
+	activated.listeners = []net.Listener{l}
+	activated.mutex.Unlock()
+
+	inherited, err := inheritedListener(&net.UnixAddr{Net: "unixpacket", Name: "/some/other.sock"})
+	assert.Nil(t, err)
+	assert.Nil(t, inherited)
+
+	_, err = inheritedListener(&net.UnixAddr{Net: "unix", Name: sockFN})
+	assert.ErrorIs(t, err, ErrActivationTypeMismatch)
+
+	inherited, err = inheritedListener(&net.UnixAddr{Net: "unixpacket", Name: sockFN})
+	assert.Nil(t, err)
+	assert.Equal(t, l, inherited)
+
+	// ownership transferred, not handed out twice
+	inherited, err = inheritedListener(&net.UnixAddr{Net: "unixpacket", Name: sockFN})
+	assert.Nil(t, err)
+	assert.Nil(t, inherited)
+
+	// the acceptor uses it as-is, without touching the socket file
+	opts := DefaultOpts()
+	opts.Address = sockFN
+	activated.mutex.Lock()
+	activated.listeners = []net.Listener{l}
+	activated.mutex.Unlock()
+	acc, err := NewAcceptor(nil, &opts, &AcceptorConfig{MaxSimultaneousConnections: 1})
+	require.Nil(t, err)
+	assert.Equal(t, l, acc.inherited)
+}
@@ -0,0 +1,49 @@
+//go:build unix
+
+package transfer
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// listenersFromEnv builds listeners from the descriptors described by LISTEN_PID & LISTEN_FDS, as set by systemd,
+// or by SPLITD_HANDOFF_FDS, as set by a splitd instance being upgraded
+func listenersFromEnv(getenv func(string) string, pid int, firstFD int) ([]net.Listener, error) {
+	envVar, rawFDs := handoffFDsEnv, getenv(handoffFDsEnv)
+	if rawFDs == "" {
+		rawPID := getenv("LISTEN_PID")
+		envVar, rawFDs = "LISTEN_FDS", getenv("LISTEN_FDS")
+		if rawPID == "" || rawFDs == "" {
+			return nil, nil
+		}
+
+		if listenPID, err := strconv.Atoi(rawPID); err != nil || listenPID != pid {
+			return nil, nil // sockets were meant for another process
+		}
+	}
+
+	count, err := strconv.Atoi(rawFDs)
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("invalid %s value '%s'", envVar, rawFDs)
+	}
+
+	listeners := make([]net.Listener, 0, count)
+	for fd := firstFD; fd < firstFD+count; fd++ {
+		syscall.CloseOnExec(fd)
+		if err := syscall.SetNonblock(fd, true); err != nil { // inherited sockets are usually in blocking mode
+			return nil, fmt.Errorf("error setting inherited descriptor %d as non-blocking: %w", fd, err)
+		}
+		f := os.NewFile(uintptr(fd), "listen-fd-"+strconv.Itoa(fd))
+		l, err := net.FileListener(f)
+		f.Close() // FileListener dups the descriptor
+		if err != nil {
+			return nil, fmt.Errorf("error building listener from inherited descriptor %d: %w", fd, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync"
 )
 
 var (
@@ -12,14 +13,24 @@ var (
 	ErrSizeDecode             = errors.New("error decoding size prefix from message")
 )
 
+// frames holds buffers used to assemble outgoing frames, so that writing a message doesn't allocate
+var frames = sync.Pool{New: func() any { return new([]byte) }}
+
 type Interface interface {
 	WriteFrame(raw []byte) (int, error)
 	ReadFrame(readBuf []byte) (int, error)
 }
 
 type LengthPrefixImpl struct {
-	lr io.LimitedReader
-	w  io.Writer
+	lr     io.LimitedReader
+	w      io.Writer
+	header [4]byte // kept here since it would otherwise escape to the heap through the reader interface
+
+	// payload progress of a frame whose read was interrupted (ie: deadline exceeded). It's resumed on the next
+	// ReadFrame call, which must receive the same buffer
+	pending bool
+	size    int
+	read    int
 }
 
 func NewLengthPrefix(rw io.ReadWriter) *LengthPrefixImpl {
@@ -32,9 +43,12 @@ func NewLengthPrefix(rw io.ReadWriter) *LengthPrefixImpl {
 func (l *LengthPrefixImpl) WriteFrame(raw []byte) (int, error) {
 	size := len(raw)
 	framedSize := size + 4
-	framed := make([]byte, 0, framedSize)
-	framed = encodeSize(size, framed)
+	buf := frames.Get().(*[]byte)
+	defer frames.Put(buf)
+
+	framed := encodeSize(size, (*buf)[:0])
 	framed = append(framed, raw...)
+	*buf = framed
 
 	sent := 0
 	for sent < framedSize {
@@ -50,34 +64,36 @@ func (l *LengthPrefixImpl) WriteFrame(raw []byte) (int, error) {
 }
 
 func (l *LengthPrefixImpl) ReadFrame(readBuf []byte) (int, error) {
-	var sizeb [4]byte
-	l.lr.N = 4
-	n, err := l.lr.Read(sizeb[:])
-	if err != nil {
-		return 0, fmt.Errorf("error reading size: %w", err)
-	}
+	if !l.pending {
+		l.lr.N = 4
+		n, err := l.lr.Read(l.header[:])
+		if err != nil {
+			return 0, fmt.Errorf("error reading size: %w", err)
+		}
 
-	if n != 4 {
-		return 0, ErrInsufficientHeaderData
-	}
+		if n != 4 {
+			return 0, ErrInsufficientHeaderData
+		}
 
-	size := decodeSize(sizeb[:])
-	if bufSize := len(readBuf); int(size) > bufSize {
-		return 0, fmt.Errorf("read buffer is too small (%d bytes) to handle incoming message (%d bytes)", bufSize, size)
-	}
+		size := decodeSize(l.header[:])
+		if bufSize := len(readBuf); int(size) > bufSize {
+			return 0, fmt.Errorf("read buffer is too small (%d bytes) to handle incoming message (%d bytes)", bufSize, size)
+		}
 
-	l.lr.N = int64(size)
+		l.lr.N = int64(size)
+		l.pending, l.size, l.read = true, int(size), 0
+	}
 
-	read := 0
-	for read < int(size) {
-		n, err = l.lr.Read(readBuf[read:])
-		read += n
+	for l.read < l.size {
+		n, err := l.lr.Read(readBuf[l.read:])
+		l.read += n
 		if err != nil {
-			return read, err
+			return l.read, err
 		}
 	}
 
-	return read, nil
+	l.pending = false
+	return l.read, nil
 }
 
 func encodeSize(size int, target []byte) []byte {
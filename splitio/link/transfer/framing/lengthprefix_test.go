@@ -1,7 +1,9 @@
 package framing
 
 import (
+	"bytes"
 	"io"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -42,6 +44,40 @@ func TestLengthPrefixRead(t *testing.T) {
 	assert.Equal(t, "SOMETHING TO SEND", string(buffer[:n]))
 }
 
+func TestLengthPrefixReadResumedAfterInterruption(t *testing.T) {
+	sock := &sockMock{}
+	sock.On("Read", mock.Anything).
+		Run(func(args mock.Arguments) { copy(args.Get(0).([]byte), encodeSize(len("SOMETHING TO SEND"), nil)) }).
+		Return(4, (error)(nil)).Once()
+	sock.On("Read", mock.Anything).
+		Run(func(args mock.Arguments) { copy(args.Get(0).([]byte), []byte("SOMETHING")) }).
+		Return(len("SOMETHING"), os.ErrDeadlineExceeded).Once()
+	sock.On("Read", mock.Anything).
+		Run(func(args mock.Arguments) { copy(args.Get(0).([]byte), []byte(" TO SEND")) }).
+		Return(len(" TO SEND"), (error)(nil)).Once()
+	sock.On("Read", mock.Anything).
+		Run(func(args mock.Arguments) { copy(args.Get(0).([]byte), encodeSize(len("NEXT"), nil)) }).
+		Return(4, (error)(nil)).Once()
+	sock.On("Read", mock.Anything).
+		Run(func(args mock.Arguments) { copy(args.Get(0).([]byte), []byte("NEXT")) }).
+		Return(len("NEXT"), (error)(nil)).Once()
+
+	lp := NewLengthPrefix(sock)
+	var buffer [2048]byte
+	_, err := lp.ReadFrame(buffer[:])
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	// the frame header is not read again
+	n, err := lp.ReadFrame(buffer[:])
+	assert.Nil(t, err)
+	assert.Equal(t, "SOMETHING TO SEND", string(buffer[:n]))
+
+	n, err = lp.ReadFrame(buffer[:])
+	assert.Nil(t, err)
+	assert.Equal(t, "NEXT", string(buffer[:n]))
+	sock.AssertExpectations(t)
+}
+
 func TestLengthPrefixWrite(t *testing.T) {
 
 	// the test will write the following string in 3 parts
@@ -84,3 +120,20 @@ func (s *sockMock) Read(p []byte) (n int, err error) {
 
 var _ io.Reader = (*sockMock)(nil)
 var _ io.Writer = (*sockMock)(nil)
+
+func BenchmarkLengthPrefixRoundTrip(b *testing.B) {
+	var stream bytes.Buffer
+	lp := NewLengthPrefix(&stream)
+	message := bytes.Repeat([]byte("a"), 128)
+	var buffer [2048]byte
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := lp.WriteFrame(message); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := lp.ReadFrame(buffer[:]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"context"
+
 	"github.com/splitio/splitd/splitio/link/transfer"
 	"github.com/stretchr/testify/mock"
 )
@@ -28,3 +30,22 @@ func (m *RawConnMock) Shutdown() error {
 }
 
 var _ transfer.RawConn = (*RawConnMock)(nil)
+
+// ContextConnMock is a RawConnMock whose operations can be bound to a context
+type ContextConnMock struct {
+	RawConnMock
+}
+
+// ReceiveMessageContext implements transfer.ContextConn
+func (m *ContextConnMock) ReceiveMessageContext(ctx context.Context) ([]byte, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+// SendMessageContext implements transfer.ContextConn
+func (m *ContextConnMock) SendMessageContext(ctx context.Context, data []byte) error {
+	args := m.Called(ctx, data)
+	return args.Error(0)
+}
+
+var _ transfer.ContextConn = (*ContextConnMock)(nil)
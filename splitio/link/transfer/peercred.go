@@ -0,0 +1,143 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"slices"
+	"strconv"
+)
+
+var (
+	ErrPeerCredentialsUnsupported = errors.New("peer credentials are not supported on this platform")
+	ErrPeerNotAuthorized          = errors.New("peer is not authorized to connect")
+)
+
+// PeerCredentials identify the process on the other end of a unix socket, as reported by the kernel (SO_PEERCRED)
+type PeerCredentials struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+// PeerCredentialsOf returns the credentials of the process that opened a connection accepted by this package,
+// or nil if they couldn't be fetched
+func PeerCredentialsOf(rc RawConn) *PeerCredentials {
+	if withCreds, ok := rc.(*connWithPeer); ok {
+		return withCreds.peer
+	}
+	return nil
+}
+
+// connWithPeer decorates a RawConn with the credentials of the peer, fetched when accepting the connection
+type connWithPeer struct {
+	RawConn
+	peer *PeerCredentials
+}
+
+// PeerAuthorization restricts connections to processes running with certain UIDs/GIDs.
+// If both lists are empty, any peer is allowed
+type PeerAuthorization struct {
+	AllowedUIDs []uint32
+	AllowedGIDs []uint32
+}
+
+func (p *PeerAuthorization) enabled() bool {
+	return len(p.AllowedUIDs) > 0 || len(p.AllowedGIDs) > 0
+}
+
+func (p *PeerAuthorization) authorize(peer *PeerCredentials) error {
+	if !p.enabled() {
+		return nil
+	}
+
+	if peer == nil {
+		return fmt.Errorf("%w: unable to determine peer credentials", ErrPeerNotAuthorized)
+	}
+
+	if slices.Contains(p.AllowedUIDs, peer.UID) || slices.Contains(p.AllowedGIDs, peer.GID) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: pid=%d uid=%d gid=%d", ErrPeerNotAuthorized, peer.PID, peer.UID, peer.GID)
+}
+
+// SocketPermissions are applied to the socket file right after it's created (on unix, only accessible by this
+// process' user until then). Zero values leave the process defaults
+type SocketPermissions struct {
+	Mode  os.FileMode
+	Owner string // user name or numeric uid
+	Group string // group name or numeric gid
+}
+
+func (s *SocketPermissions) configured() bool {
+	return s.Mode != 0 || s.Owner != "" || s.Group != ""
+}
+
+func (s *SocketPermissions) apply(fn string) error {
+	if s.Mode != 0 {
+		if err := os.Chmod(fn, s.Mode); err != nil {
+			return fmt.Errorf("error setting socket file mode: %w", err)
+		}
+	}
+
+	if s.Owner == "" && s.Group == "" {
+		return nil
+	}
+
+	uid, gid := -1, -1 // -1 == unchanged
+	var err error
+	if s.Owner != "" {
+		if uid, err = lookupID(s.Owner, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return u.Uid, nil
+		}); err != nil {
+			return fmt.Errorf("error resolving socket owner '%s': %w", s.Owner, err)
+		}
+	}
+
+	if s.Group != "" {
+		if gid, err = lookupID(s.Group, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return g.Gid, nil
+		}); err != nil {
+			return fmt.Errorf("error resolving socket group '%s': %w", s.Group, err)
+		}
+	}
+
+	if err := os.Chown(fn, uid, gid); err != nil {
+		return fmt.Errorf("error setting socket file ownership: %w", err)
+	}
+
+	return nil
+}
+
+func lookupID(nameOrID string, lookup func(string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(nameOrID); err == nil {
+		return id, nil
+	}
+
+	raw, err := lookup(nameOrID)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(raw)
+}
+
+func peerCredentials(c net.Conn) (*PeerCredentials, error) {
+	uc, ok := c.(*net.UnixConn)
+	if !ok {
+		return nil, ErrPeerCredentialsUnsupported
+	}
+	return readPeerCredentials(uc)
+}
+
+var _ RawConn = (*connWithPeer)(nil)
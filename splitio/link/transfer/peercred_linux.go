@@ -0,0 +1,30 @@
+//go:build linux
+
+package transfer
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+func readPeerCredentials(c *net.UnixConn) (*PeerCredentials, error) {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return nil, fmt.Errorf("error accessing raw socket: %w", err)
+	}
+
+	var ucred *syscall.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		ucred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error accessing socket descriptor: %w", err)
+	}
+	if credErr != nil {
+		return nil, fmt.Errorf("error reading SO_PEERCRED: %w", credErr)
+	}
+
+	return &PeerCredentials{PID: ucred.Pid, UID: ucred.Uid, GID: ucred.Gid}, nil
+}
@@ -0,0 +1,82 @@
+//go:build linux
+
+package transfer
+
+import (
+	"io"
+	"net"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcceptorPeerCredentials(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	serverSockFN := path.Join(t.TempDir(), "peercred.sock")
+	connOpts := DefaultOpts()
+
+	acceptorConfig := DefaultAcceptorConfig()
+	acceptorConfig.AcceptTimeout = 100 * time.Millisecond
+	acceptorConfig.SocketPermissions.Mode = 0o600
+	acceptorConfig.PeerAuthorization.AllowedUIDs = []uint32{uint32(os.Getuid())}
+	acc := newAcceptor(&net.UnixAddr{Net: "unix", Name: serverSockFN}, func(c net.Conn) RawConn {
+		return newConnWrapper(c, lpFramerFromConn, &connOpts)
+	}, logger, &acceptorConfig)
+
+	peers := make(chan *PeerCredentials, 1)
+	endc, err := acc.Start(func(c RawConn) {
+		peers <- PeerCredentialsOf(c)
+		c.SendMessage([]byte("hi"))
+	})
+	assert.Nil(t, err)
+
+	st, err := os.Stat(serverSockFN)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0o600), st.Mode().Perm())
+
+	clientOpts := DefaultOpts()
+	clientOpts.Address = serverSockFN
+	clientOpts.ConnType = ConnTypeUnixStream
+	client, err := NewClientConn(logger, &clientOpts)
+	assert.Nil(t, err)
+	recv, err := client.ReceiveMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hi"), recv)
+	assert.Equal(t, &PeerCredentials{PID: int32(os.Getpid()), UID: uint32(os.Getuid()), GID: uint32(os.Getgid())}, <-peers)
+
+	assert.Nil(t, acc.Shutdown())
+	assert.Nil(t, <-endc)
+}
+
+func TestAcceptorRejectsUnauthorizedPeer(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	serverSockFN := path.Join(t.TempDir(), "peercred.sock")
+	connOpts := DefaultOpts()
+
+	acceptorConfig := DefaultAcceptorConfig()
+	acceptorConfig.AcceptTimeout = 100 * time.Millisecond
+	acceptorConfig.PeerAuthorization.AllowedUIDs = []uint32{uint32(os.Getuid()) + 1}
+	acc := newAcceptor(&net.UnixAddr{Net: "unix", Name: serverSockFN}, func(c net.Conn) RawConn {
+		return newConnWrapper(c, lpFramerFromConn, &connOpts)
+	}, logger, &acceptorConfig)
+
+	endc, err := acc.Start(func(c RawConn) {
+		t.Error("unauthorized peer should not be handed to the callback")
+	})
+	assert.Nil(t, err)
+
+	clientOpts := DefaultOpts()
+	clientOpts.Address = serverSockFN
+	clientOpts.ConnType = ConnTypeUnixStream
+	client, err := NewClientConn(logger, &clientOpts)
+	assert.Nil(t, err)
+	_, err = client.ReceiveMessage()
+	assert.ErrorIs(t, err, io.EOF)
+
+	assert.Nil(t, acc.Shutdown())
+	assert.Nil(t, <-endc)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package transfer
+
+import "net"
+
+func readPeerCredentials(c *net.UnixConn) (*PeerCredentials, error) {
+	return nil, ErrPeerCredentialsUnsupported
+}
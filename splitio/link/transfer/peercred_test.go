@@ -0,0 +1,53 @@
+package transfer
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerAuthorization(t *testing.T) {
+	var none PeerAuthorization
+	assert.Nil(t, none.authorize(nil))
+	assert.Nil(t, none.authorize(&PeerCredentials{PID: 1, UID: 1000, GID: 1000}))
+
+	auth := PeerAuthorization{AllowedUIDs: []uint32{1000}, AllowedGIDs: []uint32{50}}
+	assert.Nil(t, auth.authorize(&PeerCredentials{PID: 1, UID: 1000, GID: 1000}))
+	assert.Nil(t, auth.authorize(&PeerCredentials{PID: 1, UID: 1001, GID: 50}))
+	assert.ErrorIs(t, auth.authorize(&PeerCredentials{PID: 1, UID: 1001, GID: 1001}), ErrPeerNotAuthorized)
+	assert.ErrorIs(t, auth.authorize(nil), ErrPeerNotAuthorized)
+}
+
+func TestLookupID(t *testing.T) {
+	failing := func(string) (string, error) { return "", user.UnknownUserError("nobody") }
+	id, err := lookupID("1234", failing)
+	assert.Nil(t, err)
+	assert.Equal(t, 1234, id)
+
+	_, err = lookupID("nobody", failing)
+	assert.NotNil(t, err)
+
+	id, err = lookupID("somebody", func(string) (string, error) { return "42", nil })
+	assert.Nil(t, err)
+	assert.Equal(t, 42, id)
+}
+
+func TestSocketPermissions(t *testing.T) {
+	fn, err := os.CreateTemp(t.TempDir(), "perms")
+	assert.Nil(t, err)
+	fn.Close()
+
+	perms := SocketPermissions{Mode: 0o640, Owner: strconv.Itoa(os.Getuid()), Group: strconv.Itoa(os.Getgid())}
+	assert.Nil(t, perms.apply(fn.Name()))
+	st, err := os.Stat(fn.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0o640), st.Mode().Perm())
+
+	perms = SocketPermissions{Owner: "surely-not-an-existing-user"}
+	assert.NotNil(t, perms.apply(fn.Name()))
+
+	assert.Nil(t, PeerCredentialsOf(&PacketBasedConnection{}))
+}
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package transfer
+
+import (
+	"fmt"
+	"net"
+)
+
+// listenWithPermissions creates the socket & applies the configured permissions right after (there's no umask to
+// restrict access in the meantime on this platform)
+func listenWithPermissions(address net.Addr, permissions SocketPermissions) (net.Listener, error) {
+	l, err := net.Listen(address.Network(), address.String())
+	if err != nil {
+		return nil, fmt.Errorf("error listening on provided address: %w", err)
+	}
+
+	if err := permissions.apply(address.String()); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
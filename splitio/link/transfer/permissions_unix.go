@@ -0,0 +1,36 @@
+//go:build unix
+
+package transfer
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+)
+
+var umaskMutex sync.Mutex
+
+// listenWithPermissions creates a unix socket under a restrictive umask, so that nobody but this process' user can
+// connect to it before the configured permissions are applied. The umask is process-wide, hence the lock
+func listenWithPermissions(address net.Addr, permissions SocketPermissions) (net.Listener, error) {
+	umaskMutex.Lock()
+	previous := syscall.Umask(0o177)
+	l, err := net.Listen(address.Network(), address.String())
+	syscall.Umask(previous)
+	umaskMutex.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("error listening on provided address: %w", err)
+	}
+
+	if permissions.Mode == 0 { // restore the mode the socket would have been created with
+		permissions.Mode = os.FileMode(0o777 &^ previous)
+	}
+
+	if err := permissions.apply(address.String()); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
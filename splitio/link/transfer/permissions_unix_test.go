@@ -0,0 +1,37 @@
+//go:build unix
+
+package transfer
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenWithPermissions(t *testing.T) {
+	umask := syscall.Umask(0o022)
+	defer syscall.Umask(umask)
+
+	address := &net.UnixAddr{Net: "unix", Name: filepath.Join(t.TempDir(), "splitd.sock")}
+	l, err := listenWithPermissions(address, SocketPermissions{Mode: 0o660})
+	assert.Nil(t, err)
+	st, err := os.Stat(address.Name)
+	assert.Nil(t, err)
+	assert.Equal(t, os.ModeSocket|0o660, st.Mode())
+	assert.Nil(t, l.Close())
+
+	// without a mode, the socket ends up with the one it'd have been created with
+	l, err = listenWithPermissions(address, SocketPermissions{Group: strconv.Itoa(os.Getgid())})
+	assert.Nil(t, err)
+	st, err = os.Stat(address.Name)
+	assert.Nil(t, err)
+	assert.Equal(t, os.ModeSocket|0o755, st.Mode())
+	assert.Nil(t, l.Close())
+
+	assert.Equal(t, 0o022, syscall.Umask(0o022)) // the process umask is restored
+}
@@ -49,12 +49,22 @@ func NewAcceptor(logger logging.LoggerInterface, o *Options, listenerConfig *Acc
 		return nil, ErrInvalidConnType
 	}
 
-	if err := ensureAddressUsable(logger, address); err != nil {
-		return nil, err
+	inherited, err := inheritedListener(address)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up inherited listener: %w", err)
+	}
+
+	// an inherited socket file must be left alone, since it's owned by whoever passed it to this process
+	if inherited == nil {
+		if err := ensureAddressUsable(logger, address); err != nil {
+			return nil, err
+		}
 	}
 
 	cf := func(c net.Conn) RawConn { return newConnWrapper(c, ff, o) }
-	return newAcceptor(address, cf, logger, listenerConfig), nil
+	acceptor := newAcceptor(address, cf, logger, listenerConfig)
+	acceptor.inherited = inherited
+	return acceptor, nil
 }
 
 func NewClientConn(logger logging.LoggerInterface, o *Options) (RawConn, error) {
@@ -1,10 +1,12 @@
 package transfer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net"
+	"os"
 	"time"
 
 	"github.com/splitio/splitd/splitio/link/transfer/framing"
@@ -13,8 +15,12 @@ import (
 var (
 	ErrSentDataMismatch = errors.New("sent data size mismatch")
 	ErrBufferTooSmall   = errors.New("insufficient capacity in read buffer")
+	ErrIncompleteWrite  = errors.New("message partially written, the connection can no longer be used")
 )
 
+// aLongTimeAgo is set as deadline to interrupt blocked operations
+var aLongTimeAgo = time.Unix(1, 0)
+
 type FramingWrapperFactory func(c net.Conn) framing.Interface
 
 type RawConn interface {
@@ -23,6 +29,15 @@ type RawConn interface {
 	Shutdown() error
 }
 
+// ContextConn is implemented by connections whose operations can be bound to a context. The context's deadline is
+// applied if it's earlier than the configured timeout, and cancelling it interrupts the operation in progress.
+// A read interrupted half-way through a message is resumed by the next ReceiveMessageContext call
+type ContextConn interface {
+	RawConn
+	ReceiveMessageContext(ctx context.Context) ([]byte, error)
+	SendMessageContext(ctx context.Context, data []byte) error
+}
+
 type BaseConn struct {
 	conn         net.Conn
 	readBuffer   []byte
@@ -39,15 +54,83 @@ func (b *BaseConn) Shutdown() error {
 	return nil
 }
 
-func (b *BaseConn) setReadDeadline() error {
-	if err := b.conn.SetReadDeadline(time.Now().Add(b.readTimeout)); err != nil {
+// bounded runs op with the read (or write) deadline being the earliest of the configured timeout from now & the
+// context's one. If the context is cancelled before op returns, the deadline is moved to the past in order to interrupt it.
+// Contexts that can never be cancelled (ie: context.Background) skip the interruption machinery altogether, keeping
+// plain calls free of allocations
+func (b *BaseConn) bounded(ctx context.Context, write bool, op func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	timeout := b.readTimeout
+	if write {
+		timeout = b.writeTimeout
+	}
+
+	deadline, byContext := time.Now().Add(timeout), false
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline, byContext = ctxDeadline, true
+	}
+
+	if err := b.setDeadline(write, deadline); err != nil {
+		return err
+	}
+
+	if ctx.Done() == nil {
+		return op()
+	}
+
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		b.setDeadline(write, aLongTimeAgo)
+		close(interrupted)
+	})
+
+	err := op()
+	if !stop() {
+		<-interrupted // make sure the interruption doesn't land on a later operation
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%w: %w", ctxErr, err)
+	}
+
+	if byContext && errors.Is(err, os.ErrDeadlineExceeded) {
+		return fmt.Errorf("%w: %w", context.DeadlineExceeded, err)
+	}
+
+	return err
+}
+
+func (b *BaseConn) boundedRead(ctx context.Context, op func() error) error {
+	return b.bounded(ctx, false, op)
+}
+
+func (b *BaseConn) boundedWrite(ctx context.Context, op func() error) error {
+	return b.bounded(ctx, true, op)
+}
+
+func (b *BaseConn) setDeadline(write bool, deadline time.Time) error {
+	if write {
+		return b.setWriteDeadline(deadline)
+	}
+	return b.setReadDeadline(deadline)
+}
+
+func (b *BaseConn) setReadDeadline(deadline time.Time) error {
+	if err := b.conn.SetReadDeadline(deadline); err != nil {
 		return fmt.Errorf("error setting read timeout: %w", err)
 	}
 	return nil
 }
 
-func (b *BaseConn) setWriteDeadline() error {
-	if err := b.conn.SetWriteDeadline(time.Now().Add(b.writeTimeout)); err != nil {
+func (b *BaseConn) setWriteDeadline(deadline time.Time) error {
+	if err := b.conn.SetWriteDeadline(deadline); err != nil {
 		return fmt.Errorf("error setting write timeout: %w", err)
 	}
 	return nil
@@ -59,11 +142,16 @@ type PacketBasedConnection struct {
 
 // ReceiveMessage implements ClientConnection
 func (c *PacketBasedConnection) ReceiveMessage() ([]byte, error) {
-	if err := c.setReadDeadline(); err != nil {
-		return nil, err
-	}
+	return c.ReceiveMessageContext(context.Background())
+}
 
-	n, err := c.conn.Read(c.readBuffer)
+// ReceiveMessageContext implements ContextConn
+func (c *PacketBasedConnection) ReceiveMessageContext(ctx context.Context) ([]byte, error) {
+	var n int
+	err := c.boundedRead(ctx, func() (err error) {
+		n, err = c.conn.Read(c.readBuffer)
+		return err
+	})
 	if err != nil {
 		return nil, formatErrorIfApplicable("error reading from socket: %w", err)
 	}
@@ -76,11 +164,15 @@ func (c *PacketBasedConnection) ReceiveMessage() ([]byte, error) {
 
 // SendMessage implements ClientConnection
 func (c *PacketBasedConnection) SendMessage(data []byte) error {
-	if err := c.setWriteDeadline(); err != nil {
-		return err
-	}
+	return c.SendMessageContext(context.Background(), data)
+}
 
-	_, err := c.conn.Write(data)
+// SendMessageContext implements ContextConn
+func (c *PacketBasedConnection) SendMessageContext(ctx context.Context, data []byte) error {
+	err := c.boundedWrite(ctx, func() error {
+		_, err := c.conn.Write(data)
+		return err
+	})
 	if err != nil {
 		return formatErrorIfApplicable("error when sending message to client: %w", err)
 	}
@@ -94,11 +186,17 @@ type StreamBasedConnection struct {
 }
 
 func (c *StreamBasedConnection) ReceiveMessage() ([]byte, error) {
-	if err := c.setReadDeadline(); err != nil {
-		return nil, err
-	}
+	return c.ReceiveMessageContext(context.Background())
+}
 
-	n, err := c.framer.ReadFrame(c.readBuffer)
+// ReceiveMessageContext implements ContextConn. The framer keeps track of partially read frames, so reading
+// can be resumed after an interruption
+func (c *StreamBasedConnection) ReceiveMessageContext(ctx context.Context) ([]byte, error) {
+	var n int
+	err := c.boundedRead(ctx, func() (err error) {
+		n, err = c.framer.ReadFrame(c.readBuffer)
+		return err
+	})
 	if err != nil {
 		return nil, formatErrorIfApplicable("error reading frame: %w", err)
 	}
@@ -107,11 +205,21 @@ func (c *StreamBasedConnection) ReceiveMessage() ([]byte, error) {
 }
 
 func (c *StreamBasedConnection) SendMessage(data []byte) error {
-	if err := c.setWriteDeadline(); err != nil {
+	return c.SendMessageContext(context.Background(), data)
+}
+
+// SendMessageContext implements ContextConn. A frame interrupted half-way leaves the stream in an unknown state,
+// which is reported with ErrIncompleteWrite
+func (c *StreamBasedConnection) SendMessageContext(ctx context.Context, data []byte) error {
+	var n int
+	err := c.boundedWrite(ctx, func() (err error) {
+		n, err = c.framer.WriteFrame(data)
 		return err
+	})
+	if err != nil && n > 0 {
+		return fmt.Errorf("%w: %w", ErrIncompleteWrite, err)
 	}
-
-	if _, err := c.framer.WriteFrame(data); err != nil {
+	if err != nil {
 		return formatErrorIfApplicable("error writing frame: %w", err)
 	}
 
@@ -148,5 +256,5 @@ func newConnWrapper(c net.Conn, f FramingWrapperFactory, o *Options) RawConn {
 	return &StreamBasedConnection{framer: f(c), BaseConn: bc}
 }
 
-var _ RawConn = (*PacketBasedConnection)(nil)
-var _ RawConn = (*StreamBasedConnection)(nil)
+var _ ContextConn = (*PacketBasedConnection)(nil)
+var _ ContextConn = (*StreamBasedConnection)(nil)
@@ -2,12 +2,15 @@ package transfer
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
 	"io"
 	"net"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/splitio/splitd/splitio/link/transfer/framing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -91,6 +94,88 @@ func TestReadInsufficientBufferSpace(t *testing.T) {
 	assert.Equal(t, ErrBufferTooSmall, err)
 }
 
+func TestContextCancelledRead(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	wrapped := newConnWrapper(client, nil, &Options{BufferSize: 1024, ReadTimeout: time.Minute, WriteTimeout: time.Minute}).(ContextConn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	message, err := wrapped.ReceiveMessageContext(ctx)
+	assert.Nil(t, message)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = wrapped.ReceiveMessageContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// the connection is still usable
+	go server.Write([]byte("LATE RESPONSE"))
+	message, err = wrapped.ReceiveMessageContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("LATE RESPONSE"), message)
+}
+
+func TestContextDeadline(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	wrapped := newConnWrapper(client, nil, &Options{BufferSize: 1024, ReadTimeout: time.Minute, WriteTimeout: time.Minute}).(ContextConn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	before := time.Now()
+	_, err := wrapped.ReceiveMessageContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(before), time.Second)
+
+	// nobody reads on the other end
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = wrapped.SendMessageContext(ctx, []byte("SOME MESSAGE"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// configured timeouts still apply when the context has a later deadline
+	wrapped = newConnWrapper(client, nil, &Options{BufferSize: 1024, ReadTimeout: 20 * time.Millisecond}).(ContextConn)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err = wrapped.ReceiveMessageContext(ctx)
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	assert.NotErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestContextCancelledFrameResumed(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	framer := func(c net.Conn) framing.Interface { return framing.NewLengthPrefix(c) }
+	wrapped := newConnWrapper(client, framer, &Options{BufferSize: 1024, ReadTimeout: time.Minute, WriteTimeout: time.Minute}).(ContextConn)
+
+	var frame []byte
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(len("SOME MESSAGE")))
+	frame = append(frame, []byte("SOME MESSAGE")...)
+
+	// only part of the frame arrives before the call is cancelled
+	go server.Write(frame[:8])
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, err := wrapped.ReceiveMessageContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	go server.Write(frame[8:])
+	message, err := wrapped.ReceiveMessageContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("SOME MESSAGE"), message)
+
+	// a frame partially written cannot be resumed
+	go func() {
+		var buf [6]byte
+		server.Read(buf[:])
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = wrapped.SendMessageContext(ctx, []byte("SOME MESSAGE"))
+	assert.ErrorIs(t, err, ErrIncompleteWrite)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
 type connMock struct {
 	mock.Mock
 }
@@ -1,6 +1,9 @@
 package conf
 
 import (
+	"fmt"
+	"math"
+	"slices"
 	"time"
 
 	"github.com/splitio/go-split-commons/v9/conf"
@@ -14,16 +17,139 @@ const (
 	minimumImpressionsRefreshRate = 30 * time.Minute
 )
 
+// Storage types
+const (
+	StorageMemory = "memory" // data is synchronized by splitd itself
+	StorageRedis  = "redis"  // data is read from a redis populated by Split Synchronizer
+)
+
+// Drop policies, applied when impressions/events don't fit in their memory budget
+const (
+	DropNewest = "newest" // incoming items are rejected
+	DropOldest = "oldest" // queued items are evicted to make room for new ones
+)
+
+// Queue types, used to hold impressions & events until they're flushed
+const (
+	QueueLocking = "locking" // single mutex-guarded ring per client metadata
+	QueueSharded = "sharded" // lock-free rings spread across shards, for many concurrent connections sharing metadata
+)
+
 type Config struct {
-	LabelsEnabled     bool
-	StreamingEnabled  bool
-	Splits            Splits
-	Segments          Segments
-	Impressions       Impressions
-	Events            Events
-	URLs              URLs
-	FlagSetsFilter    []string
-	FallbackTreatment dtos.FallbackTreatmentConfig
+	LabelsEnabled       bool
+	StreamingEnabled    bool
+	Storage             string
+	QueueType           string
+	Redis               Redis
+	Splits              Splits
+	Segments            Segments
+	Impressions         Impressions
+	Events              Events
+	URLs                URLs
+	HTTP                HTTP
+	FlagSetsFilter      []string
+	FallbackTreatment   dtos.FallbackTreatmentConfig
+	Staleness           Staleness
+	Snapshot            Snapshot
+	ImpressionListeners ImpressionListeners
+	Overrides           Overrides
+
+	// DefaultAttributes are merged into the attributes of every evaluation. Values bound by the client for its
+	// session, and those sent along with each call take precedence
+	DefaultAttributes map[string]interface{}
+}
+
+// Overrides configures forced treatments for specific keys & feature flags (used mostly for QA).
+// They can be seeded from a yaml/json file, and managed at runtime through the admin api
+type Overrides struct {
+	File string
+}
+
+// Redis holds the connection settings used in redis consumer mode. Impressions & events are accumulated in memory
+// and written to redis every `FlushPeriod`, in the format expected by Split Synchronizer.
+type Redis struct {
+	Host              string
+	Port              int
+	Database          int
+	Username          string
+	Password          string
+	Prefix            string
+	SentinelAddresses []string
+	SentinelMaster    string
+	ClusterNodes      []string
+	ClusterKeyHashTag string
+	PoolSize          int
+	MaxRetries        int
+	DialTimeout       time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	FlushPeriod       time.Duration
+}
+
+func (r *Redis) ToRedisConfig() *conf.RedisConfig {
+	return &conf.RedisConfig{
+		Host:              r.Host,
+		Port:              r.Port,
+		Database:          r.Database,
+		Username:          r.Username,
+		Password:          r.Password,
+		Prefix:            r.Prefix,
+		SentinelAddresses: r.SentinelAddresses,
+		SentinelMaster:    r.SentinelMaster,
+		ClusterNodes:      r.ClusterNodes,
+		ClusterKeyHashTag: r.ClusterKeyHashTag,
+		PoolSize:          r.PoolSize,
+		MaxRetries:        r.MaxRetries,
+		DialTimeout:       int(r.DialTimeout.Seconds()),
+		ReadTimeout:       int(r.ReadTimeout.Seconds()),
+		WriteTimeout:      int(r.WriteTimeout.Seconds()),
+	}
+}
+
+// Staleness holds the max time allowed without a successful synchronization before data is considered stale.
+// A zero threshold disables the check.
+type Staleness struct {
+	SplitsThreshold       time.Duration
+	SegmentsThreshold     time.Duration
+	UseFallbackTreatments bool
+}
+
+// Snapshot controls the periodic export of synchronized data to a local file & warm-starting from it.
+// An empty path disables both.
+type Snapshot struct {
+	Path          string
+	ExportPeriod  time.Duration
+	LoadOnStartup bool
+	MaxAge        time.Duration // zero means snapshots are loaded regardless of their age
+}
+
+// ImpressionListeners configures the sinks every impression is forwarded to (besides Split's backend).
+// Each sink is enabled by setting its destination.
+type ImpressionListeners struct {
+	QueueSize   int
+	BatchSize   int
+	FlushPeriod time.Duration
+	File        FileListener
+	Stream      StreamListener
+	Webhook     WebhookListener
+}
+
+type FileListener struct {
+	Path            string
+	MaxFiles        int
+	MaxBytesPerFile int64
+}
+
+type StreamListener struct {
+	Network      string // unix | tcp
+	Address      string
+	WriteTimeout time.Duration
+}
+
+type WebhookListener struct {
+	URL     string
+	Headers map[string]string
+	Timeout time.Duration
 }
 
 type Splits struct {
@@ -45,12 +171,80 @@ type Impressions struct {
 	SyncPeriod      time.Duration
 	CountSyncPeriod time.Duration
 	PostConcurrency int
+
+	// Watermark is the fill level (as a percentage of a queue, or of the memory budget) at which an early flush is
+	// requested, without waiting for the sync period to elapse. Zero disables it
+	Watermark int
+
+	// MaxMemoryBytes bounds the estimated size of all queued impressions (across every client metadata), zero
+	// meaning unbounded. DropPolicy determines what happens once it's reached
+	MaxMemoryBytes int64
+	DropPolicy     string
+
+	// ClientModes lists the modes a client can request upon registration, overriding `Mode` for its own impressions.
+	// Requests for modes not listed here are ignored
+	ClientModes []string
+
+	// ClientLabels allows clients to enable or disable labels for their own impressions upon registration
+	ClientLabels bool
 }
 
 type Events struct {
 	QueueSize       int
 	SyncPeriod      time.Duration
 	PostConcurrency int
+	Watermark       int    // same as in Impressions
+	MaxMemoryBytes  int64  // same as in Impressions, applied to events (and the queue of each sink, separately)
+	DropPolicy      string // same as in Impressions
+	File            EventsFileSink
+	Webhook         EventsWebhookSink
+	Collector       EventsCollectorSink
+}
+
+// EventsSink holds the settings shared by destinations events are forwarded to (besides Split's backend).
+// Each sink gets its own queue & flushing task. Empty filters match everything
+type EventsSink struct {
+	QueueSize    int
+	SyncPeriod   time.Duration
+	TrafficTypes []string
+	EventTypes   []string
+}
+
+// EventsFileSink writes events as newline-delimited JSON. It's enabled by setting a path
+type EventsFileSink struct {
+	EventsSink
+	Path            string
+	MaxFiles        int
+	MaxBytesPerFile int64
+}
+
+// EventsWebhookSink POSTs batches of events as JSON arrays. It's enabled by setting a URL
+type EventsWebhookSink struct {
+	EventsSink
+	URL     string
+	Headers map[string]string
+	Timeout time.Duration
+}
+
+// EventsCollectorSink posts events to a service implementing Split's events API (ie: a local collector).
+// It's enabled by setting its base URL
+type EventsCollectorSink struct {
+	EventsSink
+	URL string
+}
+
+// HTTP holds the settings applied to outgoing requests. Timeouts, proxy & TLS settings apply to every request made
+// by the process (including webhook sinks), whereas custom headers are only sent to split's services (or whatever
+// is configured to replace them, ie: split proxy or an events collector).
+type HTTP struct {
+	Timeout             time.Duration
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+	ProxyURL            string // if empty, HTTP_PROXY/HTTPS_PROXY/NO_PROXY env vars are honored
+	CAFile              string // PEM bundle trusted in addition to the system's CAs
+	CertFile            string // client certificate for mTLS
+	KeyFile             string
+	Headers             map[string]string
 }
 
 type URLs struct {
@@ -64,6 +258,9 @@ type URLs struct {
 func (c *Config) ToAdvancedConfig() *conf.AdvancedConfig {
 	d := conf.GetDefaultAdvancedConfig()
 
+	// commons takes whole seconds. round up so that sub-second timeouts don't end up disabling it
+	d.HTTPTimeout = int(math.Ceil(c.HTTP.Timeout.Seconds()))
+
 	d.SplitsRefreshRate = int(c.Splits.SyncPeriod.Seconds())
 	d.SplitUpdateQueueSize = int64(c.Splits.UpdateBufferSize)
 	d.SegmentsRefreshRate = int(c.Segments.SyncPeriod.Seconds())
@@ -91,6 +288,19 @@ func DefaultConfig() *Config {
 	return &Config{
 		LabelsEnabled:    true,
 		StreamingEnabled: true,
+		Storage:          StorageMemory,
+		QueueType:        QueueLocking,
+		Redis: Redis{
+			Host:              "localhost",
+			Port:              6379,
+			SentinelAddresses: []string{},
+			ClusterNodes:      []string{},
+			PoolSize:          10,
+			DialTimeout:       5 * time.Second,
+			ReadTimeout:       10 * time.Second,
+			WriteTimeout:      5 * time.Second,
+			FlushPeriod:       5 * time.Second,
+		},
 		Splits: Splits{
 			SyncPeriod:       30 * time.Second,
 			UpdateBufferSize: 5000,
@@ -108,11 +318,27 @@ func DefaultConfig() *Config {
 			SyncPeriod:      30 * time.Minute,
 			CountSyncPeriod: 60 * time.Minute,
 			PostConcurrency: 1,
+			DropPolicy:      DropNewest,
+			ClientModes:     []string{},
 		},
 		Events: Events{
 			QueueSize:       8192,
 			SyncPeriod:      1 * time.Minute,
 			PostConcurrency: 1,
+			DropPolicy:      DropNewest,
+			File: EventsFileSink{
+				EventsSink:      defaultEventsSink(5 * time.Second),
+				MaxFiles:        10,
+				MaxBytesPerFile: 100 * 1024 * 1024,
+			},
+			Webhook: EventsWebhookSink{
+				EventsSink: defaultEventsSink(5 * time.Second),
+				Headers:    map[string]string{},
+				Timeout:    5 * time.Second,
+			},
+			Collector: EventsCollectorSink{
+				EventsSink: defaultEventsSink(1 * time.Minute),
+			},
 		},
 		URLs: URLs{
 			Auth:      "https://auth.split.io",
@@ -121,13 +347,81 @@ func DefaultConfig() *Config {
 			Streaming: "https://streaming.split.io/sse",
 			Telemetry: "https://telemetry.split.io/api/v1",
 		},
+		HTTP: HTTP{
+			Timeout:             30 * time.Second,
+			DialTimeout:         30 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+			Headers:             map[string]string{},
+		},
 		FlagSetsFilter: []string{},
+		Snapshot: Snapshot{
+			ExportPeriod: 5 * time.Minute,
+			MaxAge:       24 * time.Hour,
+		},
+		ImpressionListeners: ImpressionListeners{
+			QueueSize:   10000,
+			BatchSize:   500,
+			FlushPeriod: time.Second,
+			File: FileListener{
+				MaxFiles:        10,
+				MaxBytesPerFile: 100 * 1024 * 1024,
+			},
+			Stream: StreamListener{
+				Network:      "unix",
+				WriteTimeout: time.Second,
+			},
+			Webhook: WebhookListener{
+				Headers: map[string]string{},
+				Timeout: 5 * time.Second,
+			},
+		},
 	}
 }
 
+func defaultEventsSink(period time.Duration) EventsSink {
+	return EventsSink{QueueSize: 8192, SyncPeriod: period, TrafficTypes: []string{}, EventTypes: []string{}}
+}
+
 func (c *Config) Normalize() []string {
 	var warnings []string
-	if c.Impressions.Mode == "optimized" && c.Impressions.SyncPeriod < minimumImpressionsRefreshRate {
+
+	clientModes := make([]string, 0, len(c.Impressions.ClientModes))
+	for _, mode := range c.Impressions.ClientModes {
+		switch mode {
+		case conf.ImpressionsModeDebug, conf.ImpressionsModeOptimized, conf.ImpressionsModeNone:
+			clientModes = append(clientModes, mode)
+		default:
+			warnings = append(warnings, fmt.Sprintf("unknown impressions mode '%s' allowed for clients. ignoring it", mode))
+		}
+	}
+	c.Impressions.ClientModes = clientModes
+
+	for _, queue := range []struct {
+		name       string
+		dropPolicy *string
+		watermark  *int
+	}{
+		{"impressions", &c.Impressions.DropPolicy, &c.Impressions.Watermark},
+		{"events", &c.Events.DropPolicy, &c.Events.Watermark},
+	} {
+		if *queue.dropPolicy != DropNewest && *queue.dropPolicy != DropOldest {
+			warnings = append(warnings, fmt.Sprintf("unknown %s drop policy '%s'. using '%s'", queue.name, *queue.dropPolicy, DropNewest))
+			*queue.dropPolicy = DropNewest
+		}
+		if *queue.watermark < 0 || *queue.watermark > 100 {
+			warnings = append(warnings, fmt.Sprintf("%s watermark must be between 0 and 100 (got %d). disabling it", queue.name, *queue.watermark))
+			*queue.watermark = 0
+		}
+	}
+
+	if c.QueueType != QueueLocking && c.QueueType != QueueSharded {
+		warnings = append(warnings, fmt.Sprintf("unknown queue type '%s'. using '%s'", c.QueueType, QueueLocking))
+		c.QueueType = QueueLocking
+	}
+
+	// impressions are posted to split directly only when running in memory mode
+	optimized := c.Impressions.Mode == conf.ImpressionsModeOptimized || slices.Contains(c.Impressions.ClientModes, conf.ImpressionsModeOptimized)
+	if c.Storage != StorageRedis && optimized && c.Impressions.SyncPeriod < minimumImpressionsRefreshRate {
 		warnings = append(warnings, "minimum impressions refresh rate is 30 min. ignoring user config")
 		c.Impressions.SyncPeriod = minimumImpressionsRefreshRate
 	}
@@ -143,5 +437,17 @@ func (c *Config) Normalize() []string {
 	}
 	c.FlagSetsFilter = sanitizedFlagSets
 
+	// in consumer mode data is kept up to date by Split Synchronizer, so these don't apply
+	if c.Storage == StorageRedis {
+		if c.Snapshot.Path != "" {
+			warnings = append(warnings, "snapshots are not supported when using redis storage. ignoring user config")
+			c.Snapshot.Path = ""
+		}
+		if c.Staleness.SplitsThreshold > 0 || c.Staleness.SegmentsThreshold > 0 {
+			warnings = append(warnings, "staleness checks are not supported when using redis storage. ignoring user config")
+			c.Staleness = Staleness{}
+		}
+	}
+
 	return warnings
 }
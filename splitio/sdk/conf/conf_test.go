@@ -33,4 +33,109 @@ func TestSDKConf(t *testing.T) {
 	assert.Equal(t, int(dc.Splits.SyncPeriod.Seconds()), adv.SplitsRefreshRate)
 	assert.Equal(t, int(dc.Segments.SyncPeriod.Seconds()), adv.SegmentsRefreshRate)
 	assert.Equal(t, dc.FlagSetsFilter, adv.FlagSetsFilter)
+
+	dc.HTTP.Timeout = 1500 * time.Millisecond
+	assert.Equal(t, 2, dc.ToAdvancedConfig().HTTPTimeout)
+}
+
+func TestSDKConfClientImpressionModes(t *testing.T) {
+	dc := DefaultConfig()
+	dc.Impressions.Mode = "debug"
+	dc.Impressions.SyncPeriod = 1 * time.Minute
+	dc.Impressions.ClientModes = []string{"none", "sarasa"}
+	warns := dc.Normalize()
+	assert.Equal(t, []string{"unknown impressions mode 'sarasa' allowed for clients. ignoring it"}, warns)
+	assert.Equal(t, []string{"none"}, dc.Impressions.ClientModes)
+	assert.Equal(t, 1*time.Minute, dc.Impressions.SyncPeriod)
+
+	// the minimum refresh rate applies if any client can use optimized mode
+	dc.Impressions.ClientModes = []string{"optimized"}
+	warns = dc.Normalize()
+	assert.Equal(t, []string{"minimum impressions refresh rate is 30 min. ignoring user config"}, warns)
+	assert.Equal(t, 30*time.Minute, dc.Impressions.SyncPeriod)
+}
+
+func TestSDKConfRedis(t *testing.T) {
+	dc := DefaultConfig()
+	dc.Storage = StorageRedis
+	dc.Impressions.SyncPeriod = 1 * time.Minute
+	dc.Snapshot.Path = "/tmp/snapshot.json"
+	dc.Staleness.SplitsThreshold = time.Minute
+	warns := dc.Normalize()
+	assert.Equal(t, []string{
+		"snapshots are not supported when using redis storage. ignoring user config",
+		"staleness checks are not supported when using redis storage. ignoring user config",
+	}, warns)
+	assert.Equal(t, 1*time.Minute, dc.Impressions.SyncPeriod)
+	assert.Equal(t, "", dc.Snapshot.Path)
+	assert.Equal(t, Staleness{}, dc.Staleness)
+
+	dc.Redis.Prefix = "someprefix"
+	dc.Redis.Password = "pass"
+	rc := dc.Redis.ToRedisConfig()
+	assert.Equal(t, "localhost", rc.Host)
+	assert.Equal(t, 6379, rc.Port)
+	assert.Equal(t, "someprefix", rc.Prefix)
+	assert.Equal(t, "pass", rc.Password)
+	assert.Equal(t, 10, rc.PoolSize)
+	assert.Equal(t, 5, rc.DialTimeout)
+	assert.Equal(t, 10, rc.ReadTimeout)
+	assert.Equal(t, 5, rc.WriteTimeout)
+}
+
+func TestSDKConfQueueType(t *testing.T) {
+	dc := DefaultConfig()
+	assert.Equal(t, QueueLocking, dc.QueueType)
+
+	dc.QueueType = QueueSharded
+	assert.Empty(t, dc.Normalize())
+	assert.Equal(t, QueueSharded, dc.QueueType)
+
+	dc.QueueType = "sarasa"
+	assert.Equal(t, []string{"unknown queue type 'sarasa'. using 'locking'"}, dc.Normalize())
+	assert.Equal(t, QueueLocking, dc.QueueType)
+}
+
+func TestSDKConfDropPolicy(t *testing.T) {
+	dc := DefaultConfig()
+	assert.Equal(t, int64(0), dc.Impressions.MaxMemoryBytes)
+	assert.Equal(t, DropNewest, dc.Impressions.DropPolicy)
+	assert.Equal(t, int64(0), dc.Events.MaxMemoryBytes)
+	assert.Equal(t, DropNewest, dc.Events.DropPolicy)
+
+	dc.Impressions.DropPolicy = DropOldest
+	dc.Events.DropPolicy = DropOldest
+	assert.Empty(t, dc.Normalize())
+	assert.Equal(t, DropOldest, dc.Impressions.DropPolicy)
+	assert.Equal(t, DropOldest, dc.Events.DropPolicy)
+
+	dc.Impressions.DropPolicy = "sarasa"
+	dc.Events.DropPolicy = "other"
+	assert.Equal(t, []string{
+		"unknown impressions drop policy 'sarasa'. using 'newest'",
+		"unknown events drop policy 'other'. using 'newest'",
+	}, dc.Normalize())
+	assert.Equal(t, DropNewest, dc.Impressions.DropPolicy)
+	assert.Equal(t, DropNewest, dc.Events.DropPolicy)
+}
+
+func TestSDKConfWatermark(t *testing.T) {
+	dc := DefaultConfig()
+	assert.Equal(t, 0, dc.Impressions.Watermark)
+	assert.Equal(t, 0, dc.Events.Watermark)
+
+	dc.Impressions.Watermark = 80
+	dc.Events.Watermark = 100
+	assert.Empty(t, dc.Normalize())
+	assert.Equal(t, 80, dc.Impressions.Watermark)
+	assert.Equal(t, 100, dc.Events.Watermark)
+
+	dc.Impressions.Watermark = -1
+	dc.Events.Watermark = 150
+	assert.Equal(t, []string{
+		"impressions watermark must be between 0 and 100 (got -1). disabling it",
+		"events watermark must be between 0 and 100 (got 150). disabling it",
+	}, dc.Normalize())
+	assert.Equal(t, 0, dc.Impressions.Watermark)
+	assert.Equal(t, 0, dc.Events.Watermark)
 }
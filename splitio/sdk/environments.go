@@ -0,0 +1,64 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// DefaultEnvironment is the name under which the sdk built from the top-level config is registered
+const DefaultEnvironment = ""
+
+var ErrUnknownEnvironment = errors.New("unknown environment")
+
+// Environments maps environment names to the sdk instance serving them, allowing a single daemon
+// to serve several split environments (each with its own sdk key)
+type Environments map[string]Interface
+
+// SingleEnvironment builds an environment set where the supplied sdk is the default (and only) one
+func SingleEnvironment(splitSDK Interface) Environments {
+	return Environments{DefaultEnvironment: splitSDK}
+}
+
+// Get returns the sdk instance serving environment `name`
+func (e Environments) Get(name string) (Interface, error) {
+	splitSDK, ok := e[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownEnvironment, name)
+	}
+	return splitSDK, nil
+}
+
+// Names returns the names of all registered environments, sorted
+func (e Environments) Names() []string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Ready returns true once every environment has completed its initial synchronization
+func (e Environments) Ready() bool {
+	for _, splitSDK := range e {
+		if splitSDK == nil || !splitSDK.Readiness().IsReady() {
+			return false
+		}
+	}
+	return true
+}
+
+// Shutdown shuts down every sdk instance, returning all errors encountered
+func (e Environments) Shutdown() error {
+	var errs []error
+	for _, name := range e.Names() {
+		if e[name] == nil {
+			continue
+		}
+		if err := e[name].Shutdown(); err != nil {
+			errs = append(errs, fmt.Errorf("error shutting down environment '%s': %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
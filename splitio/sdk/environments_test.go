@@ -0,0 +1,59 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type shutdownTracker struct {
+	Interface
+	calls int
+	err   error
+}
+
+func (s *shutdownTracker) Shutdown() error {
+	s.calls++
+	return s.err
+}
+
+type readinessTracker struct {
+	Interface
+	state State
+}
+
+func (r *readinessTracker) Readiness() State { return r.state }
+
+func TestEnvironmentsReady(t *testing.T) {
+	def, staging := &readinessTracker{state: StateReady}, &readinessTracker{state: StateNotReady}
+	envs := Environments{DefaultEnvironment: def, "staging": staging}
+	assert.False(t, envs.Ready())
+	staging.state = StateReadyDegraded
+	assert.True(t, envs.Ready())
+}
+
+func TestEnvironments(t *testing.T) {
+	def := &shutdownTracker{}
+	envs := SingleEnvironment(def)
+	res, err := envs.Get(DefaultEnvironment)
+	require.Nil(t, err)
+	assert.Equal(t, def, res)
+
+	staging := &shutdownTracker{err: errors.New("someErr")}
+	envs["staging"] = staging
+	res, err = envs.Get("staging")
+	require.Nil(t, err)
+	assert.Equal(t, staging, res)
+	assert.Equal(t, []string{DefaultEnvironment, "staging"}, envs.Names())
+
+	res, err = envs.Get("production")
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, ErrUnknownEnvironment)
+
+	err = envs.Shutdown()
+	assert.ErrorContains(t, err, "environment 'staging': someErr")
+	assert.Equal(t, 1, def.calls)
+	assert.Equal(t, 1, staging.calls)
+}
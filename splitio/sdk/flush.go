@@ -0,0 +1,122 @@
+package sdk
+
+import (
+	"sync/atomic"
+
+	"github.com/splitio/go-split-commons/v9/synchronizer/worker/event"
+	"github.com/splitio/go-split-commons/v9/synchronizer/worker/impression"
+	"github.com/splitio/splitd/splitio/sdk/types"
+)
+
+// FlushTriggers counts how many times a flush of impressions/events was triggered through each path
+type FlushTriggers struct {
+	Periodic  int64 // the sync period elapsed
+	QueueFull int64 // an item didn't fit in its queue
+	Watermark int64 // a queue filled up past the configured watermark
+}
+
+// FlushStats reports the flushes triggered since startup
+type FlushStats struct {
+	Impressions FlushTriggers
+	Events      FlushTriggers
+}
+
+type fillLevelProvider interface {
+	FillLevel(md types.ClientMetadata) int
+}
+
+// flushTrigger requests asynchronous flushes of an impressions/events storage by notifying the synchronizer,
+// either because a push didn't fit or because a queue reached the watermark. To avoid requesting a flush on every
+// push while one is in progress, the watermark is only re-armed once the fill level drops below half of it
+type flushTrigger struct {
+	notif     string
+	ch        chan<- string
+	storage   fillLevelProvider
+	high      int
+	low       int
+	armed     atomic.Bool
+	periodic  atomic.Int64
+	queueFull atomic.Int64
+	watermark atomic.Int64
+}
+
+func newFlushTrigger(notif string, ch chan<- string, storage fillLevelProvider, watermark int) *flushTrigger {
+	t := &flushTrigger{notif: notif, ch: ch, storage: storage, high: watermark, low: watermark / 2}
+	t.armed.Store(true)
+	return t
+}
+
+// full requests a flush after a push didn't fit. It returns false if the synchronizer has flushes pending already
+func (t *flushTrigger) full() bool {
+	if t == nil {
+		return false
+	}
+
+	select {
+	case t.ch <- t.notif:
+		t.queueFull.Add(1)
+		return true
+	default:
+		return false
+	}
+}
+
+// pushed checks the fill level of the queue `md` just pushed to, requesting a flush if it reached the watermark
+func (t *flushTrigger) pushed(md types.ClientMetadata) {
+	if t == nil || t.high <= 0 {
+		return
+	}
+
+	level := t.storage.FillLevel(md)
+	if level < t.low {
+		if !t.armed.Load() {
+			t.armed.Store(true)
+		}
+		return
+	}
+
+	if level < t.high || !t.armed.CompareAndSwap(true, false) {
+		return
+	}
+
+	select {
+	case t.ch <- t.notif:
+		t.watermark.Add(1)
+	default:
+		t.armed.Store(true) // the synchronizer is busy, try again on the next push
+	}
+}
+
+func (t *flushTrigger) stats() FlushTriggers {
+	if t == nil {
+		return FlushTriggers{}
+	}
+	return FlushTriggers{Periodic: t.periodic.Load(), QueueFull: t.queueFull.Load(), Watermark: t.watermark.Load()}
+}
+
+// periodicImpressionRecorder counts the flushes performed by the impressions sync task
+type periodicImpressionRecorder struct {
+	impression.ImpressionRecorder
+	trigger *flushTrigger
+}
+
+func (r *periodicImpressionRecorder) SynchronizeImpressions(bulkSize int64) error {
+	r.trigger.periodic.Add(1)
+	return r.ImpressionRecorder.SynchronizeImpressions(bulkSize)
+}
+
+// periodicEventRecorder counts the flushes performed by the events sync task
+type periodicEventRecorder struct {
+	event.EventRecorder
+	trigger *flushTrigger
+}
+
+func (r *periodicEventRecorder) SynchronizeEvents(bulkSize int64) error {
+	r.trigger.periodic.Add(1)
+	return r.EventRecorder.SynchronizeEvents(bulkSize)
+}
+
+var (
+	_ impression.ImpressionRecorder = (*periodicImpressionRecorder)(nil)
+	_ event.EventRecorder           = (*periodicEventRecorder)(nil)
+)
@@ -0,0 +1,109 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/splitd/splitio/sdk/storage"
+	"github.com/splitio/splitd/splitio/sdk/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlushTriggerWatermark(t *testing.T) {
+	md := types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}
+	es, _ := storage.NewEventsQueue(10) // rounded up to 16
+	ch := make(chan string, 2)
+	trigger := newFlushTrigger(eventsFullNotif, ch, es, 50)
+
+	push := func(n int) {
+		for idx := 0; idx < n; idx++ {
+			_, err := es.Push(md, dtos.EventDTO{Key: "k1"})
+			assert.Nil(t, err)
+			trigger.pushed(md)
+		}
+	}
+
+	// 7/16 items: below the watermark
+	push(7)
+	assert.Empty(t, ch)
+
+	// 8/16 items: watermark reached, a single flush is requested while above it
+	push(4)
+	assert.Equal(t, eventsFullNotif, <-ch)
+	assert.Empty(t, ch)
+	assert.Equal(t, FlushTriggers{Watermark: 1}, trigger.stats())
+
+	// dropping below the watermark (but not below half of it) doesn't re-arm it
+	flushed := make([]dtos.EventDTO, 0, 16)
+	es.Range(func(_ types.ClientMetadata, q storage.BackingQueue[dtos.EventDTO]) { q.Pop(6, &flushed) })
+	push(4)
+	assert.Empty(t, ch)
+
+	// once flushed, the next crossing triggers again
+	es.RangeAndClear(func(_ types.ClientMetadata, q storage.BackingQueue[dtos.EventDTO]) {})
+	push(8)
+	assert.Equal(t, eventsFullNotif, <-ch)
+	assert.Equal(t, FlushTriggers{Watermark: 2}, trigger.stats())
+}
+
+func TestFlushTriggerBusyChannel(t *testing.T) {
+	md := types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}
+	is, _ := storage.NewImpressionsQueue(4)
+	ch := make(chan string, 1)
+	ch <- eventsFullNotif
+	trigger := newFlushTrigger(impressionsFullNotif, ch, is, 50)
+
+	// the watermark stays armed while the notification can't be sent
+	is.Push(md, dtos.Impression{KeyName: "k1"}, dtos.Impression{KeyName: "k2"})
+	trigger.pushed(md)
+	assert.False(t, trigger.full())
+	assert.Equal(t, FlushTriggers{}, trigger.stats())
+
+	<-ch
+	trigger.pushed(md)
+	assert.Equal(t, impressionsFullNotif, <-ch)
+	assert.True(t, trigger.full())
+	assert.Equal(t, impressionsFullNotif, <-ch)
+	assert.Equal(t, FlushTriggers{QueueFull: 1, Watermark: 1}, trigger.stats())
+}
+
+func TestFlushTriggerDisabled(t *testing.T) {
+	md := types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}
+	is, _ := storage.NewImpressionsQueue(2)
+	ch := make(chan string, 2)
+	trigger := newFlushTrigger(impressionsFullNotif, ch, is, 0)
+	is.Push(md, dtos.Impression{KeyName: "k1"}, dtos.Impression{KeyName: "k2"})
+	trigger.pushed(md)
+	assert.Empty(t, ch)
+
+	// nil triggers are no-ops
+	var none *flushTrigger
+	none.pushed(md)
+	assert.False(t, none.full())
+	assert.Equal(t, FlushTriggers{}, none.stats())
+}
+
+type recorderMock struct{ synced int }
+
+func (r *recorderMock) SynchronizeImpressions(int64) error { r.synced++; return nil }
+func (r *recorderMock) FlushImpressions(int64) error       { return nil }
+func (r *recorderMock) SynchronizeEvents(int64) error      { r.synced++; return nil }
+func (r *recorderMock) FlushEvents(int64) error            { return nil }
+
+func TestFlushTriggerPeriodic(t *testing.T) {
+	impTrigger := newFlushTrigger(impressionsFullNotif, make(chan string), nil, 0)
+	evTrigger := newFlushTrigger(eventsFullNotif, make(chan string), nil, 0)
+	recorder := &recorderMock{}
+
+	imps := &periodicImpressionRecorder{recorder, impTrigger}
+	assert.Nil(t, imps.SynchronizeImpressions(100))
+	assert.Nil(t, imps.SynchronizeImpressions(100))
+	assert.Nil(t, imps.FlushImpressions(100)) // shutdown flushes are not periodic
+
+	evs := &periodicEventRecorder{recorder, evTrigger}
+	assert.Nil(t, evs.SynchronizeEvents(100))
+
+	assert.Equal(t, 3, recorder.synced)
+	assert.Equal(t, FlushTriggers{Periodic: 2}, impTrigger.stats())
+	assert.Equal(t, FlushTriggers{Periodic: 1}, evTrigger.stats())
+}
@@ -0,0 +1,168 @@
+package sdk
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/service/api"
+	commonStorage "github.com/splitio/go-split-commons/v9/storage"
+	"github.com/splitio/go-split-commons/v9/synchronizer/worker/event"
+	"github.com/splitio/go-toolkit/v5/asynctask"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/splitio/sdk/conf"
+	"github.com/splitio/splitd/splitio/sdk/sinks"
+	"github.com/splitio/splitd/splitio/sdk/storage"
+	"github.com/splitio/splitd/splitio/sdk/tasks"
+	"github.com/splitio/splitd/splitio/sdk/types"
+	"github.com/splitio/splitd/splitio/sdk/workers"
+)
+
+// eventForwarder tees tracked events into a dedicated queue that is periodically flushed to an alternative destination,
+// so that a slow destination can neither block nor delay the upload of events to Split
+type eventForwarder struct {
+	name         string
+	trafficTypes map[string]struct{}
+	eventTypes   map[string]struct{}
+	queue        *storage.EventsStorage
+	recorder     event.EventRecorder
+	task         *asynctask.AsyncTask
+	onStop       func() error
+	logger       logging.LoggerInterface
+	dropped      atomic.Int64
+}
+
+func newEventForwarder(name string, cfg *conf.EventsSink, opts storage.QueueOptions, recorderFactory func(*storage.EventsStorage) event.EventRecorder, logger logging.LoggerInterface) *eventForwarder {
+	queue, _ := storage.NewEventsQueueWithOptions(cfg.QueueSize, opts)
+	recorder := recorderFactory(queue)
+	f := &eventForwarder{
+		name:         name,
+		trafficTypes: toLowercaseSet(cfg.TrafficTypes), // traffic types are lowercased on track
+		eventTypes:   toSet(cfg.EventTypes),
+		queue:        queue,
+		recorder:     recorder,
+		logger:       logger,
+	}
+	f.task = tasks.NewEventsForwardingTask(fmt.Sprintf("events-forwarder-%s", name), f, logger, cfg.SyncPeriod)
+	if closer, ok := recorder.(interface{ Close() error }); ok {
+		f.onStop = closer.Close
+	}
+	return f
+}
+
+func (f *eventForwarder) accepts(e *dtos.EventDTO) bool {
+	if len(f.trafficTypes) > 0 {
+		if _, ok := f.trafficTypes[e.TrafficTypeName]; !ok {
+			return false
+		}
+	}
+	if len(f.eventTypes) > 0 {
+		if _, ok := f.eventTypes[e.EventTypeID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *eventForwarder) push(md types.ClientMetadata, e *dtos.EventDTO) {
+	if _, err := f.queue.Push(md, *e); err != nil {
+		f.dropped.Add(1)
+	}
+}
+
+// SynchronizeEvents implements event.EventRecorder. Events dropped since the previous flush are reported once,
+// rather than logging each of them on the track path
+func (f *eventForwarder) SynchronizeEvents(bulkSize int64) error {
+	if dropped := f.dropped.Swap(0); dropped > 0 {
+		f.logger.Warning(fmt.Sprintf("events forwarder '%s' queue is full. %d events were dropped", f.name, dropped))
+	}
+	return f.recorder.SynchronizeEvents(bulkSize)
+}
+
+// FlushEvents implements event.EventRecorder
+func (f *eventForwarder) FlushEvents(bulkSize int64) error {
+	return f.SynchronizeEvents(bulkSize)
+}
+
+func (f *eventForwarder) stop() error {
+	f.task.Stop(true) // flushes pending events
+	if f.onStop != nil {
+		return f.onStop()
+	}
+	return nil
+}
+
+type eventForwarders []*eventForwarder
+
+func setupEventForwarders(
+	logger logging.LoggerInterface,
+	apikey string,
+	cfg *conf.Config,
+	telemetry commonStorage.TelemetryRuntimeProducer,
+) eventForwarders {
+	var forwarders eventForwarders
+
+	evCfg := &cfg.Events
+	if fcfg := &evCfg.File; fcfg.Path != "" {
+		forwarders = append(forwarders, newEventForwarder("file", &fcfg.EventsSink, eventsQueueOptions(cfg), func(q *storage.EventsStorage) event.EventRecorder {
+			return workers.NewEventsSinkWorker(logger, sinks.NewFile[workers.EventRecord](fcfg.Path, fcfg.MaxFiles, fcfg.MaxBytesPerFile), q)
+		}, logger))
+	}
+
+	if wcfg := &evCfg.Webhook; wcfg.URL != "" {
+		forwarders = append(forwarders, newEventForwarder("webhook", &wcfg.EventsSink, eventsQueueOptions(cfg), func(q *storage.EventsStorage) event.EventRecorder {
+			return workers.NewEventsSinkWorker(logger, sinks.NewWebhook[workers.EventRecord](wcfg.URL, wcfg.Headers, wcfg.Timeout), q)
+		}, logger))
+	}
+
+	if ccfg := &evCfg.Collector; ccfg.URL != "" {
+		advCfg := cfg.ToAdvancedConfig()
+		advCfg.EventsURL = ccfg.URL
+		forwarders = append(forwarders, newEventForwarder("collector", &ccfg.EventsSink, eventsQueueOptions(cfg), func(q *storage.EventsStorage) event.EventRecorder {
+			return workers.NewEventsWorker(logger, telemetry, api.NewHTTPEventsRecorder(apikey, *advCfg, logger), q, evCfg)
+		}, logger))
+	}
+
+	return forwarders
+}
+
+func (f eventForwarders) start() {
+	for _, forwarder := range f {
+		forwarder.task.Start()
+	}
+}
+
+func (f eventForwarders) push(md types.ClientMetadata, e *dtos.EventDTO) {
+	for _, forwarder := range f {
+		if forwarder.accepts(e) {
+			forwarder.push(md, e)
+		}
+	}
+}
+
+func (f eventForwarders) stop(logger logging.LoggerInterface) {
+	for _, forwarder := range f {
+		if err := forwarder.stop(); err != nil {
+			logger.Error(fmt.Sprintf("error stopping events forwarder '%s': %s", forwarder.name, err))
+		}
+	}
+}
+
+func toSet(items []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		set[item] = struct{}{}
+	}
+	return set
+}
+
+func toLowercaseSet(items []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		set[strings.ToLower(item)] = struct{}{}
+	}
+	return set
+}
+
+var _ event.EventRecorder = (*eventForwarder)(nil)
@@ -0,0 +1,151 @@
+package sdk
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/storage/inmemory"
+	"github.com/splitio/go-split-commons/v9/synchronizer/worker/event"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/external/commons/mocks"
+	"github.com/splitio/splitd/splitio/common/lang"
+	"github.com/splitio/splitd/splitio/sdk/conf"
+	"github.com/splitio/splitd/splitio/sdk/storage"
+	"github.com/splitio/splitd/splitio/sdk/types"
+	"github.com/splitio/splitd/splitio/sdk/workers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventForwarderFilters(t *testing.T) {
+	f := &eventForwarder{trafficTypes: toLowercaseSet(nil), eventTypes: toSet(nil)}
+	assert.True(t, f.accepts(&dtos.EventDTO{TrafficTypeName: "user", EventTypeID: "checkin"}))
+
+	f.trafficTypes = toLowercaseSet([]string{"User"})
+	assert.True(t, f.accepts(&dtos.EventDTO{TrafficTypeName: "user", EventTypeID: "checkin"}))
+	assert.False(t, f.accepts(&dtos.EventDTO{TrafficTypeName: "account", EventTypeID: "checkin"}))
+
+	f.eventTypes = toSet([]string{"checkout"})
+	assert.False(t, f.accepts(&dtos.EventDTO{TrafficTypeName: "user", EventTypeID: "checkin"}))
+	assert.True(t, f.accepts(&dtos.EventDTO{TrafficTypeName: "user", EventTypeID: "checkout"}))
+}
+
+func TestEventForwarderDrops(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	var flushed int
+	cfg := conf.EventsSink{QueueSize: 4, SyncPeriod: time.Hour} // room for 3 events
+	f := newEventForwarder("some", &cfg, storage.QueueOptions{}, func(q *storage.EventsStorage) event.EventRecorder {
+		return &eventRecorderFunc{func() {
+			q.RangeAndClear(func(_ types.ClientMetadata, q storage.BackingQueue[dtos.EventDTO]) { flushed += q.Len() })
+		}}
+	}, logger)
+
+	md := types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}
+	forwarders := eventForwarders{f}
+	for idx := 0; idx < 5; idx++ {
+		forwarders.push(md, &dtos.EventDTO{Key: "key1", TrafficTypeName: "user", EventTypeID: "checkin"})
+	}
+	assert.Equal(t, int64(2), f.dropped.Load())
+
+	// drops are reported (& reset) once per flush
+	assert.Nil(t, f.SynchronizeEvents(5000))
+	assert.Equal(t, int64(0), f.dropped.Load())
+	assert.Equal(t, 3, flushed)
+}
+
+func TestTrackWithEventForwarders(t *testing.T) {
+	logger := logging.NewLogger(nil)
+
+	var mutex sync.Mutex
+	var webhookEvents []workers.EventRecord
+	var collectorEvents []dtos.EventDTO
+	var collectorVersion string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/webhook":
+			assert.Nil(t, json.Unmarshal(body, &webhookEvents))
+		case "/collector/events/bulk":
+			collectorVersion = r.Header.Get("SplitSDKVersion")
+			assert.Nil(t, json.Unmarshal(body, &collectorEvents))
+		default:
+			t.Error("unexpected path: ", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	cfg := conf.DefaultConfig()
+	cfg.Events.File.Path = filepath.Join(t.TempDir(), "events.ndjson")
+	cfg.Events.File.EventTypes = []string{"checkout"}
+	cfg.Events.Webhook.URL = server.URL + "/webhook"
+	cfg.Events.Webhook.TrafficTypes = []string{"account"}
+	cfg.Events.Collector.URL = server.URL + "/collector"
+
+	ts, _ := inmemory.NewTelemetryStorage()
+	forwarders := setupEventForwarders(logger, "someApikey", cfg, ts)
+	require.Len(t, forwarders, 3)
+	forwarders.start()
+
+	ss := &mocks.SplitStorageMock{}
+	ss.On("TrafficTypeExists", "user").Return(true)
+	ss.On("TrafficTypeExists", "account").Return(true)
+	es, _ := storage.NewEventsQueue(1000)
+	client := &Impl{
+		logger:     logger,
+		es:         es,
+		forwarders: forwarders,
+		validator:  Validator{logger, ss},
+	}
+
+	md := types.ClientConfig{Metadata: types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}}
+	_, err := client.Track(&md, "key1", "user", "checkin", nil, nil)
+	assert.Nil(t, err)
+	_, err = client.Track(&md, "key2", "user", "checkout", lang.Ref(1.5), nil)
+	assert.Nil(t, err)
+	_, err = client.Track(&md, "key3", "account", "checkin", nil, map[string]interface{}{"a": "b"})
+	assert.Nil(t, err)
+
+	for _, f := range forwarders { // wait for tasks to be running, otherwise stopping them won't flush
+		require.Eventually(t, f.task.IsRunning, time.Second, 5*time.Millisecond)
+	}
+	forwarders.stop(logger)
+
+	raw, err := os.ReadFile(cfg.Events.File.Path)
+	require.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	require.Len(t, lines, 1)
+	var fromFile workers.EventRecord
+	require.Nil(t, json.Unmarshal([]byte(lines[0]), &fromFile))
+	assert.Equal(t, "key2", fromFile.Key)
+	assert.Equal(t, 1.5, fromFile.Value)
+	assert.Equal(t, "some", fromFile.ClientID)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Len(t, webhookEvents, 1)
+	assert.Equal(t, "key3", webhookEvents[0].Key)
+	assert.Equal(t, map[string]interface{}{"a": "b"}, webhookEvents[0].Properties)
+	assert.Equal(t, "go-1.2.3", webhookEvents[0].ClientSdkVersion)
+
+	assert.Len(t, collectorEvents, 3)
+	assert.Equal(t, "go-1.2.3", collectorVersion)
+
+	// every event is still queued to be sent to split
+	es.RangeAndClear(func(_ types.ClientMetadata, q storage.BackingQueue[dtos.EventDTO]) { assert.Equal(t, 3, q.Len()) })
+}
+
+type eventRecorderFunc struct{ flush func() }
+
+func (r *eventRecorderFunc) SynchronizeEvents(int64) error { r.flush(); return nil }
+func (r *eventRecorderFunc) FlushEvents(int64) error       { r.flush(); return nil }
@@ -74,6 +74,8 @@ func setupTasks(
 	logger logging.LoggerInterface,
 	workers *synchronizer.Workers,
 	impComponents impComponents,
+	impFlush *flushTrigger,
+	evFlush *flushTrigger,
 ) *synchronizer.SplitTasks {
 	impCfg := cfg.Impressions
 	evCfg := cfg.Events
@@ -88,8 +90,8 @@ func setupTasks(
 			logger,
 			dummyHC,
 		),
-		ImpressionSyncTask: tasks.NewRecordImpressionsTask(workers.ImpressionRecorder, int(impCfg.SyncPeriod.Seconds()), logger, 5000),
-		EventSyncTask:      tasks.NewRecordEventsTask(workers.EventRecorder, 5000, int(evCfg.SyncPeriod.Seconds()), logger),
+		ImpressionSyncTask: tasks.NewRecordImpressionsTask(&periodicImpressionRecorder{workers.ImpressionRecorder, impFlush}, int(impCfg.SyncPeriod.Seconds()), logger, 5000),
+		EventSyncTask:      tasks.NewRecordEventsTask(&periodicEventRecorder{workers.EventRecorder, evFlush}, 5000, int(evCfg.SyncPeriod.Seconds()), logger),
 		TelemetrySyncTask:  &NoOpTask{},
 		UniqueKeysTask:     tasks.NewRecordUniqueKeysTask(workers.TelemetryRecorder, *impComponents.tracker, uniqueKeysPeriodTaskInMemory, logger),
 		CleanFilterTask:    tasks.NewCleanFilterTask(*impComponents.filter, logger, bfCleaningPeriod),
@@ -105,6 +107,7 @@ func setupTasks(
 
 type impComponents struct {
 	manager provisional.ImpressionManager
+	byMode  map[string]provisional.ImpressionManager // managers for modes clients are allowed to request
 	counter *strategy.ImpressionsCounter
 	tracker *strategy.UniqueKeysTracker
 	filter  *storage.Filter
@@ -112,30 +115,50 @@ type impComponents struct {
 
 func setupImpressionsComponents(c *sdkConf.Impressions, telemetry storage.TelemetryRuntimeProducer) (impComponents, error) {
 
-	observer, err := strategy.NewImpressionObserver(c.ObserverSize)
-	if err != nil {
-		return impComponents{}, fmt.Errorf("error building impressions observer: %w", err)
-	}
-
 	counter := strategy.NewImpressionsCounter()
 	bf := filter.NewBloomFilter(bfExpectedElemenets, bfFalsePositiveProbability)
 	tracker := strategy.NewUniqueKeysTracker(bf)
 	none := strategy.NewNoneImpl(counter, tracker, false)
 
-	var s strategy.ProcessStrategyInterface
-	switch c.Mode {
-	case conf.ImpressionsModeDebug:
-		s = strategy.NewDebugImpl(observer, false)
-	case conf.ImpressionsModeNone:
-		s = none
-	default: // optimized
-		s = strategy.NewOptimizedImpl(observer, counter, telemetry, false)
+	// counts & unique keys are shared by all strategies, since they're flushed by the same tasks.
+	// each strategy gets its own observer, so that dedupe in one mode doesn't affect the others
+	newManager := func(mode string) (provisional.ImpressionManager, error) {
+		observer, err := strategy.NewImpressionObserver(c.ObserverSize)
+		if err != nil {
+			return nil, fmt.Errorf("error building impressions observer: %w", err)
+		}
+
+		var s strategy.ProcessStrategyInterface
+		switch mode {
+		case conf.ImpressionsModeDebug:
+			s = strategy.NewDebugImpl(observer, false)
+		case conf.ImpressionsModeNone:
+			s = none
+		default: // optimized
+			s = strategy.NewOptimizedImpl(observer, counter, telemetry, false)
+		}
+		return provisional.NewImpressionManagerImp(none, s), nil
 	}
 
-	impManager := provisional.NewImpressionManagerImp(none, s)
+	impManager, err := newManager(c.Mode)
+	if err != nil {
+		return impComponents{}, err
+	}
+
+	byMode := make(map[string]provisional.ImpressionManager, len(c.ClientModes))
+	for _, mode := range c.ClientModes {
+		if mode == c.Mode {
+			byMode[mode] = impManager
+			continue
+		}
+		if byMode[mode], err = newManager(mode); err != nil {
+			return impComponents{}, err
+		}
+	}
 
 	return impComponents{
 		manager: impManager,
+		byMode:  byMode,
 		counter: counter,
 		tracker: &tracker,
 		filter:  &bf,
@@ -151,10 +174,40 @@ type storages struct {
 	events            *sss.EventsStorage
 }
 
+func queueType(cfg *sdkConf.Config) sss.QueueType {
+	if cfg.QueueType == sdkConf.QueueSharded {
+		return sss.QueueSharded
+	}
+	return sss.QueueLocking
+}
+
+func dropPolicy(policy string) sss.DropPolicy {
+	if policy == sdkConf.DropOldest {
+		return sss.DropOldest
+	}
+	return sss.DropNewest
+}
+
+func impressionsQueueOptions(cfg *sdkConf.Config) sss.QueueOptions {
+	return sss.QueueOptions{
+		Type:         queueType(cfg),
+		MemoryBudget: cfg.Impressions.MaxMemoryBytes,
+		DropPolicy:   dropPolicy(cfg.Impressions.DropPolicy),
+	}
+}
+
+func eventsQueueOptions(cfg *sdkConf.Config) sss.QueueOptions {
+	return sss.QueueOptions{
+		Type:         queueType(cfg),
+		MemoryBudget: cfg.Events.MaxMemoryBytes,
+		DropPolicy:   dropPolicy(cfg.Events.DropPolicy),
+	}
+}
+
 func setupStorages(cfg *sdkConf.Config, flagSetsFilter flagsets.FlagSetFilter) *storages {
 	ts, _ := inmemory.NewTelemetryStorage()
-	iq, _ := sss.NewImpressionsQueue(cfg.Impressions.QueueSize)
-	eq, _ := sss.NewEventsQueue(cfg.Events.QueueSize)
+	iq, _ := sss.NewImpressionsQueueWithOptions(cfg.Impressions.QueueSize, impressionsQueueOptions(cfg))
+	eq, _ := sss.NewEventsQueueWithOptions(cfg.Events.QueueSize, eventsQueueOptions(cfg))
 
 	return &storages{
 		splits:            mutexmap.NewMMSplitStorage(flagSetsFilter),
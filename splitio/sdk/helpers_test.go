@@ -3,8 +3,11 @@ package sdk
 import (
 	"testing"
 
+	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/go-split-commons/v9/flagsets"
 	sdkConf "github.com/splitio/splitd/splitio/sdk/conf"
+	sss "github.com/splitio/splitd/splitio/sdk/storage"
+	"github.com/splitio/splitd/splitio/sdk/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,12 +22,66 @@ func TestSetupImpressionsComponents(t *testing.T) {
 	assert.NotNil(t, ic.tracker)
 	assert.NotNil(t, ic.filter)
 
+	assert.Empty(t, ic.byMode)
+
 	sdkCfg.Impressions.Mode = "debug"
+	sdkCfg.Impressions.ClientModes = []string{"debug", "none"}
 	ic, err = setupImpressionsComponents(&sdkCfg.Impressions, storages.telemetry)
 	assert.Nil(t, err)
 	assert.NotNil(t, ic.counter)
 	assert.NotNil(t, ic.tracker)
 	assert.NotNil(t, ic.filter)
+	assert.Len(t, ic.byMode, 2)
+	assert.Equal(t, ic.manager, ic.byMode["debug"])
+	assert.NotNil(t, ic.byMode["none"])
+}
+
+func TestSetupStoragesQueueType(t *testing.T) {
+	sdkCfg := sdkConf.DefaultConfig()
+	md := types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}
+
+	storages := setupStorages(sdkCfg, flagsets.FlagSetFilter{})
+	storages.impressions.Push(md, dtos.Impression{KeyName: "k1"})
+	storages.events.Push(md, dtos.EventDTO{Key: "k1"})
+	storages.impressions.Range(func(_ types.ClientMetadata, q sss.BackingQueue[dtos.Impression]) {
+		assert.IsType(t, &sss.LockingQueue[dtos.Impression]{}, q)
+	})
+	storages.events.Range(func(_ types.ClientMetadata, q sss.BackingQueue[dtos.EventDTO]) {
+		assert.IsType(t, &sss.LockingQueue[dtos.EventDTO]{}, q)
+	})
+
+	sdkCfg.QueueType = sdkConf.QueueSharded
+	storages = setupStorages(sdkCfg, flagsets.FlagSetFilter{})
+	storages.impressions.Push(md, dtos.Impression{KeyName: "k1"})
+	storages.events.Push(md, dtos.EventDTO{Key: "k1"})
+	storages.impressions.Range(func(_ types.ClientMetadata, q sss.BackingQueue[dtos.Impression]) {
+		assert.IsType(t, &sss.ShardedQueue[dtos.Impression]{}, q)
+	})
+	storages.events.Range(func(_ types.ClientMetadata, q sss.BackingQueue[dtos.EventDTO]) {
+		assert.IsType(t, &sss.ShardedQueue[dtos.EventDTO]{}, q)
+	})
+}
+
+func TestSetupStoragesMemoryBudget(t *testing.T) {
+	sdkCfg := sdkConf.DefaultConfig()
+	storages := setupStorages(sdkCfg, flagsets.FlagSetFilter{})
+	assert.Nil(t, storages.impressions.Budget())
+	assert.Nil(t, storages.events.Budget())
+
+	sdkCfg.Impressions.MaxMemoryBytes = 1024
+	sdkCfg.Events.MaxMemoryBytes = 2048
+	sdkCfg.Events.DropPolicy = sdkConf.DropOldest
+	storages = setupStorages(sdkCfg, flagsets.FlagSetFilter{})
+	assert.Equal(t, int64(1024), storages.impressions.Budget().Limit())
+	assert.Equal(t, int64(2048), storages.events.Budget().Limit())
+
+	md := types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}
+	var err error
+	for err == nil {
+		_, err = storages.impressions.Push(md, dtos.Impression{KeyName: "k1"})
+	}
+	assert.ErrorIs(t, err, sss.ErrQueueFull)
+	assert.Equal(t, int64(1), storages.impressions.Budget().Dropped())
 }
 
 func TestNoOpTask(t *testing.T) {
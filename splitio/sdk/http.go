@@ -0,0 +1,121 @@
+package sdk
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/splitio/splitd/splitio/sdk/conf"
+)
+
+var ErrInvalidHTTPConfig = errors.New("invalid http config")
+
+// setupHTTP installs a transport built from the http settings as the process-wide default.
+// Commons builds its fetchers, recorders & streaming client internally on top of `http.DefaultTransport`
+// (the latter cloning it as an *http.Transport), so that's the only way to have the settings apply to all of them.
+func setupHTTP(cfg *conf.Config) error {
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("%w: default transport has been replaced by an incompatible implementation", ErrInvalidHTTPConfig)
+	}
+
+	transport, err := newTransport(base, &cfg.HTTP, splitHosts(cfg))
+	if err != nil {
+		return err
+	}
+
+	http.DefaultTransport = transport
+	return nil
+}
+
+func newTransport(base *http.Transport, cfg *conf.HTTP, headerHosts map[string]struct{}) (*http.Transport, error) {
+	transport := base.Clone()
+	transport.DialContext = (&net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+
+	proxy := http.ProxyFromEnvironment
+	if cfg.ProxyURL != "" {
+		parsed, err := url.Parse(cfg.ProxyURL)
+		if err != nil || parsed.Host == "" {
+			return nil, fmt.Errorf("%w: cannot parse proxy url '%s'", ErrInvalidHTTPConfig, cfg.ProxyURL)
+		}
+		proxy = http.ProxyURL(parsed)
+	}
+
+	// the proxy hook is the only per-request extension point in an *http.Transport. we use it to add
+	// the custom headers to requests bound to split's services, without leaking them to any other destination
+	headers := cfg.Headers
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		if _, ok := headerHosts[req.URL.Host]; ok {
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+		}
+		return proxy(req)
+	}
+
+	tlsConfig, err := newTLSConfig(base.TLSClientConfig, cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+func newTLSConfig(base *tls.Config, cfg *conf.HTTP) (*tls.Config, error) {
+	if cfg.CAFile == "" && cfg.CertFile == "" && cfg.KeyFile == "" {
+		return base, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if base != nil {
+		tlsConfig = base.Clone()
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: error reading CA file: %s", ErrInvalidHTTPConfig, err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: no certificates found in CA file '%s'", ErrInvalidHTTPConfig, cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, fmt.Errorf("%w: both a client certificate & key are required for mTLS", ErrInvalidHTTPConfig)
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: error loading client certificate: %s", ErrInvalidHTTPConfig, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// splitHosts returns the hosts of split's services (or whatever is configured to replace them)
+func splitHosts(cfg *conf.Config) map[string]struct{} {
+	hosts := make(map[string]struct{})
+	for _, raw := range []string{cfg.URLs.Auth, cfg.URLs.SDK, cfg.URLs.Events, cfg.URLs.Streaming, cfg.URLs.Telemetry, cfg.Events.Collector.URL} {
+		if parsed, err := url.Parse(raw); err == nil && parsed.Host != "" {
+			hosts[parsed.Host] = struct{}{}
+		}
+	}
+	return hosts
+}
@@ -0,0 +1,110 @@
+package sdk
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/splitio/splitd/splitio/sdk/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPProxyAndHeaders(t *testing.T) {
+	var received []*http.Request
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r)
+	}))
+	defer proxy.Close()
+
+	cfg := conf.DefaultConfig()
+	cfg.URLs.SDK = "http://sdk.split.local/api"
+	cfg.HTTP.ProxyURL = proxy.URL
+	cfg.HTTP.Headers = map[string]string{"X-Custom": "someValue"}
+
+	transport, err := newTransport(http.DefaultTransport.(*http.Transport), &cfg.HTTP, splitHosts(cfg))
+	require.Nil(t, err)
+	client := http.Client{Transport: transport}
+
+	resp, err := client.Get("http://sdk.split.local/api/splitChanges")
+	require.Nil(t, err)
+	resp.Body.Close()
+	resp, err = client.Get("http://elsewhere.local/webhook")
+	require.Nil(t, err)
+	resp.Body.Close()
+
+	require.Len(t, received, 2)
+	assert.Equal(t, "sdk.split.local", received[0].Host)
+	assert.Equal(t, "someValue", received[0].Header.Get("X-Custom"))
+	assert.Equal(t, "elsewhere.local", received[1].Host)
+	assert.Equal(t, "", received[1].Header.Get("X-Custom")) // headers are only sent to split
+
+	cfg.HTTP.ProxyURL = "not a url"
+	_, err = newTransport(http.DefaultTransport.(*http.Transport), &cfg.HTTP, nil)
+	assert.ErrorIs(t, err, ErrInvalidHTTPConfig)
+}
+
+func TestHTTPCustomCAAndClientCert(t *testing.T) {
+	var peerCerts int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peerCerts = len(r.TLS.PeerCertificates)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	// reuse the test server's self-signed certificate both as CA & client certificate
+	dir := t.TempDir()
+	cert := server.TLS.Certificates[0]
+	caFile := filepath.Join(dir, "ca.pem")
+	require.Nil(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600))
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	require.Nil(t, err)
+	keyFile := filepath.Join(dir, "key.pem")
+	require.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}), 0600))
+
+	cfg := conf.DefaultConfig().HTTP
+	base := http.DefaultTransport.(*http.Transport)
+
+	// unknown CA
+	transport, err := newTransport(base, &cfg, nil)
+	require.Nil(t, err)
+	_, err = (&http.Client{Transport: transport}).Get(server.URL)
+	assert.NotNil(t, err)
+
+	cfg.CAFile = caFile
+	cfg.CertFile = caFile
+	cfg.KeyFile = keyFile
+	transport, err = newTransport(base, &cfg, nil)
+	require.Nil(t, err)
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 1, peerCerts)
+
+	cfg.KeyFile = ""
+	_, err = newTransport(base, &cfg, nil)
+	assert.ErrorIs(t, err, ErrInvalidHTTPConfig)
+
+	cfg.CAFile = keyFile // no certificates in it
+	cfg.CertFile = ""
+	_, err = newTransport(base, &cfg, nil)
+	assert.ErrorIs(t, err, ErrInvalidHTTPConfig)
+}
+
+func TestSplitHosts(t *testing.T) {
+	cfg := conf.DefaultConfig()
+	cfg.Events.Collector.URL = "http://localhost:3010/api"
+	hosts := splitHosts(cfg)
+	for _, raw := range []string{cfg.URLs.Auth, cfg.URLs.SDK, cfg.URLs.Events, cfg.URLs.Streaming, cfg.URLs.Telemetry, cfg.Events.Collector.URL} {
+		parsed, _ := url.Parse(raw)
+		assert.Contains(t, hosts, parsed.Host)
+	}
+	assert.Len(t, hosts, 6)
+}
@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/go-split-commons/v9/service/api/specs"
@@ -122,6 +123,7 @@ func TestInstantiationAndGetTreatmentE2E(t *testing.T) {
 	logger := logging.NewLogger(nil)
 	client, err := New(logger, "someApikey", sdkConf)
 	assert.Nil(t, err)
+	assert.Nil(t, client.BlockUntilReady(time.Second))
 
 	res, _ := client.Treatment(&types.ClientConfig{}, "aaaaaaklmnbv", nil, "split", nil, nil)
 	assert.Equal(t, "on", res.Treatment)
@@ -234,6 +236,7 @@ func TestInstantiationAndGetTreatmentE2EWithFallbackTreatment(t *testing.T) {
 	logger := logging.NewLogger(nil)
 	client, err := New(logger, "someApikey", sdkConf)
 	assert.Nil(t, err)
+	assert.Nil(t, client.BlockUntilReady(time.Second))
 
 	opts := dtos.EvaluationOptions{
 		Properties: map[string]interface{}{
@@ -369,6 +372,7 @@ func TestInstantiationAndGetTreatmentE2EWithPrerequistesNotAchive(t *testing.T)
 	logger := logging.NewLogger(nil)
 	client, err := New(logger, "someApikey", sdkConf)
 	assert.Nil(t, err)
+	assert.Nil(t, client.BlockUntilReady(time.Second))
 
 	res1, _ := client.Treatment(&types.ClientConfig{}, "aaaaaaklmnbv", nil, "split", nil, nil)
 	assert.Equal(t, "default", res1.Treatment)
@@ -497,6 +501,7 @@ func TestInstantiationAndGetTreatmentE2EWithPrerequistesAchive(t *testing.T) {
 	logger := logging.NewLogger(nil)
 	client, err := New(logger, "someApikey", sdkConf)
 	assert.Nil(t, err)
+	assert.Nil(t, client.BlockUntilReady(time.Second))
 
 	res1, _ := client.Treatment(&types.ClientConfig{}, "aaaaaaklmnbv", nil, "split", nil, nil)
 	assert.Equal(t, "on", res1.Treatment)
@@ -646,6 +651,7 @@ func TestInstantiationAndGetTreatmentE2EWithRBS(t *testing.T) {
 	logger := logging.NewLogger(nil)
 	client, err := New(logger, "someApikey", sdkConf)
 	assert.Nil(t, err)
+	assert.Nil(t, client.BlockUntilReady(time.Second))
 	attributes := make(map[string]interface{})
 	attributes["version"] = "3.4.5"
 
@@ -0,0 +1,204 @@
+package listeners
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	sdkconf "github.com/splitio/splitd/splitio/sdk/conf"
+	"github.com/splitio/splitd/splitio/sdk/sinks"
+	"github.com/splitio/splitd/splitio/sdk/types"
+)
+
+// Record is the representation of an impression handed to listener sinks
+type Record struct {
+	Feature          string          `json:"feature"`
+	Key              string          `json:"key"`
+	BucketingKey     string          `json:"bucketingKey,omitempty"`
+	Treatment        string          `json:"treatment"`
+	Label            string          `json:"label,omitempty"`
+	ChangeNumber     int64           `json:"changeNumber"`
+	Time             int64           `json:"time"`
+	Properties       json.RawMessage `json:"properties,omitempty"`
+	ClientID         string          `json:"clientId"`
+	ClientSdkVersion string          `json:"clientSdkVersion"`
+}
+
+func newRecord(md types.ClientMetadata, imp *dtos.Impression) Record {
+	r := Record{
+		Feature:          imp.FeatureName,
+		Key:              imp.KeyName,
+		BucketingKey:     imp.BucketingKey,
+		Treatment:        imp.Treatment,
+		Label:            imp.Label,
+		ChangeNumber:     imp.ChangeNumber,
+		Time:             imp.Time,
+		ClientID:         md.ID,
+		ClientSdkVersion: md.SdkVersion,
+	}
+	if imp.Properties != "" && json.Valid([]byte(imp.Properties)) {
+		r.Properties = json.RawMessage(imp.Properties)
+	}
+	return r
+}
+
+// Sink is a destination for impression records
+type Sink = sinks.Sink[Record]
+
+// Manager fans out impressions to every configured sink. Each sink is fed asynchronously through its own
+// bounded queue, so that a slow or failing sink neither blocks evaluations nor delays the other sinks.
+type Manager struct {
+	logger logging.LoggerInterface
+	sinks  []*asyncSink
+	mutex  sync.RWMutex
+	closed bool
+}
+
+// New builds a manager with the sinks enabled in the config. It returns nil if none is enabled
+func New(logger logging.LoggerInterface, cfg *sdkconf.ImpressionListeners) (*Manager, error) {
+	var enabled []Sink
+	if cfg.File.Path != "" {
+		enabled = append(enabled, sinks.NewFile[Record](cfg.File.Path, cfg.File.MaxFiles, cfg.File.MaxBytesPerFile))
+	}
+	if cfg.Stream.Address != "" {
+		enabled = append(enabled, sinks.NewStream[Record](cfg.Stream.Network, cfg.Stream.Address, cfg.Stream.WriteTimeout))
+	}
+	if cfg.Webhook.URL != "" {
+		enabled = append(enabled, sinks.NewWebhook[Record](cfg.Webhook.URL, cfg.Webhook.Headers, cfg.Webhook.Timeout))
+	}
+
+	if len(enabled) == 0 {
+		return nil, nil
+	}
+
+	return NewWithSinks(logger, cfg, enabled...)
+}
+
+// NewWithSinks builds a manager with custom sinks
+func NewWithSinks(logger logging.LoggerInterface, cfg *sdkconf.ImpressionListeners, custom ...Sink) (*Manager, error) {
+	if cfg.QueueSize <= 0 || cfg.BatchSize <= 0 || cfg.FlushPeriod <= 0 {
+		return nil, fmt.Errorf("queue size, batch size & flush period must be greater than zero")
+	}
+
+	m := &Manager{logger: logger, sinks: make([]*asyncSink, 0, len(custom))}
+	for _, sink := range custom {
+		s := &asyncSink{
+			sink:        sink,
+			logger:      logger,
+			queue:       make(chan Record, cfg.QueueSize),
+			batchSize:   cfg.BatchSize,
+			flushPeriod: cfg.FlushPeriod,
+			done:        make(chan struct{}),
+		}
+		go s.run()
+		m.sinks = append(m.sinks, s)
+	}
+	return m, nil
+}
+
+// Push queues impressions for every sink without blocking. Impressions are dropped if a sink's queue is full
+func (m *Manager) Push(md types.ClientMetadata, imps ...dtos.Impression) {
+	if m == nil || len(imps) == 0 {
+		return
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if m.closed {
+		return
+	}
+
+	for idx := range imps {
+		record := newRecord(md, &imps[idx])
+		for _, s := range m.sinks {
+			s.push(record)
+		}
+	}
+}
+
+// Shutdown stops accepting impressions, flushes whatever is queued & closes the sinks
+func (m *Manager) Shutdown() {
+	if m == nil {
+		return
+	}
+
+	m.mutex.Lock()
+	if m.closed {
+		m.mutex.Unlock()
+		return
+	}
+	m.closed = true
+	for _, s := range m.sinks {
+		close(s.queue)
+	}
+	m.mutex.Unlock()
+
+	for _, s := range m.sinks {
+		<-s.done
+		if err := s.sink.Close(); err != nil {
+			m.logger.Error(fmt.Sprintf("error closing impression listener '%s': %s", s.sink.Name(), err))
+		}
+	}
+}
+
+type asyncSink struct {
+	sink        Sink
+	logger      logging.LoggerInterface
+	queue       chan Record
+	batchSize   int
+	flushPeriod time.Duration
+	dropped     atomic.Int64
+	done        chan struct{}
+}
+
+func (s *asyncSink) push(r Record) {
+	select {
+	case s.queue <- r:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+func (s *asyncSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.flushPeriod)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, s.batchSize)
+	for {
+		select {
+		case r, ok := <-s.queue:
+			if !ok {
+				s.flush(batch)
+				return
+			}
+			batch = append(batch, r)
+			if len(batch) >= s.batchSize {
+				batch = s.flush(batch)
+			}
+		case <-ticker.C:
+			batch = s.flush(batch)
+		}
+	}
+}
+
+// flush writes the batch & returns an empty one (reusing the underlying array)
+func (s *asyncSink) flush(batch []Record) []Record {
+	if dropped := s.dropped.Swap(0); dropped > 0 {
+		s.logger.Warning(fmt.Sprintf("impression listener '%s' queue is full. %d impressions were dropped", s.sink.Name(), dropped))
+	}
+
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := s.sink.Write(batch); err != nil {
+		s.logger.Error(fmt.Sprintf("impression listener '%s' failed to write %d impressions: %s", s.sink.Name(), len(batch), err))
+	}
+	return batch[:0]
+}
@@ -0,0 +1,126 @@
+package listeners
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	sdkconf "github.com/splitio/splitd/splitio/sdk/conf"
+	"github.com/splitio/splitd/splitio/sdk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerBatching(t *testing.T) {
+	sink := &sinkMock{}
+	cfg := &sdkconf.ImpressionListeners{QueueSize: 100, BatchSize: 2, FlushPeriod: time.Hour}
+	m, err := NewWithSinks(logging.NewLogger(nil), cfg, sink)
+	require.Nil(t, err)
+
+	md := types.ClientMetadata{ID: "some", SdkVersion: "php-1.2.3"}
+	m.Push(md, dtos.Impression{FeatureName: "f1", KeyName: "k1", Treatment: "on", Label: "l1", ChangeNumber: 1, Time: 2, Properties: `{"a":1}`})
+	m.Push(md, dtos.Impression{FeatureName: "f2", KeyName: "k1", BucketingKey: "b1", Treatment: "off"})
+	m.Push(md, dtos.Impression{FeatureName: "f3", KeyName: "k1", Treatment: "off"})
+
+	// first batch is written as soon as it's full
+	require.Eventually(t, func() bool { return len(sink.batches()) == 1 }, time.Second, 5*time.Millisecond)
+
+	// second one (incomplete) on shutdown
+	m.Shutdown()
+	m.Push(md, dtos.Impression{FeatureName: "f4"}) // ignored
+	m.Shutdown()                                   // no-op
+
+	batches := sink.batches()
+	require.Len(t, batches, 2)
+	assert.Equal(t, []Record{
+		{Feature: "f1", Key: "k1", Treatment: "on", Label: "l1", ChangeNumber: 1, Time: 2, Properties: json.RawMessage(`{"a":1}`), ClientID: "some", ClientSdkVersion: "php-1.2.3"},
+		{Feature: "f2", Key: "k1", BucketingKey: "b1", Treatment: "off", ClientID: "some", ClientSdkVersion: "php-1.2.3"},
+	}, batches[0])
+	assert.Equal(t, []Record{{Feature: "f3", Key: "k1", Treatment: "off", ClientID: "some", ClientSdkVersion: "php-1.2.3"}}, batches[1])
+	assert.True(t, sink.closed)
+}
+
+func TestManagerPeriodicFlush(t *testing.T) {
+	sink := &sinkMock{}
+	cfg := &sdkconf.ImpressionListeners{QueueSize: 100, BatchSize: 100, FlushPeriod: 10 * time.Millisecond}
+	m, err := NewWithSinks(logging.NewLogger(nil), cfg, sink)
+	require.Nil(t, err)
+	defer m.Shutdown()
+
+	m.Push(types.ClientMetadata{}, dtos.Impression{FeatureName: "f1"})
+	require.Eventually(t, func() bool { return len(sink.batches()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestManagerDropsWhenFull(t *testing.T) {
+	blocked := make(chan struct{})
+	sink := &sinkMock{block: blocked}
+	cfg := &sdkconf.ImpressionListeners{QueueSize: 2, BatchSize: 1, FlushPeriod: time.Hour}
+	m, err := NewWithSinks(logging.NewLogger(nil), cfg, sink)
+	require.Nil(t, err)
+
+	// first one is picked up & blocks the sink, 2 more fill the queue, the rest are dropped
+	m.Push(types.ClientMetadata{}, dtos.Impression{FeatureName: "f1"})
+	require.Eventually(t, func() bool { return len(m.sinks[0].queue) == 0 }, time.Second, 5*time.Millisecond)
+	for idx := 0; idx < 5; idx++ {
+		m.Push(types.ClientMetadata{}, dtos.Impression{FeatureName: "f2"})
+	}
+	assert.Equal(t, int64(3), m.sinks[0].dropped.Load())
+
+	close(blocked)
+	m.Shutdown()
+	assert.Len(t, sink.batches(), 3)
+}
+
+func TestManagerSetup(t *testing.T) {
+	cfg := sdkconf.DefaultConfig().ImpressionListeners
+	m, err := New(logging.NewLogger(nil), &cfg)
+	assert.Nil(t, err)
+	assert.Nil(t, m)
+	m.Push(types.ClientMetadata{}, dtos.Impression{}) // nil-safe
+	m.Shutdown()
+
+	cfg.Webhook.URL = "http://localhost:1234"
+	cfg.File.Path = "/tmp/impressions.ndjson"
+	m, err = New(logging.NewLogger(nil), &cfg)
+	assert.Nil(t, err)
+	require.NotNil(t, m)
+	assert.Len(t, m.sinks, 2)
+	m.Shutdown()
+
+	cfg.BatchSize = 0
+	_, err = New(logging.NewLogger(nil), &cfg)
+	assert.NotNil(t, err)
+}
+
+type sinkMock struct {
+	mutex   sync.Mutex
+	written [][]Record
+	block   chan struct{}
+	closed  bool
+}
+
+func (s *sinkMock) Name() string { return "mock" }
+
+func (s *sinkMock) Write(records []Record) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.written = append(s.written, append([]Record(nil), records...))
+	return nil
+}
+
+func (s *sinkMock) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *sinkMock) batches() [][]Record {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([][]Record(nil), s.written...)
+}
@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"time"
+
 	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/splitd/splitio/sdk"
 	"github.com/splitio/splitd/splitio/sdk/types"
@@ -64,9 +66,9 @@ func (m *SDKMock) TreatmentsByFlagSets(
 }
 
 // Track implements sdk.Interface
-func (m *SDKMock) Track(cfg *types.ClientConfig, key string, trafficType string, eventType string, value *float64, properties map[string]interface{}) error {
+func (m *SDKMock) Track(cfg *types.ClientConfig, key string, trafficType string, eventType string, value *float64, properties map[string]interface{}) ([]sdk.ValidationWarning, error) {
 	args := m.Called(cfg, key, trafficType, eventType, value, properties)
-	return args.Error(0)
+	return args.Get(0).([]sdk.ValidationWarning), args.Error(1)
 }
 
 func (m *SDKMock) Shutdown() error {
@@ -92,4 +94,64 @@ func (m *SDKMock) Splits() ([]sdk.SplitView, error) {
 	return args.Get(0).([]sdk.SplitView), args.Error(1)
 }
 
+// SplitDefinition implements sdk.Interface
+func (m *SDKMock) SplitDefinition(name string) (*dtos.SplitDTO, error) {
+	args := m.Called(name)
+	return args.Get(0).(*dtos.SplitDTO), args.Error(1)
+}
+
+// SegmentNames implements sdk.Interface
+func (m *SDKMock) SegmentNames() ([]string, error) {
+	args := m.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// Segment implements sdk.Interface
+func (m *SDKMock) Segment(name string) (*sdk.SegmentView, error) {
+	args := m.Called(name)
+	return args.Get(0).(*sdk.SegmentView), args.Error(1)
+}
+
+// SegmentContainsKey implements sdk.Interface
+func (m *SDKMock) SegmentContainsKey(name string, key string) (bool, error) {
+	args := m.Called(name, key)
+	return args.Bool(0), args.Error(1)
+}
+
+// RuleBasedSegmentNames implements sdk.Interface
+func (m *SDKMock) RuleBasedSegmentNames() ([]string, error) {
+	args := m.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// RuleBasedSegment implements sdk.Interface
+func (m *SDKMock) RuleBasedSegment(name string) (*dtos.RuleBasedSegmentDTO, error) {
+	args := m.Called(name)
+	return args.Get(0).(*dtos.RuleBasedSegmentDTO), args.Error(1)
+}
+
+// Readiness implements sdk.Interface
+func (m *SDKMock) Readiness() sdk.State {
+	args := m.Called()
+	return args.Get(0).(sdk.State)
+}
+
+// Staleness implements sdk.Interface
+func (m *SDKMock) Staleness() sdk.Staleness {
+	args := m.Called()
+	return args.Get(0).(sdk.Staleness)
+}
+
+// FlushStats implements sdk.Interface
+func (m *SDKMock) FlushStats() sdk.FlushStats {
+	args := m.Called()
+	return args.Get(0).(sdk.FlushStats)
+}
+
+// BlockUntilReady implements sdk.Interface
+func (m *SDKMock) BlockUntilReady(timeout time.Duration) error {
+	args := m.Called(timeout)
+	return args.Error(0)
+}
+
 var _ sdk.Interface = (*SDKMock)(nil)
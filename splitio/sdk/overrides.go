@@ -0,0 +1,69 @@
+package sdk
+
+import (
+	"github.com/splitio/go-split-commons/v9/engine/evaluator"
+	"github.com/splitio/splitd/splitio/sdk/overrides"
+)
+
+// LabelOverride is the impression label used for treatments forced by an override. It's kept even when labels are disabled,
+// so that these impressions can be told apart from regular ones
+const LabelOverride = "forced by override"
+
+// overridingEvaluator returns forced treatments for (flag, key) pairs with an override, and delegates
+// to the wrapped evaluator for everything else
+type overridingEvaluator struct {
+	evaluator.Interface
+	overrides *overrides.Store
+}
+
+func (e *overridingEvaluator) EvaluateFeature(key string, bucketingKey *string, feature string, attributes map[string]interface{}) *evaluator.Result {
+	if o, ok := e.overrides.Get(feature, key); ok {
+		return overrideResult(o)
+	}
+	return e.Interface.EvaluateFeature(key, bucketingKey, feature, attributes)
+}
+
+func (e *overridingEvaluator) EvaluateFeatures(key string, bucketingKey *string, features []string, attributes map[string]interface{}) evaluator.Results {
+	forced := make(map[string]evaluator.Result)
+	toEvaluate := make([]string, 0, len(features))
+	for _, feature := range features {
+		if o, ok := e.overrides.Get(feature, key); ok {
+			forced[feature] = *overrideResult(o)
+			continue
+		}
+		toEvaluate = append(toEvaluate, feature)
+	}
+
+	if len(forced) == 0 {
+		return e.Interface.EvaluateFeatures(key, bucketingKey, features, attributes)
+	}
+
+	results := evaluator.Results{Evaluations: make(map[string]evaluator.Result, len(features))}
+	if len(toEvaluate) > 0 {
+		results = e.Interface.EvaluateFeatures(key, bucketingKey, toEvaluate, attributes)
+		if results.Evaluations == nil {
+			results.Evaluations = make(map[string]evaluator.Result, len(features))
+		}
+	}
+	for feature, res := range forced {
+		results.Evaluations[feature] = res
+	}
+	return results
+}
+
+func (e *overridingEvaluator) EvaluateFeatureByFlagSets(key string, bucketingKey *string, flagSets []string, attributes map[string]interface{}) evaluator.Results {
+	// flag sets are resolved by the wrapped evaluator, overrides are applied to the flags it returns
+	results := e.Interface.EvaluateFeatureByFlagSets(key, bucketingKey, flagSets, attributes)
+	for feature := range results.Evaluations {
+		if o, ok := e.overrides.Get(feature, key); ok {
+			results.Evaluations[feature] = *overrideResult(o)
+		}
+	}
+	return results
+}
+
+func overrideResult(o *overrides.Override) *evaluator.Result {
+	return &evaluator.Result{Treatment: o.Treatment, Config: o.Config, Label: LabelOverride}
+}
+
+var _ evaluator.Interface = (*overridingEvaluator)(nil)
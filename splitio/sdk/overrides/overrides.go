@@ -0,0 +1,138 @@
+package overrides
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidOverride = errors.New("invalid override")
+
+// Override forces a treatment (and optionally a config) for a specific key & feature flag
+type Override struct {
+	Flag      string     `json:"flag" yaml:"flag"`
+	Key       string     `json:"key" yaml:"key"`
+	Treatment string     `json:"treatment" yaml:"treatment"`
+	Config    *string    `json:"config,omitempty" yaml:"config"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" yaml:"expiresAt"` // nil means no expiration
+}
+
+func (o *Override) validate() error {
+	if o.Flag == "" || o.Key == "" || o.Treatment == "" {
+		return fmt.Errorf("%w: flag, key & treatment are required", ErrInvalidOverride)
+	}
+	return nil
+}
+
+func (o *Override) expired(now time.Time) bool {
+	return o.ExpiresAt != nil && !now.Before(*o.ExpiresAt)
+}
+
+type entryKey struct {
+	flag string
+	key  string
+}
+
+// Store holds forced treatments indexed by feature flag & key. It's safe for concurrent use
+type Store struct {
+	mutex   sync.RWMutex
+	entries map[entryKey]Override
+	now     func() time.Time
+}
+
+func NewStore() *Store {
+	return &Store{entries: make(map[entryKey]Override), now: time.Now}
+}
+
+// Set adds or replaces the override for the flag & key in `o`
+func (s *Store) Set(o Override) error {
+	if err := o.validate(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries[entryKey{flag: o.Flag, key: o.Key}] = o
+	return nil
+}
+
+// Get returns the override for a flag & key, if there's one and it hasn't expired
+func (s *Store) Get(flag string, key string) (*Override, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	s.mutex.RLock()
+	o, ok := s.entries[entryKey{flag: flag, key: key}]
+	s.mutex.RUnlock()
+	if !ok || o.expired(s.now()) {
+		return nil, false
+	}
+	return &o, true
+}
+
+// Remove deletes the override for a flag & key, returning whether there was one
+func (s *Store) Remove(flag string, key string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	k := entryKey{flag: flag, key: key}
+	_, ok := s.entries[k]
+	delete(s.entries, k)
+	return ok
+}
+
+// Clear removes all overrides
+func (s *Store) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries = make(map[entryKey]Override)
+}
+
+// List returns all overrides that haven't expired, sorted by flag & key. Expired ones are evicted
+func (s *Store) List() []Override {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	toRet := make([]Override, 0, len(s.entries))
+	for k, o := range s.entries {
+		if o.expired(now) {
+			delete(s.entries, k)
+			continue
+		}
+		toRet = append(toRet, o)
+	}
+
+	sort.Slice(toRet, func(i, j int) bool {
+		if toRet[i].Flag != toRet[j].Flag {
+			return toRet[i].Flag < toRet[j].Flag
+		}
+		return toRet[i].Key < toRet[j].Key
+	})
+	return toRet
+}
+
+// LoadFile seeds the store with the overrides listed in a yaml (or json) file
+func (s *Store) LoadFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading overrides file: %w", err)
+	}
+
+	var parsed []Override
+	if err := yaml.Unmarshal(raw, &parsed); err != nil {
+		return fmt.Errorf("error parsing overrides file: %w", err)
+	}
+
+	for idx := range parsed {
+		if err := s.Set(parsed[idx]); err != nil {
+			return fmt.Errorf("override #%d: %w", idx, err)
+		}
+	}
+	return nil
+}
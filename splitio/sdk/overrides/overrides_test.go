@@ -0,0 +1,90 @@
+package overrides
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/splitio/splitd/splitio/common/lang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewStore()
+	s.now = func() time.Time { return now }
+
+	require.Nil(t, s.Set(Override{Flag: "f1", Key: "k1", Treatment: "on", Config: lang.Ref(`{"a":1}`)}))
+	require.Nil(t, s.Set(Override{Flag: "f1", Key: "k2", Treatment: "off", ExpiresAt: lang.Ref(now.Add(time.Minute))}))
+	require.Nil(t, s.Set(Override{Flag: "f0", Key: "k1", Treatment: "v1"}))
+	assert.ErrorIs(t, s.Set(Override{Flag: "f1", Key: "k3"}), ErrInvalidOverride)
+
+	o, ok := s.Get("f1", "k1")
+	require.True(t, ok)
+	assert.Equal(t, "on", o.Treatment)
+	assert.Equal(t, `{"a":1}`, *o.Config)
+
+	_, ok = s.Get("f1", "k3")
+	assert.False(t, ok)
+
+	o, ok = s.Get("f1", "k2")
+	require.True(t, ok)
+	assert.Equal(t, "off", o.Treatment)
+
+	list := s.List()
+	require.Len(t, list, 3)
+	assert.Equal(t, "f0", list[0].Flag)
+	assert.Equal(t, "k1", list[1].Key)
+	assert.Equal(t, "k2", list[2].Key)
+
+	// expired overrides are ignored & evicted when listing
+	now = now.Add(time.Minute)
+	_, ok = s.Get("f1", "k2")
+	assert.False(t, ok)
+	assert.Len(t, s.List(), 2)
+
+	assert.True(t, s.Remove("f1", "k1"))
+	assert.False(t, s.Remove("f1", "k1"))
+	assert.Len(t, s.List(), 1)
+
+	s.Clear()
+	assert.Empty(t, s.List())
+
+	var nilStore *Store
+	_, ok = nilStore.Get("f1", "k1")
+	assert.False(t, ok)
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "overrides.yaml")
+	require.Nil(t, os.WriteFile(path, []byte(`
+- flag: f1
+  key: k1
+  treatment: "on"
+  config: '{"a":1}'
+- flag: f2
+  key: k2
+  treatment: "off"
+  expiresAt: 2100-01-01T00:00:00Z
+`), 0600))
+
+	s := NewStore()
+	require.Nil(t, s.LoadFile(path))
+	list := s.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, Override{Flag: "f1", Key: "k1", Treatment: "on", Config: lang.Ref(`{"a":1}`)}, list[0])
+	assert.Equal(t, time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC), *list[1].ExpiresAt)
+
+	// json works too
+	jsonPath := filepath.Join(dir, "overrides.json")
+	require.Nil(t, os.WriteFile(jsonPath, []byte(`[{"flag": "f3", "key": "k3", "treatment": "v3"}]`), 0600))
+	require.Nil(t, s.LoadFile(jsonPath))
+	assert.Len(t, s.List(), 3)
+
+	require.Nil(t, os.WriteFile(path, []byte(`[{"flag": "f3"}]`), 0600))
+	assert.ErrorIs(t, s.LoadFile(path), ErrInvalidOverride)
+	assert.NotNil(t, s.LoadFile(filepath.Join(dir, "nonexistant")))
+}
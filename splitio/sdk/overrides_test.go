@@ -0,0 +1,89 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/engine/evaluator"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/external/commons/mocks"
+	"github.com/splitio/splitd/splitio/common/lang"
+	"github.com/splitio/splitd/splitio/sdk/conf"
+	"github.com/splitio/splitd/splitio/sdk/overrides"
+	"github.com/splitio/splitd/splitio/sdk/storage"
+	"github.com/splitio/splitd/splitio/sdk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverridingEvaluator(t *testing.T) {
+	store := overrides.NewStore()
+	require.Nil(t, store.Set(overrides.Override{Flag: "f1", Key: "key1", Treatment: "forced", Config: lang.Ref(`{"a":1}`)}))
+
+	ev := &mocks.EvaluatorMock{}
+	ev.On("EvaluateFeature", "key2", (*string)(nil), "f1", Attributes(nil)).
+		Return(&evaluator.Result{Treatment: "on", Label: "l1"}).
+		Once()
+	ev.On("EvaluateFeatures", "key1", (*string)(nil), []string{"f2"}, Attributes(nil)).
+		Return(evaluator.Results{Evaluations: map[string]evaluator.Result{"f2": {Treatment: "off", Label: "l2"}}}).
+		Once()
+	ev.On("EvaluateFeatures", "key2", (*string)(nil), []string{"f1", "f2"}, Attributes(nil)).
+		Return(evaluator.Results{Evaluations: map[string]evaluator.Result{"f1": {Treatment: "on"}, "f2": {Treatment: "off"}}}).
+		Once()
+	ev.On("EvaluateFeatureByFlagSets", "key1", (*string)(nil), []string{"s1"}, Attributes(nil)).
+		Return(evaluator.Results{Evaluations: map[string]evaluator.Result{"f1": {Treatment: "on"}, "f2": {Treatment: "off"}}}).
+		Once()
+
+	oe := &overridingEvaluator{Interface: ev, overrides: store}
+
+	res := oe.EvaluateFeature("key1", nil, "f1", nil)
+	assert.Equal(t, &evaluator.Result{Treatment: "forced", Config: lang.Ref(`{"a":1}`), Label: LabelOverride}, res)
+	assert.Equal(t, "on", oe.EvaluateFeature("key2", nil, "f1", nil).Treatment)
+
+	results := oe.EvaluateFeatures("key1", nil, []string{"f1", "f2"}, nil)
+	assert.Equal(t, "forced", results.Evaluations["f1"].Treatment)
+	assert.Equal(t, LabelOverride, results.Evaluations["f1"].Label)
+	assert.Equal(t, "off", results.Evaluations["f2"].Treatment)
+
+	results = oe.EvaluateFeatures("key2", nil, []string{"f1", "f2"}, nil)
+	assert.Equal(t, "on", results.Evaluations["f1"].Treatment)
+
+	results = oe.EvaluateFeatureByFlagSets("key1", nil, []string{"s1"}, nil)
+	assert.Equal(t, "forced", results.Evaluations["f1"].Treatment)
+	assert.Equal(t, "off", results.Evaluations["f2"].Treatment)
+
+	// everything forced -> wrapped evaluator not called
+	results = oe.EvaluateFeatures("key1", nil, []string{"f1"}, nil)
+	assert.Equal(t, "forced", results.Evaluations["f1"].Treatment)
+
+	ev.AssertExpectations(t)
+}
+
+func TestOverrideLabelKeptWithLabelsDisabled(t *testing.T) {
+	is, _ := storage.NewImpressionsQueue(100)
+	store := overrides.NewStore()
+	require.Nil(t, store.Set(overrides.Override{Flag: "f1", Key: "key1", Treatment: "forced"}))
+
+	expectedImpression := &dtos.Impression{KeyName: "key1", FeatureName: "f1", Treatment: "forced", Label: LabelOverride}
+	im := &mocks.ImpressionManagerMock{}
+	im.On("Process", mock.Anything).
+		Run(func(a mock.Arguments) { assertImpEq(t, expectedImpression, &a.Get(0).([]dtos.Impression)[0]) }).
+		Return([]dtos.Impression{*expectedImpression}, []dtos.Impression{}).
+		Once()
+
+	client := &Impl{
+		logger:    logging.NewLogger(nil),
+		is:        is,
+		ev:        &overridingEvaluator{Interface: &mocks.EvaluatorMock{}, overrides: store},
+		iq:        im,
+		cfg:       conf.Config{LabelsEnabled: false},
+		overrides: store,
+	}
+
+	res, err := client.Treatment(&types.ClientConfig{Metadata: types.ClientMetadata{ID: "some"}}, "key1", nil, "f1", nil, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "forced", res.Treatment)
+	assertImpEq(t, expectedImpression, res.Impression)
+	assert.Equal(t, store, client.Overrides())
+}
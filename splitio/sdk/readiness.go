@@ -0,0 +1,167 @@
+package sdk
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/engine/evaluator"
+	"github.com/splitio/go-split-commons/v9/engine/evaluator/impressionlabels"
+	commonStorage "github.com/splitio/go-split-commons/v9/storage"
+	"github.com/splitio/go-split-commons/v9/telemetry"
+)
+
+var ErrNotReady = errors.New("sdk not ready")
+
+// State represents the readiness of the SDK to serve evaluations
+type State int32
+
+const (
+	StateNotReady      State = iota // initial synchronization hasn't completed yet
+	StateReady                      // data is synchronized and kept up to date in the configured mode
+	StateReadyDegraded              // data is synchronized, but streaming has degraded to polling
+)
+
+func (s State) String() string {
+	switch s {
+	case StateNotReady:
+		return "not-ready"
+	case StateReady:
+		return "ready"
+	case StateReadyDegraded:
+		return "ready-degraded"
+	default:
+		return "unknown"
+	}
+}
+
+// IsReady returns true if evaluations can be performed against synchronized data
+func (s State) IsReady() bool {
+	return s == StateReady || s == StateReadyDegraded
+}
+
+type readiness struct {
+	state     atomic.Int32
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+func newReadiness() *readiness {
+	return &readiness{ready: make(chan struct{})}
+}
+
+func (r *readiness) get() State {
+	return State(r.state.Load())
+}
+
+func (r *readiness) setReady() {
+	r.readyOnce.Do(func() {
+		r.state.CompareAndSwap(int32(StateNotReady), int32(StateReady))
+		close(r.ready)
+	})
+}
+
+// setDegraded flips the state between ready & ready-degraded. It's a no-op before the initial sync completes
+func (r *readiness) setDegraded(degraded bool) {
+	if degraded {
+		r.state.CompareAndSwap(int32(StateReady), int32(StateReadyDegraded))
+	} else {
+		r.state.CompareAndSwap(int32(StateReadyDegraded), int32(StateReady))
+	}
+}
+
+func (r *readiness) await(timeout time.Duration) error {
+	if r.get().IsReady() {
+		return nil
+	}
+
+	if timeout <= 0 {
+		return ErrNotReady
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-r.ready:
+		return nil
+	case <-timer.C:
+		return ErrNotReady
+	}
+}
+
+// syncModeTracker wraps the runtime telemetry producer handed to the sync manager in order to
+// learn when streaming degrades to polling (and when it recovers), since the manager doesn't expose it.
+type syncModeTracker struct {
+	commonStorage.TelemetryRuntimeProducer
+	r                *readiness
+	streamingEnabled bool
+}
+
+func (t *syncModeTracker) RecordStreamingEvent(event *dtos.StreamingEvent) {
+	if t.streamingEnabled && event != nil && event.Type == telemetry.EventTypeSyncMode {
+		t.r.setDegraded(event.Data == telemetry.Polling)
+	}
+	t.TelemetryRuntimeProducer.RecordStreamingEvent(event)
+}
+
+// guardedEvaluator short-circuits evaluations with a `control` treatment & a `not ready` label until the
+// initial synchronization is complete. If configured to do so, it also short-circuits them with a `stale data`
+// label when synchronization has been failing for longer than the allowed thresholds.
+type guardedEvaluator struct {
+	evaluator.Interface
+	r                 *readiness
+	staleness         *stalenessTracker
+	fallbackWhenStale bool
+}
+
+func (e *guardedEvaluator) EvaluateFeature(key string, bucketingKey *string, feature string, attributes map[string]interface{}) *evaluator.Result {
+	if label, ok := e.shortCircuitLabel(); ok {
+		return controlResult(label)
+	}
+	return e.Interface.EvaluateFeature(key, bucketingKey, feature, attributes)
+}
+
+func (e *guardedEvaluator) EvaluateFeatures(key string, bucketingKey *string, features []string, attributes map[string]interface{}) evaluator.Results {
+	if label, ok := e.shortCircuitLabel(); ok {
+		results := evaluator.Results{Evaluations: make(map[string]evaluator.Result, len(features))}
+		for _, feature := range features {
+			results.Evaluations[feature] = *controlResult(label)
+		}
+		return results
+	}
+	return e.Interface.EvaluateFeatures(key, bucketingKey, features, attributes)
+}
+
+func (e *guardedEvaluator) EvaluateFeatureByFlagSets(key string, bucketingKey *string, flagSets []string, attributes map[string]interface{}) evaluator.Results {
+	if !e.r.get().IsReady() { // flag sets cannot be resolved into feature flags without data
+		return evaluator.Results{Evaluations: map[string]evaluator.Result{}}
+	}
+
+	if label, ok := e.shortCircuitLabel(); ok {
+		results := e.Interface.EvaluateFeatureByFlagSets(key, bucketingKey, flagSets, attributes)
+		for feature := range results.Evaluations {
+			results.Evaluations[feature] = *controlResult(label)
+		}
+		return results
+	}
+	return e.Interface.EvaluateFeatureByFlagSets(key, bucketingKey, flagSets, attributes)
+}
+
+func (e *guardedEvaluator) shortCircuitLabel() (string, bool) {
+	if !e.r.get().IsReady() {
+		return impressionlabels.ClientNotReady, true
+	}
+	if e.fallbackWhenStale && e.staleness.get().Any() {
+		return LabelStaleData, true
+	}
+	return "", false
+}
+
+func controlResult(label string) *evaluator.Result {
+	return &evaluator.Result{Treatment: defaultFallbackTreatment, Label: label}
+}
+
+var _ evaluator.Interface = (*guardedEvaluator)(nil)
+var _ commonStorage.TelemetryRuntimeProducer = (*syncModeTracker)(nil)
@@ -0,0 +1,130 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/engine/evaluator"
+	"github.com/splitio/go-split-commons/v9/engine/evaluator/impressionlabels"
+	"github.com/splitio/go-split-commons/v9/storage/inmemory"
+	"github.com/splitio/go-split-commons/v9/telemetry"
+	"github.com/splitio/splitd/external/commons/mocks"
+	"github.com/splitio/splitd/splitio/sdk/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessTransitions(t *testing.T) {
+	r := newReadiness()
+	assert.Equal(t, StateNotReady, r.get())
+	assert.ErrorIs(t, r.await(0), ErrNotReady)
+	assert.ErrorIs(t, r.await(10*time.Millisecond), ErrNotReady)
+
+	r.setDegraded(true) // no-op until ready
+	assert.Equal(t, StateNotReady, r.get())
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		r.setReady()
+	}()
+	assert.Nil(t, r.await(time.Second))
+	assert.Equal(t, StateReady, r.get())
+
+	r.setDegraded(true)
+	assert.Equal(t, StateReadyDegraded, r.get())
+	assert.Nil(t, r.await(0))
+
+	r.setReady() // calling it again must not panic nor reset the degraded state
+	assert.Equal(t, StateReadyDegraded, r.get())
+
+	r.setDegraded(false)
+	assert.Equal(t, StateReady, r.get())
+}
+
+func TestSyncModeTracker(t *testing.T) {
+	ts, _ := inmemory.NewTelemetryStorage()
+	r := newReadiness()
+	r.setReady()
+
+	tracker := &syncModeTracker{TelemetryRuntimeProducer: ts, r: r, streamingEnabled: true}
+	tracker.RecordStreamingEvent(telemetry.GetStreamingEvent(telemetry.EventTypeSyncMode, telemetry.Polling))
+	assert.Equal(t, StateReadyDegraded, r.get())
+	tracker.RecordStreamingEvent(telemetry.GetStreamingEvent(telemetry.EventTypeSyncMode, telemetry.Streaming))
+	assert.Equal(t, StateReady, r.get())
+	assert.Len(t, ts.PopStreamingEvents(), 2)
+
+	// polling is the expected mode when streaming is disabled by config
+	tracker.streamingEnabled = false
+	tracker.RecordStreamingEvent(telemetry.GetStreamingEvent(telemetry.EventTypeSyncMode, telemetry.Polling))
+	assert.Equal(t, StateReady, r.get())
+}
+
+func TestGuardedEvaluatorNotReady(t *testing.T) {
+	var ev mocks.EvaluatorMock
+	ev.On("EvaluateFeature", "key1", (*string)(nil), "f1", Attributes(nil)).
+		Return(&evaluator.Result{Treatment: "on", Label: "l1", SplitChangeNumber: 123}).
+		Once()
+
+	r := newReadiness()
+	wrapped := &guardedEvaluator{Interface: &ev, r: r}
+
+	res := wrapped.EvaluateFeature("key1", nil, "f1", nil)
+	assert.Equal(t, &evaluator.Result{Treatment: "control", Label: impressionlabels.ClientNotReady}, res)
+
+	ress := wrapped.EvaluateFeatures("key1", nil, []string{"f1", "f2"}, nil)
+	assert.Equal(t, map[string]evaluator.Result{
+		"f1": {Treatment: "control", Label: impressionlabels.ClientNotReady},
+		"f2": {Treatment: "control", Label: impressionlabels.ClientNotReady},
+	}, ress.Evaluations)
+
+	ress = wrapped.EvaluateFeatureByFlagSets("key1", nil, []string{"s1"}, nil)
+	assert.Empty(t, ress.Evaluations)
+
+	r.setReady()
+	res = wrapped.EvaluateFeature("key1", nil, "f1", nil)
+	assert.Equal(t, &evaluator.Result{Treatment: "on", Label: "l1", SplitChangeNumber: 123}, res)
+	ev.AssertExpectations(t)
+}
+
+func TestStateStringify(t *testing.T) {
+	assert.Equal(t, "not-ready", StateNotReady.String())
+	assert.Equal(t, "ready", StateReady.String())
+	assert.Equal(t, "ready-degraded", StateReadyDegraded.String())
+	assert.False(t, StateNotReady.IsReady())
+	assert.True(t, StateReady.IsReady())
+	assert.True(t, StateReadyDegraded.IsReady())
+}
+
+func TestGuardedEvaluatorStale(t *testing.T) {
+	var ev mocks.EvaluatorMock
+	ev.On("EvaluateFeature", "key1", (*string)(nil), "f1", Attributes(nil)).
+		Return(&evaluator.Result{Treatment: "on", Label: "l1", SplitChangeNumber: 123}).
+		Once()
+	ev.On("EvaluateFeatureByFlagSets", "key1", (*string)(nil), []string{"s1"}, Attributes(nil)).
+		Return(evaluator.Results{Evaluations: map[string]evaluator.Result{"f1": {Treatment: "on"}}}).
+		Once()
+
+	r := newReadiness()
+	r.setReady()
+	r.setDegraded(true)
+
+	now := time.Now()
+	tracker := &stalenessTracker{
+		cfg:         conf.Staleness{SplitsThreshold: time.Minute},
+		lastSyncs:   &lastSyncsMock{splits: now.Add(-2 * time.Minute).UnixMilli()},
+		readiness:   r,
+		hasSegments: func() bool { return true },
+		now:         func() time.Time { return now },
+	}
+
+	// not configured to fallback, evaluations proceed as usual
+	wrapped := &guardedEvaluator{Interface: &ev, r: r, staleness: tracker}
+	assert.Equal(t, &evaluator.Result{Treatment: "on", Label: "l1", SplitChangeNumber: 123}, wrapped.EvaluateFeature("key1", nil, "f1", nil))
+
+	wrapped.fallbackWhenStale = true
+	assert.Equal(t, &evaluator.Result{Treatment: "control", Label: LabelStaleData}, wrapped.EvaluateFeature("key1", nil, "f1", nil))
+	assert.Equal(t,
+		map[string]evaluator.Result{"f1": {Treatment: "control", Label: LabelStaleData}},
+		wrapped.EvaluateFeatureByFlagSets("key1", nil, []string{"s1"}, nil).Evaluations,
+	)
+	ev.AssertExpectations(t)
+}
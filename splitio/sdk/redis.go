@@ -0,0 +1,121 @@
+package sdk
+
+import (
+	"os"
+
+	sdkConf "github.com/splitio/splitd/splitio/sdk/conf"
+	sss "github.com/splitio/splitd/splitio/sdk/storage"
+	"github.com/splitio/splitd/splitio/sdk/workers"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/flagsets"
+	"github.com/splitio/go-split-commons/v9/storage/inmemory"
+	"github.com/splitio/go-split-commons/v9/storage/redis"
+	"github.com/splitio/go-split-commons/v9/synchronizer"
+	"github.com/splitio/go-split-commons/v9/synchronizer/worker/impressionscount"
+	"github.com/splitio/go-split-commons/v9/tasks"
+	"github.com/splitio/go-split-commons/v9/telemetry"
+	"github.com/splitio/go-toolkit/v5/asynctask"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/go-toolkit/v5/nethelpers"
+	toolkitredis "github.com/splitio/go-toolkit/v5/redis"
+)
+
+const redisReadinessCheckPeriod = 1 // seconds
+
+// setupRedisStorages builds storages that read feature flags & segments from a redis populated by Split Synchronizer.
+// Impressions & events are still accumulated in memory (grouped by client metadata) and flushed periodically.
+func setupRedisStorages(logger logging.LoggerInterface, cfg *sdkConf.Config, flagSetsFilter flagsets.FlagSetFilter, client *toolkitredis.PrefixedRedisClient) *storages {
+	ts, _ := inmemory.NewTelemetryStorage()
+	iq, _ := sss.NewImpressionsQueueWithOptions(cfg.Impressions.QueueSize, impressionsQueueOptions(cfg))
+	eq, _ := sss.NewEventsQueueWithOptions(cfg.Events.QueueSize, eventsQueueOptions(cfg))
+
+	return &storages{
+		splits:            redis.NewSplitStorage(client, logger, flagSetsFilter),
+		segments:          redis.NewSegmentStorage(client, logger),
+		ruleBasedSegments: redis.NewRuleBasedStorage(client, logger),
+		impressions:       iq,
+		events:            eq,
+		telemetry:         ts,
+	}
+}
+
+// setupRedisSync builds a manager that periodically writes impressions, impression counts, unique keys & events to redis,
+// in the format expected by Split Synchronizer. No data is fetched from split in this mode.
+func setupRedisSync(
+	logger logging.LoggerInterface,
+	client *toolkitredis.PrefixedRedisClient,
+	str *storages,
+	cfg *sdkConf.Config,
+	md dtos.Metadata,
+	impc impComponents,
+	r *readiness,
+	queueFullChan chan string,
+	impFlush *flushTrigger,
+	evFlush *flushTrigger,
+) (synchronizer.Synchronizer, synchronizer.Manager) {
+	machineName, _ := os.Hostname()
+	machineIP, _ := nethelpers.ExternalIP()
+
+	impRecorder := workers.NewRedisImpressionsRecorder(client, logger, machineName, machineIP)
+	telemetryStorage := redis.NewTelemetryStorage(client, logger, dtos.Metadata{SDKVersion: md.SDKVersion, MachineName: machineName, MachineIP: machineIP})
+	wrks := &synchronizer.Workers{
+		ImpressionRecorder:       workers.NewImpressionsWorker(logger, str.telemetry, impRecorder, str.impressions, &cfg.Impressions),
+		EventRecorder:            workers.NewEventsWorker(logger, str.telemetry, workers.NewRedisEventsRecorder(client, machineName, machineIP), str.events, &cfg.Events),
+		ImpressionsCountRecorder: impressionscount.NewRecorderRedis(impc.counter, redis.NewImpressionsCountStorage(client, logger), logger),
+		TelemetryRecorder:        telemetry.NewSynchronizerRedis(telemetryStorage, logger),
+	}
+
+	flushPeriod := int(cfg.Redis.FlushPeriod.Seconds())
+	tsks := &synchronizer.SplitTasks{
+		ImpressionSyncTask:    tasks.NewRecordImpressionsTask(&periodicImpressionRecorder{wrks.ImpressionRecorder, impFlush}, flushPeriod, logger, impressionsBulkSizeRedis),
+		EventSyncTask:         tasks.NewRecordEventsTask(&periodicEventRecorder{wrks.EventRecorder, evFlush}, 5000, flushPeriod, logger),
+		UniqueKeysTask:        tasks.NewRecordUniqueKeysTask(wrks.TelemetryRecorder, *impc.tracker, uniqueKeysPeriodTaskRedis, logger),
+		CleanFilterTask:       tasks.NewCleanFilterTask(*impc.filter, logger, bfCleaningPeriod),
+		ImpsCountConsumerTask: tasks.NewRecordImpressionsCountTask(wrks.ImpressionsCountRecorder, logger, impressionsCountPeriodTaskRedis),
+	}
+
+	sync := synchronizer.NewSynchronizer(*cfg.ToAdvancedConfig(), *tsks, *wrks, logger, queueFullChan)
+	return sync, &redisManager{
+		Manager:    synchronizer.NewSynchronizerManagerRedis(sync, logger),
+		readyCheck: newRedisReadinessCheck(logger, str, r),
+	}
+}
+
+// redisManager extends commons' redis manager (which only records data) with a periodic check that flags the
+// sdk as ready once Split Synchronizer has populated redis, since there's no initial synchronization in this mode
+type redisManager struct {
+	synchronizer.Manager
+	readyCheck *asynctask.AsyncTask
+}
+
+func (m *redisManager) Start() {
+	m.Manager.Start()
+	m.readyCheck.Start()
+}
+
+func (m *redisManager) Stop() {
+	m.readyCheck.Stop(true)
+	m.Manager.Stop()
+}
+
+func newRedisReadinessCheck(logger logging.LoggerInterface, str *storages, r *readiness) *asynctask.AsyncTask {
+	return asynctask.NewAsyncTask(
+		"redis-readiness-check",
+		func(l logging.LoggerInterface) error {
+			if r.get().IsReady() {
+				return nil
+			}
+
+			// the synchronizer only writes the feature flags change number after a successful fetch
+			if cn, err := str.splits.ChangeNumber(); err == nil && cn > -1 {
+				r.setReady()
+			}
+			return nil
+		},
+		redisReadinessCheckPeriod,
+		nil,
+		nil,
+		logger,
+	)
+}
@@ -0,0 +1,143 @@
+package sdk
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	commonsConf "github.com/splitio/go-split-commons/v9/conf"
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/flagsets"
+	"github.com/splitio/go-split-commons/v9/storage/redis"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/splitio/sdk/conf"
+	"github.com/splitio/splitd/splitio/sdk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisConsumerMode(t *testing.T) {
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+
+	cfg := conf.DefaultConfig()
+	cfg.Storage = conf.StorageRedis
+	cfg.Redis.Host = mr.Host()
+	cfg.Redis.Port = port
+	cfg.Redis.Prefix = "someprefix"
+	cfg.Impressions.Mode = "debug"
+
+	logger := logging.NewLogger(nil)
+	client, err := New(logger, "someApikey", cfg)
+	require.Nil(t, err)
+
+	// not ready until the synchronizer populates redis
+	assert.Equal(t, StateNotReady, client.Readiness())
+	md := types.ClientConfig{Metadata: types.ClientMetadata{ID: "i1", SdkVersion: "php-1.2.3"}}
+	res, err := client.Treatment(&md, "key1", nil, "split1", nil, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "control", res.Treatment)
+
+	populateRedisAsSynchronizer(t, client.cfg.Redis.ToRedisConfig(), logger)
+	require.Nil(t, client.BlockUntilReady(5*time.Second))
+
+	res, err = client.Treatment(&md, "key1", nil, "split1", nil, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "on", res.Treatment)
+	res, err = client.Treatment(&md, "key2", nil, "split1", nil, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "off", res.Treatment)
+
+	names, err := client.SplitNames()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"split1"}, names)
+	contains, err := client.SegmentContainsKey("segment1", "key1")
+	require.Nil(t, err)
+	assert.True(t, contains)
+
+	_, err = client.Track(&md, "key1", "user", "checkout", nil, nil)
+	require.Nil(t, err)
+
+	// pending impressions & events are flushed to redis on shutdown
+	require.Nil(t, client.Shutdown())
+
+	rawImps, err := mr.List("someprefix." + redis.KeyImpressionsQueue)
+	require.Nil(t, err)
+	require.Len(t, rawImps, 3)
+	treatments := make([]string, 0, len(rawImps))
+	for _, raw := range rawImps {
+		var imp dtos.ImpressionQueueObject
+		require.Nil(t, json.Unmarshal([]byte(raw), &imp))
+		assert.Equal(t, "php-1.2.3", imp.Metadata.SDKVersion)
+		assert.Equal(t, "split1", imp.Impression.FeatureName)
+		treatments = append(treatments, imp.Impression.Treatment)
+	}
+	assert.ElementsMatch(t, []string{"control", "on", "off"}, treatments)
+
+	rawEvents, err := mr.List("someprefix." + redis.KeyEvents)
+	require.Nil(t, err)
+	require.Len(t, rawEvents, 1)
+	var ev dtos.QueueStoredEventDTO
+	require.Nil(t, json.Unmarshal([]byte(rawEvents[0]), &ev))
+	assert.Equal(t, "php-1.2.3", ev.Metadata.SDKVersion)
+	assert.Equal(t, "checkout", ev.Event.EventTypeID)
+}
+
+func TestRedisConsumerModeConnectionError(t *testing.T) {
+	mr := miniredis.RunT(t)
+	host := mr.Host()
+	port, _ := strconv.Atoi(mr.Port())
+	mr.Close()
+
+	cfg := conf.DefaultConfig()
+	cfg.Storage = conf.StorageRedis
+	cfg.Redis.Host = host
+	cfg.Redis.Port = port
+	_, err := New(logging.NewLogger(nil), "someApikey", cfg)
+	assert.NotNil(t, err)
+
+	cfg.Storage = "nonexistant"
+	_, err = New(logging.NewLogger(nil), "someApikey", cfg)
+	assert.NotNil(t, err)
+}
+
+// populateRedisAsSynchronizer writes data the same way Split Synchronizer does
+func populateRedisAsSynchronizer(t *testing.T, cfg *commonsConf.RedisConfig, logger logging.LoggerInterface) {
+	t.Helper()
+	client, err := redis.NewRedisClient(cfg, logger)
+	require.Nil(t, err)
+
+	segments := redis.NewSegmentStorage(client, logger)
+	require.Nil(t, segments.Update("segment1", set.NewSet("key1"), set.NewSet(), 10))
+
+	splits := redis.NewSplitStorage(client, logger, flagsets.NewFlagSetFilter(nil))
+	splits.Update([]dtos.SplitDTO{{
+		Name:                  "split1",
+		TrafficTypeName:       "user",
+		Status:                "ACTIVE",
+		DefaultTreatment:      "off",
+		Algo:                  2,
+		TrafficAllocation:     100,
+		TrafficAllocationSeed: 1,
+		Seed:                  1,
+		ChangeNumber:          123,
+		Conditions: []dtos.ConditionDTO{
+			{
+				ConditionType: "ROLLOUT",
+				MatcherGroup: dtos.MatcherGroupDTO{Combiner: "AND", Matchers: []dtos.MatcherDTO{{
+					MatcherType:        "IN_SEGMENT",
+					UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "segment1"},
+				}}},
+				Partitions: []dtos.PartitionDTO{{Treatment: "on", Size: 100}},
+			},
+			{
+				ConditionType: "ROLLOUT",
+				MatcherGroup:  dtos.MatcherGroupDTO{Combiner: "AND", Matchers: []dtos.MatcherDTO{{MatcherType: "ALL_KEYS"}}},
+				Partitions:    []dtos.PartitionDTO{{Treatment: "off", Size: 100}},
+			},
+		},
+	}}, nil, 123)
+}
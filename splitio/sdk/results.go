@@ -6,6 +6,9 @@ type EvaluationResult struct {
 	Treatment  string
 	Impression *dtos.Impression
 	Config     *string
+	Stale      bool
+	Label      string              // only set for results not backed by an actual evaluation (ie: LabelFlagNotFound)
+	Warnings   []ValidationWarning // non-fatal issues found in the input of the call
 }
 
 type SplitView struct {
@@ -19,3 +22,9 @@ type SplitView struct {
 	Sets                []string
 	ImpressionsDisabled bool
 }
+
+type SegmentView struct {
+	Name         string
+	KeyCount     int64
+	ChangeNumber int64
+}
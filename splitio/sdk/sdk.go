@@ -4,15 +4,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/splitio/splitd/splitio/sdk/conf"
+	"github.com/splitio/splitd/splitio/sdk/listeners"
+	"github.com/splitio/splitd/splitio/sdk/overrides"
 	"github.com/splitio/splitd/splitio/sdk/storage"
 	"github.com/splitio/splitd/splitio/sdk/types"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/go-split-commons/v9/engine"
 	"github.com/splitio/go-split-commons/v9/engine/evaluator"
+	"github.com/splitio/go-split-commons/v9/engine/evaluator/impressionlabels"
 	"github.com/splitio/go-split-commons/v9/engine/grammar"
 	"github.com/splitio/go-split-commons/v9/engine/grammar/constants"
 	"github.com/splitio/go-split-commons/v9/flagsets"
@@ -20,9 +24,12 @@ import (
 	"github.com/splitio/go-split-commons/v9/provisional"
 	"github.com/splitio/go-split-commons/v9/service/api"
 	commonStorage "github.com/splitio/go-split-commons/v9/storage"
+	"github.com/splitio/go-split-commons/v9/storage/redis"
 	"github.com/splitio/go-split-commons/v9/synchronizer"
+	"github.com/splitio/go-toolkit/v5/asynctask"
 	"github.com/splitio/go-toolkit/v5/common"
 	"github.com/splitio/go-toolkit/v5/logging"
+	toolkitredis "github.com/splitio/go-toolkit/v5/redis"
 	"github.com/splitio/splitd/splitio"
 )
 
@@ -32,8 +39,10 @@ const (
 )
 
 var (
-	ErrEventsQueueFull = errors.New("events queue full")
-	ErrSplitNotFound   = errors.New("split not found")
+	ErrEventsQueueFull          = errors.New("events queue full")
+	ErrSplitNotFound            = errors.New("split not found")
+	ErrSegmentNotFound          = errors.New("segment not found")
+	ErrRuleBasedSegmentNotFound = errors.New("rule-based segment not found")
 )
 
 var featureFlagsRules = []string{constants.MatcherTypeAllKeys, constants.MatcherTypeInSegment, constants.MatcherTypeWhitelist, constants.MatcherTypeEqualTo, constants.MatcherTypeGreaterThanOrEqualTo, constants.MatcherTypeLessThanOrEqualTo, constants.MatcherTypeBetween,
@@ -53,25 +62,45 @@ type Interface interface {
 	Treatments(cfg *types.ClientConfig, key string, bucketingKey *string, features []string, attributes map[string]interface{}, evaluationOptions *dtos.EvaluationOptions) (map[string]EvaluationResult, error)
 	TreatmentsByFlagSet(cfg *types.ClientConfig, key string, bucketingKey *string, flagSet string, attributes map[string]interface{}, evaluationOptions *dtos.EvaluationOptions) (map[string]EvaluationResult, error)
 	TreatmentsByFlagSets(cfg *types.ClientConfig, key string, bucketingKey *string, flagSets []string, attributes map[string]interface{}, evaluationOptions *dtos.EvaluationOptions) (map[string]EvaluationResult, error)
-	Track(cfg *types.ClientConfig, key string, trafficType string, eventType string, value *float64, properties map[string]interface{}) error
+	Track(cfg *types.ClientConfig, key string, trafficType string, eventType string, value *float64, properties map[string]interface{}) ([]ValidationWarning, error)
 	SplitNames() ([]string, error)
 	Splits() ([]SplitView, error)
 	Split(name string) (*SplitView, error)
+	SplitDefinition(name string) (*dtos.SplitDTO, error)
+	SegmentNames() ([]string, error)
+	Segment(name string) (*SegmentView, error)
+	SegmentContainsKey(name string, key string) (bool, error)
+	RuleBasedSegmentNames() ([]string, error)
+	RuleBasedSegment(name string) (*dtos.RuleBasedSegmentDTO, error)
+	Readiness() State
+	Staleness() Staleness
+	FlushStats() FlushStats
+	BlockUntilReady(timeout time.Duration) error
 	Shutdown() error
 }
 
 type Impl struct {
-	logger        logging.LoggerInterface
-	ev            evaluator.Interface
-	sm            synchronizer.Manager
-	ss            synchronizer.Synchronizer
-	is            *storage.ImpressionsStorage
-	es            *storage.EventsStorage
-	iq            provisional.ImpressionManager
-	splitStorage  commonStorage.SplitStorage
-	cfg           conf.Config
-	queueFullChan chan string
-	validator     Validator
+	logger       logging.LoggerInterface
+	ev           evaluator.Interface
+	sm           synchronizer.Manager
+	ss           synchronizer.Synchronizer
+	is           *storage.ImpressionsStorage
+	es           *storage.EventsStorage
+	iq           provisional.ImpressionManager
+	iqByMode     map[string]provisional.ImpressionManager
+	splitStorage commonStorage.SplitStorage
+	segStorage   commonStorage.SegmentStorage
+	rbsStorage   commonStorage.RuleBasedSegmentsStorage
+	readiness    *readiness
+	staleness    *stalenessTracker
+	snapshots    *asynctask.AsyncTask
+	listeners    *listeners.Manager
+	forwarders   eventForwarders
+	cfg          conf.Config
+	impFlush     *flushTrigger
+	evFlush      *flushTrigger
+	validator    Validator
+	overrides    *overrides.Store
 }
 
 func New(logger logging.LoggerInterface, apikey string, c *conf.Config) (*Impl, error) {
@@ -82,101 +111,230 @@ func New(logger logging.LoggerInterface, apikey string, c *conf.Config) (*Impl,
 		}
 	}
 
+	if err := setupHTTP(c); err != nil {
+		return nil, fmt.Errorf("error setting up http client: %w", err)
+	}
+
 	md := dtos.Metadata{SDKVersion: fmt.Sprintf("splitd-%s", splitio.Version)}
 	advCfg := c.ToAdvancedConfig()
 
 	flagSetsFilter := flagsets.NewFlagSetFilter(advCfg.FlagSetsFilter)
 
-	stores := setupStorages(c, flagSetsFilter)
+	var stores *storages
+	var redisClient *toolkitredis.PrefixedRedisClient
+	switch c.Storage {
+	case conf.StorageMemory:
+		stores = setupStorages(c, flagSetsFilter)
+	case conf.StorageRedis:
+		var err error
+		if redisClient, err = redis.NewRedisClient(c.Redis.ToRedisConfig(), logger); err != nil {
+			return nil, fmt.Errorf("error connecting to redis: %w", err)
+		}
+		stores = setupRedisStorages(logger, c, flagSetsFilter, redisClient)
+	default:
+		return nil, fmt.Errorf("unknown storage type '%s'", c.Storage)
+	}
+
 	impc, err := setupImpressionsComponents(&c.Impressions, stores.telemetry)
 	if err != nil {
 		return nil, fmt.Errorf("error setting up impressions components")
 	}
 
+	forced := overrides.NewStore()
+	if c.Overrides.File != "" {
+		if err := forced.LoadFile(c.Overrides.File); err != nil {
+			return nil, fmt.Errorf("error loading overrides: %w", err)
+		}
+	}
+
+	impListeners, err := listeners.New(logger, &c.ImpressionListeners)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up impression listeners: %w", err)
+	}
+
 	hc := &application.Dummy{}
 
 	queueFullChan := make(chan string, 2)
-	splitApi := api.NewSplitAPI(apikey, *advCfg, logger, md)
+	impFlush := newFlushTrigger(impressionsFullNotif, queueFullChan, stores.impressions, c.Impressions.Watermark)
+	evFlush := newFlushTrigger(eventsFullNotif, queueFullChan, stores.events, c.Events.Watermark)
 	fallbackTreatmentCalculator := createFallbackTreatmentCalculator(&advCfg.FallbackTreatment, logger)
 	evaluator := evaluator.NewEvaluator(stores.splits, stores.segments, stores.ruleBasedSegments, nil, engine.NewEngine(logger), logger, featureFlagsRules, ruleBasedSegmentRules, fallbackTreatmentCalculator)
-	ruleBuilder := grammar.NewRuleBuilder(stores.segments, stores.ruleBasedSegments, nil, featureFlagsRules, ruleBasedSegmentRules, logger, evaluator)
-	workers := setupWorkers(logger, splitApi, stores, hc, c, flagSetsFilter, md, impc, ruleBuilder)
-	tasks := setupTasks(c, logger, workers, impc)
-	sync := synchronizer.NewSynchronizer(*advCfg, *tasks, *workers, logger, queueFullChan)
+	readiness := newReadiness()
+
+	var sync synchronizer.Synchronizer
+	var manager synchronizer.Manager
+	var staleness *stalenessTracker
+	var snapshots *asynctask.AsyncTask
+	if redisClient != nil {
+		// data is kept up to date by Split Synchronizer. we only need to write impressions & events back
+		sync, manager = setupRedisSync(logger, redisClient, stores, c, md, impc, readiness, queueFullChan, impFlush, evFlush)
+		manager.Start()
+	} else {
+		splitApi := api.NewSplitAPI(apikey, *advCfg, logger, md)
+		staleness = &stalenessTracker{
+			cfg:              c.Staleness,
+			lastSyncs:        stores.telemetry,
+			readiness:        readiness,
+			streamingEnabled: advCfg.StreamingEnabled,
+			hasSegments:      func() bool { return stores.splits.SegmentNames().Size() > 0 },
+			now:              time.Now,
+		}
+		ruleBuilder := grammar.NewRuleBuilder(stores.segments, stores.ruleBasedSegments, nil, featureFlagsRules, ruleBasedSegmentRules, logger, evaluator)
+		workers := setupWorkers(logger, splitApi, stores, hc, c, flagSetsFilter, md, impc, ruleBuilder)
+		tasks := setupTasks(c, logger, workers, impc, impFlush, evFlush)
+		sync = synchronizer.NewSynchronizer(*advCfg, *tasks, *workers, logger, queueFullChan)
+
+		status := make(chan int, 10)
+		modeTracker := &syncModeTracker{TelemetryRuntimeProducer: stores.telemetry, r: readiness, streamingEnabled: advCfg.StreamingEnabled}
+		manager, err = synchronizer.NewSynchronizerManager(sync, logger, *advCfg, splitApi.AuthClient, stores.splits, status, modeTracker, md, nil, hc)
+		if err != nil {
+			impListeners.Shutdown()
+			return nil, fmt.Errorf("error initializing split evaluation service: %w", err)
+		}
 
-	status := make(chan int, 10)
-	manager, err := synchronizer.NewSynchronizerManager(sync, logger, *advCfg, splitApi.AuthClient, stores.splits, status, stores.telemetry, md, nil, hc)
-	if err != nil {
-		return nil, fmt.Errorf("error initializing split evaluation service: %w", err)
-	}
+		// Serve evaluations from a previous snapshot (if any) while the synchronizer catches up.
+		if warmStart(logger, stores, c) {
+			readiness.setReady()
+		}
+
+		snapshots = newSnapshotExporter(logger, stores, c, readiness)
+		if snapshots != nil {
+			snapshots.Start()
+		}
 
-	// Start initial sync and await readiness
-	manager.Start()
-	res := <-status
-	if res == synchronizer.Error {
-		return nil, fmt.Errorf("failed to perform initial sync")
+		// Start initial sync in BG. Evaluations will return control/fallback treatments until it succeeds,
+		// and failed attempts will be retried with backoff.
+		go func() {
+			if err := manager.StartBGSync(status, true, readiness.setReady); err != nil {
+				logger.Warning("initial synchronization failed, retrying in background: ", err)
+			}
+		}()
 	}
 
+	forwarders := setupEventForwarders(logger, apikey, c, stores.telemetry)
+	forwarders.start()
+
 	return &Impl{
-		logger:        logger,
-		sm:            manager,
-		ss:            sync,
-		ev:            evaluator,
-		is:            stores.impressions,
-		es:            stores.events,
-		iq:            impc.manager,
-		splitStorage:  stores.splits,
-		cfg:           *c,
-		queueFullChan: queueFullChan,
-		validator:     Validator{logger: logger, splits: stores.splits},
+		logger: logger,
+		sm:     manager,
+		ss:     sync,
+		ev: &overridingEvaluator{
+			Interface: &guardedEvaluator{
+				Interface:         evaluator,
+				r:                 readiness,
+				staleness:         staleness,
+				fallbackWhenStale: c.Staleness.UseFallbackTreatments,
+			},
+			overrides: forced,
+		},
+		overrides:    forced,
+		is:           stores.impressions,
+		es:           stores.events,
+		iq:           impc.manager,
+		iqByMode:     impc.byMode,
+		splitStorage: stores.splits,
+		segStorage:   stores.segments,
+		rbsStorage:   stores.ruleBasedSegments,
+		readiness:    readiness,
+		staleness:    staleness,
+		snapshots:    snapshots,
+		listeners:    impListeners,
+		forwarders:   forwarders,
+		cfg:          *c,
+		impFlush:     impFlush,
+		evFlush:      evFlush,
+		validator:    Validator{logger: logger, splits: stores.splits},
 	}, nil
 }
 
 // Treatment implements Interface
 func (i *Impl) Treatment(cfg *types.ClientConfig, key string, bk *string, feature string, attributes Attributes, evaluationOptions *dtos.EvaluationOptions) (*EvaluationResult, error) {
-	res := i.ev.EvaluateFeature(key, bk, feature, attributes)
+	stale := i.staleness.get().Any()
+	if err := i.validator.validateEvaluationKeys("Treatment", key, bk); err != nil {
+		return i.rejectedResult(feature, stale, err), nil
+	}
+
+	var warnings []ValidationWarning
+	name, err := i.validator.validateFlagName("Treatment", feature, &warnings)
+	if err != nil {
+		return i.rejectedResult(feature, stale, err), nil
+	}
+
+	res := i.ev.EvaluateFeature(key, bk, name, i.mergeAttributes(cfg, attributes))
 	if res == nil {
 		return nil, fmt.Errorf("nil result")
 	}
-	treatment := res.Treatment
-	config := res.Config
-	if treatment == defaultFallbackTreatment {
-		if t, c := i.getFallbackTreatment(feature); t != defaultFallbackTreatment {
-			treatment, config = t, c
-		}
-	}
-	imp := i.handleImpression(key, bk, feature, res, cfg.Metadata, SerializeProperties(evaluationOptions))
-	return &EvaluationResult{
-		Treatment:  treatment,
-		Impression: imp,
-		Config:     config,
-	}, nil
+
+	eres := i.toEvaluationResult(key, bk, name, res, cfg, stale, SerializeProperties(evaluationOptions))
+	eres.Warnings = append(warnings, eres.Warnings...)
+	return &eres, nil
 }
 
 // Treatment implements Interface
 func (i *Impl) Treatments(cfg *types.ClientConfig, key string, bk *string, features []string, attributes Attributes, evaluationOptions *dtos.EvaluationOptions) (map[string]EvaluationResult, error) {
 
-	res := i.ev.EvaluateFeatures(key, bk, features, attributes)
-	toRet := make(map[string]EvaluationResult, len(res.Evaluations))
+	stale := i.staleness.get().Any()
+	toRet := make(map[string]EvaluationResult, len(features))
+	if err := i.validator.validateEvaluationKeys("Treatments", key, bk); err != nil {
+		for _, feature := range features {
+			toRet[feature] = *i.rejectedResult(feature, stale, err)
+		}
+		return toRet, nil
+	}
+
+	// results are keyed by the flag names as requested. names that are invalid, or duplicate once trimmed,
+	// are not evaluated (again). each distinct name is validated once, whether it's accepted or rejected
+	names := make(map[string]string, len(features))
+	warnings := make(map[string][]ValidationWarning, len(features))
+	toEvaluate := make([]string, 0, len(features))
+	seen := make(map[string]struct{}, len(features))
 	for _, feature := range features {
+		if _, ok := names[feature]; ok {
+			continue
+		}
+		if _, rejected := toRet[feature]; rejected {
+			continue
+		}
+
+		var ws []ValidationWarning
+		name, err := i.validator.validateFlagName("Treatments", feature, &ws)
+		if err != nil {
+			toRet[feature] = *i.rejectedResult(feature, stale, err)
+			continue
+		}
 
-		curr, ok := res.Evaluations[feature]
+		if _, ok := seen[name]; ok {
+			i.validator.warn(&ws, WarningDuplicateFlag, fmt.Sprintf("Treatments: feature flag '%s' was requested more than once", name))
+		} else {
+			seen[name] = struct{}{}
+			toEvaluate = append(toEvaluate, name)
+		}
+		names[feature] = name
+		warnings[feature] = ws
+	}
+
+	var res evaluator.Results
+	if len(toEvaluate) > 0 {
+		res = i.ev.EvaluateFeatures(key, bk, toEvaluate, i.mergeAttributes(cfg, attributes))
+	}
+
+	evaluated := make(map[string]EvaluationResult, len(toEvaluate))
+	for _, name := range toEvaluate {
+		curr, ok := res.Evaluations[name]
 		if !ok {
-			treatment, config := i.getFallbackTreatment(feature)
-			toRet[feature] = EvaluationResult{Treatment: treatment, Config: config}
+			treatment, config := i.getFallbackTreatment(name)
+			evaluated[name] = EvaluationResult{Treatment: treatment, Config: config, Stale: stale}
 			continue
 		}
-		treatment := curr.Treatment
-		config := curr.Config
-		if treatment == defaultFallbackTreatment {
-			if t, c := i.getFallbackTreatment(feature); t != defaultFallbackTreatment {
-				treatment, config = t, c
-			}
+		evaluated[name] = i.toEvaluationResult(key, bk, name, &curr, cfg, stale, SerializeProperties(evaluationOptions))
+	}
+
+	for feature, name := range names {
+		eres := evaluated[name]
+		eres.Warnings = append(append([]ValidationWarning(nil), warnings[feature]...), eres.Warnings...)
+		if len(eres.Warnings) == 0 {
+			eres.Warnings = nil
 		}
-		var eres EvaluationResult
-		eres.Treatment = treatment
-		eres.Impression = i.handleImpression(key, bk, feature, &curr, cfg.Metadata, SerializeProperties(evaluationOptions))
-		eres.Config = config
 		toRet[feature] = eres
 	}
 
@@ -185,59 +343,95 @@ func (i *Impl) Treatments(cfg *types.ClientConfig, key string, bk *string, featu
 
 // TreatmentsByFlagSet implements Interface
 func (i *Impl) TreatmentsByFlagSet(cfg *types.ClientConfig, key string, bk *string, flagSet string, attributes Attributes, evaluationOptions *dtos.EvaluationOptions) (map[string]EvaluationResult, error) {
+	return i.treatmentsByFlagSets(cfg, key, bk, []string{flagSet}, attributes, evaluationOptions)
+}
+
+// TreatmentsByFlagSets implements Interface
+func (i *Impl) TreatmentsByFlagSets(cfg *types.ClientConfig, key string, bk *string, flagSets []string, attributes Attributes, evaluationOptions *dtos.EvaluationOptions) (map[string]EvaluationResult, error) {
+	return i.treatmentsByFlagSets(cfg, key, bk, flagSets, attributes, evaluationOptions)
+}
 
-	res := i.ev.EvaluateFeatureByFlagSets(key, bk, []string{flagSet}, attributes)
+func (i *Impl) treatmentsByFlagSets(cfg *types.ClientConfig, key string, bk *string, flagSets []string, attributes Attributes, evaluationOptions *dtos.EvaluationOptions) (map[string]EvaluationResult, error) {
+
+	stale := i.staleness.get().Any()
+	res := i.ev.EvaluateFeatureByFlagSets(key, bk, flagSets, i.mergeAttributes(cfg, attributes))
 	toRet := make(map[string]EvaluationResult, len(res.Evaluations))
 	for feature, curr := range res.Evaluations {
-		treatment, config := curr.Treatment, curr.Config
-		if treatment == defaultFallbackTreatment {
-			if t, c := i.getFallbackTreatment(feature); t != defaultFallbackTreatment {
-				treatment, config = t, c
-			}
-		}
-		var eres EvaluationResult
-		eres.Treatment = treatment
-		eres.Impression = i.handleImpression(key, bk, feature, &curr, cfg.Metadata, SerializeProperties(evaluationOptions))
-		eres.Config = config
-		toRet[feature] = eres
+		toRet[feature] = i.toEvaluationResult(key, bk, feature, &curr, cfg, stale, SerializeProperties(evaluationOptions))
 	}
 
 	return toRet, nil
 }
 
-// TreatmentsByFlagSets implements Interface
-func (i *Impl) TreatmentsByFlagSets(cfg *types.ClientConfig, key string, bk *string, flagSets []string, attributes Attributes, evaluationOptions *dtos.EvaluationOptions) (map[string]EvaluationResult, error) {
+// toEvaluationResult applies fallback treatments & records the impression for an evaluation. Feature flags that don't
+// exist are reported with a specific label & warning, and only get an impression if a fallback treatment was applied
+func (i *Impl) toEvaluationResult(
+	key string,
+	bk *string,
+	feature string,
+	res *evaluator.Result,
+	cfg *types.ClientConfig,
+	stale bool,
+	properties string,
+) EvaluationResult {
+	treatment, config := res.Treatment, res.Config
+	if treatment == defaultFallbackTreatment {
+		if t, c := i.getFallbackTreatment(feature); t != defaultFallbackTreatment {
+			treatment, config = t, c
+		}
+	}
 
-	res := i.ev.EvaluateFeatureByFlagSets(key, bk, flagSets, attributes)
-	toRet := make(map[string]EvaluationResult, len(res.Evaluations))
-	for feature, curr := range res.Evaluations {
-		treatment, config := curr.Treatment, curr.Config
-		if treatment == defaultFallbackTreatment {
-			if t, c := i.getFallbackTreatment(feature); t != defaultFallbackTreatment {
-				treatment, config = t, c
-			}
+	eres := EvaluationResult{Treatment: treatment, Config: config, Stale: stale}
+	if isFlagNotFound(res) {
+		eres.Label = LabelFlagNotFound
+		eres.Warnings = []ValidationWarning{{
+			Code:    WarningFlagNotFound,
+			Message: fmt.Sprintf("feature flag '%s' does not exist in this environment, returning control/fallback treatment", feature),
+		}}
+		if res.Label == impressionlabels.SplitNotFound { // fallback treatments (labeled with a prefix) do get an impression
+			return eres
 		}
-		var eres EvaluationResult
-		eres.Treatment = treatment
-		eres.Impression = i.handleImpression(key, bk, feature, &curr, cfg.Metadata, SerializeProperties(evaluationOptions))
-		eres.Config = config
-		toRet[feature] = eres
 	}
 
-	return toRet, nil
+	eres.Impression = i.handleImpression(key, bk, feature, res, cfg, properties)
+	return eres
 }
 
-func (i *Impl) Track(cfg *types.ClientConfig, key string, trafficType string, eventType string, value *float64, properties map[string]interface{}) error {
+// rejectedResult builds the result for an evaluation that wasn't performed because its input failed validation
+func (i *Impl) rejectedResult(feature string, stale bool, err error) *EvaluationResult {
+	i.logger.Error(err.Error())
+	treatment, config := i.getFallbackTreatment(feature)
+	return &EvaluationResult{
+		Treatment: treatment,
+		Config:    config,
+		Stale:     stale,
+		Warnings:  []ValidationWarning{{Code: WarningInvalidInput, Message: err.Error()}},
+	}
+}
+
+func (i *Impl) Track(cfg *types.ClientConfig, key string, trafficType string, eventType string, value *float64, properties map[string]interface{}) ([]ValidationWarning, error) {
+
+	var warnings []ValidationWarning
+	if err := i.validator.validateKey("Track", key); err != nil {
+		return nil, err
+	}
 
-	// TODO(mredolatti): validate traffic type & truncate properties if needed
-	trafficType, err := i.validator.validateTrafficType(trafficType)
+	trafficType, err := i.validator.validateTrafficType(trafficType, &warnings)
 	if err != nil {
-		return err
+		return warnings, err
 	}
 
-	properties, _, err = i.validator.validateTrackProperties(properties)
+	if err := i.validator.validateEventType(eventType); err != nil {
+		return warnings, err
+	}
+
+	if err := i.validator.validateValue(value); err != nil {
+		return warnings, err
+	}
+
+	properties, _, err = i.validator.validateTrackProperties(properties, &warnings)
 	if err != nil {
-		return err
+		return warnings, err
 	}
 
 	event := &dtos.EventDTO{
@@ -249,20 +443,22 @@ func (i *Impl) Track(cfg *types.ClientConfig, key string, trafficType string, ev
 		Properties:      properties,
 	}
 
+	// forwarded regardless of whether there's room in the queue of events to be sent to split
+	i.forwarders.push(cfg.Metadata, event)
+
 	_, err = i.es.Push(cfg.Metadata, *event)
 	if err != nil {
-		if err == storage.ErrQueueFull {
-			select {
-			case i.queueFullChan <- eventsFullNotif:
-			default:
+		if errors.Is(err, storage.ErrQueueFull) {
+			if !i.evFlush.full() {
 				i.logger.Warning("events queue has filled up and is currently performing a flush. Current event will be dropped")
 			}
-			return ErrEventsQueueFull
+			return warnings, ErrEventsQueueFull
 		}
 		i.logger.Error("error handling event: ", err)
-		return err
+		return warnings, err
 	}
-	return nil
+	i.evFlush.pushed(cfg.Metadata)
+	return warnings, nil
 }
 
 func (i *Impl) Split(name string) (*SplitView, error) {
@@ -287,14 +483,144 @@ func (i *Impl) Splits() ([]SplitView, error) {
 	return asViews, nil
 }
 
+func (i *Impl) SplitDefinition(name string) (*dtos.SplitDTO, error) {
+	split := i.splitStorage.Split(name)
+	if split == nil {
+		return nil, ErrSplitNotFound
+	}
+	return split, nil
+}
+
+func (i *Impl) SegmentNames() ([]string, error) {
+	// segments can be referenced both by feature flags & rule-based segments
+	names := i.splitStorage.SegmentNames()
+	names.Add(i.rbsStorage.Segments().List()...)
+	asStrings := make([]string, 0, names.Size())
+	for _, name := range names.List() {
+		if asStr, ok := name.(string); ok {
+			asStrings = append(asStrings, asStr)
+		}
+	}
+	sort.Strings(asStrings)
+	return asStrings, nil
+}
+
+func (i *Impl) Segment(name string) (*SegmentView, error) {
+	keys := i.segStorage.Keys(name)
+	if keys == nil {
+		return nil, ErrSegmentNotFound
+	}
+
+	cn, err := i.segStorage.ChangeNumber(name)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching change number for segment '%s': %w", name, err)
+	}
+
+	return &SegmentView{Name: name, KeyCount: int64(keys.Size()), ChangeNumber: cn}, nil
+}
+
+func (i *Impl) SegmentContainsKey(name string, key string) (bool, error) {
+	// the storage only fails (with an unexported error) if the segment doesn't exist. unlike Keys(), checking
+	// membership doesn't copy the whole segment
+	contains, err := i.segStorage.SegmentContainsKey(name, key)
+	if err != nil {
+		return false, ErrSegmentNotFound
+	}
+	return contains, nil
+}
+
+func (i *Impl) RuleBasedSegmentNames() ([]string, error) {
+	names, err := i.rbsStorage.RuleBasedSegmentNames()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching rule-based segment names: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (i *Impl) RuleBasedSegment(name string) (*dtos.RuleBasedSegmentDTO, error) {
+	rbs := i.rbsStorage.FetchMany([]string{name})[name]
+	if rbs == nil {
+		return nil, ErrRuleBasedSegmentNotFound
+	}
+	return rbs, nil
+}
+
+// mergeAttributes builds the attributes used for an evaluation: the daemon's defaults, overridden by those bound
+// to the client's session, overridden by the ones sent in the call. The caller's map is never modified
+func (i *Impl) mergeAttributes(cfg *types.ClientConfig, attributes Attributes) Attributes {
+	var session Attributes
+	if cfg != nil {
+		session = cfg.Attributes
+	}
+
+	if len(i.cfg.DefaultAttributes) == 0 && len(session) == 0 {
+		return attributes
+	}
+
+	merged := make(Attributes, len(i.cfg.DefaultAttributes)+len(session)+len(attributes))
+	for _, src := range []Attributes{i.cfg.DefaultAttributes, session, attributes} {
+		for k, v := range src {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// Overrides returns the store of forced treatments consulted before evaluating
+func (i *Impl) Overrides() *overrides.Store {
+	return i.overrides
+}
+
+// Readiness implements Interface
+func (i *Impl) Readiness() State {
+	return i.readiness.get()
+}
+
+// Staleness implements Interface
+func (i *Impl) Staleness() Staleness {
+	return i.staleness.get()
+}
+
+// FlushStats implements Interface
+func (i *Impl) FlushStats() FlushStats {
+	return FlushStats{Impressions: i.impFlush.stats(), Events: i.evFlush.stats()}
+}
+
+// BlockUntilReady implements Interface
+func (i *Impl) BlockUntilReady(timeout time.Duration) error {
+	return i.readiness.await(timeout)
+}
+
+// ExportSnapshot writes a snapshot to the configured path right away (ie: to hand it over to a process replacing this one)
+func (i *Impl) ExportSnapshot() error {
+	if i.cfg.Snapshot.Path == "" {
+		return ErrSnapshotsDisabled
+	}
+
+	stores := &storages{splits: i.splitStorage, segments: i.segStorage, ruleBasedSegments: i.rbsStorage}
+	return exportSnapshot(stores, &i.cfg, i.readiness)
+}
+
 func (i *Impl) Shutdown() error {
 	i.sm.Stop()
+	if i.snapshots != nil {
+		i.snapshots.Stop(true)
+	}
+	i.listeners.Shutdown()
+	i.forwarders.stop(i.logger)
 	return nil
 }
 
-func (i *Impl) handleImpression(key string, bk *string, f string, r *evaluator.Result, cm types.ClientMetadata, properties string) *dtos.Impression {
+func (i *Impl) handleImpression(key string, bk *string, f string, r *evaluator.Result, cfg *types.ClientConfig, properties string) *dtos.Impression {
+	cm := cfg.Metadata
+	labelsEnabled := i.cfg.LabelsEnabled
+	if cfg.LabelsEnabled != nil && i.cfg.Impressions.ClientLabels {
+		labelsEnabled = *cfg.LabelsEnabled
+	}
+
 	var label string
-	if i.cfg.LabelsEnabled {
+	if labelsEnabled || r.Label == LabelOverride {
 		label = r.Label
 	}
 
@@ -310,25 +636,37 @@ func (i *Impl) handleImpression(key string, bk *string, f string, r *evaluator.R
 		Properties:   properties,
 	}
 
-	forLog, _ := i.iq.Process([]dtos.Impression{imp}, false)
+	forLog, forListener := i.impressionManagerFor(cfg).Process([]dtos.Impression{imp}, i.listeners != nil)
+	i.listeners.Push(cm, forListener...)
 	if len(forLog) == 1 {
 		_, err := i.is.Push(cm, forLog[0])
 		if err != nil {
-			if err == storage.ErrQueueFull {
-				select {
-				case i.queueFullChan <- impressionsFullNotif:
-				default:
+			if errors.Is(err, storage.ErrQueueFull) {
+				if !i.impFlush.full() {
 					i.logger.Warning("impressions queue has filled up and is currently performing a flush. Current impression will bedropped")
 				}
 			} else {
 				i.logger.Error("error handling impression: ", err)
 			}
+		} else {
+			i.impFlush.pushed(cm)
 		}
 	}
 
 	return &imp
 }
 
+// impressionManagerFor returns the manager for the impressions mode requested by the client if it's allowed,
+// or the default one otherwise
+func (i *Impl) impressionManagerFor(cfg *types.ClientConfig) provisional.ImpressionManager {
+	if cfg.ImpressionsMode != "" {
+		if manager, ok := i.iqByMode[cfg.ImpressionsMode]; ok {
+			return manager
+		}
+	}
+	return i.iq
+}
+
 func splitToView(s *dtos.SplitDTO) *SplitView {
 
 	var treatments []string
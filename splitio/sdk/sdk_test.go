@@ -1,6 +1,7 @@
 package sdk
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -8,12 +9,17 @@ import (
 
 	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/go-split-commons/v9/engine/evaluator"
+	"github.com/splitio/go-split-commons/v9/flagsets"
+	"github.com/splitio/go-split-commons/v9/provisional"
 	"github.com/splitio/go-split-commons/v9/storage/inmemory"
+	"github.com/splitio/go-split-commons/v9/storage/inmemory/mutexmap"
 	"github.com/splitio/go-split-commons/v9/synchronizer"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/splitd/external/commons/mocks"
 	"github.com/splitio/splitd/splitio/common/lang"
 	"github.com/splitio/splitd/splitio/sdk/conf"
+	"github.com/splitio/splitd/splitio/sdk/listeners"
 	"github.com/splitio/splitd/splitio/sdk/storage"
 	"github.com/splitio/splitd/splitio/sdk/types"
 	"github.com/splitio/splitd/splitio/sdk/workers"
@@ -107,7 +113,7 @@ func TestTreatmentLabelsDisabled(t *testing.T) {
 	assert.Nil(t, res.Config)
 	assertImpEq(t, expectedImpression, res.Impression)
 
-	err = is.RangeAndClear(func(md types.ClientMetadata, st *storage.LockingQueue[dtos.Impression]) {
+	err = is.RangeAndClear(func(md types.ClientMetadata, st storage.BackingQueue[dtos.Impression]) {
 		assert.Equal(t, types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}, md)
 		assert.Equal(t, 1, st.Len())
 
@@ -162,7 +168,7 @@ func TestTreatmentLabelsEnabled(t *testing.T) {
 	assert.Nil(t, res.Config)
 	assertImpEq(t, expectedImpression, res.Impression)
 
-	err = is.RangeAndClear(func(md types.ClientMetadata, st *storage.LockingQueue[dtos.Impression]) {
+	err = is.RangeAndClear(func(md types.ClientMetadata, st storage.BackingQueue[dtos.Impression]) {
 		assert.Equal(t, types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}, md)
 		assert.Equal(t, 1, st.Len())
 
@@ -383,7 +389,7 @@ func TestTreatments(t *testing.T) {
 	assertImpEq(t, &expectedImpressions[1], res["f2"].Impression)
 	assertImpEq(t, &expectedImpressions[2], res["f3"].Impression)
 
-	err = is.RangeAndClear(func(md types.ClientMetadata, st *storage.LockingQueue[dtos.Impression]) {
+	err = is.RangeAndClear(func(md types.ClientMetadata, st storage.BackingQueue[dtos.Impression]) {
 		assert.Equal(t, types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}, md)
 		assert.Equal(t, 3, st.Len())
 
@@ -465,7 +471,7 @@ func TestTreatmentsWithImpressionProperties(t *testing.T) {
 	assertImpEq(t, &expectedImpressions[1], res["f2"].Impression)
 	assertImpEq(t, &expectedImpressions[2], res["f3"].Impression)
 
-	err = is.RangeAndClear(func(md types.ClientMetadata, st *storage.LockingQueue[dtos.Impression]) {
+	err = is.RangeAndClear(func(md types.ClientMetadata, st storage.BackingQueue[dtos.Impression]) {
 		assert.Equal(t, types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}, md)
 		assert.Equal(t, 3, st.Len())
 
@@ -554,7 +560,7 @@ func TestTreatmentsByFlagSet(t *testing.T) {
 	assertImpEq(t, expectedImpressions["f2"], res["f2"].Impression)
 	assertImpEq(t, expectedImpressions["f3"], res["f3"].Impression)
 
-	err = is.RangeAndClear(func(md types.ClientMetadata, st *storage.LockingQueue[dtos.Impression]) {
+	err = is.RangeAndClear(func(md types.ClientMetadata, st storage.BackingQueue[dtos.Impression]) {
 		assert.Equal(t, types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}, md)
 		assert.Equal(t, 3, st.Len())
 
@@ -643,7 +649,7 @@ func TestTreatmentsByFlagSets(t *testing.T) {
 	assertImpEq(t, expectedImpressions["f2"], res["f2"].Impression)
 	assertImpEq(t, expectedImpressions["f3"], res["f3"].Impression)
 
-	err = is.RangeAndClear(func(md types.ClientMetadata, st *storage.LockingQueue[dtos.Impression]) {
+	err = is.RangeAndClear(func(md types.ClientMetadata, st storage.BackingQueue[dtos.Impression]) {
 		assert.Equal(t, types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}, md)
 		assert.Equal(t, 3, st.Len())
 
@@ -696,7 +702,8 @@ func TestImpressionsQueueFull(t *testing.T) {
 		Return([]dtos.Impression{*expectedImpression}, []dtos.Impression{}).
 		Times(9)
 
-	client := &Impl{logger: logging.NewLogger(nil), ss: nil, is: is, ev: ev, iq: im, cfg: conf.Config{LabelsEnabled: true}, queueFullChan: queueFullChan}
+	impFlush := newFlushTrigger(impressionsFullNotif, queueFullChan, is, 0)
+	client := &Impl{logger: logging.NewLogger(nil), ss: nil, is: is, ev: ev, iq: im, cfg: conf.Config{LabelsEnabled: true}, impFlush: impFlush}
 	clientConf := &types.ClientConfig{Metadata: types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}}
 
 	// create 4 impressions to fill the queue (last one will be dropped and trigger a flush)
@@ -734,8 +741,9 @@ func TestImpressionsQueueFull(t *testing.T) {
 	im.AssertExpectations(t)
 	impRecorder.AssertExpectations(t)
 	var totalSize int
-	is.Range(func(md types.ClientMetadata, q *storage.LockingQueue[dtos.Impression]) { totalSize += q.Len() })
+	is.Range(func(md types.ClientMetadata, q storage.BackingQueue[dtos.Impression]) { totalSize += q.Len() })
 	assert.Equal(t, 1, totalSize) // assert no more impressions in queue
+	assert.Equal(t, int64(2), client.FlushStats().Impressions.QueueFull)
 }
 
 func TestTrack(t *testing.T) {
@@ -755,10 +763,10 @@ func TestTrack(t *testing.T) {
 
 	md := types.ClientConfig{Metadata: types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}}
 
-	err := client.Track(&md, "key1", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
+	_, err := client.Track(&md, "key1", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
 	assert.Nil(t, err)
 
-	err = es.RangeAndClear(func(md types.ClientMetadata, st *storage.LockingQueue[dtos.EventDTO]) {
+	err = es.RangeAndClear(func(md types.ClientMetadata, st storage.BackingQueue[dtos.EventDTO]) {
 		assert.Equal(t, types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}, md)
 		assert.Equal(t, 1, st.Len())
 
@@ -780,10 +788,10 @@ func TestTrack(t *testing.T) {
 	})
 	assert.Nil(t, err)
 
-	err = client.Track(&md, "key1", "", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
+	_, err = client.Track(&md, "key1", "", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
 	assert.ErrorIs(t, err, ErrEmtpyTrafficType)
 
-	err = client.Track(&md, "key1", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": strings.Repeat("qwertyui", 100000)})
+	_, err = client.Track(&md, "key1", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": strings.Repeat("qwertyui", 100000)})
 	assert.ErrorIs(t, err, ErrEventTooBig)
 
 }
@@ -796,28 +804,30 @@ func TestTrackEventsFlush(t *testing.T) {
 	ss := &mocks.SplitStorageMock{}
 	ss.On("TrafficTypeExists", "user").Return(true)
 
+	queueFullChan := make(chan string, 2)
 	client := &Impl{
-		logger:        logging.NewLogger(nil),
-		queueFullChan: make(chan string, 2),
-		es:            es,
-		cfg:           conf.Config{LabelsEnabled: false},
-		validator:     Validator{logger, ss},
+		logger:    logging.NewLogger(nil),
+		evFlush:   newFlushTrigger(eventsFullNotif, queueFullChan, es, 0),
+		es:        es,
+		cfg:       conf.Config{LabelsEnabled: false},
+		validator: Validator{logger, ss},
 	}
 
 	md := types.ClientConfig{Metadata: types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}}
 
-	err := client.Track(&md, "key1", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
+	_, err := client.Track(&md, "key1", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
 	assert.Nil(t, err)
-	err = client.Track(&md, "key2", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
+	_, err = client.Track(&md, "key2", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
 	assert.Nil(t, err)
-	err = client.Track(&md, "key3", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
+	_, err = client.Track(&md, "key3", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
 	assert.Nil(t, err)
-	err = client.Track(&md, "key4", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
+	_, err = client.Track(&md, "key4", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
 	assert.ErrorIs(t, err, ErrEventsQueueFull)
 
-	assert.Equal(t, "EVENTS_FULL", <-client.queueFullChan)
+	assert.Equal(t, "EVENTS_FULL", <-queueFullChan)
+	assert.Equal(t, FlushTriggers{QueueFull: 1}, client.FlushStats().Events)
 
-	err = es.RangeAndClear(func(md types.ClientMetadata, st *storage.LockingQueue[dtos.EventDTO]) {
+	err = es.RangeAndClear(func(md types.ClientMetadata, st storage.BackingQueue[dtos.EventDTO]) {
 		assert.Equal(t, types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}, md)
 		assert.Equal(t, 3, st.Len())
 
@@ -847,10 +857,10 @@ func TestTrackEventsFlush(t *testing.T) {
 	})
 	assert.Nil(t, err)
 
-	err = client.Track(&md, "key1", "", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
+	_, err = client.Track(&md, "key1", "", "checkin", lang.Ref(123.4), map[string]interface{}{"a": 123})
 	assert.ErrorIs(t, err, ErrEmtpyTrafficType)
 
-	err = client.Track(&md, "key1", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": strings.Repeat("qwertyui", 100000)})
+	_, err = client.Track(&md, "key1", "user", "checkin", lang.Ref(123.4), map[string]interface{}{"a": strings.Repeat("qwertyui", 100000)})
 	assert.ErrorIs(t, err, ErrEventTooBig)
 
 }
@@ -948,6 +958,122 @@ func TestSplitWithImpressionsDiabledInTrue(t *testing.T) {
 	}, split)
 }
 
+func TestSplitDefinition(t *testing.T) {
+	var ss mocks.SplitStorageMock
+	ss.On("Split", "s1").Return(&dtos.SplitDTO{Name: "s1", TrafficTypeName: "tt1", ChangeNumber: 1}).Once()
+	ss.On("Split", "s2").Return((*dtos.SplitDTO)(nil)).Once()
+
+	c := Impl{splitStorage: &ss}
+
+	split, err := c.SplitDefinition("s1")
+	assert.Nil(t, err)
+	assert.Equal(t, &dtos.SplitDTO{Name: "s1", TrafficTypeName: "tt1", ChangeNumber: 1}, split)
+
+	split, err = c.SplitDefinition("s2")
+	assert.ErrorIs(t, err, ErrSplitNotFound)
+	assert.Nil(t, split)
+}
+
+func TestSegments(t *testing.T) {
+	splits := mutexmap.NewMMSplitStorage(flagsets.NewFlagSetFilter(nil))
+	splits.Update([]dtos.SplitDTO{{
+		Name: "s1",
+		Conditions: []dtos.ConditionDTO{{MatcherGroup: dtos.MatcherGroupDTO{Matchers: []dtos.MatcherDTO{{
+			MatcherType:        "IN_SEGMENT",
+			UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "seg2"},
+		}}}}},
+	}}, nil, 1)
+
+	rbs := mutexmap.NewRuleBasedSegmentsStorage()
+	rbs.Update([]dtos.RuleBasedSegmentDTO{{
+		Name:     "rbs1",
+		Excluded: dtos.ExcludedDTO{Segments: []dtos.ExcludedSegmentDTO{{Name: "seg1", Type: dtos.TypeStandard}}},
+	}}, nil, 1)
+
+	segments := mutexmap.NewMMSegmentStorage()
+	segments.Update("seg1", set.NewSet("k1", "k2"), set.NewSet(), 123)
+
+	c := Impl{splitStorage: splits, segStorage: segments, rbsStorage: rbs}
+
+	names, err := c.SegmentNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"seg1", "seg2"}, names)
+
+	seg, err := c.Segment("seg1")
+	assert.Nil(t, err)
+	assert.Equal(t, &SegmentView{Name: "seg1", KeyCount: 2, ChangeNumber: 123}, seg)
+
+	seg, err = c.Segment("seg2")
+	assert.ErrorIs(t, err, ErrSegmentNotFound)
+	assert.Nil(t, seg)
+
+	contains, err := c.SegmentContainsKey("seg1", "k1")
+	assert.Nil(t, err)
+	assert.True(t, contains)
+
+	contains, err = c.SegmentContainsKey("seg1", "k3")
+	assert.Nil(t, err)
+	assert.False(t, contains)
+
+	_, err = c.SegmentContainsKey("seg2", "k1")
+	assert.ErrorIs(t, err, ErrSegmentNotFound)
+
+	rbsNames, err := c.RuleBasedSegmentNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"rbs1"}, rbsNames)
+
+	def, err := c.RuleBasedSegment("rbs1")
+	assert.Nil(t, err)
+	assert.Equal(t, "rbs1", def.Name)
+
+	def, err = c.RuleBasedSegment("rbs2")
+	assert.ErrorIs(t, err, ErrRuleBasedSegmentNotFound)
+	assert.Nil(t, def)
+}
+
+func TestTreatmentClientImpressionOverrides(t *testing.T) {
+	is, _ := storage.NewImpressionsQueue(100)
+
+	ev := &mocks.EvaluatorMock{}
+	ev.On("EvaluateFeature", "key1", (*string)(nil), "f1", Attributes(nil)).
+		Return(&evaluator.Result{Treatment: "on", Label: "label1", SplitChangeNumber: 123})
+
+	defaultIM := &mocks.ImpressionManagerMock{}
+	defaultIM.On("Process", mock.Anything).Return([]dtos.Impression{}, []dtos.Impression{}).Twice()
+	noneIM := &mocks.ImpressionManagerMock{}
+	noneIM.On("Process", mock.Anything).Return([]dtos.Impression{}, []dtos.Impression{}).Once()
+
+	client := &Impl{
+		logger:   logging.NewLogger(nil),
+		is:       is,
+		ev:       ev,
+		iq:       defaultIM,
+		iqByMode: map[string]provisional.ImpressionManager{"none": noneIM},
+		cfg:      conf.Config{LabelsEnabled: true},
+	}
+
+	md := types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}
+
+	// allowed mode, labels override not allowed
+	res, err := client.Treatment(&types.ClientConfig{Metadata: md, ImpressionsMode: "none", LabelsEnabled: lang.Ref(false)}, "key1", nil, "f1", nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "label1", res.Impression.Label)
+
+	// mode not allowed, falls back to the default manager
+	client.cfg.Impressions.ClientLabels = true
+	res, err = client.Treatment(&types.ClientConfig{Metadata: md, ImpressionsMode: "debug", LabelsEnabled: lang.Ref(false)}, "key1", nil, "f1", nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "", res.Impression.Label)
+
+	// no overrides
+	res, err = client.Treatment(&types.ClientConfig{Metadata: md}, "key1", nil, "f1", nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "label1", res.Impression.Label)
+
+	defaultIM.AssertExpectations(t)
+	noneIM.AssertExpectations(t)
+}
+
 func assertImpEq(t *testing.T, i1, i2 *dtos.Impression) {
 	t.Helper()
 	assert.Equal(t, i1.KeyName, i2.KeyName)
@@ -967,3 +1093,106 @@ func assertEventEq(t *testing.T, e1, e2 *dtos.EventDTO) {
 	assert.Equal(t, e1.Value, e2.Value)
 	assert.Equal(t, e1.Properties, e2.Properties)
 }
+
+func TestTreatmentWithImpressionListeners(t *testing.T) {
+	is, _ := storage.NewImpressionsQueue(100)
+
+	ev := &mocks.EvaluatorMock{}
+	ev.On("EvaluateFeature", "key1", (*string)(nil), "f1", Attributes(nil)).
+		Return(&evaluator.Result{Treatment: "on", Label: "label1", SplitChangeNumber: 123}).
+		Once()
+
+	// impressions deduped by the strategy are still handed to listeners
+	im := &mocks.ImpressionManagerMock{}
+	im.On("Process", mock.Anything).
+		Return([]dtos.Impression{}, []dtos.Impression{{KeyName: "key1", FeatureName: "f1", Treatment: "on", Label: "label1", ChangeNumber: 123, Properties: `{"a":1}`}}).
+		Once()
+
+	sink := &listenerSinkMock{written: make(chan []listeners.Record, 1)}
+	lm, err := listeners.NewWithSinks(logging.NewLogger(nil), &conf.ImpressionListeners{QueueSize: 10, BatchSize: 1, FlushPeriod: time.Hour}, sink)
+	assert.Nil(t, err)
+
+	client := &Impl{
+		logger:    logging.NewLogger(nil),
+		is:        is,
+		ev:        ev,
+		iq:        im,
+		listeners: lm,
+		cfg:       conf.Config{LabelsEnabled: true},
+	}
+
+	md := types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}
+	_, err = client.Treatment(&types.ClientConfig{Metadata: md}, "key1", nil, "f1", nil, &dtos.EvaluationOptions{Properties: map[string]interface{}{"a": 1}})
+	assert.Nil(t, err)
+
+	select {
+	case records := <-sink.written:
+		assert.Equal(t, []listeners.Record{{
+			Feature:          "f1",
+			Key:              "key1",
+			Treatment:        "on",
+			Label:            "label1",
+			ChangeNumber:     123,
+			Properties:       json.RawMessage(`{"a":1}`),
+			ClientID:         "some",
+			ClientSdkVersion: "go-1.2.3",
+		}}, records)
+	case <-time.After(time.Second):
+		assert.Fail(t, "impression should have been forwarded to listeners")
+	}
+	lm.Shutdown()
+
+	// nothing is queued to be sent to split
+	is.RangeAndClear(func(_ types.ClientMetadata, q storage.BackingQueue[dtos.Impression]) { assert.Equal(t, 0, q.Len()) })
+}
+
+type listenerSinkMock struct {
+	written chan []listeners.Record
+}
+
+func (s *listenerSinkMock) Name() string { return "mock" }
+func (s *listenerSinkMock) Close() error { return nil }
+func (s *listenerSinkMock) Write(records []listeners.Record) error {
+	s.written <- append([]listeners.Record(nil), records...)
+	return nil
+}
+
+func TestTreatmentWithDefaultAndSessionAttributes(t *testing.T) {
+	is, _ := storage.NewImpressionsQueue(100)
+
+	ev := &mocks.EvaluatorMock{}
+	ev.On("EvaluateFeature", "key1", (*string)(nil), "f1", Attributes{"region": "us-east-1", "cluster": "c2", "plan": "pro", "a": 1}).
+		Return(&evaluator.Result{Treatment: "on", Label: "label1", SplitChangeNumber: 123}).
+		Once()
+	ev.On("EvaluateFeatures", "key1", (*string)(nil), []string{"f1"}, Attributes{"region": "us-east-1", "cluster": "c1"}).
+		Return(evaluator.Results{Evaluations: map[string]evaluator.Result{"f1": {Treatment: "off"}}}).
+		Once()
+
+	im := &mocks.ImpressionManagerMock{}
+	im.On("Process", mock.Anything).Return([]dtos.Impression{}, []dtos.Impression{})
+
+	client := &Impl{
+		logger: logging.NewLogger(nil),
+		is:     is,
+		ev:     ev,
+		iq:     im,
+		cfg:    conf.Config{DefaultAttributes: Attributes{"region": "us-east-1", "cluster": "c1"}},
+	}
+
+	cfg := &types.ClientConfig{Metadata: types.ClientMetadata{ID: "some"}, Attributes: Attributes{"cluster": "c2", "plan": "free"}}
+	callAttrs := Attributes{"plan": "pro", "a": 1}
+	res, err := client.Treatment(cfg, "key1", nil, "f1", callAttrs, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "on", res.Treatment)
+	assert.Equal(t, Attributes{"plan": "pro", "a": 1}, callAttrs) // caller's map is left untouched
+
+	res2, err := client.Treatments(&types.ClientConfig{}, "key1", nil, []string{"f1"}, nil, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "off", res2["f1"].Treatment)
+
+	// no defaults nor session attributes -> passed through as-is
+	client.cfg.DefaultAttributes = nil
+	assert.Equal(t, callAttrs, client.mergeAttributes(&types.ClientConfig{}, callAttrs))
+	assert.Nil(t, client.mergeAttributes(nil, nil))
+	ev.AssertExpectations(t)
+}
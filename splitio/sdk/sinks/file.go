@@ -0,0 +1,110 @@
+package sinks
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// File writes records as newline-delimited JSON, rotating the file when it reaches the configured size
+type File[T any] struct {
+	path     string
+	maxFiles int
+	maxBytes int64
+	file     *os.File
+	size     int64
+	buffer   bytes.Buffer
+}
+
+// NewFile builds a file sink. Up to `maxFiles` rotated files are kept (`path.1` being the most recent one)
+func NewFile[T any](path string, maxFiles int, maxBytes int64) *File[T] {
+	return &File[T]{path: path, maxFiles: maxFiles, maxBytes: maxBytes}
+}
+
+// Name implements Sink
+func (f *File[T]) Name() string {
+	return "file"
+}
+
+// Write implements Sink
+func (f *File[T]) Write(records []T) error {
+	f.buffer.Reset()
+	if err := encodeNDJSON(&f.buffer, records); err != nil {
+		return err
+	}
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+
+	if f.maxBytes > 0 && f.size > 0 && f.size+int64(f.buffer.Len()) > f.maxBytes {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.file.Write(f.buffer.Bytes())
+	f.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("error writing to file: %w", err)
+	}
+	return nil
+}
+
+// Close implements Sink
+func (f *File[T]) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *File[T]) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("error reading file size: %w", err)
+	}
+
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// rotate shifts `path` -> `path.1` -> ... -> `path.<maxFiles>`, discarding the oldest one, and opens a new file
+func (f *File[T]) rotate() error {
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error closing file for rotation: %w", err)
+	}
+
+	if f.maxFiles > 0 {
+		os.Remove(f.backupName(f.maxFiles))
+		for idx := f.maxFiles - 1; idx >= 0; idx-- {
+			if err := os.Rename(f.backupName(idx), f.backupName(idx+1)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("error rotating file: %w", err)
+			}
+		}
+	} else if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error truncating file: %w", err)
+	}
+
+	return f.open()
+}
+
+func (f *File[T]) backupName(idx int) string {
+	if idx == 0 {
+		return f.path
+	}
+	return f.path + "." + strconv.Itoa(idx)
+}
+
+var _ Sink[any] = (*File[any])(nil)
@@ -0,0 +1,25 @@
+package sinks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Sink is a destination for records. Implementations are not safe for concurrent use: they're expected to be
+// driven by a single goroutine, and must not retain the slice passed to Write after returning
+type Sink[T any] interface {
+	Name() string
+	Write(records []T) error
+	Close() error
+}
+
+func encodeNDJSON[T any](buffer *bytes.Buffer, records []T) error {
+	encoder := json.NewEncoder(buffer) // Encode() appends a newline after each record
+	for idx := range records {
+		if err := encoder.Encode(&records[idx]); err != nil {
+			return fmt.Errorf("error serializing record: %w", err)
+		}
+	}
+	return nil
+}
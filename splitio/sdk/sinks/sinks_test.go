@@ -0,0 +1,132 @@
+package sinks
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "impressions.ndjson")
+	sink := NewFile[record](path, 2, 60)
+
+	records := []record{{Feature: "f1", Key: "k1", Treatment: "on"}, {Feature: "f2", Key: "k1", Treatment: "off"}}
+	require.Nil(t, sink.Write(records))
+	assert.Equal(t, records, readNDJSON(t, path))
+
+	// each record takes ~45 bytes. every following one triggers a rotation
+	require.Nil(t, sink.Write(records[:1]))
+	require.Nil(t, sink.Write(records[1:]))
+	require.Nil(t, sink.Write(records[:1]))
+	require.Nil(t, sink.Close())
+
+	assert.Equal(t, records[:1], readNDJSON(t, path))
+	assert.Equal(t, records[1:], readNDJSON(t, path+".1"))
+	assert.Equal(t, records[:1], readNDJSON(t, path+".2"))
+	_, err := os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	// appends to an existing file after a restart
+	sink = NewFile[record](path, 2, 1000)
+	require.Nil(t, sink.Write(records[1:]))
+	require.Nil(t, sink.Close())
+	assert.Equal(t, records, readNDJSON(t, path))
+}
+
+func TestStreamSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "listener.sock")
+	sink := NewStream[record]("unix", path, time.Second)
+
+	records := []record{{Feature: "f1", Key: "k1", Treatment: "on"}, {Feature: "f2", Key: "k1", Treatment: "off"}}
+	assert.NotNil(t, sink.Write(records)) // nobody listening yet
+
+	l, err := net.Listen("unix", path)
+	require.Nil(t, err)
+	defer l.Close()
+
+	received := make(chan record, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				var r record
+				json.Unmarshal(scanner.Bytes(), &r)
+				received <- r
+			}
+			conn.Close()
+		}
+	}()
+
+	require.Nil(t, sink.Write(records))
+	assert.Equal(t, records[0], <-received)
+	assert.Equal(t, records[1], <-received)
+	require.Nil(t, sink.Close())
+
+	// reconnects on next write
+	require.Nil(t, sink.Write(records[1:]))
+	assert.Equal(t, records[1], <-received)
+	require.Nil(t, sink.Close())
+}
+
+func TestWebhookSink(t *testing.T) {
+	records := []record{{Feature: "f1", Key: "k1", Treatment: "on"}, {Feature: "f2", Key: "k1", Treatment: "off"}}
+	status := http.StatusOK
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer 123", r.Header.Get("Authorization"))
+
+		body, _ := io.ReadAll(r.Body)
+		var received []record
+		assert.Nil(t, json.Unmarshal(body, &received))
+		assert.Equal(t, records, received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhook[record](server.URL, map[string]string{"Authorization": "Bearer 123"}, time.Second)
+	assert.Equal(t, "webhook", sink.Name())
+	assert.Nil(t, sink.Write(records))
+
+	status = http.StatusInternalServerError
+	assert.ErrorContains(t, sink.Write(records), "500")
+	assert.Equal(t, 2, calls)
+	assert.Nil(t, sink.Close())
+}
+
+type record struct {
+	Feature   string `json:"feature"`
+	Key       string `json:"key"`
+	Treatment string `json:"treatment"`
+}
+
+func readNDJSON(t *testing.T, path string) []record {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	require.Nil(t, err)
+
+	var records []record
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+		var r record
+		require.Nil(t, json.Unmarshal([]byte(line), &r))
+		records = append(records, r)
+	}
+	return records
+}
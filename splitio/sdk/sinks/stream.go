@@ -0,0 +1,67 @@
+package sinks
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Stream writes records as newline-delimited JSON to a unix or tcp socket. The connection is established
+// lazily and re-established on the next write after a failure. Records are dropped while the peer is unreachable
+type Stream[T any] struct {
+	network      string
+	address      string
+	writeTimeout time.Duration
+	conn         net.Conn
+	buffer       bytes.Buffer
+}
+
+// NewStream builds a stream sink for a `unix` or `tcp` network
+func NewStream[T any](network string, address string, writeTimeout time.Duration) *Stream[T] {
+	return &Stream[T]{network: network, address: address, writeTimeout: writeTimeout}
+}
+
+// Name implements Sink
+func (s *Stream[T]) Name() string {
+	return "stream"
+}
+
+// Write implements Sink
+func (s *Stream[T]) Write(records []T) error {
+	s.buffer.Reset()
+	if err := encodeNDJSON(&s.buffer, records); err != nil {
+		return err
+	}
+
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, s.writeTimeout)
+		if err != nil {
+			return fmt.Errorf("error connecting to %s://%s: %w", s.network, s.address, err)
+		}
+		s.conn = conn
+	}
+
+	if s.writeTimeout > 0 {
+		s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	}
+
+	if _, err := s.conn.Write(s.buffer.Bytes()); err != nil {
+		// a partially written line cannot be recovered, start over with a new connection
+		s.Close()
+		return fmt.Errorf("error writing to %s://%s: %w", s.network, s.address, err)
+	}
+	return nil
+}
+
+// Close implements Sink
+func (s *Stream[T]) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+var _ Sink[any] = (*Stream[any])(nil)
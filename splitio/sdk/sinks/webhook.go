@@ -0,0 +1,68 @@
+package sinks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Webhook POSTs each batch of records as a JSON array
+type Webhook[T any] struct {
+	url     string
+	headers map[string]string
+	client  http.Client
+}
+
+// NewWebhook builds a webhook sink. Headers are set on every request
+func NewWebhook[T any](url string, headers map[string]string, timeout time.Duration) *Webhook[T] {
+	return &Webhook[T]{
+		url:     url,
+		headers: headers,
+		client:  http.Client{Timeout: timeout},
+	}
+}
+
+// Name implements Sink
+func (w *Webhook[T]) Name() string {
+	return "webhook"
+}
+
+// Write implements Sink
+func (w *Webhook[T]) Write(records []T) error {
+	serialized, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("error serializing records: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(serialized))
+	if err != nil {
+		return fmt.Errorf("error building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range w.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting records: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) // allow the connection to be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close implements Sink
+func (w *Webhook[T]) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
+
+var _ Sink[any] = (*Webhook[any])(nil)
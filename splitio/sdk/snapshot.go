@@ -0,0 +1,251 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/telemetry"
+	"github.com/splitio/go-toolkit/v5/asynctask"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/splitio/sdk/conf"
+)
+
+const snapshotVersion = 1
+
+var (
+	ErrSnapshotTooOld       = errors.New("snapshot is older than the allowed max age")
+	ErrSnapshotIncompatible = errors.New("snapshot is incompatible with the current configuration")
+	ErrSnapshotsDisabled    = errors.New("snapshots are not enabled")
+	ErrSnapshotNotReady     = errors.New("data is not ready to be snapshotted")
+)
+
+// snapshot is the on-disk representation of the data required to perform evaluations
+type snapshot struct {
+	Version                       int                        `json:"version"`
+	Timestamp                     int64                      `json:"timestamp"`
+	FlagSetsFilter                []string                   `json:"flagSetsFilter"`
+	SplitsChangeNumber            int64                      `json:"splitsChangeNumber"`
+	Splits                        []dtos.SplitDTO            `json:"splits"`
+	RuleBasedSegmentsChangeNumber int64                      `json:"ruleBasedSegmentsChangeNumber"`
+	RuleBasedSegments             []dtos.RuleBasedSegmentDTO `json:"ruleBasedSegments"`
+	Segments                      map[string]segmentSnapshot `json:"segments"`
+}
+
+type segmentSnapshot struct {
+	ChangeNumber int64    `json:"changeNumber"`
+	Keys         []string `json:"keys"`
+}
+
+func takeSnapshot(stores *storages, flagSets []string, now time.Time) (*snapshot, error) {
+	splitsCN, err := stores.splits.ChangeNumber()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching feature flags change number: %w", err)
+	}
+
+	rbsCN, err := stores.ruleBasedSegments.ChangeNumber()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching rule-based segments change number: %w", err)
+	}
+
+	names := stores.splits.SegmentNames()
+	names.Add(stores.ruleBasedSegments.Segments().List()...)
+	segments := make(map[string]segmentSnapshot, names.Size())
+	for _, raw := range names.List() {
+		name, ok := raw.(string)
+		if !ok {
+			continue
+		}
+
+		keys := stores.segments.Keys(name)
+		if keys == nil { // referenced but not yet fetched
+			continue
+		}
+
+		cn, err := stores.segments.ChangeNumber(name)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching change number for segment '%s': %w", name, err)
+		}
+
+		asStrings := make([]string, 0, keys.Size())
+		for _, key := range keys.List() {
+			if asStr, ok := key.(string); ok {
+				asStrings = append(asStrings, asStr)
+			}
+		}
+		segments[name] = segmentSnapshot{ChangeNumber: cn, Keys: asStrings}
+	}
+
+	return &snapshot{
+		Version:                       snapshotVersion,
+		Timestamp:                     now.UnixMilli(),
+		FlagSetsFilter:                flagSets,
+		SplitsChangeNumber:            splitsCN,
+		Splits:                        stores.splits.All(),
+		RuleBasedSegmentsChangeNumber: rbsCN,
+		RuleBasedSegments:             stores.ruleBasedSegments.All(),
+		Segments:                      segments,
+	}, nil
+}
+
+// validate checks that the snapshot can be used to serve evaluations with the current configuration
+func (s *snapshot) validate(flagSets []string, maxAge time.Duration, now time.Time) error {
+	if s.Version != snapshotVersion {
+		return fmt.Errorf("%w: unknown version %d", ErrSnapshotIncompatible, s.Version)
+	}
+
+	// data in the snapshot & change numbers depend on the filter used when fetching it
+	if !slices.Equal(s.FlagSetsFilter, flagSets) {
+		return fmt.Errorf("%w: flag sets filter has changed", ErrSnapshotIncompatible)
+	}
+
+	if maxAge > 0 && now.Sub(time.UnixMilli(s.Timestamp)) > maxAge {
+		return ErrSnapshotTooOld
+	}
+
+	return nil
+}
+
+// restore populates the storages with the data in the snapshot. Change numbers are restored as well,
+// so that the synchronizer only fetches changes that happened after the snapshot was taken.
+func (s *snapshot) restore(stores *storages) error {
+	if err := stores.ruleBasedSegments.Update(s.RuleBasedSegments, nil, s.RuleBasedSegmentsChangeNumber); err != nil {
+		return fmt.Errorf("error restoring rule-based segments: %w", err)
+	}
+
+	for name, segment := range s.Segments {
+		keys := set.NewSet()
+		for _, key := range segment.Keys {
+			keys.Add(key)
+		}
+		if err := stores.segments.Update(name, keys, set.NewSet(), segment.ChangeNumber); err != nil {
+			return fmt.Errorf("error restoring segment '%s': %w", name, err)
+		}
+	}
+
+	// feature flags go last, so that they're never evaluated without the segments they reference
+	stores.splits.Update(s.Splits, nil, s.SplitsChangeNumber)
+
+	// data is as fresh as the snapshot
+	when := time.UnixMilli(s.Timestamp)
+	stores.telemetry.RecordSuccessfulSync(telemetry.SplitSync, when)
+	stores.telemetry.RecordSuccessfulSync(telemetry.SegmentSync, when)
+	return nil
+}
+
+func writeSnapshot(path string, s *snapshot) error {
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("error serializing snapshot: %w", err)
+	}
+
+	// write to a temporary file & rename it, so that readers never see a partially written snapshot
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	if _, err = tmp.Write(serialized); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("error closing snapshot file: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error moving snapshot into place: %w", err)
+	}
+
+	return nil
+}
+
+func readSnapshot(path string) (*snapshot, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot file: %w", err)
+	}
+
+	var s snapshot
+	if err = json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("error parsing snapshot: %w", err)
+	}
+
+	return &s, nil
+}
+
+// warmStart loads a snapshot into the storages if enabled & the snapshot is usable.
+// It returns true if evaluations can be served from the restored data.
+func warmStart(logger logging.LoggerInterface, stores *storages, cfg *conf.Config) bool {
+	if cfg.Snapshot.Path == "" || !cfg.Snapshot.LoadOnStartup {
+		return false
+	}
+
+	s, err := readSnapshot(cfg.Snapshot.Path)
+	if err != nil {
+		logger.Warning("cannot warm-start from snapshot: ", err)
+		return false
+	}
+
+	if err = s.validate(cfg.FlagSetsFilter, cfg.Snapshot.MaxAge, time.Now()); err != nil {
+		logger.Warning("cannot warm-start from snapshot: ", err)
+		return false
+	}
+
+	if err = s.restore(stores); err != nil {
+		logger.Error("error restoring snapshot: ", err)
+		return false
+	}
+
+	logger.Info(fmt.Sprintf("warm-started from snapshot taken at %s", time.UnixMilli(s.Timestamp).UTC().Format(time.RFC3339)))
+	return true
+}
+
+// newSnapshotExporter builds a task that periodically writes a snapshot (and one last time when stopped).
+// It returns nil if exporting snapshots is disabled
+func newSnapshotExporter(logger logging.LoggerInterface, stores *storages, cfg *conf.Config, r *readiness) *asynctask.AsyncTask {
+	if cfg.Snapshot.Path == "" || cfg.Snapshot.ExportPeriod <= 0 {
+		return nil
+	}
+
+	export := func(l logging.LoggerInterface) error {
+		if err := exportSnapshot(stores, cfg, r); err != nil && !errors.Is(err, ErrSnapshotNotReady) {
+			return err
+		}
+		return nil
+	}
+
+	return asynctask.NewAsyncTask(
+		"snapshot-exporter",
+		export,
+		int(cfg.Snapshot.ExportPeriod.Seconds()),
+		nil,
+		func(l logging.LoggerInterface) {
+			if err := export(l); err != nil {
+				l.Error("error exporting final snapshot: ", err)
+			}
+		},
+		logger,
+	)
+}
+
+// exportSnapshot takes a snapshot & writes it to the configured path
+func exportSnapshot(stores *storages, cfg *conf.Config, r *readiness) error {
+	if !r.get().IsReady() { // never overwrite a previous snapshot with incomplete data
+		return ErrSnapshotNotReady
+	}
+
+	s, err := takeSnapshot(stores, cfg.FlagSetsFilter, time.Now())
+	if err != nil {
+		return fmt.Errorf("error taking snapshot: %w", err)
+	}
+	return writeSnapshot(cfg.Snapshot.Path, s)
+}
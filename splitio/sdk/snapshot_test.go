@@ -0,0 +1,154 @@
+package sdk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/flagsets"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/splitio/sdk/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	cfg := conf.DefaultConfig()
+	src := setupStorages(cfg, flagsets.NewFlagSetFilter(nil))
+	populateForSnapshot(src)
+
+	now := time.Now()
+	snap, err := takeSnapshot(src, []string{}, now)
+	require.Nil(t, err)
+	assert.Equal(t, int64(123), snap.SplitsChangeNumber)
+	assert.Equal(t, int64(456), snap.RuleBasedSegmentsChangeNumber)
+	assert.Len(t, snap.Splits, 1)
+	assert.Len(t, snap.RuleBasedSegments, 1)
+	assert.Len(t, snap.Segments, 2) // `segment3` is referenced but hasn't been fetched
+	assert.ElementsMatch(t, []string{"key1", "key2"}, snap.Segments["segment1"].Keys)
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	require.Nil(t, writeSnapshot(path, snap))
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	assert.Len(t, entries, 1) // no leftover temporary files
+
+	read, err := readSnapshot(path)
+	require.Nil(t, err)
+	require.Nil(t, read.validate([]string{}, time.Hour, now))
+
+	dst := setupStorages(cfg, flagsets.NewFlagSetFilter(nil))
+	require.Nil(t, read.restore(dst))
+
+	cn, _ := dst.splits.ChangeNumber()
+	assert.Equal(t, int64(123), cn)
+	assert.NotNil(t, dst.splits.Split("split1"))
+	cn, _ = dst.ruleBasedSegments.ChangeNumber()
+	assert.Equal(t, int64(456), cn)
+	assert.Contains(t, dst.ruleBasedSegments.FetchMany([]string{"rbs1"}), "rbs1")
+	contains, _ := dst.segments.SegmentContainsKey("segment1", "key2")
+	assert.True(t, contains)
+	cn, _ = dst.segments.ChangeNumber("segment2")
+	assert.Equal(t, int64(20), cn)
+	assert.Equal(t, now.UnixMilli(), dst.telemetry.GetLastSynchronization().Splits)
+	assert.Equal(t, now.UnixMilli(), dst.telemetry.GetLastSynchronization().Segments)
+}
+
+func TestSnapshotValidation(t *testing.T) {
+	now := time.Now()
+	snap := &snapshot{Version: snapshotVersion, Timestamp: now.Add(-2 * time.Hour).UnixMilli(), FlagSetsFilter: []string{"s1"}}
+	assert.Nil(t, snap.validate([]string{"s1"}, 0, now))
+	assert.Nil(t, snap.validate([]string{"s1"}, 3*time.Hour, now))
+	assert.ErrorIs(t, snap.validate([]string{"s1"}, time.Hour, now), ErrSnapshotTooOld)
+	assert.ErrorIs(t, snap.validate([]string{"s1", "s2"}, 0, now), ErrSnapshotIncompatible)
+
+	snap.Version = snapshotVersion + 1
+	assert.ErrorIs(t, snap.validate([]string{"s1"}, 0, now), ErrSnapshotIncompatible)
+
+	_, err := readSnapshot(filepath.Join(t.TempDir(), "nonexistant.json"))
+	assert.NotNil(t, err)
+}
+
+func TestWarmStartAndExporter(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	cfg := conf.DefaultConfig()
+	cfg.Snapshot.Path = path
+	cfg.Snapshot.LoadOnStartup = true
+
+	src := setupStorages(cfg, flagsets.NewFlagSetFilter(nil))
+	assert.False(t, warmStart(logger, src, cfg)) // no snapshot yet
+
+	// nothing is exported until the data is ready
+	r := newReadiness()
+	exporter := newSnapshotExporter(logger, src, cfg, r)
+	require.NotNil(t, exporter)
+	exporter.Start()
+	require.Eventually(t, exporter.IsRunning, time.Second, 5*time.Millisecond)
+	exporter.Stop(true)
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	populateForSnapshot(src)
+	r.setReady()
+	exporter = newSnapshotExporter(logger, src, cfg, r)
+	exporter.Start()
+	require.Eventually(t, exporter.IsRunning, time.Second, 5*time.Millisecond)
+	exporter.Stop(true) // a final snapshot is written when stopping
+
+	dst := setupStorages(cfg, flagsets.NewFlagSetFilter(nil))
+	assert.True(t, warmStart(logger, dst, cfg))
+	assert.NotNil(t, dst.splits.Split("split1"))
+
+	// too old
+	cfg.Snapshot.MaxAge = time.Nanosecond
+	assert.False(t, warmStart(logger, setupStorages(cfg, flagsets.NewFlagSetFilter(nil)), cfg))
+
+	// disabled
+	cfg.Snapshot.MaxAge = 0
+	cfg.Snapshot.LoadOnStartup = false
+	assert.False(t, warmStart(logger, setupStorages(cfg, flagsets.NewFlagSetFilter(nil)), cfg))
+	cfg.Snapshot.Path = ""
+	assert.Nil(t, newSnapshotExporter(logger, src, cfg, r))
+}
+
+func TestExportSnapshot(t *testing.T) {
+	cfg := conf.DefaultConfig()
+	src := setupStorages(cfg, flagsets.NewFlagSetFilter(nil))
+	populateForSnapshot(src)
+
+	i := &Impl{splitStorage: src.splits, segStorage: src.segments, rbsStorage: src.ruleBasedSegments, readiness: newReadiness(), cfg: *cfg}
+	assert.ErrorIs(t, i.ExportSnapshot(), ErrSnapshotsDisabled)
+
+	i.cfg.Snapshot.Path = filepath.Join(t.TempDir(), "snapshot.json")
+	assert.ErrorIs(t, i.ExportSnapshot(), ErrSnapshotNotReady)
+
+	i.readiness.setReady()
+	require.Nil(t, i.ExportSnapshot())
+	read, err := readSnapshot(i.cfg.Snapshot.Path)
+	require.Nil(t, err)
+	assert.Equal(t, int64(123), read.SplitsChangeNumber)
+	assert.Len(t, read.Segments, 2)
+}
+
+func populateForSnapshot(st *storages) {
+	st.splits.Update([]dtos.SplitDTO{{
+		Name:            "split1",
+		TrafficTypeName: "user",
+		Conditions: []dtos.ConditionDTO{
+			{MatcherGroup: dtos.MatcherGroupDTO{Matchers: []dtos.MatcherDTO{{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "segment1"}}}}},
+			{MatcherGroup: dtos.MatcherGroupDTO{Matchers: []dtos.MatcherDTO{{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "segment3"}}}}},
+		},
+	}}, nil, 123)
+	st.ruleBasedSegments.Update([]dtos.RuleBasedSegmentDTO{{
+		Name: "rbs1",
+		Conditions: []dtos.RuleBasedConditionDTO{
+			{MatcherGroup: dtos.MatcherGroupDTO{Matchers: []dtos.MatcherDTO{{MatcherType: "IN_SEGMENT", UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "segment2"}}}}},
+		},
+	}}, nil, 456)
+	st.segments.Update("segment1", set.NewSet("key1", "key2"), set.NewSet(), 10)
+	st.segments.Update("segment2", set.NewSet("key3"), set.NewSet(), 20)
+}
@@ -0,0 +1,70 @@
+package sdk
+
+import (
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/splitd/splitio/sdk/conf"
+)
+
+// LabelStaleData is used as impression label when evaluations are short-circuited due to stale data
+const LabelStaleData = "stale data"
+
+// Staleness reports which kinds of data haven't been successfully synchronized within the configured thresholds
+type Staleness struct {
+	Splits   bool
+	Segments bool
+}
+
+// Any returns true if at least one kind of data is stale
+func (s Staleness) Any() bool {
+	return s.Splits || s.Segments
+}
+
+// Status summarizes the readiness of the SDK & the freshness of the data used for evaluations
+type Status struct {
+	State     State
+	Staleness Staleness
+}
+
+type lastSyncProvider interface {
+	GetLastSynchronization() dtos.LastSynchronization
+}
+
+// stalenessTracker checks the time elapsed since the last successful split & segment synchronizations.
+// When streaming is enabled & healthy, changes are pushed as they happen, and periodic fetches are paused,
+// so data is only considered stale while operating in polling mode.
+type stalenessTracker struct {
+	cfg              conf.Staleness
+	lastSyncs        lastSyncProvider
+	readiness        *readiness
+	streamingEnabled bool
+	hasSegments      func() bool
+	now              func() time.Time
+}
+
+func (t *stalenessTracker) get() Staleness {
+	if t == nil || (t.cfg.SplitsThreshold <= 0 && t.cfg.SegmentsThreshold <= 0) {
+		return Staleness{}
+	}
+
+	state := t.readiness.get()
+	if !state.IsReady() || (t.streamingEnabled && state == StateReady) {
+		return Staleness{}
+	}
+
+	now := t.now()
+	syncs := t.lastSyncs.GetLastSynchronization()
+	return Staleness{
+		Splits: isOlderThan(syncs.Splits, t.cfg.SplitsThreshold, now),
+		// segment syncs are never recorded when no feature flag references a segment
+		Segments: isOlderThan(syncs.Segments, t.cfg.SegmentsThreshold, now) && t.hasSegments(),
+	}
+}
+
+func isOlderThan(lastSyncMillis int64, threshold time.Duration, now time.Time) bool {
+	if threshold <= 0 {
+		return false
+	}
+	return now.Sub(time.UnixMilli(lastSyncMillis)) > threshold
+}
@@ -0,0 +1,72 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/splitd/splitio/sdk/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStalenessTracker(t *testing.T) {
+	now := time.Now()
+	syncs := &lastSyncsMock{
+		splits:   now.Add(-5 * time.Minute).UnixMilli(),
+		segments: now.Add(-30 * time.Second).UnixMilli(),
+	}
+
+	r := newReadiness()
+	hasSegments := true
+	tracker := &stalenessTracker{
+		cfg:              conf.Staleness{SplitsThreshold: 2 * time.Minute, SegmentsThreshold: 10 * time.Second},
+		lastSyncs:        syncs,
+		readiness:        r,
+		streamingEnabled: true,
+		hasSegments:      func() bool { return hasSegments },
+		now:              func() time.Time { return now },
+	}
+
+	// not ready yet
+	assert.Equal(t, Staleness{}, tracker.get())
+
+	// streaming healthy
+	r.setReady()
+	assert.Equal(t, Staleness{}, tracker.get())
+
+	// streaming degraded to polling
+	r.setDegraded(true)
+	assert.Equal(t, Staleness{Splits: true, Segments: true}, tracker.get())
+	assert.True(t, tracker.get().Any())
+
+	// no segments referenced
+	hasSegments = false
+	assert.Equal(t, Staleness{Splits: true}, tracker.get())
+
+	// polling by config
+	tracker.streamingEnabled = false
+	r.setDegraded(false)
+	assert.Equal(t, Staleness{Splits: true}, tracker.get())
+
+	// recent sync
+	syncs.splits = now.UnixMilli()
+	assert.Equal(t, Staleness{}, tracker.get())
+	assert.False(t, tracker.get().Any())
+
+	// thresholds disabled
+	syncs.splits = 0
+	tracker.cfg = conf.Staleness{}
+	assert.Equal(t, Staleness{}, tracker.get())
+
+	// nil tracker
+	assert.Equal(t, Staleness{}, (*stalenessTracker)(nil).get())
+}
+
+type lastSyncsMock struct {
+	splits   int64
+	segments int64
+}
+
+func (l *lastSyncsMock) GetLastSynchronization() dtos.LastSynchronization {
+	return dtos.LastSynchronization{Splits: l.splits, Segments: l.segments}
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrMemoryBudgetExceeded is returned when items don't fit in the memory budget shared by a set of queues.
+// It wraps ErrQueueFull, since callers should react the same way (ie: triggering a flush)
+var ErrMemoryBudgetExceeded = fmt.Errorf("%w: memory budget exceeded", ErrQueueFull)
+
+// DropPolicy determines what happens when a push doesn't fit in the memory budget
+type DropPolicy int
+
+const (
+	DropNewest DropPolicy = iota // incoming items are rejected
+	DropOldest                   // queued items are evicted (starting with the longest queue) to make room for new ones
+)
+
+// MemoryBudget bounds the (estimated) memory taken by the items of every queue sharing it
+type MemoryBudget[T any] struct {
+	limit   int64
+	policy  DropPolicy
+	sizeOf  func(*T) int64
+	used    atomic.Int64
+	dropped atomic.Int64
+}
+
+// NewMemoryBudget builds a budget of `limit` bytes, using `sizeOf` to estimate the size of each item
+func NewMemoryBudget[T any](limit int64, policy DropPolicy, sizeOf func(*T) int64) *MemoryBudget[T] {
+	return &MemoryBudget[T]{limit: limit, policy: policy, sizeOf: sizeOf}
+}
+
+// Limit returns the max amount of bytes queued items can take
+func (b *MemoryBudget[T]) Limit() int64 {
+	return b.limit
+}
+
+// Used returns the amount of bytes currently taken by queued items
+func (b *MemoryBudget[T]) Used() int64 {
+	return b.used.Load()
+}
+
+// Dropped returns how many items were rejected or evicted so far for not fitting in the budget
+func (b *MemoryBudget[T]) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+func (b *MemoryBudget[T]) reserve(size int64) bool {
+	for {
+		used := b.used.Load()
+		if used+size > b.limit {
+			return false
+		}
+		if b.used.CompareAndSwap(used, used+size) {
+			return true
+		}
+	}
+}
+
+func (b *MemoryBudget[T]) release(size int64) {
+	b.used.Add(-size)
+}
+
+// wrap returns a queue accounting the size of the items going through `queue` against this budget
+func (b *MemoryBudget[T]) wrap(queue BackingQueue[T]) BackingQueue[T] {
+	return &budgetedQueue[T]{queue: queue, budget: b}
+}
+
+type budgetedQueue[T any] struct {
+	queue  BackingQueue[T]
+	budget *MemoryBudget[T]
+}
+
+// Push implements BackingQueue. Items are pushed one at a time, since each of them has to be accounted separately
+func (q *budgetedQueue[T]) Push(ts ...T) (int, error) {
+	for idx := range ts {
+		size := q.budget.sizeOf(&ts[idx])
+		if !q.budget.reserve(size) {
+			return idx, ErrMemoryBudgetExceeded
+		}
+		if _, err := q.queue.Push(ts[idx]); err != nil {
+			q.budget.release(size)
+			return idx, err
+		}
+	}
+	return len(ts), nil
+}
+
+// Pop implements BackingQueue
+func (q *budgetedQueue[T]) Pop(n int, buf *[]T) (int, error) {
+	start := len(*buf)
+	popped, err := q.queue.Pop(n, buf)
+	for idx := start; idx < len(*buf); idx++ {
+		q.budget.release(q.budget.sizeOf(&(*buf)[idx]))
+	}
+	return popped, err
+}
+
+// Len implements BackingQueue
+func (q *budgetedQueue[T]) Len() int {
+	return q.queue.Len()
+}
+
+var _ BackingQueue[int] = (*budgetedQueue[int])(nil)
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/splitd/splitio/sdk/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// every K takes 10 bytes plus its value
+func kSize(k *K) int64 { return int64(10 + k.V) }
+
+func newBudgetedMultiQueue(limit int64, policy DropPolicy) *MultiMetaQueues[K, string, BackingQueue[K]] {
+	budget := NewMemoryBudget(limit, policy, kSize)
+	return NewBoundedMultiMetaQueue[K, string](func() BackingQueue[K] { return budget.wrap(NewLKQueue[K](4)) }, budget)
+}
+
+func popAll(q BackingQueue[K]) []K {
+	var buf []K
+	q.Pop(q.Len(), &buf)
+	return buf
+}
+
+func TestMemoryBudgetDropNewest(t *testing.T) {
+	mq := newBudgetedMultiQueue(45, DropNewest)
+	budget := mq.Budget()
+	assert.Equal(t, int64(45), budget.Limit())
+
+	n, err := mq.Push("a", K{0}, K{1}, K{2}, K{3})
+	assert.Equal(t, 3, n)
+	assert.ErrorIs(t, err, ErrMemoryBudgetExceeded)
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.Equal(t, int64(33), budget.Used())
+	assert.Equal(t, int64(1), budget.Dropped())
+
+	// the budget is shared across queues
+	n, err = mq.Push("b", K{5})
+	assert.Equal(t, 0, n)
+	assert.ErrorIs(t, err, ErrMemoryBudgetExceeded)
+	n, err = mq.Push("b", K{2})
+	assert.Equal(t, 1, n)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(45), budget.Used())
+	assert.Equal(t, int64(2), budget.Dropped())
+
+	// popping releases the items' share of the budget
+	mq.RangeAndClear(func(_ string, q BackingQueue[K]) { popAll(q) })
+	assert.Equal(t, int64(0), budget.Used())
+
+	// items that don't fit in the per-metadata queue don't take any budget
+	mq = newBudgetedMultiQueue(1000, DropNewest)
+	n, err = mq.Push("a", make([]K, 16)...)
+	assert.Equal(t, 15, n)
+	assert.Equal(t, ErrQueueFull, err)
+	assert.Equal(t, int64(150), mq.Budget().Used())
+	assert.Equal(t, int64(0), mq.Budget().Dropped())
+}
+
+func TestMemoryBudgetDropOldest(t *testing.T) {
+	mq := newBudgetedMultiQueue(40, DropOldest)
+	budget := mq.Budget()
+
+	n, err := mq.Push("a", K{0}, K{1}, K{2})
+	assert.Equal(t, 3, n)
+	assert.Nil(t, err)
+
+	// the oldest items of the longest queue are evicted to make room
+	n, err = mq.Push("b", K{5}, K{0})
+	assert.Equal(t, 2, n)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), budget.Dropped())
+	assert.Equal(t, int64(37), budget.Used())
+
+	queues := map[string][]K{}
+	mq.RangeAndClear(func(key string, q BackingQueue[K]) { queues[key] = popAll(q) })
+	assert.Equal(t, map[string][]K{"a": {{2}}, "b": {{5}, {0}}}, queues)
+	assert.Equal(t, int64(0), budget.Used())
+
+	// queues left empty by evictions are removed
+	mq = newBudgetedMultiQueue(20, DropOldest)
+	mq.Push("a", K{0})
+	n, err = mq.Push("b", K{5})
+	assert.Equal(t, 1, n)
+	assert.Nil(t, err)
+	keys := []string{}
+	mq.Range(func(key string, _ BackingQueue[K]) { keys = append(keys, key) })
+	assert.Equal(t, []string{"b"}, keys)
+	assert.Equal(t, int64(15), mq.Budget().Used())
+
+	// items bigger than the whole budget are dropped without evicting anything
+	n, err = mq.Push("c", K{100})
+	assert.Equal(t, 0, n)
+	assert.ErrorIs(t, err, ErrMemoryBudgetExceeded)
+	assert.Equal(t, int64(15), mq.Budget().Used())
+	assert.Equal(t, int64(2), mq.Budget().Dropped())
+
+	// room for a whole batch is made at once, evening out the longest queues
+	mq = newBudgetedMultiQueue(100, DropOldest)
+	mq.Push("a", K{0}, K{1}, K{2}, K{3}, K{4}) // 60 bytes
+	mq.Push("b", K{0}, K{0}, K{0})             // 30 bytes
+	n, err = mq.Push("c", K{0}, K{0}, K{0}, K{0})
+	assert.Equal(t, 4, n)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), mq.Budget().Dropped())
+
+	queues = map[string][]K{}
+	mq.RangeAndClear(func(key string, q BackingQueue[K]) { queues[key] = popAll(q) })
+	assert.Equal(t, []K{{3}, {4}}, queues["a"])
+	assert.Len(t, queues["b"], 3)
+	assert.Len(t, queues["c"], 4)
+}
+
+func TestMultiMetaQueuePrune(t *testing.T) {
+	mq := NewMultiMetaQueue[K, string](func() BackingQueue[K] { return NewLKQueue[K](4) })
+	mq.Push("a", K{1})
+	mq.Push("b", K{1})
+	mq.Range(func(key string, q BackingQueue[K]) {
+		if key == "a" {
+			popAll(q)
+		}
+	})
+
+	assert.Equal(t, 1, mq.Prune())
+	keys := []string{}
+	mq.Range(func(key string, _ BackingQueue[K]) { keys = append(keys, key) })
+	assert.Equal(t, []string{"b"}, keys)
+	assert.Equal(t, 0, mq.Prune())
+}
+
+func TestMultiMetaQueueConcurrentFlush(t *testing.T) {
+	const producers, perProducer = 4, 1000
+	budget := NewMemoryBudget(1<<30, DropNewest, kSize)
+	mq := NewBoundedMultiMetaQueue[K, string](func() BackingQueue[K] { return budget.wrap(NewLKQueue[K](12)) }, budget)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for idx := 0; idx < perProducer; idx++ {
+				for {
+					if _, err := mq.Push(fmt.Sprintf("md-%d", idx%3), K{1}); err == nil {
+						break
+					}
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+
+	// items pushed while queues are being removed must not get lost
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	flushed := 0
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		mq.RangeAndClear(func(_ string, q BackingQueue[K]) { flushed += len(popAll(q)) })
+		mq.Prune()
+	}
+	mq.RangeAndClear(func(_ string, q BackingQueue[K]) { flushed += len(popAll(q)) })
+
+	assert.Equal(t, producers*perProducer, flushed)
+	assert.Equal(t, int64(0), mq.Budget().Used())
+}
+
+func TestStorageItemSizes(t *testing.T) {
+	imp := dtos.Impression{KeyName: "key", FeatureName: "feature", Treatment: "on", Label: "default rule"}
+	assert.Equal(t, int64(impressionOverhead+3+7+2+12), impressionSize(&imp))
+
+	ev := dtos.EventDTO{Key: "key", Properties: map[string]interface{}{"prop": "value"}}
+	assert.Equal(t, int64(ev.Size()), eventSize(&ev))
+
+	st, _ := NewImpressionsQueueWithOptions(100, QueueOptions{MemoryBudget: 2 * impressionSize(&imp), DropPolicy: DropNewest})
+	md := types.ClientMetadata{ID: "some"}
+	n, err := st.Push(md, imp, imp, imp)
+	assert.Equal(t, 2, n)
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.Equal(t, 2*impressionSize(&imp), st.Budget().Used())
+
+	unbounded, _ := NewEventsQueue(100)
+	assert.Nil(t, unbounded.Budget())
+}
@@ -1,12 +1,18 @@
 package storage
 
 import (
+	"container/heap"
+	"errors"
+	"runtime"
 	"sync"
+	"sync/atomic"
 )
 
 type MultiMetaQueues[T elemConstraint, U comparable, Q BackingQueue[T]] struct {
 	m        sync.Map
 	cFactory func() Q
+	budget   *MemoryBudget[T]
+	capacity int // items each queue can hold, if known
 }
 
 func NewMultiMetaQueue[T elemConstraint, U comparable, Q BackingQueue[T]](cFactory func() Q) *MultiMetaQueues[T, U, Q] {
@@ -16,19 +22,60 @@ func NewMultiMetaQueue[T elemConstraint, U comparable, Q BackingQueue[T]](cFacto
 	}
 }
 
+// NewBoundedMultiMetaQueue builds a set of queues sharing a memory budget. Queues built by `cFactory` are expected
+// to account their items against it (see MemoryBudget.wrap), while the budget's drop policy is enforced here
+func NewBoundedMultiMetaQueue[T elemConstraint, U comparable, Q BackingQueue[T]](cFactory func() Q, budget *MemoryBudget[T]) *MultiMetaQueues[T, U, Q] {
+	return &MultiMetaQueues[T, U, Q]{
+		m:        sync.Map{},
+		cFactory: cFactory,
+		budget:   budget,
+	}
+}
+
+// Budget returns the memory budget shared by the queues, or nil if they're unbounded
+func (m *MultiMetaQueues[T, U, Q]) Budget() *MemoryBudget[T] {
+	return m.budget
+}
+
+// FillLevel returns how full (as a percentage) the queue of `grouper` is, or the memory budget, if it's fuller.
+// Zero is returned for groupers without a queue, as well as for queues of unknown capacity
+func (m *MultiMetaQueues[T, U, Q]) FillLevel(grouper U) int {
+	level := 0
+	if current, ok := m.m.Load(grouper); ok && m.capacity > 0 {
+		level = current.(*queueEntry[Q]).queue.Len() * 100 / m.capacity
+	}
+	if m.budget != nil {
+		level = max(level, int(m.budget.Used()*100/m.budget.Limit()))
+	}
+	return level
+}
+
 func (m *MultiMetaQueues[T, U, Q]) Push(grouper U, items ...T) (int, error) {
-	current, ok := m.m.Load(grouper)
-	if !ok {
-		q := m.cFactory()
-		current, _ = m.m.LoadOrStore(grouper, q)
+	added := 0
+	for {
+		n, err := m.pushTo(grouper, items[added:])
+		added += n
+		if added == len(items) || !errors.Is(err, ErrMemoryBudgetExceeded) {
+			return added, err
+		}
+
+		// items that wouldn't fit even in an empty budget are dropped without evicting anything
+		canEvict := m.budget != nil && m.budget.policy == DropOldest && m.budget.sizeOf(&items[added]) <= m.budget.limit
+		if !canEvict || !m.evict(m.bytesToEvict(items[added:])) {
+			if m.budget != nil {
+				m.budget.dropped.Add(int64(len(items) - added))
+			}
+			return added, err
+		}
 	}
-	return current.(Q).Push(items...)
 }
 
 func (m *MultiMetaQueues[T, U, Q]) RangeAndClear(f func(U, Q)) error {
 	m.m.Range(func(key, value any) bool {
 		m.m.Delete(key)
-		f(key.(U), value.(Q))
+		entry := value.(*queueEntry[Q])
+		entry.retire()
+		f(key.(U), entry.queue)
 		return true
 	})
 	return nil
@@ -36,16 +83,166 @@ func (m *MultiMetaQueues[T, U, Q]) RangeAndClear(f func(U, Q)) error {
 
 func (m *MultiMetaQueues[T, U, Q]) Range(f func(U, Q)) error {
 	m.m.Range(func(key, value any) bool {
-		f(key.(U), value.(Q))
+		f(key.(U), value.(*queueEntry[Q]).queue)
 		return true
 	})
 	return nil
 }
 
+// Prune removes the queues that are currently empty, so that groupers no longer in use don't pile up.
+// It returns the number of queues removed
+func (m *MultiMetaQueues[T, U, Q]) Prune() int {
+	pruned := 0
+	m.m.Range(func(key, value any) bool {
+		if m.removeIfEmpty(key.(U), value.(*queueEntry[Q])) {
+			pruned++
+		}
+		return true
+	})
+	return pruned
+}
+
+func (m *MultiMetaQueues[T, U, Q]) removeIfEmpty(grouper U, entry *queueEntry[Q]) bool {
+	if entry.queue.Len() > 0 || !m.m.CompareAndDelete(grouper, entry) {
+		return false
+	}
+
+	entry.retire()
+	if entry.queue.Len() == 0 {
+		return true
+	}
+
+	// items were pushed right before the queue got retired, move them to a new one
+	items := make([]T, 0, entry.queue.Len())
+	entry.queue.Pop(entry.queue.Len(), &items)
+	if n, _ := m.pushTo(grouper, items); n < len(items) && m.budget != nil {
+		m.budget.dropped.Add(int64(len(items) - n))
+	}
+	return false
+}
+
+func (m *MultiMetaQueues[T, U, Q]) pushTo(grouper U, items []T) (int, error) {
+	for {
+		current, ok := m.m.Load(grouper)
+		if !ok {
+			current, _ = m.m.LoadOrStore(grouper, &queueEntry[Q]{queue: m.cFactory()})
+		}
+
+		entry := current.(*queueEntry[Q])
+		if !entry.acquire() {
+			continue // removed from the map in the meantime, a new one will be created
+		}
+		n, err := entry.queue.Push(items...)
+		entry.release()
+		return n, err
+	}
+}
+
+// bytesToEvict returns how much room has to be made for the remaining `items` (as many as the budget can hold)
+func (m *MultiMetaQueues[T, U, Q]) bytesToEvict(items []T) int64 {
+	var size int64
+	for idx := range items {
+		size += m.budget.sizeOf(&items[idx])
+	}
+	return max(min(size, m.budget.limit)-(m.budget.limit-m.budget.Used()), 1)
+}
+
+// evict drops the oldest items of the longest queues until `bytes` have been freed, evening out their lengths.
+// Queues are scanned once per call (rather than once per evicted item), so concurrent pushes & flushes may make the
+// choice slightly off, which is fine. Queues left empty are removed. It returns false if there was nothing to evict
+func (m *MultiMetaQueues[T, U, Q]) evict(bytes int64) bool {
+	var candidates evictionHeap[U, Q]
+	m.m.Range(func(key, value any) bool {
+		entry := value.(*queueEntry[Q])
+		if l := entry.queue.Len(); l > 0 {
+			candidates = append(candidates, evictionCandidate[U, Q]{grouper: key.(U), entry: entry, len: l})
+		}
+		return true
+	})
+
+	if len(candidates) == 0 {
+		return false
+	}
+
+	heap.Init(&candidates)
+	evicted := make([]T, 0, 1)
+	for freed := int64(0); freed < bytes && len(candidates) > 0; {
+		longest := &candidates[0]
+		evicted = evicted[:0]
+		if n, _ := longest.entry.queue.Pop(1, &evicted); n > 0 {
+			freed += m.budget.sizeOf(&evicted[0])
+			m.budget.dropped.Add(1)
+			longest.len--
+		} else {
+			longest.len = 0 // popped concurrently (ie: by a flush), room has been made anyway
+		}
+
+		if longest.len > 0 {
+			heap.Fix(&candidates, 0)
+			continue
+		}
+		m.removeIfEmpty(longest.grouper, longest.entry)
+		heap.Pop(&candidates)
+	}
+	return true
+}
+
+// evictionHeap keeps the queues eligible for eviction sorted by length, longest first
+type evictionHeap[U comparable, Q any] []evictionCandidate[U, Q]
+
+type evictionCandidate[U comparable, Q any] struct {
+	grouper U
+	entry   *queueEntry[Q]
+	len     int
+}
+
+func (h evictionHeap[U, Q]) Len() int           { return len(h) }
+func (h evictionHeap[U, Q]) Less(i, j int) bool { return h[i].len > h[j].len }
+func (h evictionHeap[U, Q]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *evictionHeap[U, Q]) Push(x any)        { *h = append(*h, x.(evictionCandidate[U, Q])) }
+func (h *evictionHeap[U, Q]) Pop() any {
+	last := (*h)[len(*h)-1]
+	*h = (*h)[:len(*h)-1]
+	return last
+}
+
+// queueEntry holds the queue of a grouper, keeping track of pushes in progress so that it can be safely removed
+// from the map: once retired, the queue no longer accepts items, and those pushed before are all visible
+type queueEntry[Q any] struct {
+	queue    Q
+	inflight atomic.Int32
+	retired  atomic.Bool
+}
+
+func (e *queueEntry[Q]) acquire() bool {
+	e.inflight.Add(1)
+	if e.retired.Load() {
+		e.inflight.Add(-1)
+		return false
+	}
+	return true
+}
+
+func (e *queueEntry[Q]) release() {
+	e.inflight.Add(-1)
+}
+
+// retire must be called after removing the entry from the map. It waits for pushes already in progress to complete
+func (e *queueEntry[Q]) retire() {
+	e.retired.Store(true)
+	for e.inflight.Load() > 0 {
+		runtime.Gosched()
+	}
+}
+
 type elemConstraint interface{}
 
 type grupingConstraint comparable
 
+// BackingQueue is a bounded queue holding the items of a single grouper. Push returns how many items were added
+// (ErrQueueFull if not all of them fit), and Pop appends up to `n` items to `buf` (ErrQueueEmpty if there weren't enough)
 type BackingQueue[T any] interface {
 	Push(...T) (int, error)
+	Pop(n int, buf *[]T) (int, error)
+	Len() int
 }
@@ -11,7 +11,7 @@ import (
 
 func TestMultiStorageBasic(t *testing.T) {
 
-	mq := NewMultiMetaQueue[dtos.EventDTO, types.ClientMetadata](func() *LockingQueue[dtos.EventDTO] { return NewLKQueue[dtos.EventDTO](4) })
+	mq := NewMultiMetaQueue[dtos.EventDTO, types.ClientMetadata](func() BackingQueue[dtos.EventDTO] { return NewLKQueue[dtos.EventDTO](4) })
 
 	n, err := mq.Push(types.ClientMetadata{SdkVersion: "go-1.2.3"}, dtos.EventDTO{Key: "k1"})
 	assert.Equal(t, 1, n)
@@ -25,7 +25,7 @@ func TestMultiStorageBasic(t *testing.T) {
 	assert.Equal(t, 3, n)
 	assert.Nil(t, err)
 
-	mq.Range(func(cm types.ClientMetadata, q *LockingQueue[dtos.EventDTO]) {
+	mq.Range(func(cm types.ClientMetadata, q BackingQueue[dtos.EventDTO]) {
 		switch cm.SdkVersion {
 		case "go-1.2.3":
 			assert.Equal(t, 4, q.Len())
@@ -40,7 +40,7 @@ func TestMultiStorageBasic(t *testing.T) {
 		}
 	})
 
-	mq.RangeAndClear(func(cm types.ClientMetadata, q *LockingQueue[dtos.EventDTO]) {
+	mq.RangeAndClear(func(cm types.ClientMetadata, q BackingQueue[dtos.EventDTO]) {
 		switch cm.SdkVersion {
 		case "go-1.2.3":
 			// used previously
@@ -55,6 +55,6 @@ func TestMultiStorageBasic(t *testing.T) {
 		}
 	})
 
-	mq.Range(func(cm types.ClientMetadata, q *LockingQueue[dtos.EventDTO]) { assert.Fail(t, "should not execute") })
-	mq.RangeAndClear(func(cm types.ClientMetadata, q *LockingQueue[dtos.EventDTO]) { assert.Fail(t, "should not execute") })
+	mq.Range(func(cm types.ClientMetadata, q BackingQueue[dtos.EventDTO]) { assert.Fail(t, "should not execute") })
+	mq.RangeAndClear(func(cm types.ClientMetadata, q BackingQueue[dtos.EventDTO]) { assert.Fail(t, "should not execute") })
 }
@@ -84,3 +84,5 @@ func (q *LockingQueue[T]) dequeue() (T, bool) {
 	q.tail = ((q.tail + 1) & q.mask)
 	return tmp, true
 }
+
+var _ BackingQueue[int] = (*LockingQueue[int])(nil)
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"runtime"
+	"sync/atomic"
+)
+
+const (
+	cacheLineSize = 64
+
+	// shards are never made smaller than this, since tiny rings fill up (and spill into other shards) too often
+	minShardPow = 6
+)
+
+// ShardedQueue is a BackingQueue spread across a set of lock-free rings, meant for storages receiving items from
+// many goroutines at once (ie: lots of connections sharing the same metadata). Each Push picks a shard at random &
+// only moves on to the next one if it's full, so producers rarely contend on the same ring.
+// Items are popped in FIFO order within each shard, but there's no ordering guarantee across shards
+type ShardedQueue[T any] struct {
+	shards []*ring[T]
+	mask   int
+	next   atomic.Uint32 // first shard to pop from, rotated so that no shard is favored on partial pops
+}
+
+// NewShardedQueue builds a queue able to hold 2^pow items, split in as many shards as GOMAXPROCS (rounded up to
+// a power of 2), as long as each of them can still hold a reasonable amount of items
+func NewShardedQueue[T any](pow int) *ShardedQueue[T] {
+	shardBits := bits.Len(uint(runtime.GOMAXPROCS(0) - 1))
+	if maxBits := pow - minShardPow; shardBits > maxBits {
+		shardBits = max(maxBits, 0)
+	}
+	return newShardedQueue[T](pow, shardBits)
+}
+
+func newShardedQueue[T any](pow int, shardBits int) *ShardedQueue[T] {
+	count := 1 << shardBits
+	shards := make([]*ring[T], count)
+	for idx := range shards {
+		shards[idx] = newRing[T](pow - shardBits)
+	}
+	return &ShardedQueue[T]{shards: shards, mask: count - 1}
+}
+
+// Push implements BackingQueue. ErrQueueFull is only returned once every shard is full
+func (q *ShardedQueue[T]) Push(ts ...T) (int, error) {
+	shard, tried := rand.IntN(len(q.shards)), 1
+	for added, t := range ts {
+		for !q.shards[shard].push(t) {
+			if tried == len(q.shards) {
+				return added, ErrQueueFull
+			}
+			shard, tried = (shard+1)&q.mask, tried+1
+		}
+	}
+	return len(ts), nil
+}
+
+// Pop implements BackingQueue
+func (q *ShardedQueue[T]) Pop(n int, buf *[]T) (int, error) {
+	start := int(q.next.Add(1))
+	popped := 0
+	for offset := 0; offset < len(q.shards) && popped < n; offset++ {
+		shard := q.shards[(start+offset)&q.mask]
+		for popped < n {
+			elem, ok := shard.pop()
+			if !ok {
+				break
+			}
+			*buf = append(*buf, elem)
+			popped++
+		}
+	}
+
+	if popped < n {
+		return popped, ErrQueueEmpty
+	}
+	return n, nil
+}
+
+// Len implements BackingQueue. It's exact when there are no concurrent operations, and an approximation otherwise
+func (q *ShardedQueue[T]) Len() int {
+	var total int
+	for _, shard := range q.shards {
+		total += shard.len()
+	}
+	return total
+}
+
+// ring is a bounded multi-producer/multi-consumer lock-free queue, based on Dmitry Vyukov's design: every cell
+// carries a sequence number telling whether it's ready to be written (seq == pos) or read (seq == pos + 1) by the
+// operation that claimed position `pos`. Positions are claimed with a CAS, and cells are published by updating
+// their sequence number once the data has been written/read
+type ring[T any] struct {
+	cells []cell[T]
+	mask  uint64
+	_     [cacheLineSize - 32]byte
+	head  atomic.Uint64 // next position to write
+	_     [cacheLineSize - 8]byte
+	tail  atomic.Uint64 // next position to read
+	_     [cacheLineSize - 8]byte
+}
+
+type cell[T any] struct {
+	seq  atomic.Uint64
+	data T
+}
+
+func newRing[T any](pow int) *ring[T] {
+	size := uint64(1) << pow
+	r := &ring[T]{cells: make([]cell[T], size), mask: size - 1}
+	for idx := range r.cells {
+		r.cells[idx].seq.Store(uint64(idx))
+	}
+	return r
+}
+
+func (r *ring[T]) push(t T) bool {
+	pos := r.head.Load()
+	for {
+		c := &r.cells[pos&r.mask]
+		switch seq := c.seq.Load(); {
+		case seq == pos:
+			if r.head.CompareAndSwap(pos, pos+1) {
+				c.data = t
+				c.seq.Store(pos + 1)
+				return true
+			}
+			pos = r.head.Load()
+		case seq < pos:
+			return false // the cell hasn't been read since the last lap: ring full
+		default:
+			pos = r.head.Load() // another producer claimed this position
+		}
+	}
+}
+
+func (r *ring[T]) pop() (T, bool) {
+	pos := r.tail.Load()
+	for {
+		c := &r.cells[pos&r.mask]
+		switch seq := c.seq.Load(); {
+		case seq == pos+1:
+			if r.tail.CompareAndSwap(pos, pos+1) {
+				t := c.data
+				var zero T
+				c.data = zero
+				c.seq.Store(pos + r.mask + 1)
+				return t, true
+			}
+			pos = r.tail.Load()
+		case seq < pos+1:
+			var zero T
+			return zero, false // nothing written at this position yet: ring empty
+		default:
+			pos = r.tail.Load() // another consumer claimed this position
+		}
+	}
+}
+
+func (r *ring[T]) len() int {
+	tail := r.tail.Load()
+	head := r.head.Load()
+	if head <= tail {
+		return 0
+	}
+	return int(min(head-tail, r.mask+1))
+}
+
+var _ BackingQueue[int] = (*ShardedQueue[int])(nil)
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardedQueueBasic(t *testing.T) {
+	st := newShardedQueue[K](2, 0)
+
+	n, err := st.Push(K{1}, K{2}, K{3}, K{4})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, 4, st.Len())
+
+	n, err = st.Push(K{5})
+	assert.Equal(t, ErrQueueFull, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 4, st.Len())
+
+	buf := make([]K, 0, 10)
+	n, err = st.Pop(3, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []K{{1}, {2}, {3}}, buf)
+	assert.Equal(t, 1, st.Len())
+
+	n, err = st.Pop(2, &buf)
+	assert.Equal(t, ErrQueueEmpty, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []K{{1}, {2}, {3}, {4}}, buf)
+	assert.Equal(t, 0, st.Len())
+
+	// wraps around
+	n, err = st.Push(K{5}, K{6}, K{7}, K{8}, K{9})
+	assert.Equal(t, ErrQueueFull, err)
+	assert.Equal(t, 4, n)
+
+	buf = buf[:0]
+	n, err = st.Pop(4, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, []K{{5}, {6}, {7}, {8}}, buf)
+	assert.Equal(t, 0, st.Len())
+}
+
+func TestShardedQueueSpillsIntoOtherShards(t *testing.T) {
+	st := newShardedQueue[K](3, 2) // 4 shards of 2 items each
+	assert.Len(t, st.shards, 4)
+
+	items := make([]K, 10)
+	for idx := range items {
+		items[idx] = K{idx}
+	}
+
+	n, err := st.Push(items...)
+	assert.Equal(t, ErrQueueFull, err)
+	assert.Equal(t, 8, n)
+	assert.Equal(t, 8, st.Len())
+	for _, shard := range st.shards {
+		assert.Equal(t, 2, shard.len())
+	}
+
+	var buf []K
+	n, err = st.Pop(10, &buf)
+	assert.Equal(t, ErrQueueEmpty, err)
+	assert.Equal(t, 8, n)
+	assert.ElementsMatch(t, items[:8], buf)
+	assert.Equal(t, 0, st.Len())
+}
+
+func TestShardedQueueSizing(t *testing.T) {
+	// small queues are not split
+	assert.Len(t, NewShardedQueue[K](minShardPow).shards, 1)
+	assert.Len(t, NewShardedQueue[K](2).shards, 1)
+	assert.Len(t, NewShardedQueue[K](2).shards[0].cells, 4)
+
+	st := NewShardedQueue[K](16)
+	assert.LessOrEqual(t, len(st.shards), 1<<(16-minShardPow))
+	assert.Equal(t, 1<<16, len(st.shards)*len(st.shards[0].cells))
+}
+
+func TestShardedQueueConcurrency(t *testing.T) {
+	const producers, perProducer = 8, 2000
+	st := newShardedQueue[K](10, 2)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for idx := 0; idx < perProducer; idx++ {
+				item := K{p*perProducer + idx}
+				for {
+					if _, err := st.Push(item); err == nil {
+						break
+					}
+				}
+			}
+		}(p)
+	}
+
+	// two consumers draining concurrently
+	var mutex sync.Mutex
+	var popped []K
+	done := make(chan struct{})
+	var consumers sync.WaitGroup
+	for c := 0; c < 2; c++ {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			buf := make([]K, 0, 100)
+			for {
+				select {
+				case <-done:
+					st.Pop(producers*perProducer, &buf)
+					mutex.Lock()
+					popped = append(popped, buf...)
+					mutex.Unlock()
+					return
+				default:
+				}
+				st.Pop(100, &buf)
+				assert.LessOrEqual(t, st.Len(), 1<<10)
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	consumers.Wait()
+
+	assert.Len(t, popped, producers*perProducer)
+	sort.Slice(popped, func(i, j int) bool { return popped[i].V < popped[j].V })
+	for idx, item := range popped {
+		assert.Equal(t, idx, item.V)
+	}
+	assert.Equal(t, 0, st.Len())
+}
+
+func TestQueuesConcurrentPushLen(t *testing.T) {
+	queues := map[string]BackingQueue[K]{
+		"locking": NewLKQueue[K](12),
+		"sharded": NewShardedQueue[K](12),
+	}
+	for name, st := range queues {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			for p := 0; p < 4; p++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for idx := 0; idx < 500; idx++ {
+						_, err := st.Push(K{idx})
+						assert.Nil(t, err)
+						st.Len()
+					}
+				}()
+			}
+			wg.Wait()
+			assert.Equal(t, 2000, st.Len())
+
+			var buf []K
+			n, err := st.Pop(2000, &buf)
+			assert.Nil(t, err)
+			assert.Equal(t, 2000, n)
+		})
+	}
+}
+
+func benchmarkParallelPush(b *testing.B, st BackingQueue[K]) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		buf := make([]K, 0, 64)
+		count := 0
+		for pb.Next() {
+			if _, err := st.Push(K{count}); err != nil {
+				// keep the queue from staying full, as a flusher would
+				buf = buf[:0]
+				st.Pop(64, &buf)
+			}
+			count++
+		}
+	})
+}
+
+func BenchmarkLockingQueueParallelPush(b *testing.B) {
+	benchmarkParallelPush(b, NewLKQueue[K](14))
+}
+
+func BenchmarkShardedQueueParallelPush(b *testing.B) {
+	benchmarkParallelPush(b, NewShardedQueue[K](14))
+}
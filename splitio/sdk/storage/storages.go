@@ -7,22 +7,80 @@ import (
 	"github.com/splitio/splitd/splitio/sdk/types"
 )
 
-type ImpressionsStorage = MultiMetaQueues[dtos.Impression, types.ClientMetadata, *LockingQueue[dtos.Impression]]
+// QueueType selects the BackingQueue implementation used for each client metadata
+type QueueType int
 
-type EventsStorage = MultiMetaQueues[dtos.EventDTO, types.ClientMetadata, *LockingQueue[dtos.EventDTO]]
+const (
+	QueueLocking QueueType = iota // single ring guarded by a mutex
+	QueueSharded                  // lock-free rings spread across shards, for highly concurrent producers
+)
+
+// QueueOptions configures the queues (one per client metadata) of an impressions/events storage
+type QueueOptions struct {
+	Type         QueueType
+	MemoryBudget int64 // max bytes taken by the items of all queues combined. Zero means unbounded
+	DropPolicy   DropPolicy
+}
+
+// impressionOverhead approximates the memory taken by an impression, besides the contents of its strings
+const impressionOverhead = 128
+
+type ImpressionsStorage = MultiMetaQueues[dtos.Impression, types.ClientMetadata, BackingQueue[dtos.Impression]]
+
+type EventsStorage = MultiMetaQueues[dtos.EventDTO, types.ClientMetadata, BackingQueue[dtos.EventDTO]]
 
 func NewImpressionsQueue(approxSize int) (st *ImpressionsStorage, realSize int) {
+	return NewImpressionsQueueWithOptions(approxSize, QueueOptions{})
+}
+
+func NewImpressionsQueueWithType(approxSize int, queueType QueueType) (st *ImpressionsStorage, realSize int) {
+	return NewImpressionsQueueWithOptions(approxSize, QueueOptions{Type: queueType})
+}
+
+func NewImpressionsQueueWithOptions(approxSize int, opts QueueOptions) (st *ImpressionsStorage, realSize int) {
 	bits := getNearestSizePowerOf2(approxSize)
-	return NewMultiMetaQueue[dtos.Impression, types.ClientMetadata](func() *LockingQueue[dtos.Impression] {
-		return NewLKQueue[dtos.Impression](bits)
-	}), int(math.Pow(2, float64(bits)))
+	return newStorage(bits, opts, impressionSize), int(math.Pow(2, float64(bits)))
 }
 
 func NewEventsQueue(approxSize int) (st *EventsStorage, realSize int) {
+	return NewEventsQueueWithOptions(approxSize, QueueOptions{})
+}
+
+func NewEventsQueueWithType(approxSize int, queueType QueueType) (st *EventsStorage, realSize int) {
+	return NewEventsQueueWithOptions(approxSize, QueueOptions{Type: queueType})
+}
+
+func NewEventsQueueWithOptions(approxSize int, opts QueueOptions) (st *EventsStorage, realSize int) {
 	bits := getNearestSizePowerOf2(approxSize)
-	return NewMultiMetaQueue[dtos.EventDTO, types.ClientMetadata](func() *LockingQueue[dtos.EventDTO] {
-		return NewLKQueue[dtos.EventDTO](bits)
-	}), int(math.Pow(2, float64(bits)))
+	return newStorage(bits, opts, eventSize), int(math.Pow(2, float64(bits)))
+}
+
+func newStorage[T any](bits int, opts QueueOptions, sizeOf func(*T) int64) *MultiMetaQueues[T, types.ClientMetadata, BackingQueue[T]] {
+	factory := queueFactory[T](opts.Type, bits)
+	var st *MultiMetaQueues[T, types.ClientMetadata, BackingQueue[T]]
+	if opts.MemoryBudget <= 0 {
+		st = NewMultiMetaQueue[T, types.ClientMetadata](factory)
+	} else {
+		budget := NewMemoryBudget(opts.MemoryBudget, opts.DropPolicy, sizeOf)
+		st = NewBoundedMultiMetaQueue[T, types.ClientMetadata](func() BackingQueue[T] { return budget.wrap(factory()) }, budget)
+	}
+	st.capacity = 1 << bits
+	return st
+}
+
+func queueFactory[T any](queueType QueueType, bits int) func() BackingQueue[T] {
+	if queueType == QueueSharded {
+		return func() BackingQueue[T] { return NewShardedQueue[T](bits) }
+	}
+	return func() BackingQueue[T] { return NewLKQueue[T](bits) }
+}
+
+func impressionSize(i *dtos.Impression) int64 {
+	return int64(impressionOverhead + len(i.KeyName) + len(i.BucketingKey) + len(i.FeatureName) + len(i.Treatment) + len(i.Label) + len(i.Properties))
+}
+
+func eventSize(e *dtos.EventDTO) int64 {
+	return int64(e.Size())
 }
 
 // to make the round-queue performant , we need to replace the modulo operation with an AND.
@@ -3,6 +3,9 @@ package storage
 import (
 	"testing"
 
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/splitd/splitio/sdk/types"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,9 +22,46 @@ func TestGetNearestSizePowerOf2(t *testing.T) {
 func TestStorageConstruction(t *testing.T) {
 	ist, size := NewImpressionsQueue(1024)
 	assert.Equal(t, 1024, size)
-	assert.Equal(t, 1024, len(ist.cFactory().data))
+	assert.Equal(t, 1024, len(ist.cFactory().(*LockingQueue[dtos.Impression]).data))
 
 	est, size := NewEventsQueue(1024)
 	assert.Equal(t, 1024, size)
-	assert.Equal(t, 1024, len(est.cFactory().data))
+	assert.Equal(t, 1024, len(est.cFactory().(*LockingQueue[dtos.EventDTO]).data))
+
+	ist, size = NewImpressionsQueueWithType(1024, QueueSharded)
+	assert.Equal(t, 1024, size)
+	sharded := ist.cFactory().(*ShardedQueue[dtos.Impression])
+	total := 0
+	for _, shard := range sharded.shards {
+		total += len(shard.cells)
+	}
+	assert.Equal(t, 1024, total)
+
+	est, _ = NewEventsQueueWithType(1024, QueueSharded)
+	assert.IsType(t, &ShardedQueue[dtos.EventDTO]{}, est.cFactory())
+}
+
+func TestStorageFillLevel(t *testing.T) {
+	md1 := types.ClientMetadata{ID: "i1", SdkVersion: "php-1.2.3"}
+	md2 := types.ClientMetadata{ID: "i2", SdkVersion: "php-1.2.3"}
+
+	st, _ := NewEventsQueue(4)
+	assert.Equal(t, 0, st.FillLevel(md1))
+	st.Push(md1, dtos.EventDTO{Key: "k1"})
+	assert.Equal(t, 25, st.FillLevel(md1))
+	st.Push(md1, dtos.EventDTO{Key: "k2"}, dtos.EventDTO{Key: "k3"})
+	assert.Equal(t, 75, st.FillLevel(md1))
+	assert.Equal(t, 0, st.FillLevel(md2))
+
+	// the budget is shared across queues, so it's reflected in the level of all of them
+	event := dtos.EventDTO{Key: "k1"}
+	st, _ = NewEventsQueueWithOptions(1024, QueueOptions{MemoryBudget: int64(event.Size()) * 4})
+	st.Push(md1, event, event)
+	assert.Equal(t, 50, st.FillLevel(md1))
+	assert.Equal(t, 50, st.FillLevel(md2))
+
+	// queues of unknown capacity only report the budget
+	unsized := NewMultiMetaQueue[K, string](func() BackingQueue[K] { return NewLKQueue[K](2) })
+	unsized.Push("a", K{1})
+	assert.Equal(t, 0, unsized.FillLevel("a"))
 }
@@ -0,0 +1,29 @@
+package tasks
+
+import (
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/synchronizer/worker/event"
+	"github.com/splitio/go-toolkit/v5/asynctask"
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+func NewEventsForwardingTask(
+	name string,
+	recorder event.EventRecorder,
+	logger logging.LoggerInterface,
+	period time.Duration,
+) *asynctask.AsyncTask {
+	return asynctask.NewAsyncTask(
+		name,
+		func(logging.LoggerInterface) error { return recorder.SynchronizeEvents(defaultEventsBulkSize) },
+		int(period.Seconds()),
+		nil,
+		func(l logging.LoggerInterface) {
+			if err := recorder.SynchronizeEvents(defaultEventsBulkSize); err != nil {
+				l.Error("error flushing events on shutdown: ", err)
+			}
+		},
+		logger,
+	)
+}
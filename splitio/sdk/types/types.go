@@ -3,9 +3,22 @@ package types
 type ClientConfig struct {
 	ReturnImpressionData bool
 	Metadata             ClientMetadata
+
+	// ImpressionsMode & LabelsEnabled are requested by the client upon registration, and only honored
+	// if allowed by the daemon's config. Zero values mean the daemon's defaults are used
+	ImpressionsMode string
+	LabelsEnabled   *bool
+
+	// Attributes are bound by the client for the rest of its session, and applied to every evaluation it issues
+	Attributes map[string]interface{}
 }
 
 type ClientMetadata struct {
 	ID         string
 	SdkVersion string
+
+	// PID & UID of the client process, as reported by the kernel when connecting through a unix socket.
+	// Both are zero if the peer's credentials couldn't be fetched
+	PID int32
+	UID uint32
 }
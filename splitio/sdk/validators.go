@@ -2,63 +2,188 @@ package sdk
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/splitio/go-split-commons/v9/engine/evaluator"
+	"github.com/splitio/go-split-commons/v9/engine/evaluator/impressionlabels"
 	"github.com/splitio/go-split-commons/v9/storage"
 	"github.com/splitio/go-toolkit/v5/logging"
 )
 
-// MaxEventLength constant to limit the event size
-const MaxEventLength = 32768
+// Validation limits, in line with the ones enforced by other Split SDKs
+const (
+	MaxEventLength     = 32768 // MaxEventLength constant to limit the event size
+	MaxKeyLength       = 250
+	MaxEventProperties = 300
+)
+
+var eventTypeRegex = regexp.MustCompile(`^[a-zA-Z0-9][-_.:a-zA-Z0-9]{0,79}$`)
+
+// ErrInvalidInput is matched (via errors.Is) by every error caused by a call failing validation
+var ErrInvalidInput = errors.New("invalid input")
+
+var (
+	ErrEventTooBig      = newValidationError("The maximum size allowed for the properties is 32kb. Event not queued")
+	ErrEmtpyTrafficType = newValidationError("Traffic type cannot be empty")
+	ErrEmptyKey         = newValidationError("key cannot be empty")
+	ErrKeyTooLong       = newValidationError(fmt.Sprintf("key too long - must be %d characters or less", MaxKeyLength))
+	ErrEmptyEventType   = newValidationError("event type cannot be empty")
+	ErrInvalidEventType = newValidationError("event type must adhere to the regular expression " + eventTypeRegex.String() +
+		". This means an event name must be alphanumeric, cannot be more than 80 characters long, and can only include a " +
+		"dash, underscore, period, or colon as separators of alphanumeric characters")
+	ErrNonFiniteValue = newValidationError("value must be a finite number")
+	ErrEmptyFlagName  = newValidationError("feature flag name cannot be empty")
+)
+
+type validationError struct{ msg string }
+
+func newValidationError(msg string) error       { return &validationError{msg: msg} }
+func (e *validationError) Error() string        { return e.msg }
+func (e *validationError) Is(target error) bool { return target == ErrInvalidInput }
+
+// WarningCode identifies the kind of a non-fatal validation issue, so that clients can handle them programmatically
+type WarningCode uint8
+
+const (
+	WarningTrafficTypeLowercased WarningCode = 0x01
+	WarningTrafficTypeNotFound   WarningCode = 0x02
+	WarningPropertiesTruncated   WarningCode = 0x03
+	WarningPropertyInvalidType   WarningCode = 0x04
+	WarningPropertyNonFinite     WarningCode = 0x05
+	WarningFlagNameTrimmed       WarningCode = 0x06
+	WarningDuplicateFlag         WarningCode = 0x07
+	WarningFlagNotFound          WarningCode = 0x08
+	WarningInvalidInput          WarningCode = 0x09 // the evaluation was not performed, control/fallback treatment returned
+)
+
+// LabelFlagNotFound is the label of results for feature flags that don't exist
+const LabelFlagNotFound = impressionlabels.SplitNotFound
 
-var ErrEventTooBig = errors.New("The maximum size allowed for the properties is 32kb. Event not queued")
-var ErrEmtpyTrafficType = errors.New("Traffic type cannot be empty")
+// ValidationWarning describes an issue found in the input of a call that didn't prevent it from being processed
+type ValidationWarning struct {
+	Code    WarningCode
+	Message string
+}
 
 type Validator struct {
 	logger logging.LoggerInterface
 	splits storage.SplitStorage
 }
 
-func (i *Validator) validateTrafficType(trafficType string) (string, error) {
+// warn logs a warning on the daemon side & appends it to the ones to be returned to the client
+func (i *Validator) warn(warnings *[]ValidationWarning, code WarningCode, message string) {
+	i.logger.Warning(message)
+	*warnings = append(*warnings, ValidationWarning{Code: code, Message: message})
+}
+
+func (i *Validator) validateKey(operation string, key string) error {
+	if key == "" {
+		return fmt.Errorf("%s: %w", operation, ErrEmptyKey)
+	}
+	if len(key) > MaxKeyLength {
+		return fmt.Errorf("%s: %w", operation, ErrKeyTooLong)
+	}
+	return nil
+}
+
+// validateEvaluationKeys checks the matching key, as well as the bucketing key if supplied
+func (i *Validator) validateEvaluationKeys(operation string, key string, bucketingKey *string) error {
+	if err := i.validateKey(operation, key); err != nil {
+		return err
+	}
+	if bucketingKey != nil {
+		if err := i.validateKey(operation, *bucketingKey); err != nil {
+			return fmt.Errorf("bucketing %w", err)
+		}
+	}
+	return nil
+}
+
+// validateFlagName returns the flag name without leading/trailing whitespace
+func (i *Validator) validateFlagName(operation string, name string, warnings *[]ValidationWarning) (string, error) {
+	trimmed := strings.TrimSpace(name)
+	if trimmed == "" {
+		return "", fmt.Errorf("%s: %w", operation, ErrEmptyFlagName)
+	}
+	if trimmed != name {
+		i.warn(warnings, WarningFlagNameTrimmed, fmt.Sprintf("%s: feature flag name '%s' has extra whitespace, trimming", operation, name))
+	}
+	return trimmed, nil
+}
+
+func (i *Validator) validateTrafficType(trafficType string, warnings *[]ValidationWarning) (string, error) {
 	if len(trafficType) == 0 {
 		return "", ErrEmtpyTrafficType
 	}
 
 	toLower := strings.ToLower(trafficType)
 	if toLower != trafficType {
-		i.logger.Warning("Track: traffic type should be all lowercase - converting string to lowercase")
+		i.warn(warnings, WarningTrafficTypeLowercased, "Track: traffic type should be all lowercase - converting string to lowercase")
 	}
 
 	if !i.splits.TrafficTypeExists(toLower) {
-		i.logger.Warning("Track: traffic type " + toLower + " does not have any corresponding feature flags in this environment, " +
+		i.warn(warnings, WarningTrafficTypeNotFound, "Track: traffic type "+toLower+" does not have any corresponding feature flags in this environment, "+
 			"make sure you’re tracking your events to a valid traffic type defined in the Split user interface")
 	}
 
 	return toLower, nil
 }
 
-func (i *Validator) validateTrackProperties(properties map[string]interface{}) (map[string]interface{}, int, error) {
+func (i *Validator) validateEventType(eventType string) error {
+	if eventType == "" {
+		return ErrEmptyEventType
+	}
+	if !eventTypeRegex.MatchString(eventType) {
+		return ErrInvalidEventType
+	}
+	return nil
+}
+
+func (i *Validator) validateValue(value *float64) error {
+	if value != nil && !isFinite(*value) {
+		return ErrNonFiniteValue
+	}
+	return nil
+}
+
+func (i *Validator) validateTrackProperties(properties map[string]interface{}, warnings *[]ValidationWarning) (map[string]interface{}, int, error) {
 	if len(properties) == 0 {
 		return nil, 0, nil
 	}
 
-	if len(properties) > 300 {
-		i.logger.Warning("Track: Event has more than 300 properties. Some of them will be trimmed when processed")
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names) // so that the same properties are kept when truncating
+
+	if len(names) > MaxEventProperties {
+		i.warn(warnings, WarningPropertiesTruncated, fmt.Sprintf(
+			"Track: Event has more than %d properties. Only the first %d (sorted by name) will be kept", MaxEventProperties, MaxEventProperties))
+		names = names[:MaxEventProperties]
 	}
 
-	processed := make(map[string]interface{})
+	processed := make(map[string]interface{}, len(names))
 	size := 1024 // Average event size is ~750 bytes. Using 1kbyte as a starting point.
-	for name, value := range properties {
+	for _, name := range names {
+		value := properties[name]
 		size += len(name)
-		switch value.(type) {
-		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool, nil:
+		switch parsed := value.(type) {
+		case float32:
+			processed[name] = i.finiteOrNil(name, float64(parsed), value, warnings)
+		case float64:
+			processed[name] = i.finiteOrNil(name, parsed, value, warnings)
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, bool, nil:
 			processed[name] = value
 		case string:
-			asStr := value.(string)
-			size += len(asStr)
+			size += len(parsed)
 			processed[name] = value
 		default:
-			i.logger.Warning("Property %s is of invalid type. Setting value to nil")
+			i.warn(warnings, WarningPropertyInvalidType, fmt.Sprintf("Track: Property %s is of invalid type. Setting value to nil", name))
 			processed[name] = nil
 		}
 
@@ -69,3 +194,20 @@ func (i *Validator) validateTrackProperties(properties map[string]interface{}) (
 	}
 	return processed, size, nil
 }
+
+func (i *Validator) finiteOrNil(name string, asFloat float64, value interface{}, warnings *[]ValidationWarning) interface{} {
+	if isFinite(asFloat) {
+		return value
+	}
+	i.warn(warnings, WarningPropertyNonFinite, fmt.Sprintf("Track: Property %s is not a finite number. Setting value to nil", name))
+	return nil
+}
+
+func isFlagNotFound(res *evaluator.Result) bool {
+	// when fallback treatments are configured, the label gets a prefix
+	return res.Label != LabelOverride && strings.HasSuffix(res.Label, impressionlabels.SplitNotFound)
+}
+
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
@@ -0,0 +1,248 @@
+package sdk
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/engine/evaluator"
+	"github.com/splitio/go-split-commons/v9/engine/evaluator/impressionlabels"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/splitd/external/commons/mocks"
+	"github.com/splitio/splitd/splitio/common/lang"
+	"github.com/splitio/splitd/splitio/sdk/storage"
+	"github.com/splitio/splitd/splitio/sdk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateKey(t *testing.T) {
+	v := Validator{logger: logging.NewLogger(nil)}
+	assert.Nil(t, v.validateKey("Track", "key1"))
+	assert.Nil(t, v.validateKey("Track", strings.Repeat("k", MaxKeyLength)))
+	assert.ErrorIs(t, v.validateKey("Track", ""), ErrEmptyKey)
+	assert.ErrorIs(t, v.validateKey("Track", strings.Repeat("k", MaxKeyLength+1)), ErrKeyTooLong)
+	assert.ErrorIs(t, v.validateKey("Track", ""), ErrInvalidInput)
+}
+
+func TestValidateEventTypeAndValue(t *testing.T) {
+	v := Validator{logger: logging.NewLogger(nil)}
+	for _, valid := range []string{"checkin", "page.view", "a:b_c-d", "1st", strings.Repeat("e", 80)} {
+		assert.Nil(t, v.validateEventType(valid), valid)
+	}
+	assert.ErrorIs(t, v.validateEventType(""), ErrEmptyEventType)
+	for _, invalid := range []string{"-checkin", "page view", "a/b", strings.Repeat("e", 81)} {
+		assert.ErrorIs(t, v.validateEventType(invalid), ErrInvalidEventType, invalid)
+	}
+
+	assert.Nil(t, v.validateValue(nil))
+	assert.Nil(t, v.validateValue(lang.Ref(1.5)))
+	assert.ErrorIs(t, v.validateValue(lang.Ref(math.NaN())), ErrNonFiniteValue)
+	assert.ErrorIs(t, v.validateValue(lang.Ref(math.Inf(-1))), ErrNonFiniteValue)
+}
+
+func TestValidateTrafficType(t *testing.T) {
+	ss := &mocks.SplitStorageMock{}
+	ss.On("TrafficTypeExists", "user").Return(true)
+	ss.On("TrafficTypeExists", "account").Return(false)
+	v := Validator{logger: logging.NewLogger(nil), splits: ss}
+
+	var warnings []ValidationWarning
+	tt, err := v.validateTrafficType("user", &warnings)
+	assert.Nil(t, err)
+	assert.Equal(t, "user", tt)
+	assert.Empty(t, warnings)
+
+	tt, err = v.validateTrafficType("Account", &warnings)
+	assert.Nil(t, err)
+	assert.Equal(t, "account", tt)
+	require.Len(t, warnings, 2)
+	assert.Equal(t, WarningTrafficTypeLowercased, warnings[0].Code)
+	assert.Equal(t, WarningTrafficTypeNotFound, warnings[1].Code)
+
+	_, err = v.validateTrafficType("", &warnings)
+	assert.ErrorIs(t, err, ErrEmtpyTrafficType)
+}
+
+func TestValidateTrackProperties(t *testing.T) {
+	v := Validator{logger: logging.NewLogger(nil)}
+
+	var warnings []ValidationWarning
+	props, _, err := v.validateTrackProperties(map[string]interface{}{
+		"a": 1,
+		"b": "str",
+		"c": math.NaN(),
+		"d": float32(math.Inf(1)),
+		"e": []string{"invalid"},
+		"f": 1.5,
+	}, &warnings)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": "str", "c": nil, "d": nil, "e": nil, "f": 1.5}, props)
+	require.Len(t, warnings, 3)
+	assert.Equal(t, ValidationWarning{Code: WarningPropertyNonFinite, Message: "Track: Property c is not a finite number. Setting value to nil"}, warnings[0])
+	assert.Equal(t, WarningPropertyNonFinite, warnings[1].Code)
+	assert.Equal(t, ValidationWarning{Code: WarningPropertyInvalidType, Message: "Track: Property e is of invalid type. Setting value to nil"}, warnings[2])
+
+	many := make(map[string]interface{}, MaxEventProperties+10)
+	for idx := 0; idx < MaxEventProperties+10; idx++ {
+		many[fmt.Sprintf("p%03d", idx)] = idx
+	}
+	warnings = nil
+	props, _, err = v.validateTrackProperties(many, &warnings)
+	assert.Nil(t, err)
+	assert.Len(t, props, MaxEventProperties)
+	assert.Contains(t, props, "p000")
+	assert.NotContains(t, props, fmt.Sprintf("p%03d", MaxEventProperties))
+	require.Len(t, warnings, 1)
+	assert.Equal(t, WarningPropertiesTruncated, warnings[0].Code)
+
+	_, _, err = v.validateTrackProperties(map[string]interface{}{"a": strings.Repeat("qwertyui", 100000)}, &warnings)
+	assert.ErrorIs(t, err, ErrEventTooBig)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestTrackValidation(t *testing.T) {
+	es, _ := storage.NewEventsQueue(1000)
+	ss := &mocks.SplitStorageMock{}
+	ss.On("TrafficTypeExists", "user").Return(true)
+
+	client := &Impl{
+		logger:    logging.NewLogger(nil),
+		es:        es,
+		validator: Validator{logging.NewLogger(nil), ss},
+	}
+	md := types.ClientConfig{Metadata: types.ClientMetadata{ID: "some", SdkVersion: "go-1.2.3"}}
+
+	warnings, err := client.Track(&md, "key1", "User", "checkin", nil, map[string]interface{}{"a": math.Inf(1)})
+	assert.Nil(t, err)
+	require.Len(t, warnings, 2)
+	assert.Equal(t, WarningTrafficTypeLowercased, warnings[0].Code)
+	assert.Equal(t, WarningPropertyNonFinite, warnings[1].Code)
+
+	_, err = client.Track(&md, "", "user", "checkin", nil, nil)
+	assert.ErrorIs(t, err, ErrEmptyKey)
+	_, err = client.Track(&md, strings.Repeat("k", 251), "user", "checkin", nil, nil)
+	assert.ErrorIs(t, err, ErrKeyTooLong)
+	_, err = client.Track(&md, "key1", "user", "check in", nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidEventType)
+	_, err = client.Track(&md, "key1", "user", "checkin", lang.Ref(math.NaN()), nil)
+	assert.ErrorIs(t, err, ErrNonFiniteValue)
+
+	var queued int
+	es.Range(func(_ types.ClientMetadata, q storage.BackingQueue[dtos.EventDTO]) { queued += q.Len() })
+	assert.Equal(t, 1, queued)
+}
+
+func TestValidateEvaluationKeysAndFlagName(t *testing.T) {
+	v := Validator{logger: logging.NewLogger(nil)}
+	assert.Nil(t, v.validateEvaluationKeys("Treatment", "key1", nil))
+	assert.Nil(t, v.validateEvaluationKeys("Treatment", "key1", lang.Ref("bk1")))
+	assert.ErrorIs(t, v.validateEvaluationKeys("Treatment", "", nil), ErrEmptyKey)
+	err := v.validateEvaluationKeys("Treatment", "key1", lang.Ref(""))
+	assert.ErrorIs(t, err, ErrEmptyKey)
+	assert.Equal(t, "bucketing Treatment: key cannot be empty", err.Error())
+
+	var warnings []ValidationWarning
+	name, err := v.validateFlagName("Treatment", "f1", &warnings)
+	assert.Nil(t, err)
+	assert.Equal(t, "f1", name)
+	assert.Empty(t, warnings)
+
+	name, err = v.validateFlagName("Treatment", " f1\t", &warnings)
+	assert.Nil(t, err)
+	assert.Equal(t, "f1", name)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, WarningFlagNameTrimmed, warnings[0].Code)
+
+	_, err = v.validateFlagName("Treatment", "  ", &warnings)
+	assert.ErrorIs(t, err, ErrEmptyFlagName)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestTreatmentsInputValidation(t *testing.T) {
+	is, _ := storage.NewImpressionsQueue(100)
+
+	ev := &mocks.EvaluatorMock{}
+	ev.On("EvaluateFeatures", "key1", (*string)(nil), []string{"f1", "missing"}, Attributes(nil)).
+		Return(evaluator.Results{Evaluations: map[string]evaluator.Result{
+			"f1":      {Treatment: "on", Label: "label1", SplitChangeNumber: 123},
+			"missing": {Treatment: "control", Label: impressionlabels.SplitNotFound},
+		}}).
+		Once()
+
+	im := &mocks.ImpressionManagerMock{}
+	im.On("Process", mock.Anything).
+		Run(func(args mock.Arguments) { assert.Equal(t, "f1", args.Get(0).([]dtos.Impression)[0].FeatureName) }).
+		Return([]dtos.Impression{{FeatureName: "f1"}}, []dtos.Impression{}).
+		Once()
+
+	client := &Impl{
+		logger:    logging.NewLogger(nil),
+		is:        is,
+		ev:        ev,
+		iq:        im,
+		validator: Validator{logging.NewLogger(nil), nil},
+	}
+	md := &types.ClientConfig{Metadata: types.ClientMetadata{ID: "some"}}
+
+	res, err := client.Treatments(md, "key1", nil, []string{"f1", " f1", "", "missing"}, nil, nil)
+	require.Nil(t, err)
+	require.Len(t, res, 4)
+
+	assert.Equal(t, "on", res["f1"].Treatment)
+	assert.Empty(t, res["f1"].Warnings)
+	assert.NotNil(t, res["f1"].Impression)
+
+	assert.Equal(t, "on", res[" f1"].Treatment)
+	require.Len(t, res[" f1"].Warnings, 2)
+	assert.Equal(t, WarningFlagNameTrimmed, res[" f1"].Warnings[0].Code)
+	assert.Equal(t, WarningDuplicateFlag, res[" f1"].Warnings[1].Code)
+
+	assert.Equal(t, "control", res[""].Treatment)
+	require.Len(t, res[""].Warnings, 1)
+	assert.Equal(t, WarningInvalidInput, res[""].Warnings[0].Code)
+
+	assert.Equal(t, "control", res["missing"].Treatment)
+	assert.Equal(t, LabelFlagNotFound, res["missing"].Label)
+	assert.Nil(t, res["missing"].Impression)
+	require.Len(t, res["missing"].Warnings, 1)
+	assert.Equal(t, WarningFlagNotFound, res["missing"].Warnings[0].Code)
+
+	// rejected names are validated (& logged) once, no matter how many times they're requested
+	logger := &errorCountingLogger{LoggerInterface: logging.NewLogger(nil)}
+	client.logger = logger
+	res, err = client.Treatments(md, "key1", nil, []string{"", "", ""}, nil, nil)
+	require.Nil(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "control", res[""].Treatment)
+	assert.Equal(t, 1, logger.errors)
+	client.logger = logging.NewLogger(nil)
+
+	// invalid keys -> nothing is evaluated
+	res, err = client.Treatments(md, strings.Repeat("k", MaxKeyLength+1), nil, []string{"f1", "f2"}, nil, nil)
+	require.Nil(t, err)
+	for _, r := range res {
+		assert.Equal(t, "control", r.Treatment)
+		assert.Equal(t, WarningInvalidInput, r.Warnings[0].Code)
+	}
+
+	single, err := client.Treatment(md, "key1", lang.Ref(""), "f1", nil, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "control", single.Treatment)
+	assert.Equal(t, WarningInvalidInput, single.Warnings[0].Code)
+
+	ev.AssertExpectations(t)
+	im.AssertExpectations(t)
+}
+
+type errorCountingLogger struct {
+	logging.LoggerInterface
+	errors int
+}
+
+func (l *errorCountingLogger) Error(msg ...interface{}) {
+	l.errors++
+}
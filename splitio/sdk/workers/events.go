@@ -59,7 +59,8 @@ func (m *MultiMetaEventsWorker) FlushEvents(bulkSize int64) error {
 
 	// same logic as impressions workers, without the need for formatting. check impressions.go for a better
 	// description of what's being done
-	if err := m.iq.RangeAndClear(func(md types.ClientMetadata, q *sss.LockingQueue[dtos.EventDTO]) {
+	m.iq.Prune()
+	if err := m.iq.Range(func(md types.ClientMetadata, q sss.BackingQueue[dtos.EventDTO]) {
 		extracted := make([]dtos.EventDTO, 0, q.Len())
 		n, err := q.Pop(q.Len(), &extracted)
 		if err != nil && !errors.Is(err, sss.ErrQueueEmpty) {
@@ -102,6 +102,38 @@ func TestEventsTaskNoParallelism(t *testing.T) {
 
 }
 
+func TestEventsTaskPrunesIdleQueues(t *testing.T) {
+	es, _ := sss.NewEventsQueue(100)
+	ts, _ := inmemory.NewTelemetryStorage()
+	logger := logging.NewLogger(nil)
+	rec := &EventsRecorderMock{}
+	rec.On("Record", mock.Anything, mock.Anything).Return(nil)
+
+	worker := NewEventsWorker(logger, ts, rec, es, &conf.Events{})
+
+	md1 := types.ClientMetadata{ID: "i1", SdkVersion: "php-1.2.3"}
+	md2 := types.ClientMetadata{ID: "i2", SdkVersion: "go-1.2.3"}
+	queues := func() []types.ClientMetadata {
+		var mds []types.ClientMetadata
+		es.Range(func(md types.ClientMetadata, _ sss.BackingQueue[dtos.EventDTO]) { mds = append(mds, md) })
+		return mds
+	}
+
+	es.Push(md1, dtos.EventDTO{Key: "key1"})
+	es.Push(md2, dtos.EventDTO{Key: "key2"})
+	worker.SynchronizeEvents(5000)
+	assert.ElementsMatch(t, []types.ClientMetadata{md1, md2}, queues()) // flushed queues are kept for the next period
+
+	// i2 has been idle for a whole period, so its queue is dropped on the next flush
+	es.Push(md1, dtos.EventDTO{Key: "key3"})
+	worker.SynchronizeEvents(5000)
+	assert.ElementsMatch(t, []types.ClientMetadata{md1}, queues())
+
+	worker.SynchronizeEvents(5000)
+	assert.Empty(t, queues())
+	rec.AssertNumberOfCalls(t, "Record", 3)
+}
+
 type EventsRecorderMock struct {
 	mock.Mock
 }
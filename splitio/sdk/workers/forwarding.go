@@ -0,0 +1,104 @@
+package workers
+
+import (
+	"errors"
+
+	"github.com/splitio/splitd/splitio/sdk/sinks"
+	sss "github.com/splitio/splitd/splitio/sdk/storage"
+	"github.com/splitio/splitd/splitio/sdk/types"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/synchronizer/worker/event"
+	"github.com/splitio/go-toolkit/v5/logging"
+	gtsync "github.com/splitio/go-toolkit/v5/sync"
+)
+
+// EventRecord is the representation of an event handed to forwarding sinks
+type EventRecord struct {
+	Key              string                 `json:"key"`
+	TrafficType      string                 `json:"trafficType"`
+	EventType        string                 `json:"eventType"`
+	Value            interface{}            `json:"value,omitempty"`
+	Timestamp        int64                  `json:"timestamp"`
+	Properties       map[string]interface{} `json:"properties,omitempty"`
+	ClientID         string                 `json:"clientId"`
+	ClientSdkVersion string                 `json:"clientSdkVersion"`
+}
+
+// EventsSinkWorker flushes queued events to an arbitrary sink
+type EventsSinkWorker struct {
+	logger   logging.LoggerInterface
+	sink     sinks.Sink[EventRecord]
+	iq       *sss.EventsStorage
+	runnning gtsync.AtomicBool
+}
+
+func NewEventsSinkWorker(logger logging.LoggerInterface, sink sinks.Sink[EventRecord], iq *sss.EventsStorage) *EventsSinkWorker {
+	return &EventsSinkWorker{
+		logger: logger,
+		sink:   sink,
+		iq:     iq,
+	}
+}
+
+// FlushEvents implements event.EventRecorder
+func (m *EventsSinkWorker) FlushEvents(bulkSize int64) error {
+	if !m.runnning.TestAndSet() {
+		m.logger.Warning("flush/sync requested while another one is in progress. ignoring")
+		return nil
+	}
+	defer m.runnning.Unset()
+
+	// sinks are not safe for concurrent use, so unlike with split's backend, queues are flushed sequentially
+	var errs []error
+	m.iq.Prune() // same as with split's backend, see impressions.go
+	if err := m.iq.Range(func(md types.ClientMetadata, q sss.BackingQueue[dtos.EventDTO]) {
+		extracted := make([]dtos.EventDTO, 0, q.Len())
+		n, err := q.Pop(q.Len(), &extracted)
+		if err != nil && !errors.Is(err, sss.ErrQueueEmpty) {
+			m.logger.Error("error fetching items from queue: ", err)
+			return // continue with next one
+		}
+
+		if n == 0 {
+			return // nothing to do here
+		}
+
+		if err := m.sink.Write(toEventRecords(md, extracted)); err != nil {
+			errs = append(errs, err)
+		}
+	}); err != nil {
+		m.logger.Error("error traversing event queues: ", err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// SynchronizeEvents implements event.EventRecorder
+func (m *EventsSinkWorker) SynchronizeEvents(bulkSize int64) error {
+	return m.FlushEvents(bulkSize)
+}
+
+// Close releases the resources held by the sink
+func (m *EventsSinkWorker) Close() error {
+	return m.sink.Close()
+}
+
+func toEventRecords(md types.ClientMetadata, events []dtos.EventDTO) []EventRecord {
+	records := make([]EventRecord, 0, len(events))
+	for idx := range events {
+		records = append(records, EventRecord{
+			Key:              events[idx].Key,
+			TrafficType:      events[idx].TrafficTypeName,
+			EventType:        events[idx].EventTypeID,
+			Value:            events[idx].Value,
+			Timestamp:        events[idx].Timestamp,
+			Properties:       events[idx].Properties,
+			ClientID:         md.ID,
+			ClientSdkVersion: md.SdkVersion,
+		})
+	}
+	return records
+}
+
+var _ event.EventRecorder = (*EventsSinkWorker)(nil)
@@ -0,0 +1,70 @@
+package workers
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	sss "github.com/splitio/splitd/splitio/sdk/storage"
+	"github.com/splitio/splitd/splitio/sdk/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventsSinkWorker(t *testing.T) {
+	es, _ := sss.NewEventsQueue(100)
+	sink := &eventSinkMock{}
+	worker := NewEventsSinkWorker(logging.NewLogger(nil), sink, es)
+
+	value := 1.5
+	es.Push(types.ClientMetadata{ID: "i1", SdkVersion: "php-1.2.3"},
+		dtos.EventDTO{Key: "key1", TrafficTypeName: "user", EventTypeID: "checkin", Value: &value, Timestamp: 123, Properties: map[string]interface{}{"a": 2}},
+	)
+	es.Push(types.ClientMetadata{ID: "i2", SdkVersion: "go-1.2.3"},
+		dtos.EventDTO{Key: "key2", TrafficTypeName: "user", EventTypeID: "checkout", Timestamp: 124},
+		dtos.EventDTO{Key: "key3", TrafficTypeName: "user", EventTypeID: "checkout", Timestamp: 125},
+	)
+
+	assert.Nil(t, worker.SynchronizeEvents(5000))
+	sort.Slice(sink.written, func(i, j int) bool { return sink.written[i][0].Key < sink.written[j][0].Key })
+	assert.Equal(t, [][]EventRecord{
+		{{Key: "key1", TrafficType: "user", EventType: "checkin", Value: &value, Timestamp: 123, Properties: map[string]interface{}{"a": 2}, ClientID: "i1", ClientSdkVersion: "php-1.2.3"}},
+		{
+			{Key: "key2", TrafficType: "user", EventType: "checkout", Timestamp: 124, ClientID: "i2", ClientSdkVersion: "go-1.2.3"},
+			{Key: "key3", TrafficType: "user", EventType: "checkout", Timestamp: 125, ClientID: "i2", ClientSdkVersion: "go-1.2.3"},
+		},
+	}, sink.written)
+
+	// nothing left to flush
+	assert.Nil(t, worker.FlushEvents(5000))
+	assert.Len(t, sink.written, 2)
+
+	sink.err = errors.New("something")
+	es.Push(types.ClientMetadata{ID: "i1", SdkVersion: "php-1.2.3"}, dtos.EventDTO{Key: "key4"})
+	assert.ErrorContains(t, worker.SynchronizeEvents(5000), "something")
+
+	assert.Nil(t, worker.Close())
+	assert.True(t, sink.closed)
+}
+
+type eventSinkMock struct {
+	written [][]EventRecord
+	err     error
+	closed  bool
+}
+
+func (s *eventSinkMock) Name() string { return "mock" }
+
+func (s *eventSinkMock) Write(records []EventRecord) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.written = append(s.written, records)
+	return nil
+}
+
+func (s *eventSinkMock) Close() error {
+	s.closed = true
+	return nil
+}
@@ -57,11 +57,15 @@ func (m *MultiMetaImpressionWorker) FlushImpressions(bulkSize int64) error {
 	var errs serrors.ConcurrentErrorCollector
 	var wg sync.WaitGroup
 
+	// queues that haven't received anything since the last flush belong to clients no longer in use, drop them.
+	// the rest are kept (rather than re-created for every flush), so active clients don't reallocate their buffers
+	m.iq.Prune()
+
 	// iterate all internal queues (one per thin-client associate-data)
 	// for each [metadata, impressions] tuple, format impressions accordingly, and create a goroutine to post them in BG.
 	// after all impressions posting-goroutines have been created, wait for all of them to complete, collect errors,
 	// and unset the `running` flag so that this func can be called again
-	if err := m.iq.RangeAndClear(func(md types.ClientMetadata, q *sss.LockingQueue[dtos.Impression]) {
+	if err := m.iq.Range(func(md types.ClientMetadata, q sss.BackingQueue[dtos.Impression]) {
 		extracted := make([]dtos.Impression, 0, q.Len())
 		n, err := q.Pop(q.Len(), &extracted)
 		if err != nil && !errors.Is(err, sss.ErrQueueEmpty) {
@@ -0,0 +1,127 @@
+package workers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/service"
+	"github.com/splitio/go-split-commons/v9/storage"
+	"github.com/splitio/go-split-commons/v9/storage/redis"
+	"github.com/splitio/go-toolkit/v5/logging"
+	toolkitredis "github.com/splitio/go-toolkit/v5/redis"
+)
+
+// RedisImpressionsRecorder writes impressions to redis (instead of posting them to split), in the format
+// expected by Split Synchronizer. Each batch is pushed with a single RPUSH.
+type RedisImpressionsRecorder struct {
+	client      *toolkitredis.PrefixedRedisClient
+	counts      storage.ImpressionsCountProducer
+	machineName string
+	machineIP   string
+}
+
+func NewRedisImpressionsRecorder(
+	client *toolkitredis.PrefixedRedisClient,
+	logger logging.LoggerInterface,
+	machineName string,
+	machineIP string,
+) *RedisImpressionsRecorder {
+	return &RedisImpressionsRecorder{
+		client:      client,
+		counts:      redis.NewImpressionsCountStorage(client, logger),
+		machineName: machineName,
+		machineIP:   machineIP,
+	}
+}
+
+// Record implements service.ImpressionsRecorder
+func (r *RedisImpressionsRecorder) Record(impressions []dtos.ImpressionsDTO, metadata dtos.Metadata, _ map[string]string) error {
+	md := withMachineInfo(metadata, r.machineName, r.machineIP)
+	serialized := make([]interface{}, 0, len(impressions))
+	for _, forFeature := range impressions {
+		for _, ki := range forFeature.KeyImpressions {
+			raw, err := json.Marshal(dtos.ImpressionQueueObject{
+				Metadata: md,
+				Impression: dtos.Impression{
+					KeyName:      ki.KeyName,
+					BucketingKey: ki.BucketingKey,
+					FeatureName:  forFeature.TestName,
+					Treatment:    ki.Treatment,
+					Label:        ki.Label,
+					ChangeNumber: ki.ChangeNumber,
+					Time:         ki.Time,
+					Pt:           ki.Pt,
+					Properties:   ki.Properties,
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("error serializing impression: %w", err)
+			}
+			serialized = append(serialized, raw)
+		}
+	}
+
+	return pushWithTTL(r.client, redis.KeyImpressionsQueue, serialized, redis.TTLImpressions)
+}
+
+// RecordImpressionsCount implements service.ImpressionsRecorder
+func (r *RedisImpressionsRecorder) RecordImpressionsCount(pf dtos.ImpressionsCountDTO, _ dtos.Metadata) error {
+	return r.counts.RecordImpressionsCount(pf)
+}
+
+// RedisEventsRecorder writes events to redis (instead of posting them to split), in the format
+// expected by Split Synchronizer. Each batch is pushed with a single RPUSH.
+type RedisEventsRecorder struct {
+	client      *toolkitredis.PrefixedRedisClient
+	machineName string
+	machineIP   string
+}
+
+func NewRedisEventsRecorder(client *toolkitredis.PrefixedRedisClient, machineName string, machineIP string) *RedisEventsRecorder {
+	return &RedisEventsRecorder{client: client, machineName: machineName, machineIP: machineIP}
+}
+
+// Record implements service.EventsRecorder
+func (r *RedisEventsRecorder) Record(events []dtos.EventDTO, metadata dtos.Metadata) error {
+	md := withMachineInfo(metadata, r.machineName, r.machineIP)
+	serialized := make([]interface{}, 0, len(events))
+	for idx := range events {
+		raw, err := json.Marshal(dtos.QueueStoredEventDTO{Metadata: md, Event: events[idx]})
+		if err != nil {
+			return fmt.Errorf("error serializing event: %w", err)
+		}
+		serialized = append(serialized, raw)
+	}
+
+	// the synchronizer doesn't expect the events queue to expire
+	return pushWithTTL(r.client, redis.KeyEvents, serialized, 0)
+}
+
+func withMachineInfo(md dtos.Metadata, name string, ip string) dtos.Metadata {
+	md.MachineName = name
+	md.MachineIP = ip
+	return md
+}
+
+// pushWithTTL appends items to a list. If the list has just been created, an expiration is set, so that it
+// doesn't grow indefinitely if no synchronizer is consuming it
+func pushWithTTL(client *toolkitredis.PrefixedRedisClient, key string, items []interface{}, ttlSeconds int) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	inserted, err := client.RPush(key, items...)
+	if err != nil {
+		return fmt.Errorf("error pushing items to redis: %w", err)
+	}
+
+	if ttlSeconds > 0 && inserted == int64(len(items)) {
+		client.Expire(key, time.Duration(ttlSeconds)*time.Second)
+	}
+	return nil
+}
+
+var _ service.ImpressionsRecorder = (*RedisImpressionsRecorder)(nil)
+var _ service.EventsRecorder = (*RedisEventsRecorder)(nil)
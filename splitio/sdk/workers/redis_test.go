@@ -0,0 +1,100 @@
+package workers
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/splitio/go-split-commons/v9/conf"
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/storage/redis"
+	"github.com/splitio/go-toolkit/v5/logging"
+	toolkitredis "github.com/splitio/go-toolkit/v5/redis"
+	sdkconf "github.com/splitio/splitd/splitio/sdk/conf"
+	sss "github.com/splitio/splitd/splitio/sdk/storage"
+	"github.com/splitio/splitd/splitio/sdk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisImpressionsRecorder(t *testing.T) {
+	mr, client := setupMiniRedis(t)
+
+	is, _ := sss.NewImpressionsQueue(100)
+	is.Push(types.ClientMetadata{ID: "i1", SdkVersion: "php-1.2.3"},
+		dtos.Impression{KeyName: "k1", FeatureName: "f1", Treatment: "on", Time: 123456, ChangeNumber: 123, Label: "l1"},
+		dtos.Impression{KeyName: "k2", BucketingKey: "b2", FeatureName: "f1", Treatment: "off", Time: 123457, ChangeNumber: 123, Label: "l1"},
+	)
+
+	rec := NewRedisImpressionsRecorder(client, logging.NewLogger(nil), "host1", "10.0.0.1")
+	worker := NewImpressionsWorker(logging.NewLogger(nil), nil, rec, is, &sdkconf.Impressions{})
+	require.Nil(t, worker.SynchronizeImpressions(5000))
+
+	raw, err := mr.List("someprefix." + redis.KeyImpressionsQueue)
+	require.Nil(t, err)
+	require.Len(t, raw, 2)
+
+	byKey := make(map[string]dtos.ImpressionQueueObject)
+	for _, item := range raw {
+		var parsed dtos.ImpressionQueueObject
+		require.Nil(t, json.Unmarshal([]byte(item), &parsed))
+		byKey[parsed.Impression.KeyName] = parsed
+	}
+
+	md := dtos.Metadata{SDKVersion: "php-1.2.3", MachineName: "host1", MachineIP: "10.0.0.1"}
+	assert.Equal(t, dtos.ImpressionQueueObject{
+		Metadata:   md,
+		Impression: dtos.Impression{KeyName: "k1", FeatureName: "f1", Treatment: "on", Time: 123456, ChangeNumber: 123, Label: "l1"},
+	}, byKey["k1"])
+	assert.Equal(t, dtos.ImpressionQueueObject{
+		Metadata:   md,
+		Impression: dtos.Impression{KeyName: "k2", BucketingKey: "b2", FeatureName: "f1", Treatment: "off", Time: 123457, ChangeNumber: 123, Label: "l1"},
+	}, byKey["k2"])
+	assert.Equal(t, time.Duration(redis.TTLImpressions)*time.Second, mr.TTL("someprefix."+redis.KeyImpressionsQueue))
+
+	// impression counts are written through commons' storage
+	require.Nil(t, rec.RecordImpressionsCount(dtos.ImpressionsCountDTO{PerFeature: []dtos.ImpressionsInTimeFrameDTO{
+		{FeatureName: "f1", TimeFrame: 123, RawCount: 3},
+	}}, dtos.Metadata{}))
+	count := mr.HGet("someprefix."+redis.KeyImpressionsCount, "f1::123")
+	assert.Equal(t, "3", count)
+}
+
+func TestRedisEventsRecorder(t *testing.T) {
+	mr, client := setupMiniRedis(t)
+
+	es, _ := sss.NewEventsQueue(100)
+	es.Push(types.ClientMetadata{ID: "i1", SdkVersion: "ruby-1.2.3"},
+		dtos.EventDTO{Key: "k1", TrafficTypeName: "user", EventTypeID: "checkout", Timestamp: 123},
+	)
+
+	worker := NewEventsWorker(logging.NewLogger(nil), nil, NewRedisEventsRecorder(client, "host1", "10.0.0.1"), es, &sdkconf.Events{})
+	require.Nil(t, worker.SynchronizeEvents(5000))
+
+	raw, err := mr.List("someprefix." + redis.KeyEvents)
+	require.Nil(t, err)
+	require.Len(t, raw, 1)
+
+	var parsed dtos.QueueStoredEventDTO
+	require.Nil(t, json.Unmarshal([]byte(raw[0]), &parsed))
+	assert.Equal(t, dtos.QueueStoredEventDTO{
+		Metadata: dtos.Metadata{SDKVersion: "ruby-1.2.3", MachineName: "host1", MachineIP: "10.0.0.1"},
+		Event:    dtos.EventDTO{Key: "k1", TrafficTypeName: "user", EventTypeID: "checkout", Timestamp: 123},
+	}, parsed)
+
+	// nothing is pushed when there's nothing to flush
+	require.Nil(t, worker.SynchronizeEvents(5000))
+	raw, _ = mr.List("someprefix." + redis.KeyEvents)
+	assert.Len(t, raw, 1)
+}
+
+func setupMiniRedis(t *testing.T) (*miniredis.Miniredis, *toolkitredis.PrefixedRedisClient) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	client, err := redis.NewRedisClient(&conf.RedisConfig{Host: mr.Host(), Port: port, Prefix: "someprefix"}, logging.NewLogger(nil))
+	require.Nil(t, err)
+	return mr, client
+}
@@ -0,0 +1,73 @@
+// Package sdnotify implements the systemd service notification protocol (see sd_notify(3))
+package sdnotify
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+	Watchdog = "WATCHDOG=1"
+)
+
+// Status builds a free-form status message, shown by `systemctl status`
+func Status(message string) string {
+	return "STATUS=" + message
+}
+
+// MainPID tells the service manager that another process (ie: one replacing this one) is now the service's main process
+func MainPID(pid int) string {
+	return "MAINPID=" + strconv.Itoa(pid)
+}
+
+// Enabled returns true if the process was started by systemd expecting notifications
+func Enabled() bool {
+	return os.Getenv("NOTIFY_SOCKET") != ""
+}
+
+// Notify sends the supplied state lines to the service manager. If the process wasn't started by systemd
+// (or the service isn't of type notify), nothing is sent and false is returned
+func Notify(states ...string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	if strings.HasPrefix(socket, "@") { // abstract namespace
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("error connecting to notification socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return false, fmt.Errorf("error sending notification: %w", err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the interval in which the service manager expects watchdog keep-alive messages, or
+// 0 if the watchdog is disabled for this process. Keep-alives should be sent at (at least) half this interval
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	if rawPID := os.Getenv("WATCHDOG_PID"); rawPID != "" {
+		if pid, err := strconv.Atoi(rawPID); err != nil || pid != os.Getpid() {
+			return 0
+		}
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
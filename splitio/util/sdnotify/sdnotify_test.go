@@ -0,0 +1,66 @@
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	sent, err := Notify(Ready)
+	assert.False(t, sent)
+	assert.Nil(t, err)
+
+	sockFN := path.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sockFN, Net: "unixgram"})
+	require.Nil(t, err)
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", sockFN)
+	sent, err = Notify(Ready, Status("all environments ready"))
+	assert.True(t, sent)
+	assert.Nil(t, err)
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "READY=1\nSTATUS=all environments ready", string(buf[:n]))
+
+	_, err = Notify(MainPID(1234))
+	assert.Nil(t, err)
+	n, err = conn.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "MAINPID=1234", string(buf[:n]))
+
+	t.Setenv("NOTIFY_SOCKET", path.Join(t.TempDir(), "nonexistant.sock"))
+	sent, err = Notify(Stopping)
+	assert.False(t, sent)
+	assert.NotNil(t, err)
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	t.Setenv("WATCHDOG_PID", "")
+	assert.Equal(t, time.Duration(0), WatchdogInterval())
+
+	t.Setenv("WATCHDOG_USEC", "3000000")
+	assert.Equal(t, 3*time.Second, WatchdogInterval())
+
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	assert.Equal(t, 3*time.Second, WatchdogInterval())
+
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	assert.Equal(t, time.Duration(0), WatchdogInterval())
+
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", "sarasa")
+	assert.Equal(t, time.Duration(0), WatchdogInterval())
+}
@@ -57,6 +57,11 @@ func (s *ShutdownHandler) Wait() {
 	<-s.done
 }
 
+// Trigger starts the shutdown sequence without waiting for it to complete
+func (s *ShutdownHandler) Trigger() {
+	s.incoming <- syscall.SIGTERM
+}
+
 func (s *ShutdownHandler) TriggerAndWait() {
 	defer s.Wait()
 	s.incoming <- syscall.SIGTERM
@@ -0,0 +1,132 @@
+// Package upgrade implements zero-downtime binary upgrades: a running instance spawns a new one from the current
+// executable, hands its listeners over, and waits for the new process to report it's ready before stepping down
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/splitio/splitd/splitio/link/transfer"
+)
+
+const (
+	readyFDEnv  = "SPLITD_UPGRADE_READY_FD"
+	snapshotEnv = "SPLITD_UPGRADE_SNAPSHOT"
+
+	watchdogPIDEnv = "WATCHDOG_PID"
+)
+
+var (
+	ErrExitedBeforeReady = errors.New("new process exited before becoming ready")
+	ErrReadyTimeout      = errors.New("timed out waiting for the new process to become ready")
+)
+
+// Handoff describes what's passed on to the new process
+type Handoff struct {
+	Listeners    []*os.File    // listening sockets, used by the new process instead of binding new ones
+	Snapshot     bool          // a fresh snapshot has been exported & should be loaded by the new process
+	ReadyTimeout time.Duration // how long to wait for the new process to become ready
+}
+
+// Spawn starts a new instance of the running executable (with the same arguments), passing it the listeners in
+// the handoff. It blocks until the new process reports it's ready to serve (see NotifyReady), exits, or the timeout
+// expires (in which case it's killed)
+func Spawn(h *Handoff) (*os.Process, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("error determining current executable: %w", err)
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("error creating readiness pipe: %w", err)
+	}
+	defer readyR.Close()
+
+	// extra files are laid out from descriptor 3 onwards: listeners first, readiness pipe last.
+	// WATCHDOG_PID refers to this process, and would keep the new one from sending watchdog keep-alives
+	env := slices.DeleteFunc(os.Environ(), func(entry string) bool {
+		return strings.HasPrefix(entry, readyFDEnv+"=") || strings.HasPrefix(entry, snapshotEnv+"=") ||
+			strings.HasPrefix(entry, watchdogPIDEnv+"=")
+	})
+	env = append(env, transfer.HandoffEnv(len(h.Listeners)), readyFDEnv+"="+strconv.Itoa(3+len(h.Listeners)))
+	if h.Snapshot {
+		env = append(env, snapshotEnv+"=1")
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = env
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = append(slices.Clone(h.Listeners), readyW)
+	err = cmd.Start()
+	readyW.Close() // only the child writes to it. EOF is read if it exits without doing so
+	if err != nil {
+		return nil, fmt.Errorf("error starting new process: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	ready := make(chan error, 1)
+	go func() {
+		_, err := readyR.Read(make([]byte, 1))
+		ready <- err
+	}()
+
+	timer := time.NewTimer(h.ReadyTimeout)
+	defer timer.Stop()
+	select {
+	case err = <-ready:
+		if err == nil {
+			return cmd.Process, nil
+		}
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("%w: readiness pipe closed (%s)", ErrExitedBeforeReady, err)
+	case err = <-exited:
+		return nil, fmt.Errorf("%w: %v", ErrExitedBeforeReady, err)
+	case <-timer.C:
+		cmd.Process.Kill()
+		return nil, ErrReadyTimeout
+	}
+}
+
+// InProgress returns true if this process was spawned by a running instance, as part of an upgrade
+func InProgress() bool {
+	return os.Getenv(readyFDEnv) != ""
+}
+
+// SnapshotHandedOver returns true if the instance being replaced exported a fresh snapshot for this one to load
+func SnapshotHandedOver() bool {
+	return os.Getenv(snapshotEnv) != ""
+}
+
+// NotifyReady tells the instance being replaced that this one is ready to take over.
+// It's a no-op if the process wasn't spawned as part of an upgrade
+func NotifyReady() error {
+	raw := os.Getenv(readyFDEnv)
+	if raw == "" {
+		return nil
+	}
+
+	// this process might upgrade itself later on, so the handoff must not be passed on
+	os.Unsetenv(readyFDEnv)
+	os.Unsetenv(snapshotEnv)
+
+	fd, err := strconv.Atoi(raw)
+	if err != nil {
+		return fmt.Errorf("invalid %s value '%s'", readyFDEnv, raw)
+	}
+
+	f := os.NewFile(uintptr(fd), "upgrade-ready")
+	defer f.Close()
+	if _, err = f.Write([]byte{1}); err != nil {
+		return fmt.Errorf("error notifying readiness: %w", err)
+	}
+	return nil
+}
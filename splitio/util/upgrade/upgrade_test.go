@@ -0,0 +1,74 @@
+package upgrade
+
+import (
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const helperModeEnv = "SPLITD_UPGRADE_TEST_HELPER"
+
+// TestMain turns the test binary into the "new process" when spawned by the tests below
+func TestMain(m *testing.M) {
+	switch os.Getenv(helperModeEnv) {
+	case "":
+		os.Exit(m.Run())
+	case "ready":
+		// the listener handed over must be usable
+		l, err := net.FileListener(os.NewFile(3, "inherited"))
+		_, hasWatchdogPID := os.LookupEnv("WATCHDOG_PID")
+		if err != nil || os.Getenv("SPLITD_HANDOFF_FDS") != "1" || !InProgress() || !SnapshotHandedOver() || hasWatchdogPID {
+			os.Exit(1)
+		}
+		l.Close()
+		if NotifyReady() != nil || InProgress() {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "exit":
+		os.Exit(1)
+	case "hang":
+		time.Sleep(10 * time.Second)
+		os.Exit(0)
+	}
+}
+
+func TestSpawn(t *testing.T) {
+	l, err := net.Listen("unix", path.Join(t.TempDir(), "handoff.sock"))
+	require.Nil(t, err)
+	defer l.Close()
+	f, err := l.(*net.UnixListener).File()
+	require.Nil(t, err)
+	defer f.Close()
+
+	handoff := &Handoff{Listeners: []*os.File{f}, Snapshot: true, ReadyTimeout: 5 * time.Second}
+
+	t.Setenv(helperModeEnv, "ready")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	p, err := Spawn(handoff)
+	require.Nil(t, err)
+	assert.NotNil(t, p)
+
+	t.Setenv(helperModeEnv, "exit")
+	_, err = Spawn(handoff)
+	assert.ErrorIs(t, err, ErrExitedBeforeReady)
+
+	t.Setenv(helperModeEnv, "hang")
+	handoff.ReadyTimeout = 200 * time.Millisecond
+	before := time.Now()
+	_, err = Spawn(handoff)
+	assert.ErrorIs(t, err, ErrReadyTimeout)
+	assert.Less(t, time.Since(before), 5*time.Second)
+}
+
+func TestNotifyReadyWithoutUpgrade(t *testing.T) {
+	t.Setenv(readyFDEnv, "")
+	assert.False(t, InProgress())
+	assert.Nil(t, NotifyReady())
+}